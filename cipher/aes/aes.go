@@ -0,0 +1,33 @@
+// Package aes implements AES block cipher and AES-GCM-SIV (RFC 8452).
+//
+// NewCipher returns implementation which uses AES-NI if available, otherwise
+// a constant-time bitsliced implementation. Returned block cipher can be
+// used with modes from cipher/modes as well as with modes from the standard
+// crypto/cipher package.
+package aes
+
+import (
+	"crypto/cipher"
+
+	"github.com/henrydcase/nobs/utils"
+)
+
+// newBlock returns constant-time implementation of AES, the best one
+// available on the platform.
+func newBlock(key []byte) (IAES, error) {
+	var c IAES = &AESCT{}
+	if utils.X86.HasAES {
+		c = &AESAsm{}
+	}
+	if err := c.SetKey(key); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewCipher creates and returns a new cipher.Block. The key argument should
+// be the AES key, either 16, 24, or 32 bytes to select AES-128, AES-192,
+// or AES-256.
+func NewCipher(key []byte) (cipher.Block, error) {
+	return newBlock(key)
+}
@@ -0,0 +1,308 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aes
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/aestable"
+	"github.com/henrydcase/nobs/internal/bitslice"
+	"github.com/henrydcase/nobs/utils"
+)
+
+func implementations() []IAES {
+	impls := []IAES{new(aestable.Cipher), new(AESCT)}
+	if utils.X86.HasAES {
+		impls = append(impls, new(AESAsm))
+	}
+	return impls
+}
+
+// Appendix B, C of FIPS 197: Cipher examples, Example vectors.
+type CryptTest struct {
+	key []byte
+	in  []byte
+	out []byte
+}
+
+var encryptTests = []CryptTest{
+	{
+		// Appendix B.
+		[]byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c},
+		[]byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0xe0, 0x37, 0x07, 0x34},
+		[]byte{0x39, 0x25, 0x84, 0x1d, 0x02, 0xdc, 0x09, 0xfb, 0xdc, 0x11, 0x85, 0x97, 0x19, 0x6a, 0x0b, 0x32},
+	},
+	{
+		// Appendix C.1.  AES-128
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		[]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		[]byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a},
+	},
+	{
+		// Appendix C.2.  AES-192
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		},
+		[]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		[]byte{0xdd, 0xa9, 0x7c, 0xa4, 0x86, 0x4c, 0xdf, 0xe0, 0x6e, 0xaf, 0x70, 0xa0, 0xec, 0x0d, 0x71, 0x91},
+	},
+	{
+		// Appendix C.3.  AES-256
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		},
+		[]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		[]byte{0x8e, 0xa2, 0xb7, 0xca, 0x51, 0x67, 0x45, 0xbf, 0xea, 0xfc, 0x49, 0x90, 0x4b, 0x49, 0x60, 0x89},
+	},
+}
+
+// Test Cipher Encrypt method against FIPS 197 examples.
+func TestCipherEncrypt(t *testing.T) {
+	for _, c := range implementations() {
+		testCipherEncrypt(t, c)
+	}
+}
+
+func testCipherEncrypt(t *testing.T, c IAES) {
+	for i, tt := range encryptTests {
+		err := c.SetKey(tt.key)
+		if err != nil {
+			t.Errorf("NewCipher(%d bytes) = %s", len(tt.key), err)
+			continue
+		}
+		out := make([]byte, len(tt.in))
+		c.Encrypt(out, tt.in)
+		for j, v := range out {
+			if v != tt.out[j] {
+				t.Errorf("%T.Encrypt %d: out[%d] = %#x, want %#x", c, i, j, v, tt.out[j])
+				break
+			}
+		}
+	}
+}
+
+// Test Cipher Decrypt against FIPS 197 examples.
+func TestCipherDecrypt(t *testing.T) {
+	for _, c := range implementations() {
+		testCipherDecrypt(t, c)
+	}
+}
+
+func testCipherDecrypt(t *testing.T, c IAES) {
+	for i, tt := range encryptTests {
+		err := c.SetKey(tt.key)
+		if err != nil {
+			t.Errorf("NewCipher(%d bytes) = %s", len(tt.key), err)
+			continue
+		}
+		plain := make([]byte, len(tt.in))
+		c.Decrypt(plain, tt.out)
+		for j, v := range plain {
+			if v != tt.in[j] {
+				t.Errorf("%T.Decrypt %d: plain[%d] = %#x, want %#x", c, i, j, v, tt.in[j])
+				break
+			}
+		}
+	}
+}
+
+// Test that encrypting multiple blocks at once gives the same result
+// as encrypting them one by one.
+func TestEncryptBlocks(t *testing.T) {
+	ciphers := implementations()
+
+	src := make([]byte, 21*BlockSize)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	for _, c := range ciphers {
+		for _, tt := range encryptTests {
+			c.SetKey(tt.key)
+			exp := make([]byte, len(src))
+			got := make([]byte, len(src))
+			for i := 0; i < len(src); i += BlockSize {
+				c.Encrypt(exp[i:], src[i:])
+			}
+			c.EncryptBlocks(got, src)
+			if !bytes.Equal(exp, got) {
+				t.Errorf("%T: EncryptBlocks failed for %d-byte key", c, len(tt.key))
+			}
+			// in-place
+			copy(got, src)
+			c.EncryptBlocks(got, got)
+			if !bytes.Equal(exp, got) {
+				t.Errorf("%T: in-place EncryptBlocks failed for %d-byte key", c, len(tt.key))
+			}
+		}
+	}
+}
+
+// Test bitsliced inverse S-box against the forward one.
+func TestBitslicedInvSbox(t *testing.T) {
+	var q [8]uint64
+	for i := 0; i < 256; i += 8 {
+		q[0] = 0
+		for j := 0; j < 8; j++ {
+			q[0] |= uint64(i+j) << (8 * uint(j))
+		}
+		for j := 1; j < 8; j++ {
+			q[j] = 0
+		}
+		bitslice.Ortho(&q)
+		bitslice.Sbox(&q)
+		invSbox(&q)
+		bitslice.Ortho(&q)
+		for j := 0; j < 8; j++ {
+			if got := byte(q[0] >> (8 * uint(j))); got != byte(i+j) {
+				t.Errorf("invSbox(sbox(%#x)) = %#x", i+j, got)
+			}
+		}
+	}
+}
+
+func TestBitsliced(t *testing.T) {
+	var c AESCT
+	for i, tt := range encryptTests {
+		if err := c.SetKey(tt.key); err != nil {
+			t.Errorf("SetKey(%d bytes) = %s", len(tt.key), err)
+			continue
+		}
+		out := make([]byte, len(tt.in))
+		c.Encrypt(out, tt.in)
+		if !bytes.Equal(out, tt.out) {
+			t.Errorf("AESCT.Encrypt %d: got %X, want %X", i, out, tt.out)
+		}
+		c.Decrypt(out, tt.out)
+		if !bytes.Equal(out, tt.in) {
+			t.Errorf("AESCT.Decrypt %d: got %X, want %X", i, out, tt.in)
+		}
+	}
+	if err := c.SetKey(make([]byte, 17)); err == nil {
+		t.Error("expected error for wrong key size")
+	}
+}
+
+// Compare bitsliced implementation with the table-based one.
+func TestBitslicedVsTables(t *testing.T) {
+	var ct AESCT
+	var exp, got [BlockSize]byte
+
+	tab := new(aestable.Cipher)
+	key := make([]byte, 32)
+	blk := make([]byte, BlockSize)
+	for i := 0; i < 300; i++ {
+		for j := range key {
+			key[j] = byte(i*7 + j*13)
+		}
+		for j := range blk {
+			blk[j] = byte(i*3 + j*101)
+		}
+		kl := []int{16, 24, 32}[i%3]
+		tab.SetKey(key[:kl])
+		ct.SetKey(key[:kl])
+
+		tab.Encrypt(exp[:], blk)
+		ct.Encrypt(got[:], blk)
+		if exp != got {
+			t.Fatalf("Encrypt mismatch for %d-byte key %X", kl, key[:kl])
+		}
+		tab.Decrypt(exp[:], blk)
+		ct.Decrypt(got[:], blk)
+		if exp != got {
+			t.Fatalf("Decrypt mismatch for %d-byte key %X", kl, key[:kl])
+		}
+	}
+}
+
+// Test short input/output.
+// Assembly used to not notice.
+// See issue 7928.
+func TestShortBlocks(t *testing.T) {
+	bytes := func(n int) []byte { return make([]byte, n) }
+
+	c := new(AESCT)
+	c.SetKey(bytes(16))
+
+	mustPanic(t, "crypto/aes: input not full block", func() { c.Encrypt(bytes(1), bytes(1)) })
+	mustPanic(t, "crypto/aes: input not full block", func() { c.Decrypt(bytes(1), bytes(1)) })
+	mustPanic(t, "crypto/aes: input not full block", func() { c.Encrypt(bytes(100), bytes(1)) })
+	mustPanic(t, "crypto/aes: input not full block", func() { c.Decrypt(bytes(100), bytes(1)) })
+	mustPanic(t, "crypto/aes: output not full block", func() { c.Encrypt(bytes(1), bytes(100)) })
+	mustPanic(t, "crypto/aes: output not full block", func() { c.Decrypt(bytes(1), bytes(100)) })
+}
+
+func mustPanic(t *testing.T, msg string, f func()) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("function did not panic, wanted %q", msg)
+		} else if err != msg {
+			t.Errorf("got panic %v, wanted %q", err, msg)
+		}
+	}()
+	f()
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	tt := encryptTests[0]
+	c, err := newBlock(tt.key)
+	if err != nil {
+		b.Fatal("NewCipher:", err)
+	}
+	out := make([]byte, len(tt.in))
+	b.SetBytes(int64(len(out)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(out, tt.in)
+	}
+}
+
+func BenchmarkEncryptBlocks(b *testing.B) {
+	tt := encryptTests[0]
+	c, _ := newBlock(tt.key)
+	buf := make([]byte, 8*BlockSize)
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.EncryptBlocks(buf, buf)
+	}
+}
+
+func BenchmarkEncryptBitsliced(b *testing.B) {
+	tt := encryptTests[0]
+	var c AESCT
+	c.SetKey(tt.key)
+	buf := make([]byte, 8*BlockSize)
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.EncryptBlocks(buf, buf)
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	tt := encryptTests[0]
+	c, err := newBlock(tt.key)
+	if err != nil {
+		b.Fatal("NewCipher:", err)
+	}
+	out := make([]byte, len(tt.out))
+	b.SetBytes(int64(len(out)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Decrypt(out, tt.out)
+	}
+}
+
+func BenchmarkExpand(b *testing.B) {
+	tt := encryptTests[0]
+	c, _ := newBlock(tt.key)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.SetKey(tt.key)
+	}
+}
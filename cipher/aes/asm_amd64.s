@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build amd64, !noasm
+// +build amd64,!noasm
 
 #include "textflag.h"
 
@@ -104,6 +104,95 @@ Ldec128:
 	MOVUPS X0, 0(DX)
 	RET
 
+#define AESENC8(k) \
+	AESENC k, X1 \
+	AESENC k, X2 \
+	AESENC k, X3 \
+	AESENC k, X4 \
+	AESENC k, X5 \
+	AESENC k, X6 \
+	AESENC k, X7 \
+	AESENC k, X8
+
+// func encryptBlocks8Asm(nr int, xk *uint32, dst, src *byte)
+// Encrypts 8 consecutive blocks. Instructions for independent blocks are
+// interleaved, so that the latency of AESENC is hidden.
+TEXT ·encryptBlocks8Asm(SB),NOSPLIT,$0
+	MOVQ nr+0(FP), CX
+	MOVQ xk+8(FP), AX
+	MOVQ dst+16(FP), DX
+	MOVQ src+24(FP), BX
+	MOVUPS 0(AX), X0
+	MOVUPS 0(BX), X1
+	MOVUPS 16(BX), X2
+	MOVUPS 32(BX), X3
+	MOVUPS 48(BX), X4
+	MOVUPS 64(BX), X5
+	MOVUPS 80(BX), X6
+	MOVUPS 96(BX), X7
+	MOVUPS 112(BX), X8
+	PXOR X0, X1
+	PXOR X0, X2
+	PXOR X0, X3
+	PXOR X0, X4
+	PXOR X0, X5
+	PXOR X0, X6
+	PXOR X0, X7
+	PXOR X0, X8
+	ADDQ $16, AX
+	SUBQ $12, CX
+	JE Lenc8_192
+	JB Lenc8_128
+Lenc8_256:
+	MOVUPS 0(AX), X0
+	AESENC8(X0)
+	MOVUPS 16(AX), X0
+	AESENC8(X0)
+	ADDQ $32, AX
+Lenc8_192:
+	MOVUPS 0(AX), X0
+	AESENC8(X0)
+	MOVUPS 16(AX), X0
+	AESENC8(X0)
+	ADDQ $32, AX
+Lenc8_128:
+	MOVUPS 0(AX), X0
+	AESENC8(X0)
+	MOVUPS 16(AX), X0
+	AESENC8(X0)
+	MOVUPS 32(AX), X0
+	AESENC8(X0)
+	MOVUPS 48(AX), X0
+	AESENC8(X0)
+	MOVUPS 64(AX), X0
+	AESENC8(X0)
+	MOVUPS 80(AX), X0
+	AESENC8(X0)
+	MOVUPS 96(AX), X0
+	AESENC8(X0)
+	MOVUPS 112(AX), X0
+	AESENC8(X0)
+	MOVUPS 128(AX), X0
+	AESENC8(X0)
+	MOVUPS 144(AX), X0
+	AESENCLAST X0, X1
+	AESENCLAST X0, X2
+	AESENCLAST X0, X3
+	AESENCLAST X0, X4
+	AESENCLAST X0, X5
+	AESENCLAST X0, X6
+	AESENCLAST X0, X7
+	AESENCLAST X0, X8
+	MOVUPS X1, 0(DX)
+	MOVUPS X2, 16(DX)
+	MOVUPS X3, 32(DX)
+	MOVUPS X4, 48(DX)
+	MOVUPS X5, 64(DX)
+	MOVUPS X6, 80(DX)
+	MOVUPS X7, 96(DX)
+	MOVUPS X8, 112(DX)
+	RET
+
 // func expandKeyAsm(nr int, key *byte, enc, dec *uint32) {
 // Note that round keys are stored in uint128 format, not uint32
 TEXT ·expandKeyAsm(SB),NOSPLIT,$0
@@ -0,0 +1,340 @@
+// Constant-time, bitsliced implementation of AES. Implementation follows
+// the "aes_ct64" from BearSSL (https://bearssl.org) by Thomas Pornin,
+// distributed under the MIT license.
+//
+// Four blocks are processed in parallel. State of the four blocks is kept
+// in eight 64-bit words, word i keeps bit i of each of 64 bytes. The S-box
+// is computed with the circuit by Boyar and Peralta (ia.cr/2011/332), which
+// doesn't use any secret-dependent memory accesses nor branches.
+
+package aes
+
+import (
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/internal/bitslice"
+)
+
+// Number of blocks processed in parallel
+const ctBlocks = 4
+
+// AESCT implements AES in constant-time, without using lookup tables.
+type AESCT struct {
+	// Round keys in bitsliced representation, 8 words per round.
+	skey [8 * 15]uint64
+	nr   int
+}
+
+// Rcon values for the key schedule
+var rcon = [10]uint32{0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80, 0x1B, 0x36}
+
+// invAffine computes y -> A^-1(y ^ 0x63) on each byte of the bitsliced
+// state, where A is the linear part of the affine transformation used by
+// the S-box.
+func invAffine(q *[8]uint64) {
+	q0 := ^q[0]
+	q1 := ^q[1]
+	q2 := q[2]
+	q3 := q[3]
+	q4 := q[4]
+	q5 := ^q[5]
+	q6 := ^q[6]
+	q7 := q[7]
+	q[7] = q1 ^ q4 ^ q6
+	q[6] = q0 ^ q3 ^ q5
+	q[5] = q7 ^ q2 ^ q4
+	q[4] = q6 ^ q1 ^ q3
+	q[3] = q5 ^ q0 ^ q2
+	q[2] = q4 ^ q7 ^ q1
+	q[1] = q3 ^ q6 ^ q0
+	q[0] = q2 ^ q5 ^ q7
+}
+
+// invSbox applies inverse of the AES S-box. Inversion in GF(2^8) is
+// computed with the forward S-box circuit: inv(x) = A^-1(S(x) ^ 0x63).
+func invSbox(q *[8]uint64) {
+	invAffine(q)
+	bitslice.Sbox(q)
+	invAffine(q)
+}
+
+// interleaveIn spreads four 32-bit words of a block into two
+// 64-bit words.
+func interleaveIn(q0, q1 *uint64, w []uint32) {
+	x0, x1, x2, x3 := uint64(w[0]), uint64(w[1]), uint64(w[2]), uint64(w[3])
+	x0 |= x0 << 16
+	x1 |= x1 << 16
+	x2 |= x2 << 16
+	x3 |= x3 << 16
+	x0 &= 0x0000FFFF0000FFFF
+	x1 &= 0x0000FFFF0000FFFF
+	x2 &= 0x0000FFFF0000FFFF
+	x3 &= 0x0000FFFF0000FFFF
+	x0 |= x0 << 8
+	x1 |= x1 << 8
+	x2 |= x2 << 8
+	x3 |= x3 << 8
+	x0 &= 0x00FF00FF00FF00FF
+	x1 &= 0x00FF00FF00FF00FF
+	x2 &= 0x00FF00FF00FF00FF
+	x3 &= 0x00FF00FF00FF00FF
+	*q0 = x0 | (x2 << 8)
+	*q1 = x1 | (x3 << 8)
+}
+
+// interleaveOut is an inverse of interleaveIn.
+func interleaveOut(w []uint32, q0, q1 uint64) {
+	x0 := q0 & 0x00FF00FF00FF00FF
+	x1 := q1 & 0x00FF00FF00FF00FF
+	x2 := (q0 >> 8) & 0x00FF00FF00FF00FF
+	x3 := (q1 >> 8) & 0x00FF00FF00FF00FF
+	x0 |= x0 >> 8
+	x1 |= x1 >> 8
+	x2 |= x2 >> 8
+	x3 |= x3 >> 8
+	x0 &= 0x0000FFFF0000FFFF
+	x1 &= 0x0000FFFF0000FFFF
+	x2 &= 0x0000FFFF0000FFFF
+	x3 &= 0x0000FFFF0000FFFF
+	w[0] = uint32(x0) | uint32(x0>>16)
+	w[1] = uint32(x1) | uint32(x1>>16)
+	w[2] = uint32(x2) | uint32(x2>>16)
+	w[3] = uint32(x3) | uint32(x3>>16)
+}
+
+func shiftRows(q *[8]uint64) {
+	for i, x := range q {
+		q[i] = (x & 0x000000000000FFFF) |
+			((x & 0x00000000FFF00000) >> 4) |
+			((x & 0x00000000000F0000) << 12) |
+			((x & 0x0000FF0000000000) >> 8) |
+			((x & 0x000000FF00000000) << 8) |
+			((x & 0xF000000000000000) >> 12) |
+			((x & 0x0FFF000000000000) << 4)
+	}
+}
+
+func invShiftRows(q *[8]uint64) {
+	for i, x := range q {
+		q[i] = (x & 0x000000000000FFFF) |
+			((x & 0x000000000FFF0000) << 4) |
+			((x & 0x00000000F0000000) >> 12) |
+			((x & 0x000000FF00000000) << 8) |
+			((x & 0x0000FF0000000000) >> 8) |
+			((x & 0x000F000000000000) << 12) |
+			((x & 0xFFF0000000000000) >> 4)
+	}
+}
+
+func rotr32(x uint64) uint64 {
+	return (x << 32) | (x >> 32)
+}
+
+func mixColumns(q *[8]uint64) {
+	var r [8]uint64
+	for i, x := range q {
+		r[i] = (x >> 16) | (x << 48)
+	}
+	q0, q1, q2, q3, q4, q5, q6, q7 := q[0], q[1], q[2], q[3], q[4], q[5], q[6], q[7]
+	q[0] = q7 ^ r[7] ^ r[0] ^ rotr32(q0^r[0])
+	q[1] = q0 ^ r[0] ^ q7 ^ r[7] ^ r[1] ^ rotr32(q1^r[1])
+	q[2] = q1 ^ r[1] ^ r[2] ^ rotr32(q2^r[2])
+	q[3] = q2 ^ r[2] ^ q7 ^ r[7] ^ r[3] ^ rotr32(q3^r[3])
+	q[4] = q3 ^ r[3] ^ q7 ^ r[7] ^ r[4] ^ rotr32(q4^r[4])
+	q[5] = q4 ^ r[4] ^ r[5] ^ rotr32(q5^r[5])
+	q[6] = q5 ^ r[5] ^ r[6] ^ rotr32(q6^r[6])
+	q[7] = q6 ^ r[6] ^ r[7] ^ rotr32(q7^r[7])
+}
+
+func invMixColumns(q *[8]uint64) {
+	var r [8]uint64
+	for i, x := range q {
+		r[i] = (x >> 16) | (x << 48)
+	}
+	q0, q1, q2, q3, q4, q5, q6, q7 := q[0], q[1], q[2], q[3], q[4], q[5], q[6], q[7]
+	r0, r1, r2, r3, r4, r5, r6, r7 := r[0], r[1], r[2], r[3], r[4], r[5], r[6], r[7]
+	q[0] = q5 ^ q6 ^ q7 ^ r0 ^ r5 ^ r7 ^ rotr32(q0^q5^q6^r0^r5)
+	q[1] = q0 ^ q5 ^ r0 ^ r1 ^ r5 ^ r6 ^ r7 ^ rotr32(q1^q5^q7^r1^r5^r6)
+	q[2] = q0 ^ q1 ^ q6 ^ r1 ^ r2 ^ r6 ^ r7 ^ rotr32(q0^q2^q6^r2^r6^r7)
+	q[3] = q0 ^ q1 ^ q2 ^ q5 ^ q6 ^ r0 ^ r2 ^ r3 ^ r5 ^ rotr32(q0^q1^q3^q5^q6^q7^r0^r3^r5^r7)
+	q[4] = q1 ^ q2 ^ q3 ^ q5 ^ r1 ^ r3 ^ r4 ^ r5 ^ r6 ^ r7 ^ rotr32(q1^q2^q4^q5^q7^r1^r4^r5^r6)
+	q[5] = q2 ^ q3 ^ q4 ^ q6 ^ r2 ^ r4 ^ r5 ^ r6 ^ r7 ^ rotr32(q2^q3^q5^q6^r2^r5^r6^r7)
+	q[6] = q3 ^ q4 ^ q5 ^ q7 ^ r3 ^ r5 ^ r6 ^ r7 ^ rotr32(q3^q4^q6^q7^r3^r6^r7)
+	q[7] = q4 ^ q5 ^ q6 ^ r4 ^ r6 ^ r7 ^ rotr32(q4^q5^q7^r4^r7)
+}
+
+func addRoundKey(q *[8]uint64, sk []uint64) {
+	for i := range q {
+		q[i] ^= sk[i]
+	}
+}
+
+// subWord applies S-box to each byte of the 32-bit word.
+func subWord(x uint32) uint32 {
+	var q [8]uint64
+	q[0] = uint64(x)
+	bitslice.Ortho(&q)
+	bitslice.Sbox(&q)
+	bitslice.Ortho(&q)
+	return uint32(q[0])
+}
+
+// SetKey expands the key. Key must be 16, 24 or 32 bytes long,
+// to select AES-128, AES-192 or AES-256.
+func (c *AESCT) SetKey(key []byte) error {
+	var w [4 * 15]uint32
+
+	nk := len(key) / 4
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return KeySizeError(len(key))
+	}
+	c.nr = nk + 6
+
+	// Key expansion (FIPS-197, 5.2). Words are kept in little-endian
+	// order, hence RotWord is a rotation to the right.
+	nkf := 4 * (c.nr + 1)
+	for i := 0; i < nk; i++ {
+		w[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	tmp := w[nk-1]
+	for i, j, k := nk, 0, 0; i < nkf; i++ {
+		if j == 0 {
+			tmp = (tmp << 24) | (tmp >> 8)
+			tmp = subWord(tmp) ^ rcon[k]
+		} else if nk > 6 && j == 4 {
+			tmp = subWord(tmp)
+		}
+		tmp ^= w[i-nk]
+		w[i] = tmp
+		if j++; j == nk {
+			j = 0
+			k++
+		}
+	}
+
+	// Convert round keys to bitsliced representation. Each round key
+	// is replicated for all blocks processed in parallel.
+	for i := 0; i < nkf; i += 4 {
+		var q [8]uint64
+		interleaveIn(&q[0], &q[4], w[i:])
+		q[1], q[2], q[3] = q[0], q[0], q[0]
+		q[5], q[6], q[7] = q[4], q[4], q[4]
+		bitslice.Ortho(&q)
+		copy(c.skey[2*i:], q[:])
+	}
+
+	for i := range w {
+		w[i] = 0
+	}
+	return nil
+}
+
+// load converts up to four blocks to bitsliced representation.
+func load(q *[8]uint64, src []byte) {
+	var w [4 * ctBlocks]uint32
+	for i := 0; i < len(src)/4; i++ {
+		w[i] = binary.LittleEndian.Uint32(src[4*i:])
+	}
+	for i := 0; i < ctBlocks; i++ {
+		interleaveIn(&q[i], &q[i+4], w[4*i:])
+	}
+	bitslice.Ortho(q)
+}
+
+// store writes bitsliced state to up to four blocks in dst.
+func store(dst []byte, q *[8]uint64) {
+	var w [4 * ctBlocks]uint32
+	bitslice.Ortho(q)
+	for i := 0; i < ctBlocks; i++ {
+		interleaveOut(w[4*i:], q[i], q[i+4])
+	}
+	for i := 0; i < len(dst)/4; i++ {
+		binary.LittleEndian.PutUint32(dst[4*i:], w[i])
+	}
+}
+
+func (c *AESCT) encrypt(q *[8]uint64) {
+	addRoundKey(q, c.skey[:])
+	for r := 1; r < c.nr; r++ {
+		bitslice.Sbox(q)
+		shiftRows(q)
+		mixColumns(q)
+		addRoundKey(q, c.skey[8*r:])
+	}
+	bitslice.Sbox(q)
+	shiftRows(q)
+	addRoundKey(q, c.skey[8*c.nr:])
+}
+
+func (c *AESCT) decrypt(q *[8]uint64) {
+	addRoundKey(q, c.skey[8*c.nr:])
+	for r := c.nr - 1; r > 0; r-- {
+		invShiftRows(q)
+		invSbox(q)
+		addRoundKey(q, c.skey[8*r:])
+		invMixColumns(q)
+	}
+	invShiftRows(q)
+	invSbox(q)
+	addRoundKey(q, c.skey[:])
+}
+
+func (c *AESCT) BlockSize() int { return BlockSize }
+
+func (c *AESCT) Encrypt(dst, src []byte) {
+	var q [8]uint64
+	if len(src) < BlockSize {
+		panic("crypto/aes: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("crypto/aes: output not full block")
+	}
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+	load(&q, src[:BlockSize])
+	c.encrypt(&q)
+	store(dst[:BlockSize], &q)
+}
+
+func (c *AESCT) Decrypt(dst, src []byte) {
+	var q [8]uint64
+	if len(src) < BlockSize {
+		panic("crypto/aes: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("crypto/aes: output not full block")
+	}
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+	load(&q, src[:BlockSize])
+	c.decrypt(&q)
+	store(dst[:BlockSize], &q)
+}
+
+func (c *AESCT) EncryptBlocks(dst, src []byte) {
+	var q [8]uint64
+	if len(src)%BlockSize != 0 {
+		panic("crypto/aes: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("crypto/aes: output smaller than input")
+	}
+	if alias.InexactOverlap(dst[:len(src)], src) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+	for len(src) > 0 {
+		n := len(src)
+		if n > ctBlocks*BlockSize {
+			n = ctBlocks * BlockSize
+		}
+		load(&q, src[:n])
+		c.encrypt(&q)
+		store(dst[:n], &q)
+		dst, src = dst[n:], src[n:]
+	}
+}
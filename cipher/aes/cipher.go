@@ -0,0 +1,30 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aes
+
+import (
+	"strconv"
+)
+
+// The AES block size in bytes.
+const BlockSize = 16
+
+// IAES is implemented by all AES implementations in this package.
+// It extends cipher.Block with key setting and bulk encryption.
+type IAES interface {
+	SetKey(key []byte) error
+	BlockSize() int
+	Encrypt(dst, src []byte)
+	Decrypt(dst, src []byte)
+	// EncryptBlocks encrypts multiple consecutive blocks (ECB mode). Length
+	// of src must be a multiple of BlockSize.
+	EncryptBlocks(dst, src []byte)
+}
+
+type KeySizeError int
+
+func (k KeySizeError) Error() string {
+	return "crypto/aes: invalid key size " + strconv.Itoa(int(k))
+}
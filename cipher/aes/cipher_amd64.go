@@ -0,0 +1,19 @@
+// +build amd64,!noasm
+
+package aes
+
+//go:noescape
+func encryptBlocks8Asm(nr int, xk *uint32, dst, src *byte)
+
+// encryptBlocksAsm encrypts all full blocks from src. Blocks are processed
+// 8 at a time.
+func encryptBlocksAsm(nr int, xk *uint32, dst, src []byte) {
+	for len(src) >= 8*BlockSize {
+		encryptBlocks8Asm(nr, xk, &dst[0], &src[0])
+		dst, src = dst[8*BlockSize:], src[8*BlockSize:]
+	}
+	for len(src) >= BlockSize {
+		encryptBlockAsm(nr, xk, &dst[0], &src[0])
+		dst, src = dst[BlockSize:], src[BlockSize:]
+	}
+}
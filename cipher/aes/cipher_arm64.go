@@ -0,0 +1,11 @@
+// +build arm64,!noasm
+
+package aes
+
+// encryptBlocksAsm encrypts all full blocks from src.
+func encryptBlocksAsm(nr int, xk *uint32, dst, src []byte) {
+	for len(src) >= BlockSize {
+		encryptBlockAsm(nr, xk, &dst[0], &src[0])
+		dst, src = dst[BlockSize:], src[BlockSize:]
+	}
+}
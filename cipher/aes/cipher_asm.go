@@ -1,12 +1,13 @@
 // Copyright 2012 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
-// +build amd64, !noasm
+
+// +build amd64,!noasm arm64,!noasm
 
 package aes
 
 import (
-	"github.com/henrydcase/nobs/utils"
+	"github.com/henrydcase/nobs/internal/alias"
 )
 
 // defined in asm_*.s
@@ -23,6 +24,7 @@ func expandKeyAsm(nr int, key *byte, enc *uint32, dec *uint32)
 type AESAsm struct {
 	enc [32 + 28]uint32
 	dec [32 + 28]uint32
+	nr  int
 }
 
 func (c *AESAsm) SetKey(key []byte) error {
@@ -34,9 +36,12 @@ func (c *AESAsm) SetKey(key []byte) error {
 		rounds = 12
 	case 256 / 8:
 		rounds = 14
+	default:
+		return KeySizeError(len(key))
 	}
 
 	expandKeyAsm(rounds, &key[0], &c.enc[0], &c.dec[0])
+	c.nr = rounds
 	return nil
 }
 
@@ -49,10 +54,10 @@ func (c *AESAsm) Encrypt(dst, src []byte) {
 	if len(dst) < BlockSize {
 		panic("crypto/aes: output not full block")
 	}
-	if InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
 		panic("crypto/aes: invalid buffer overlap")
 	}
-	encryptBlockAsm(len(c.enc)/4-1, &c.enc[0], &dst[0], &src[0])
+	encryptBlockAsm(c.nr, &c.enc[0], &dst[0], &src[0])
 }
 
 func (c *AESAsm) Decrypt(dst, src []byte) {
@@ -62,25 +67,21 @@ func (c *AESAsm) Decrypt(dst, src []byte) {
 	if len(dst) < BlockSize {
 		panic("crypto/aes: output not full block")
 	}
-	if InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
 		panic("crypto/aes: invalid buffer overlap")
 	}
-	decryptBlockAsm(len(c.dec)/4-1, &c.dec[0], &dst[0], &src[0])
+	decryptBlockAsm(c.nr, &c.dec[0], &dst[0], &src[0])
 }
 
-// expandKey is used by BenchmarkExpand to ensure that the asm implementation
-// of key expansion is used for the benchmark when it is available.
-func expandKey(key []byte, enc, dec []uint32) {
-	if utils.X86.HasAES {
-		rounds := 10 // rounds needed for AES128
-		switch len(key) {
-		case 192 / 8:
-			rounds = 12
-		case 256 / 8:
-			rounds = 14
-		}
-		expandKeyAsm(rounds, &key[0], &enc[0], &dec[0])
-	} else {
-		expandKeyGo(key, enc, dec)
+func (c *AESAsm) EncryptBlocks(dst, src []byte) {
+	if len(src)%BlockSize != 0 {
+		panic("crypto/aes: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("crypto/aes: output smaller than input")
+	}
+	if alias.InexactOverlap(dst[:len(src)], src) {
+		panic("crypto/aes: invalid buffer overlap")
 	}
+	encryptBlocksAsm(c.nr, &c.enc[0], dst, src)
 }
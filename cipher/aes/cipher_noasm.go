@@ -0,0 +1,30 @@
+// +build noasm !amd64,!arm64
+
+package aes
+
+import (
+	"errors"
+)
+
+var errNoAsm = errors.New("aes: hardware acceleration not available")
+
+// AESAsm is not available on this platform, SetKey always returns an error.
+type AESAsm struct{}
+
+func (a *AESAsm) SetKey(key []byte) error {
+	return errNoAsm
+}
+
+func (a *AESAsm) BlockSize() int { return BlockSize }
+
+func (a *AESAsm) Encrypt(dst, src []byte) {
+	panic(errNoAsm)
+}
+
+func (a *AESAsm) Decrypt(dst, src []byte) {
+	panic(errNoAsm)
+}
+
+func (a *AESAsm) EncryptBlocks(dst, src []byte) {
+	panic(errNoAsm)
+}
@@ -0,0 +1,159 @@
+package aes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/internal/block"
+	"github.com/henrydcase/nobs/internal/polyval"
+)
+
+const (
+	gcmSIVNonceSize = 12
+	gcmSIVTagSize   = 16
+	// Maximal length of the plaintext and additional data (RFC 8452, 6)
+	gcmSIVMaxInput = 1 << 36
+)
+
+// gcmSIV implements AES-GCM-SIV, nonce misuse-resistant AEAD described
+// in RFC 8452.
+type gcmSIV struct {
+	// Key-generating key
+	kgk    IAES
+	keyLen int
+}
+
+// NewGCMSIV returns AES-GCM-SIV AEAD. The key must be 16 or 32 bytes long
+// to select AEAD_AES_128_GCM_SIV or AEAD_AES_256_GCM_SIV.
+func NewGCMSIV(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, KeySizeError(len(key))
+	}
+	kgk, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmSIV{kgk: kgk, keyLen: len(key)}, nil
+}
+
+func (g *gcmSIV) NonceSize() int { return gcmSIVNonceSize }
+func (g *gcmSIV) Overhead() int  { return gcmSIVTagSize }
+
+// deriveKeys derives per-nonce message-authentication and
+// message-encryption keys (RFC 8452, 4).
+func (g *gcmSIV) deriveKeys(authKey *[16]byte, nonce []byte) IAES {
+	var in, out [BlockSize]byte
+	var encKey [32]byte
+
+	copy(in[4:], nonce)
+	n := 2 + g.keyLen/8
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint32(in[:], uint32(i))
+		g.kgk.Encrypt(out[:], in[:])
+		if i < 2 {
+			copy(authKey[8*i:], out[:8])
+		} else {
+			copy(encKey[8*(i-2):], out[:8])
+		}
+	}
+	enc, _ := newBlock(encKey[:g.keyLen])
+	for i := range encKey {
+		encKey[i] = 0
+	}
+	return enc
+}
+
+// tag computes the tag of the plaintext and additional data.
+func (g *gcmSIV) tag(out *[gcmSIVTagSize]byte, enc IAES, authKey *[16]byte, nonce, plaintext, ad []byte) {
+	var p polyval.Polyval
+	var lens [BlockSize]byte
+
+	p.Init(authKey[:], false)
+	p.Update(ad)
+	p.Update(plaintext)
+	binary.LittleEndian.PutUint64(lens[:8], uint64(len(ad))*8)
+	binary.LittleEndian.PutUint64(lens[8:], uint64(len(plaintext))*8)
+	p.Update(lens[:])
+	p.Sum(out)
+
+	block.XOR(out[:], out[:gcmSIVNonceSize], nonce)
+	out[15] &= 0x7f
+	enc.Encrypt(out[:], out[:])
+}
+
+func (g *gcmSIV) Seal(dst, nonce, plaintext, ad []byte) []byte {
+	var authKey [16]byte
+	var tag [gcmSIVTagSize]byte
+	var ctr [BlockSize]byte
+
+	if len(nonce) != gcmSIVNonceSize {
+		panic("crypto/aes: incorrect nonce length given to GCM-SIV")
+	}
+	if uint64(len(plaintext)) > gcmSIVMaxInput || uint64(len(ad)) > gcmSIVMaxInput {
+		panic("crypto/aes: message too large for GCM-SIV")
+	}
+
+	ret, out := block.SliceForAppend(dst, len(plaintext)+gcmSIVTagSize)
+	if alias.InexactOverlap(out, plaintext) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+
+	enc := g.deriveKeys(&authKey, nonce)
+	g.tag(&tag, enc, &authKey, nonce, plaintext, ad)
+
+	ctr = tag
+	ctr[15] |= 0x80
+	block.CTR(enc, &ctr, incLE32, out, plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcmSIV) Open(dst, nonce, ciphertext, ad []byte) ([]byte, error) {
+	var authKey [16]byte
+	var tag [gcmSIVTagSize]byte
+	var ctr [BlockSize]byte
+
+	if len(nonce) != gcmSIVNonceSize {
+		panic("crypto/aes: incorrect nonce length given to GCM-SIV")
+	}
+	if len(ciphertext) < gcmSIVTagSize ||
+		uint64(len(ciphertext)) > gcmSIVMaxInput+gcmSIVTagSize ||
+		uint64(len(ad)) > gcmSIVMaxInput {
+		return nil, block.ErrOpen
+	}
+
+	copy(ctr[:], ciphertext[len(ciphertext)-gcmSIVTagSize:])
+	exp := ctr
+	ciphertext = ciphertext[:len(ciphertext)-gcmSIVTagSize]
+
+	ret, out := block.SliceForAppend(dst, len(ciphertext))
+	if alias.InexactOverlap(out, ciphertext) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+
+	enc := g.deriveKeys(&authKey, nonce)
+	ctr[15] |= 0x80
+	block.CTR(enc, &ctr, incLE32, out, ciphertext)
+
+	g.tag(&tag, enc, &authKey, nonce, out, ad)
+	if subtle.ConstantTimeCompare(tag[:], exp[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, block.ErrOpen
+	}
+	return ret, nil
+}
+
+// incLE32 increments the first 32 bits of the counter block, as
+// little-endian integer (RFC 8452, 4).
+func incLE32(ctr *[BlockSize]byte) {
+	for i := 0; i < 4; i++ {
+		ctr[i]++
+		if ctr[i] != 0 {
+			break
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// RFC 8452, Appendix C
+var gcmSIVTests = []struct {
+	key, nonce, pt, ad, ct string
+}{
+	{
+		"01000000000000000000000000000000", "030000000000000000000000", "", "",
+		"dc20e2d83f25705bb49e439eca56de25",
+	},
+	{
+		"01000000000000000000000000000000", "030000000000000000000000",
+		"0100000000000000", "",
+		"b5d839330ac7b786578782fff6013b815b287c22493a364c",
+	},
+	{
+		"01000000000000000000000000000000", "030000000000000000000000",
+		"010000000000000000000000", "",
+		"7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639",
+	},
+	{
+		"01000000000000000000000000000000", "030000000000000000000000",
+		"0200000000000000", "01",
+		"1e6daba35669f4273b0a1a2560969cdf790d99759abd1508",
+	},
+	{
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000", "", "",
+		"07f5f4169bbf55a8400cd47ea6fd400f",
+	},
+	{
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000", "0100000000000000", "",
+		"c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+	},
+	{
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000", "010000000000000000000000", "",
+		"9aab2aeb3faa0a34aea8e2b18ca50da9ae6559e48fd10f6e5c9ca17e",
+	},
+}
+
+func testAEAD(t *testing.T, name string, a cipher.AEAD, nonce, pt, ad, exp []byte) {
+	ct := a.Seal(nil, nonce, pt, ad)
+	if !bytes.Equal(ct, exp) {
+		t.Errorf("%s: Seal: got %X, want %X", name, ct, exp)
+	}
+	out, err := a.Open(nil, nonce, ct, ad)
+	if err != nil || !bytes.Equal(out, pt) {
+		t.Errorf("%s: Open failed", name)
+	}
+
+	// Tampered ciphertext, tag and additional data
+	ct[0] ^= 1
+	if _, err := a.Open(nil, nonce, ct, ad); err == nil {
+		t.Errorf("%s: Open accepted modified ciphertext", name)
+	}
+	ct[0] ^= 1
+	if _, err := a.Open(nil, nonce, ct, append(ad, 0)); err == nil {
+		t.Errorf("%s: Open accepted modified additional data", name)
+	}
+	if _, err := a.Open(nil, nonce, ct[:len(ct)-1], ad); err == nil {
+		t.Errorf("%s: Open accepted truncated ciphertext", name)
+	}
+
+	// In-place, appending to prefix
+	buf := append([]byte("prefix"), pt...)
+	ct = a.Seal(buf[:6], nonce, buf[6:], ad)
+	if !bytes.Equal(ct[6:], exp) {
+		t.Errorf("%s: in-place Seal failed", name)
+	}
+	out, err = a.Open(ct[6:6], nonce, ct[6:], ad)
+	if err != nil || !bytes.Equal(out, pt) {
+		t.Errorf("%s: in-place Open failed", name)
+	}
+}
+
+func TestGCMSIV(t *testing.T) {
+	for i, tt := range gcmSIVTests {
+		a, err := NewGCMSIV(test.FromHex(tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAEAD(t, fmt.Sprintf("GCM-SIV %d", i), a,
+			test.FromHex(tt.nonce), test.FromHex(tt.pt), test.FromHex(tt.ad), test.FromHex(tt.ct))
+	}
+	if _, err := NewGCMSIV(make([]byte, 24)); err == nil {
+		t.Error("AES-192 must not be accepted")
+	}
+}
+
+func TestIncLE32(t *testing.T) {
+	var ctr [BlockSize]byte
+	copy(ctr[:], test.FromHex("ffffffff01"))
+	incLE32(&ctr)
+	if !bytes.Equal(ctr[:5], test.FromHex("0000000001")) {
+		t.Errorf("wrong increment %X", ctr)
+	}
+}
+
+func BenchmarkGCMSIV(b *testing.B) {
+	buf := make([]byte, 8192+gcmSIVTagSize)
+	nonce := make([]byte, gcmSIVNonceSize)
+	a, _ := NewGCMSIV(make([]byte, 16))
+	b.SetBytes(int64(len(buf) - gcmSIVTagSize))
+	for i := 0; i < b.N; i++ {
+		a.Seal(buf[:0], nonce, buf[:len(buf)-gcmSIVTagSize], nil)
+	}
+}
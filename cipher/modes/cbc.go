@@ -0,0 +1,82 @@
+package modes
+
+import (
+	"crypto/cipher"
+
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/internal/block"
+)
+
+// cbc implements cipher block chaining mode (SP800-38A, 6.2).
+type cbc struct {
+	b   cipher.Block
+	iv  [blockSize]byte
+	tmp [blockSize]byte
+}
+
+type cbcEncrypter cbc
+type cbcDecrypter cbc
+
+func newCBC(b cipher.Block, iv []byte) *cbc {
+	if b.BlockSize() != blockSize {
+		panic("modes: CBC requires 128-bit block cipher")
+	}
+	if len(iv) != blockSize {
+		panic("modes: CBC IV length must equal block size")
+	}
+	c := &cbc{b: b}
+	copy(c.iv[:], iv)
+	return c
+}
+
+func checkBlocks(dst, src []byte) {
+	if len(src)%blockSize != 0 {
+		panic("modes: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("modes: output smaller than input")
+	}
+	if alias.InexactOverlap(dst[:len(src)], src) {
+		panic("modes: invalid buffer overlap")
+	}
+}
+
+// NewCBCEncrypter returns a cipher.BlockMode which encrypts in cipher block
+// chaining mode, using the given block cipher. The length of iv must be the
+// same as the block size, which must be 16 bytes.
+func NewCBCEncrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return (*cbcEncrypter)(newCBC(b, iv))
+}
+
+func (x *cbcEncrypter) BlockSize() int { return blockSize }
+
+func (x *cbcEncrypter) CryptBlocks(dst, src []byte) {
+	checkBlocks(dst, src)
+	for len(src) > 0 {
+		block.XOR(x.iv[:], x.iv[:], src[:blockSize])
+		x.b.Encrypt(dst, x.iv[:])
+		copy(x.iv[:], dst[:blockSize])
+		dst, src = dst[blockSize:], src[blockSize:]
+	}
+}
+
+// NewCBCDecrypter returns a cipher.BlockMode which decrypts in cipher block
+// chaining mode, using the given block cipher. The length of iv must be the
+// same as the block size, which must be 16 bytes.
+func NewCBCDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	return (*cbcDecrypter)(newCBC(b, iv))
+}
+
+func (x *cbcDecrypter) BlockSize() int { return blockSize }
+
+func (x *cbcDecrypter) CryptBlocks(dst, src []byte) {
+	checkBlocks(dst, src)
+	for len(src) > 0 {
+		// src may alias dst, keep the ciphertext block for chaining
+		copy(x.tmp[:], src[:blockSize])
+		x.b.Decrypt(dst, src)
+		block.XOR(dst, dst[:blockSize], x.iv[:])
+		x.iv = x.tmp
+		dst, src = dst[blockSize:], src[blockSize:]
+	}
+}
@@ -0,0 +1,176 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/internal/block"
+)
+
+// ccm implements Counter with CBC-MAC mode (SP800-38C, RFC 3610).
+type ccm struct {
+	b cipher.Block
+	// Length of the nonce and the tag
+	nonceSize int
+	tagSize   int
+}
+
+// NewCCM returns the given 128-bit block cipher wrapped in Counter with
+// CBC-MAC mode. Nonce size must be in range [7, 13] and tag size must be
+// an even number in range [4, 16]. Nonce size determines maximal length
+// of the plaintext, which is 2^(8*(15-nonceSize)) - 1 bytes.
+func NewCCM(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("modes: CCM requires 128-bit block cipher")
+	}
+	if nonceSize < 7 || nonceSize > 13 {
+		return nil, errors.New("modes: invalid CCM nonce size")
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize&1 != 0 {
+		return nil, errors.New("modes: invalid CCM tag size")
+	}
+	return &ccm{b: b, nonceSize: nonceSize, tagSize: tagSize}, nil
+}
+
+func (c *ccm) NonceSize() int { return c.nonceSize }
+func (c *ccm) Overhead() int  { return c.tagSize }
+
+// maxLen returns maximal length of the plaintext.
+func (c *ccm) maxLen() uint64 {
+	l := uint(15 - c.nonceSize)
+	if l >= 8 {
+		return 1<<64 - 1
+	}
+	return 1<<(8*l) - 1
+}
+
+// counter returns counter block A_i (RFC 3610, 2.3) for i=0.
+func (c *ccm) counter(a *[blockSize]byte, nonce []byte) {
+	*a = [blockSize]byte{}
+	a[0] = byte(14 - c.nonceSize)
+	copy(a[1:], nonce)
+}
+
+// tag computes CBC-MAC of the plaintext and additional data, as described
+// in RFC 3610, 2.2.
+func (c *ccm) tag(out *[blockSize]byte, nonce, plaintext, ad []byte) {
+	var x, blk [blockSize]byte
+	var n int
+
+	// Flags || Nonce || length of the plaintext
+	x[0] = byte(((c.tagSize-2)/2)<<3 | (14 - c.nonceSize))
+	if len(ad) > 0 {
+		x[0] |= 0x40
+	}
+	copy(x[1:], nonce)
+	for i, l := blockSize-1, uint64(len(plaintext)); i > c.nonceSize; i, l = i-1, l>>8 {
+		x[i] = byte(l)
+	}
+	c.b.Encrypt(x[:], x[:])
+
+	// Encoded length of additional data, followed by the data
+	if len(ad) > 0 {
+		switch l := uint64(len(ad)); {
+		case l < 0xff00:
+			binary.BigEndian.PutUint16(blk[:], uint16(l))
+			n = 2
+		case l <= 0xffffffff:
+			blk[0], blk[1] = 0xff, 0xfe
+			binary.BigEndian.PutUint32(blk[2:], uint32(l))
+			n = 6
+		default:
+			blk[0], blk[1] = 0xff, 0xff
+			binary.BigEndian.PutUint64(blk[2:], l)
+			n = 10
+		}
+		c.mac(&x, &blk, n, ad)
+	}
+	blk = [blockSize]byte{}
+	c.mac(&x, &blk, 0, plaintext)
+	*out = x
+}
+
+// mac absorbs data into CBC-MAC state x. First n bytes of blk are
+// prepended to the data, which must not be empty. Data is padded with
+// zeros to a multiple of the block size.
+func (c *ccm) mac(x, blk *[blockSize]byte, n int, data []byte) {
+	for len(data) > 0 {
+		m := copy(blk[n:], data)
+		data = data[m:]
+		if n+m < blockSize {
+			for i := n + m; i < blockSize; i++ {
+				blk[i] = 0
+			}
+		}
+		block.XOR(x[:], x[:], blk[:])
+		c.b.Encrypt(x[:], x[:])
+		n = 0
+	}
+}
+
+func (c *ccm) Seal(dst, nonce, plaintext, ad []byte) []byte {
+	var a [blockSize]byte
+	var tag [blockSize]byte
+
+	if len(nonce) != c.nonceSize {
+		panic("modes: incorrect nonce length given to CCM")
+	}
+	if uint64(len(plaintext)) > c.maxLen() {
+		panic("modes: message too large for CCM")
+	}
+
+	ret, out := block.SliceForAppend(dst, len(plaintext)+c.tagSize)
+	if alias.InexactOverlap(out, plaintext) {
+		panic("modes: invalid buffer overlap")
+	}
+
+	c.tag(&tag, nonce, plaintext, ad)
+	c.counter(&a, nonce)
+	s := NewCTR(c.b, a[:])
+	// First block of the keystream encrypts the tag
+	s.XORKeyStream(tag[:], tag[:])
+	s.XORKeyStream(out, plaintext)
+	copy(out[len(plaintext):], tag[:c.tagSize])
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, ciphertext, ad []byte) ([]byte, error) {
+	var a [blockSize]byte
+	var tag [blockSize]byte
+
+	if len(nonce) != c.nonceSize {
+		panic("modes: incorrect nonce length given to CCM")
+	}
+	if len(ciphertext) < c.tagSize ||
+		uint64(len(ciphertext)-c.tagSize) > c.maxLen() {
+		return nil, block.ErrOpen
+	}
+
+	exp := ciphertext[len(ciphertext)-c.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-c.tagSize]
+
+	ret, out := block.SliceForAppend(dst, len(ciphertext))
+	if alias.InexactOverlap(out, ciphertext) {
+		panic("modes: invalid buffer overlap")
+	}
+
+	// Tag is computed over the plaintext, which must be decrypted first
+	c.counter(&a, nonce)
+	s := NewCTR(c.b, a[:])
+	s.XORKeyStream(tag[:], tag[:])
+	mask := tag
+	s.XORKeyStream(out, ciphertext)
+
+	c.tag(&tag, nonce, out, ad)
+	block.XOR(tag[:], tag[:], mask[:])
+	if subtle.ConstantTimeCompare(tag[:c.tagSize], exp) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, block.ErrOpen
+	}
+	return ret, nil
+}
@@ -0,0 +1,82 @@
+package modes
+
+import (
+	"crypto/cipher"
+
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/internal/block"
+)
+
+// ctr implements counter mode (SP800-38A, 6.5). Counter is incremented
+// as 128-bit big-endian integer.
+type ctr struct {
+	b   cipher.Block
+	ctr [blockSize]byte
+	// Counter blocks and corresponding keystream
+	blks [block.Batch * blockSize]byte
+	ks   [block.Batch * blockSize]byte
+	// Number of keystream bytes already used
+	used int
+}
+
+// NewCTR returns a cipher.Stream which encrypts/decrypts using the given
+// block cipher in counter mode. The length of iv must be the same as the
+// block size, which must be 16 bytes.
+func NewCTR(b cipher.Block, iv []byte) cipher.Stream {
+	if b.BlockSize() != blockSize {
+		panic("modes: CTR requires 128-bit block cipher")
+	}
+	if len(iv) != blockSize {
+		panic("modes: CTR IV length must equal block size")
+	}
+	c := &ctr{b: b}
+	copy(c.ctr[:], iv)
+	c.used = len(c.ks)
+	return c
+}
+
+func inc128(ctr *[blockSize]byte) {
+	for i := blockSize - 1; i >= 0; i-- {
+		ctr[i]++
+		if ctr[i] != 0 {
+			break
+		}
+	}
+}
+
+func (c *ctr) refill() {
+	for i := 0; i < len(c.blks); i += blockSize {
+		copy(c.blks[i:], c.ctr[:])
+		inc128(&c.ctr)
+	}
+	block.Encrypt(c.b, c.ks[:], c.blks[:])
+	c.used = 0
+}
+
+func (c *ctr) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("modes: output smaller than input")
+	}
+	if alias.InexactOverlap(dst[:len(src)], src) {
+		panic("modes: invalid buffer overlap")
+	}
+	for len(src) > 0 {
+		if c.used == len(c.ks) {
+			c.refill()
+		}
+		n := block.XOR(dst, src, c.ks[c.used:])
+		c.used += n
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// incBE32 increments the last 32 bits of the counter block, as big-endian
+// integer. Used by GCM.
+func incBE32(ctr *[blockSize]byte) {
+	for i := blockSize - 1; i >= blockSize-4; i-- {
+		ctr[i]++
+		if ctr[i] != 0 {
+			break
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package modes
+
+import (
+	"crypto/cipher"
+
+	"github.com/henrydcase/nobs/internal/block"
+)
+
+// ecb implements electronic codebook mode (SP800-38A, 6.1). Each block is
+// processed independently, hence identical plaintext blocks produce
+// identical ciphertext blocks. It should only be used for compatibility.
+type ecb struct {
+	b cipher.Block
+}
+
+type ecbEncrypter ecb
+type ecbDecrypter ecb
+
+func newECB(b cipher.Block) *ecb {
+	if b.BlockSize() != blockSize {
+		panic("modes: ECB requires 128-bit block cipher")
+	}
+	return &ecb{b: b}
+}
+
+// NewECBEncrypter returns a cipher.BlockMode which encrypts in electronic
+// codebook mode, using the given 128-bit block cipher.
+func NewECBEncrypter(b cipher.Block) cipher.BlockMode {
+	return (*ecbEncrypter)(newECB(b))
+}
+
+func (x *ecbEncrypter) BlockSize() int { return blockSize }
+
+func (x *ecbEncrypter) CryptBlocks(dst, src []byte) {
+	checkBlocks(dst, src)
+	block.Encrypt(x.b, dst, src)
+}
+
+// NewECBDecrypter returns a cipher.BlockMode which decrypts in electronic
+// codebook mode, using the given 128-bit block cipher.
+func NewECBDecrypter(b cipher.Block) cipher.BlockMode {
+	return (*ecbDecrypter)(newECB(b))
+}
+
+func (x *ecbDecrypter) BlockSize() int { return blockSize }
+
+func (x *ecbDecrypter) CryptBlocks(dst, src []byte) {
+	checkBlocks(dst, src)
+	for len(src) > 0 {
+		x.b.Decrypt(dst, src)
+		dst, src = dst[blockSize:], src[blockSize:]
+	}
+}
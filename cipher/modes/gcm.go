@@ -0,0 +1,121 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/internal/block"
+	"github.com/henrydcase/nobs/internal/polyval"
+)
+
+const (
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+	// Maximal length of the plaintext: 2^39 - 256 bits (SP800-38D, 5.2.1.1)
+	gcmMaxPlaintext = (1<<32 - 2) * blockSize
+)
+
+// gcm implements Galois/Counter Mode (SP800-38D) with 96-bit nonce and
+// 128-bit tag.
+type gcm struct {
+	b cipher.Block
+	// Hash subkey H
+	h [blockSize]byte
+}
+
+// NewGCM returns the given 128-bit block cipher wrapped in Galois Counter
+// Mode with the standard nonce length (12 bytes) and tag length (16 bytes).
+func NewGCM(b cipher.Block) (cipher.AEAD, error) {
+	if b.BlockSize() != blockSize {
+		return nil, errors.New("modes: GCM requires 128-bit block cipher")
+	}
+	g := &gcm{b: b}
+	b.Encrypt(g.h[:], g.h[:])
+	return g, nil
+}
+
+func (g *gcm) NonceSize() int { return gcmNonceSize }
+func (g *gcm) Overhead() int  { return gcmTagSize }
+
+// tag computes authentication tag of the ciphertext and additional data.
+func (g *gcm) tag(out *[gcmTagSize]byte, j0 *[blockSize]byte, ciphertext, ad []byte) {
+	var p polyval.Polyval
+	var lens, mask [blockSize]byte
+
+	p.Init(g.h[:], true)
+	p.Update(ad)
+	p.Update(ciphertext)
+	binary.BigEndian.PutUint64(lens[:8], uint64(len(ad))*8)
+	binary.BigEndian.PutUint64(lens[8:], uint64(len(ciphertext))*8)
+	p.Update(lens[:])
+	p.Sum(out)
+
+	g.b.Encrypt(mask[:], j0[:])
+	block.XOR(out[:], out[:], mask[:])
+}
+
+// counter returns pre-counter block J0 for 96-bit nonce.
+func (g *gcm) counter(j0 *[blockSize]byte, nonce []byte) {
+	copy(j0[:], nonce)
+	j0[blockSize-1] = 1
+}
+
+func (g *gcm) Seal(dst, nonce, plaintext, ad []byte) []byte {
+	var j0, ctr [blockSize]byte
+	var tag [gcmTagSize]byte
+
+	if len(nonce) != gcmNonceSize {
+		panic("modes: incorrect nonce length given to GCM")
+	}
+	if uint64(len(plaintext)) > gcmMaxPlaintext {
+		panic("modes: message too large for GCM")
+	}
+
+	ret, out := block.SliceForAppend(dst, len(plaintext)+gcmTagSize)
+	if alias.InexactOverlap(out, plaintext) {
+		panic("modes: invalid buffer overlap")
+	}
+
+	g.counter(&j0, nonce)
+	ctr = j0
+	incBE32(&ctr)
+	block.CTR(g.b, &ctr, incBE32, out, plaintext)
+	g.tag(&tag, &j0, out[:len(plaintext)], ad)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcm) Open(dst, nonce, ciphertext, ad []byte) ([]byte, error) {
+	var j0, ctr [blockSize]byte
+	var tag [gcmTagSize]byte
+
+	if len(nonce) != gcmNonceSize {
+		panic("modes: incorrect nonce length given to GCM")
+	}
+	if len(ciphertext) < gcmTagSize ||
+		uint64(len(ciphertext)) > gcmMaxPlaintext+gcmTagSize {
+		return nil, block.ErrOpen
+	}
+
+	exp := ciphertext[len(ciphertext)-gcmTagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-gcmTagSize]
+
+	g.counter(&j0, nonce)
+	g.tag(&tag, &j0, ciphertext, ad)
+
+	ret, out := block.SliceForAppend(dst, len(ciphertext))
+	if alias.InexactOverlap(out, ciphertext) {
+		panic("modes: invalid buffer overlap")
+	}
+	if subtle.ConstantTimeCompare(tag[:], exp) != 1 {
+		return nil, block.ErrOpen
+	}
+
+	ctr = j0
+	incBE32(&ctr)
+	block.CTR(g.b, &ctr, incBE32, out, ciphertext)
+	return ret, nil
+}
@@ -0,0 +1,188 @@
+package modes
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// Test cases from "The Galois/Counter Mode of Operation (GCM)",
+// McGrew and Viega.
+var gcmTests = []struct {
+	key, nonce, pt, ad, ct string
+}{
+	{
+		"00000000000000000000000000000000", "000000000000000000000000", "", "",
+		"58e2fccefa7e3061367f1d57a4e7455a",
+	},
+	{
+		"00000000000000000000000000000000", "000000000000000000000000",
+		"00000000000000000000000000000000", "",
+		"0388dace60b6a392f328c2b971b2fe78ab6e47d42cec13bdf53a67b21257bddf",
+	},
+	{
+		"feffe9928665731c6d6a8f9467308308", "cafebabefacedbaddecaf888",
+		"d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a72" +
+			"1c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b391aafd255",
+		"",
+		"42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e" +
+			"21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091473f5985" +
+			"4d5c2af327cd64a62cf35abd2ba6fab4",
+	},
+	{
+		"feffe9928665731c6d6a8f9467308308", "cafebabefacedbaddecaf888",
+		"d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a72" +
+			"1c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+		"feedfacedeadbeeffeedfacedeadbeefabaddad2",
+		"42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e" +
+			"21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091" +
+			"5bc94fbc3221a5db94fae95ae7121a47",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"000000000000000000000000", "", "",
+		"530f8afbc74536b9a963b4f1c4cb738b",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"000000000000000000000000", "00000000000000000000000000000000", "",
+		"cea7403d4d606b6e074ec5d3baf39d18d0d1c8a799996bf0265b98b5d48ab919",
+	},
+	{
+		"feffe9928665731c6d6a8f9467308308feffe9928665731c6d6a8f9467308308",
+		"cafebabefacedbaddecaf888",
+		"d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a72" +
+			"1c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+		"feedfacedeadbeeffeedfacedeadbeefabaddad2",
+		"522dc1f099567d07f47f37a32a84427d643a8cdcbfe5c0c97598a2bd2555d1aa" +
+			"8cb08e48590dbb3da7b08b1056828838c5f61e6393ba7a0abcc9f662" +
+			"76fc6ece0f4e1768cddf8853bb2d551b",
+	},
+}
+
+// SP800-38C, Appendix C (examples 1, 2 and 4), RFC 3610 (packet vector #1)
+var ccmTests = []struct {
+	key, nonce, pt, ad, ct string
+	tagSize                int
+}{
+	{
+		"404142434445464748494a4b4c4d4e4f", "10111213141516", "20212223", "0001020304050607",
+		"7162015b4dac255d", 4,
+	},
+	{
+		"404142434445464748494a4b4c4d4e4f", "1011121314151617",
+		"202122232425262728292a2b2c2d2e2f", "000102030405060708090a0b0c0d0e0f",
+		"d2a1f0e051ea5f62081a7792073d593d1fc64fbfaccd", 6,
+	},
+	{
+		"404142434445464748494a4b4c4d4e4f", "101112131415161718191a1b1c",
+		"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f", "",
+		"69915dad1e84c6376a68c2967e4dab615ae0fd1faec44cc484828529463ccf72" +
+			"b4ac6bec93e8598e7f0dadbcea5b", 14,
+	},
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf", "00000003020100a0a1a2a3a4a5",
+		"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e", "0001020304050607",
+		"588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0", 8,
+	},
+}
+
+func testAEAD(t *testing.T, name string, a cipher.AEAD, nonce, pt, ad, exp []byte) {
+	ct := a.Seal(nil, nonce, pt, ad)
+	if !bytes.Equal(ct, exp) {
+		t.Errorf("%s: Seal: got %X, want %X", name, ct, exp)
+	}
+	out, err := a.Open(nil, nonce, ct, ad)
+	if err != nil || !bytes.Equal(out, pt) {
+		t.Errorf("%s: Open failed", name)
+	}
+
+	// Tampered ciphertext, tag and additional data
+	ct[0] ^= 1
+	if _, err := a.Open(nil, nonce, ct, ad); err == nil {
+		t.Errorf("%s: Open accepted modified ciphertext", name)
+	}
+	ct[0] ^= 1
+	if _, err := a.Open(nil, nonce, ct, append(ad, 0)); err == nil {
+		t.Errorf("%s: Open accepted modified additional data", name)
+	}
+	if _, err := a.Open(nil, nonce, ct[:len(ct)-1], ad); err == nil {
+		t.Errorf("%s: Open accepted truncated ciphertext", name)
+	}
+
+	// In-place, appending to prefix
+	buf := append([]byte("prefix"), pt...)
+	ct = a.Seal(buf[:6], nonce, buf[6:], ad)
+	if !bytes.Equal(ct[6:], exp) {
+		t.Errorf("%s: in-place Seal failed", name)
+	}
+	out, err = a.Open(ct[6:6], nonce, ct[6:], ad)
+	if err != nil || !bytes.Equal(out, pt) {
+		t.Errorf("%s: in-place Open failed", name)
+	}
+}
+
+func TestGCM(t *testing.T) {
+	for i, tt := range gcmTests {
+		for _, b := range allCiphers(t, test.FromHex(tt.key)) {
+			a, err := NewGCM(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testAEAD(t, fmt.Sprintf("%s GCM %d", typeName(b), i), a,
+				test.FromHex(tt.nonce), test.FromHex(tt.pt), test.FromHex(tt.ad), test.FromHex(tt.ct))
+		}
+	}
+}
+
+func TestCCM(t *testing.T) {
+	for i, tt := range ccmTests {
+		ad := test.FromHex(tt.ad)
+		if i == 2 {
+			// 65536 bytes of additional data
+			ad = make([]byte, 1<<16)
+			for j := range ad {
+				ad[j] = byte(j)
+			}
+		}
+		for _, b := range allCiphers(t, test.FromHex(tt.key)) {
+			nonce := test.FromHex(tt.nonce)
+			a, err := NewCCM(b, len(nonce), tt.tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testAEAD(t, fmt.Sprintf("%s CCM %d", typeName(b), i), a,
+				nonce, test.FromHex(tt.pt), ad, test.FromHex(tt.ct))
+		}
+	}
+
+	b, _ := aes.NewCipher(make([]byte, 16))
+	for _, p := range [][2]int{{6, 8}, {14, 8}, {12, 2}, {12, 18}, {12, 9}} {
+		if _, err := NewCCM(b, p[0], p[1]); err == nil {
+			t.Errorf("nonce size %d and tag size %d accepted", p[0], p[1])
+		}
+	}
+	// Nonce of 13 bytes limits plaintext to 2^16-1 bytes
+	a, _ := NewCCM(b, 13, 16)
+	mustPanic(t, "modes: message too large for CCM", func() {
+		a.Seal(nil, make([]byte, 13), make([]byte, 1<<16), nil)
+	})
+}
+
+func BenchmarkGCM(b *testing.B) {
+	buf := make([]byte, 8192+gcmTagSize)
+	nonce := make([]byte, gcmNonceSize)
+	for _, c := range allCiphers(b, make([]byte, 16)) {
+		b.Run(typeName(c), func(b *testing.B) {
+			a, _ := NewGCM(c)
+			b.SetBytes(int64(len(buf) - gcmTagSize))
+			for i := 0; i < b.N; i++ {
+				a.Seal(buf[:0], nonce, buf[:len(buf)-gcmTagSize], nil)
+			}
+		})
+	}
+}
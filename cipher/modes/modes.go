@@ -0,0 +1,10 @@
+// Package modes implements modes of operation of 128-bit block ciphers:
+// ECB, CBC, CTR (SP800-38A), GCM (SP800-38D) and CCM (SP800-38C, RFC 3610).
+// Modes work with any cipher.Block, like AES from cipher/aes or SM4 from
+// cipher/sm4. Block ciphers which can encrypt multiple blocks at once,
+// by implementing EncryptBlocks method, are used in this way by ECB, CTR
+// and GCM.
+package modes
+
+// Size of the block of the underlying block cipher
+const blockSize = 16
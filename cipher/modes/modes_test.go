@@ -0,0 +1,200 @@
+package modes
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/internal/aestable"
+	"github.com/henrydcase/nobs/internal/test"
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Returns all implementations of AES available on the platform,
+// keyed with 'key'.
+func allCiphers(t testing.TB, key []byte) []cipher.Block {
+	var ret []cipher.Block
+	for _, c := range implementations() {
+		if err := c.SetKey(key); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func typeName(b cipher.Block) string {
+	return fmt.Sprintf("%T", b)[1:]
+}
+
+// Returns implementations of AES, modes are tested with all of them.
+func implementations() []aes.IAES {
+	impls := []aes.IAES{new(aestable.Cipher), new(aes.AESCT)}
+	if utils.X86.HasAES {
+		impls = append(impls, new(aes.AESAsm))
+	}
+	return impls
+}
+
+func mustPanic(t *testing.T, msg string, f func()) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("function did not panic, wanted %q", msg)
+		} else if err != msg {
+			t.Errorf("got panic %v, wanted %q", err, msg)
+		}
+	}()
+	f()
+}
+
+// SP800-38A, F.2 and F.5
+var sp80038aKeys = []string{
+	"2b7e151628aed2a6abf7158809cf4f3c",
+	"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+}
+
+const sp80038aPlaintext = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+	"30c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+// SP800-38A, F.1.1
+const ecbAES128Ciphertext = "3ad77bb40d7a3660a89ecaf32466ef97f5d3d58503b9699de785895a96fdbaaf" +
+	"43b1cd7f598ece23881b00e3ed0306887b0c785e27e8ad3f8223207104725dd4"
+
+var cbcTests = []struct {
+	key, iv, out string
+}{
+	{
+		sp80038aKeys[0], "000102030405060708090a0b0c0d0e0f",
+		"7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2" +
+			"73bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7",
+	},
+	{
+		sp80038aKeys[1], "000102030405060708090a0b0c0d0e0f",
+		"f58c4c04d6e5f1ba779eabfb5f7bfbd69cfc4e967edb808d679f777bc6702c7d" +
+			"39f23369a9d9bacfa530e26304231461b2eb05e2c39be9fcda6c19078c6a9d1b",
+	},
+}
+
+var ctrTests = []struct {
+	key, iv, out string
+}{
+	{
+		sp80038aKeys[0], "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		"874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff" +
+			"5ae4df3edbd5d35e5b4f09020db03eab1e031dda2fbe03d1792170a0f3009cee",
+	},
+	{
+		sp80038aKeys[1], "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		"601ec313775789a5b7a7f504bbf3d228f443e3ca4d62b59aca84e990cacaf5c5" +
+			"2b0930daa23de94ce87017ba2d84988ddfc9c58db67aada613c2dd08457941a6",
+	},
+}
+
+func TestECB(t *testing.T) {
+	pt := test.FromHex(sp80038aPlaintext)
+	exp := test.FromHex(ecbAES128Ciphertext)
+	for _, b := range allCiphers(t, test.FromHex(sp80038aKeys[0])) {
+		out := make([]byte, len(pt))
+		NewECBEncrypter(b).CryptBlocks(out, pt)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("%T: ECB encrypt: got %X, want %X", b, out, exp)
+		}
+		NewECBDecrypter(b).CryptBlocks(out, out)
+		if !bytes.Equal(out, pt) {
+			t.Errorf("%T: ECB decrypt: got %X, want %X", b, out, pt)
+		}
+	}
+}
+
+func TestCBC(t *testing.T) {
+	pt := test.FromHex(sp80038aPlaintext)
+	for i, tt := range cbcTests {
+		exp := test.FromHex(tt.out)
+		for _, b := range allCiphers(t, test.FromHex(tt.key)) {
+			out := make([]byte, len(pt))
+			NewCBCEncrypter(b, test.FromHex(tt.iv)).CryptBlocks(out, pt)
+			if !bytes.Equal(out, exp) {
+				t.Errorf("%T: CBC encrypt %d: got %X, want %X", b, i, out, exp)
+			}
+			// In-place, one block at a time
+			d := NewCBCDecrypter(b, test.FromHex(tt.iv))
+			for j := 0; j < len(out); j += blockSize {
+				d.CryptBlocks(out[j:j+blockSize], out[j:j+blockSize])
+			}
+			if !bytes.Equal(out, pt) {
+				t.Errorf("%T: CBC decrypt %d: got %X, want %X", b, i, out, pt)
+			}
+		}
+	}
+}
+
+func TestCTR(t *testing.T) {
+	pt := test.FromHex(sp80038aPlaintext)
+	for i, tt := range ctrTests {
+		exp := test.FromHex(tt.out)
+		for _, b := range allCiphers(t, test.FromHex(tt.key)) {
+			out := make([]byte, len(pt))
+			NewCTR(b, test.FromHex(tt.iv)).XORKeyStream(out, pt)
+			if !bytes.Equal(out, exp) {
+				t.Errorf("%T: CTR %d: got %X, want %X", b, i, out, exp)
+			}
+			// Uneven chunks, in-place
+			s := NewCTR(b, test.FromHex(tt.iv))
+			for j, n := 0, 1; j < len(out); j, n = j+n, n+3 {
+				if j+n > len(out) {
+					n = len(out) - j
+				}
+				s.XORKeyStream(out[j:j+n], out[j:j+n])
+			}
+			if !bytes.Equal(out, pt) {
+				t.Errorf("%T: CTR decrypt %d: got %X, want %X", b, i, out, pt)
+			}
+		}
+	}
+}
+
+// Counter must be incremented as 128-bit integer
+func TestCTRWrap(t *testing.T) {
+	b, _ := aes.NewCipher(make([]byte, 16))
+	iv := test.FromHex("0000000000000000ffffffffffffffff")
+	out := make([]byte, 2*blockSize)
+	NewCTR(b, iv).XORKeyStream(out, out)
+
+	var exp [blockSize]byte
+	exp[7] = 1
+	b.Encrypt(exp[:], exp[:])
+	if !bytes.Equal(out[blockSize:], exp[:]) {
+		t.Error("wrong counter increment")
+	}
+}
+
+func TestModesPanic(t *testing.T) {
+	b, _ := aes.NewCipher(make([]byte, 16))
+	mustPanic(t, "modes: CTR IV length must equal block size", func() { NewCTR(b, make([]byte, 8)) })
+	mustPanic(t, "modes: CBC IV length must equal block size", func() { NewCBCEncrypter(b, nil) })
+	mustPanic(t, "modes: input not full blocks", func() {
+		NewECBEncrypter(b).CryptBlocks(make([]byte, 17), make([]byte, 17))
+	})
+	mustPanic(t, "modes: input not full blocks", func() {
+		NewCBCDecrypter(b, make([]byte, 16)).CryptBlocks(make([]byte, 20), make([]byte, 20))
+	})
+	mustPanic(t, "modes: output smaller than input", func() {
+		NewCTR(b, make([]byte, 16)).XORKeyStream(make([]byte, 1), make([]byte, 2))
+	})
+}
+
+func BenchmarkCTR(b *testing.B) {
+	buf := make([]byte, 8192)
+	for _, c := range allCiphers(b, make([]byte, 16)) {
+		b.Run(typeName(c), func(b *testing.B) {
+			s := NewCTR(c, make([]byte, blockSize))
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				s.XORKeyStream(buf, buf)
+			}
+		})
+	}
+}
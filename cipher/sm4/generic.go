@@ -0,0 +1,149 @@
+// Constant-time, bitsliced implementation of SM4.
+//
+// The SM4 S-box is affine equivalent to the AES S-box: both are based on
+// inversion in GF(2^8), only the field representation and surrounding
+// affine transformations differ. Here it is computed as
+// S(x) = post(AES-S(pre(x))), where AES-S is the circuit by Boyar and
+// Peralta (ia.cr/2011/332) from internal/bitslice, shared with the
+// bitsliced AES.
+//
+// Sixteen blocks are processed in parallel. For S-box computation, input
+// words of all blocks are kept in eight 64-bit words, word i keeps bit i
+// of each of 64 bytes.
+
+package sm4
+
+import (
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/internal/bitslice"
+)
+
+// Number of blocks processed in parallel
+const ctBlocks = 16
+
+// SM4CT implements SM4 in constant-time, without using lookup tables.
+type SM4CT struct {
+	enc [rounds]uint32
+	dec [rounds]uint32
+}
+
+// preAffine applies affine transformation which maps input of the
+// SM4 S-box to the input of the AES S-box.
+func preAffine(q *[8]uint64) {
+	x0, x1, x2, x3, x4, x5, x6, x7 := q[0], q[1], q[2], q[3], q[4], q[5], q[6], q[7]
+	q[0] = ^(x1 ^ x2 ^ x5 ^ x6)
+	q[1] = x0 ^ x1 ^ x3 ^ x4 ^ x7
+	q[2] = x0 ^ x2 ^ x3
+	q[3] = x5 ^ x6
+	q[4] = x1 ^ x3 ^ x7
+	q[5] = x1 ^ x2 ^ x4
+	q[6] = x1 ^ x2 ^ x3 ^ x5
+	q[7] = x2 ^ x4
+}
+
+// postAffine applies affine transformation which maps output of the
+// AES S-box to the output of the SM4 S-box.
+func postAffine(q *[8]uint64) {
+	x0, x1, x2, x3, x4, x5, x6, x7 := q[0], q[1], q[2], q[3], q[4], q[5], q[6], q[7]
+	q[0] = x1 ^ x5 ^ x6 ^ x7
+	q[1] = x2 ^ x3 ^ x5 ^ x7
+	q[2] = ^(x0 ^ x3 ^ x4 ^ x5 ^ x6 ^ x7)
+	q[3] = x0 ^ x1 ^ x2 ^ x4 ^ x5 ^ x6 ^ x7
+	q[4] = ^(x0 ^ x2 ^ x3 ^ x4 ^ x6 ^ x7)
+	q[5] = ^(x0 ^ x1 ^ x2 ^ x3 ^ x5 ^ x7)
+	q[6] = x2 ^ x4 ^ x6
+	q[7] = x1 ^ x2 ^ x3 ^ x4 ^ x5 ^ x6 ^ x7
+}
+
+// sbox applies the SM4 S-box to each byte of the bitsliced state.
+func sbox(q *[8]uint64) {
+	preAffine(q)
+	bitslice.Sbox(q)
+	postAffine(q)
+}
+
+// subWords applies the non-linear transformation tau to each word of w.
+func subWords(w *[ctBlocks]uint32) {
+	var q [8]uint64
+	for i := range q {
+		q[i] = uint64(w[2*i]) | uint64(w[2*i+1])<<32
+	}
+	bitslice.Ortho(&q)
+	sbox(&q)
+	bitslice.Ortho(&q)
+	for i := range q {
+		w[2*i], w[2*i+1] = uint32(q[i]), uint32(q[i]>>32)
+	}
+}
+
+// tau applies the S-box to each byte of x.
+func tau(x uint32) uint32 {
+	var w [ctBlocks]uint32
+	w[0] = x
+	subWords(&w)
+	return w[0]
+}
+
+// cryptBlocks encrypts or decrypts, depending on the order of round keys,
+// up to ctBlocks blocks from src.
+func cryptBlocks(rk *[rounds]uint32, dst, src []byte) {
+	var x [4][ctBlocks]uint32
+	var t [ctBlocks]uint32
+
+	n := len(src) / BlockSize
+	for i := 0; i < n; i++ {
+		for j := 0; j < 4; j++ {
+			x[j][i] = binary.BigEndian.Uint32(src[BlockSize*i+4*j:])
+		}
+	}
+	for r := 0; r < rounds; r++ {
+		x0, x1, x2, x3 := &x[r%4], &x[(r+1)%4], &x[(r+2)%4], &x[(r+3)%4]
+		for i := 0; i < n; i++ {
+			t[i] = x1[i] ^ x2[i] ^ x3[i] ^ rk[r]
+		}
+		subWords(&t)
+		for i := 0; i < n; i++ {
+			x0[i] ^= l(t[i])
+		}
+	}
+	// Output words in reverse order
+	for i := 0; i < n; i++ {
+		for j := 0; j < 4; j++ {
+			binary.BigEndian.PutUint32(dst[BlockSize*i+4*j:], x[3-j][i])
+		}
+	}
+}
+
+// SetKey expands the key. Key must be 16 bytes long.
+func (c *SM4CT) SetKey(key []byte) error {
+	if len(key) != KeySize {
+		return KeySizeError(len(key))
+	}
+	expandKey(key, &c.enc, &c.dec)
+	return nil
+}
+
+func (c *SM4CT) BlockSize() int { return BlockSize }
+
+func (c *SM4CT) Encrypt(dst, src []byte) {
+	checkBlock(dst, src)
+	cryptBlocks(&c.enc, dst[:BlockSize], src[:BlockSize])
+}
+
+func (c *SM4CT) Decrypt(dst, src []byte) {
+	checkBlock(dst, src)
+	cryptBlocks(&c.dec, dst[:BlockSize], src[:BlockSize])
+}
+
+func (c *SM4CT) EncryptBlocks(dst, src []byte) {
+	checkBlocks(dst, src)
+	for len(src) > 0 {
+		n := len(src)
+		if n > ctBlocks*BlockSize {
+			n = ctBlocks * BlockSize
+		}
+		cryptBlocks(&c.enc, dst[:n], src[:n])
+		dst, src = dst[n:], src[n:]
+	}
+}
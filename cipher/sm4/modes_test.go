@@ -0,0 +1,140 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/henrydcase/nobs/cipher/modes"
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// ECB, CBC and CTR test vectors from draft-ribose-cfrg-sm4-10, Appendix
+// A.2. The key is the one from GB/T 32907-2016, Appendix A.
+const (
+	modesKey = "0123456789abcdeffedcba9876543210"
+	modesIV  = "000102030405060708090a0b0c0d0e0f"
+)
+
+var modesTests = []struct {
+	name string
+	enc  func(b cipher.Block, iv []byte) cipher.BlockMode
+	dec  func(b cipher.Block, iv []byte) cipher.BlockMode
+	pt   string
+	ct   string
+}{
+	// A.2.1.1
+	{
+		"ECB",
+		func(b cipher.Block, _ []byte) cipher.BlockMode { return modes.NewECBEncrypter(b) },
+		func(b cipher.Block, _ []byte) cipher.BlockMode { return modes.NewECBDecrypter(b) },
+		"aaaaaaaabbbbbbbbccccccccddddddddeeeeeeeeffffffffaaaaaaaabbbbbbbb",
+		"5ec8143de509cff7b5179f8f474b86192f1d305a7fb17df985f81c8482192304",
+	},
+	// A.2.2.1
+	{
+		"CBC", modes.NewCBCEncrypter, modes.NewCBCDecrypter,
+		"aaaaaaaabbbbbbbbccccccccddddddddeeeeeeeeffffffffaaaaaaaabbbbbbbb",
+		"78ebb11cc40b0a48312aaeb2040244cb4cb7016951909226979b0d15dc6a8f6d",
+	},
+}
+
+// draft-ribose-cfrg-sm4-10, A.2.5.1
+const (
+	ctrPlaintext = "aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccdddddddddddddddd" +
+		"eeeeeeeeeeeeeeeeffffffffffffffffaaaaaaaaaaaaaaaabbbbbbbbbbbbbbbb"
+	ctrCiphertext = "ac3236cb970cc20791364c395a1342d1a3cbc1878c6f30cd074cce385cdd70c7" +
+		"f234bc0e24c11980fd1286310ce37b926e02fcd0faa0baf38b2933851d824514"
+)
+
+// GCM and CCM test vectors from RFC 8998, Appendix A.
+const (
+	aeadPlaintext = "aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccdddddddddddddddd" +
+		"eeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa"
+	aeadNonce = "00001234567800000000abcd"
+	aeadAD    = "feedfacedeadbeeffeedfacedeadbeefabaddad2"
+)
+
+func TestModes(t *testing.T) {
+	iv := test.FromHex(modesIV)
+	for _, b := range allCiphers(t, test.FromHex(modesKey)) {
+		for _, tt := range modesTests {
+			pt, exp := test.FromHex(tt.pt), test.FromHex(tt.ct)
+			out := make([]byte, len(pt))
+			tt.enc(b, iv).CryptBlocks(out, pt)
+			if !bytes.Equal(out, exp) {
+				t.Errorf("%s %s: got %X, want %X", typeName(b), tt.name, out, exp)
+			}
+			tt.dec(b, iv).CryptBlocks(out, out)
+			if !bytes.Equal(out, pt) {
+				t.Errorf("%s %s: decryption failed", typeName(b), tt.name)
+			}
+		}
+
+		pt, exp := test.FromHex(ctrPlaintext), test.FromHex(ctrCiphertext)
+		out := make([]byte, len(pt))
+		modes.NewCTR(b, iv).XORKeyStream(out, pt)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("%s CTR: got %X, want %X", typeName(b), out, exp)
+		}
+	}
+}
+
+func testAEAD(t *testing.T, name string, a cipher.AEAD, exp []byte) {
+	nonce, pt, ad := test.FromHex(aeadNonce), test.FromHex(aeadPlaintext), test.FromHex(aeadAD)
+	ct := a.Seal(nil, nonce, pt, ad)
+	if !bytes.Equal(ct, exp) {
+		t.Errorf("%s: Seal: got %X, want %X", name, ct, exp)
+	}
+	out, err := a.Open(nil, nonce, ct, ad)
+	if err != nil || !bytes.Equal(out, pt) {
+		t.Errorf("%s: Open failed", name)
+	}
+	ct[len(ct)-1] ^= 1
+	if _, err := a.Open(nil, nonce, ct, ad); err == nil {
+		t.Errorf("%s: Open accepted modified tag", name)
+	}
+}
+
+// RFC 8998, A.1
+func TestGCM(t *testing.T) {
+	exp := test.FromHex("17f399f08c67d5ee19d0dc9969c4bb7d5fd46fd3756489069157b282bb200735" +
+		"d82710ca5c22f0ccfa7cbf93d496ac15a56834cbcf98c397b4024a2691233b8d" +
+		"83de3541e4c2b58177e065a9bf7b62ec")
+	for _, b := range allCiphers(t, test.FromHex(modesKey)) {
+		a, err := modes.NewGCM(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAEAD(t, fmt.Sprintf("%s GCM", typeName(b)), a, exp)
+	}
+}
+
+// RFC 8998, A.2
+func TestCCM(t *testing.T) {
+	exp := test.FromHex("48af93501fa62adbcd414cce6034d895dda1bf8f132f042098661572e7483094" +
+		"fd12e518ce062c98acee28d95df4416bed31a2f04476c18bb40c84a74b97dc5b" +
+		"16842d4fa186f56ab33256971fa110f4")
+	for _, b := range allCiphers(t, test.FromHex(modesKey)) {
+		a, err := modes.NewCCM(b, 12, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAEAD(t, fmt.Sprintf("%s CCM", typeName(b)), a, exp)
+	}
+}
+
+func BenchmarkGCM(b *testing.B) {
+	buf := make([]byte, 8192+16)
+	nonce := make([]byte, 12)
+	for _, c := range allCiphers(b, make([]byte, KeySize)) {
+		b.Run(typeName(c), func(b *testing.B) {
+			a, _ := modes.NewGCM(c)
+			b.SetBytes(int64(len(buf) - 16))
+			for i := 0; i < b.N; i++ {
+				a.Seal(buf[:0], nonce, buf[:len(buf)-16], nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package sm4 implements SM4 block cipher, specified in GB/T 32907-2016
+// and ISO/IEC 18033-3:2010/Amd 1:2021.
+//
+// NewCipher returns implementation which uses AES-NI and SSSE3 if available,
+// otherwise a constant-time bitsliced implementation. Both implementations
+// satisfy the aes.IAES interface, hence returned block cipher can be used
+// with modes from the cipher/modes package (ECB, CBC, CTR, GCM and CCM) as
+// well as with modes from the standard crypto/cipher package.
+package sm4
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"strconv"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/internal/alias"
+	"github.com/henrydcase/nobs/utils"
+)
+
+const (
+	// The SM4 block size in bytes.
+	BlockSize = 16
+	// The SM4 key size in bytes.
+	KeySize = 16
+	// Number of rounds
+	rounds = 32
+)
+
+// Both implementations can be used wherever AES is expected.
+var (
+	_ aes.IAES = (*SM4CT)(nil)
+	_ aes.IAES = (*SM4Asm)(nil)
+)
+
+type KeySizeError int
+
+func (k KeySizeError) Error() string {
+	return "sm4: invalid key size " + strconv.Itoa(int(k))
+}
+
+// System parameter FK and fixed parameters CK used by the key schedule.
+var fk = [4]uint32{0xA3B1BAC6, 0x56AA3350, 0x677D9197, 0xB27022DC}
+
+var ck = [rounds]uint32{
+	0x00070E15, 0x1C232A31, 0x383F464D, 0x545B6269,
+	0x70777E85, 0x8C939AA1, 0xA8AFB6BD, 0xC4CBD2D9,
+	0xE0E7EEF5, 0xFC030A11, 0x181F262D, 0x343B4249,
+	0x50575E65, 0x6C737A81, 0x888F969D, 0xA4ABB2B9,
+	0xC0C7CED5, 0xDCE3EAF1, 0xF8FF060D, 0x141B2229,
+	0x30373E45, 0x4C535A61, 0x686F767D, 0x848B9299,
+	0xA0A7AEB5, 0xBCC3CAD1, 0xD8DFE6ED, 0xF4FB0209,
+	0x10171E25, 0x2C333A41, 0x484F565D, 0x646B7279,
+}
+
+func rotl(x uint32, n uint) uint32 { return x<<n | x>>(32-n) }
+
+// l is the linear transformation L used by the round function.
+func l(b uint32) uint32 {
+	return b ^ rotl(b, 2) ^ rotl(b, 10) ^ rotl(b, 18) ^ rotl(b, 24)
+}
+
+// lKey is the linear transformation L' used by the key schedule.
+func lKey(b uint32) uint32 {
+	return b ^ rotl(b, 13) ^ rotl(b, 23)
+}
+
+// expandKey computes round keys used for encryption and decryption
+// (GB/T 32907, 7.3). Decryption uses round keys in reverse order.
+func expandKey(key []byte, enc, dec *[rounds]uint32) {
+	var k [4]uint32
+	for i := range k {
+		k[i] = binary.BigEndian.Uint32(key[4*i:]) ^ fk[i]
+	}
+	for i := 0; i < rounds; i++ {
+		t := k[(i+1)%4] ^ k[(i+2)%4] ^ k[(i+3)%4] ^ ck[i]
+		k[i%4] ^= lKey(tau(t))
+		enc[i] = k[i%4]
+		dec[rounds-1-i] = k[i%4]
+	}
+}
+
+func checkBlock(dst, src []byte) {
+	if len(src) < BlockSize {
+		panic("sm4: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("sm4: output not full block")
+	}
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+		panic("sm4: invalid buffer overlap")
+	}
+}
+
+func checkBlocks(dst, src []byte) {
+	if len(src)%BlockSize != 0 {
+		panic("sm4: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("sm4: output smaller than input")
+	}
+	if alias.InexactOverlap(dst[:len(src)], src) {
+		panic("sm4: invalid buffer overlap")
+	}
+}
+
+// newBlock returns constant-time implementation of SM4, the best one
+// available on the platform.
+func newBlock(key []byte) (aes.IAES, error) {
+	var c aes.IAES = &SM4CT{}
+	if utils.X86.HasAES && utils.X86.HasSSSE3 {
+		c = &SM4Asm{}
+	}
+	if err := c.SetKey(key); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewCipher creates and returns a new cipher.Block. The key argument must
+// be 16 bytes long.
+func NewCipher(key []byte) (cipher.Block, error) {
+	return newBlock(key)
+}
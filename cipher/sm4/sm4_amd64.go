@@ -0,0 +1,58 @@
+// +build amd64,!noasm
+
+package sm4
+
+// defined in sm4_amd64.s
+
+//go:noescape
+func cryptBlocks4Asm(rk *uint32, dst, src *byte)
+
+// SM4Asm implements SM4 with AES-NI and SSSE3 instructions. As SM4 S-box
+// is affine equivalent to the AES S-box, it is computed with AESENCLAST
+// surrounded by affine transformations done with PSHUFB. Four blocks are
+// processed in parallel.
+type SM4Asm struct {
+	enc [rounds]uint32
+	dec [rounds]uint32
+}
+
+// cryptBlocksAsm encrypts or decrypts, depending on the order of round
+// keys, all blocks from src.
+func cryptBlocksAsm(rk *[rounds]uint32, dst, src []byte) {
+	var buf [4 * BlockSize]byte
+	for len(src) >= len(buf) {
+		cryptBlocks4Asm(&rk[0], &dst[0], &src[0])
+		dst, src = dst[len(buf):], src[len(buf):]
+	}
+	if len(src) > 0 {
+		copy(buf[:], src)
+		cryptBlocks4Asm(&rk[0], &buf[0], &buf[0])
+		copy(dst, buf[:len(src)])
+	}
+}
+
+// SetKey expands the key. Key must be 16 bytes long.
+func (c *SM4Asm) SetKey(key []byte) error {
+	if len(key) != KeySize {
+		return KeySizeError(len(key))
+	}
+	expandKey(key, &c.enc, &c.dec)
+	return nil
+}
+
+func (c *SM4Asm) BlockSize() int { return BlockSize }
+
+func (c *SM4Asm) Encrypt(dst, src []byte) {
+	checkBlock(dst, src)
+	cryptBlocksAsm(&c.enc, dst[:BlockSize], src[:BlockSize])
+}
+
+func (c *SM4Asm) Decrypt(dst, src []byte) {
+	checkBlock(dst, src)
+	cryptBlocksAsm(&c.dec, dst[:BlockSize], src[:BlockSize])
+}
+
+func (c *SM4Asm) EncryptBlocks(dst, src []byte) {
+	checkBlocks(dst, src)
+	cryptBlocksAsm(&c.enc, dst, src)
+}
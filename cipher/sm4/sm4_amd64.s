@@ -0,0 +1,154 @@
+// +build amd64,!noasm
+
+#include "textflag.h"
+
+// Byte swap of each 32-bit word
+DATA ·bswapMask<>+0x00(SB)/8, $0x0405060700010203
+DATA ·bswapMask<>+0x08(SB)/8, $0x0C0D0E0F08090A0B
+GLOBL ·bswapMask<>(SB), RODATA, $16
+
+DATA ·nibbleMask<>+0x00(SB)/8, $0x0F0F0F0F0F0F0F0F
+DATA ·nibbleMask<>+0x08(SB)/8, $0x0F0F0F0F0F0F0F0F
+GLOBL ·nibbleMask<>(SB), RODATA, $16
+
+// Affine transformations applied before and after the AES S-box, so that
+// SM4 S-box(x) = post(AES S-box(pre(x))). Each transformation is computed
+// with two lookup tables indexed by low and high nibble of a byte.
+DATA ·preLo<>+0x00(SB)/8, $0x9197E2E474720701
+DATA ·preLo<>+0x08(SB)/8, $0xC7C1B4B222245157
+GLOBL ·preLo<>(SB), RODATA, $16
+
+DATA ·preHi<>+0x00(SB)/8, $0xE240AB09EB49A200
+DATA ·preHi<>+0x08(SB)/8, $0xF052B91BF95BB012
+GLOBL ·preHi<>(SB), RODATA, $16
+
+DATA ·postLo<>+0x00(SB)/8, $0x5B67F2CEA19D0834
+DATA ·postLo<>+0x08(SB)/8, $0xEDD14478172BBE82
+GLOBL ·postLo<>(SB), RODATA, $16
+
+DATA ·postHi<>+0x00(SB)/8, $0xAE7201DD73AFDC00
+DATA ·postHi<>+0x08(SB)/8, $0x11CDBE62CC1063BF
+GLOBL ·postHi<>(SB), RODATA, $16
+
+// Inverse of ShiftRows, cancels ShiftRows done by AESENCLAST
+DATA ·invShiftRows<>+0x00(SB)/8, $0x0B0E0104070A0D00
+DATA ·invShiftRows<>+0x08(SB)/8, $0x0306090C0F020508
+GLOBL ·invShiftRows<>(SB), RODATA, $16
+
+// Transposes 4x4 matrix of 32-bit words kept in r0..r3.
+#define TRANSPOSE(r0, r1, r2, r3, t0, t1) \
+	MOVO       r0, t0; \
+	PUNPCKLLQ  r1, t0; \
+	PUNPCKHLQ  r1, r0; \
+	MOVO       r2, t1; \
+	PUNPCKLLQ  r3, t1; \
+	PUNPCKHLQ  r3, r2; \
+	MOVO       t0, r1; \
+	PUNPCKHQDQ t1, r1; \
+	PUNPCKLQDQ t1, t0; \
+	MOVO       r0, r3; \
+	PUNPCKHQDQ r2, r3; \
+	PUNPCKLQDQ r2, r0; \
+	MOVO       r0, r2; \
+	MOVO       t0, r0
+
+// Applies affine transformation given by nibble tables lo and hi to
+// each byte of x. Uses X9 (nibble mask).
+#define AFFINE(lo, hi, x, t0, t1) \
+	MOVO   x, t0; \
+	PSRLL  $4, t0; \
+	PAND   X9, x; \
+	PAND   X9, t0; \
+	MOVO   lo, t1; \
+	PSHUFB x, t1; \
+	MOVO   hi, x; \
+	PSHUFB t0, x; \
+	PXOR   t1, x
+
+// Single round of SM4: x0 ^= L(tau(x1 ^ x2 ^ x3 ^ rk[off/4])), computed
+// on four blocks. Uses X4-X6 as temporaries. The S-box is computed with
+// AESENCLAST with zero round key, input is permuted first to cancel its
+// ShiftRows. L is computed as
+// L(t) = t ^ rotl(t ^ rotl(t, 8) ^ rotl(t, 16), 2) ^ rotl(t, 24).
+#define ROUND(off, x0, x1, x2, x3) \
+	MOVL       off(AX), X4; \
+	PSHUFD     $0, X4, X4; \
+	PXOR       x1, X4; \
+	PXOR       x2, X4; \
+	PXOR       x3, X4; \
+	AFFINE(X10, X11, X4, X5, X6); \
+	PSHUFB     X14, X4; \
+	AESENCLAST X15, X4; \
+	AFFINE(X12, X13, X4, X5, X6); \
+	MOVO       X4, X5; \
+	MOVO       X4, X6; \
+	PSLLL      $8, X6; \
+	PXOR       X6, X5; \
+	MOVO       X4, X6; \
+	PSRLL      $24, X6; \
+	PXOR       X6, X5; \
+	MOVO       X4, X6; \
+	PSLLL      $16, X6; \
+	PXOR       X6, X5; \
+	MOVO       X4, X6; \
+	PSRLL      $16, X6; \
+	PXOR       X6, X5; \
+	MOVO       X5, X6; \
+	PSLLL      $2, X6; \
+	PSRLL      $30, X5; \
+	PXOR       X6, x0; \
+	PXOR       X5, x0; \
+	PXOR       X4, x0; \
+	MOVO       X4, X6; \
+	PSLLL      $24, X6; \
+	PSRLL      $8, X4; \
+	PXOR       X6, x0; \
+	PXOR       X4, x0
+
+// func cryptBlocks4Asm(rk *uint32, dst, src *byte)
+TEXT ·cryptBlocks4Asm(SB),NOSPLIT,$0
+	MOVQ rk+0(FP), AX
+	MOVQ dst+8(FP), DX
+	MOVQ src+16(FP), BX
+
+	MOVOU ·bswapMask<>(SB), X8
+	MOVOU ·nibbleMask<>(SB), X9
+	MOVOU ·preLo<>(SB), X10
+	MOVOU ·preHi<>(SB), X11
+	MOVOU ·postLo<>(SB), X12
+	MOVOU ·postHi<>(SB), X13
+	MOVOU ·invShiftRows<>(SB), X14
+	PXOR  X15, X15
+
+	// Load blocks, so that X0..X3 keep words 0..3 of all four blocks
+	MOVOU  0(BX), X0
+	MOVOU  16(BX), X1
+	MOVOU  32(BX), X2
+	MOVOU  48(BX), X3
+	PSHUFB X8, X0
+	PSHUFB X8, X1
+	PSHUFB X8, X2
+	PSHUFB X8, X3
+	TRANSPOSE(X0, X1, X2, X3, X4, X5)
+
+	MOVQ $8, CX
+loop:
+	ROUND(0, X0, X1, X2, X3)
+	ROUND(4, X1, X2, X3, X0)
+	ROUND(8, X2, X3, X0, X1)
+	ROUND(12, X3, X0, X1, X2)
+	ADDQ $16, AX
+	DECQ CX
+	JNZ  loop
+
+	// Output words in reverse order
+	TRANSPOSE(X3, X2, X1, X0, X4, X5)
+	PSHUFB X8, X0
+	PSHUFB X8, X1
+	PSHUFB X8, X2
+	PSHUFB X8, X3
+	MOVOU  X3, 0(DX)
+	MOVOU  X2, 16(DX)
+	MOVOU  X1, 32(DX)
+	MOVOU  X0, 48(DX)
+	RET
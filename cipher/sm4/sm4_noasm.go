@@ -0,0 +1,25 @@
+// +build noasm !amd64
+
+package sm4
+
+// SM4Asm is not available on this platform.
+type SM4Asm struct {
+}
+
+func (c *SM4Asm) SetKey(key []byte) error {
+	panic("NotImplemented")
+}
+
+func (c *SM4Asm) BlockSize() int { return BlockSize }
+
+func (c *SM4Asm) Encrypt(dst, src []byte) {
+	panic("NotImplemented")
+}
+
+func (c *SM4Asm) Decrypt(dst, src []byte) {
+	panic("NotImplemented")
+}
+
+func (c *SM4Asm) EncryptBlocks(dst, src []byte) {
+	panic("NotImplemented")
+}
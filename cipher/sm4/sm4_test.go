@@ -0,0 +1,190 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/internal/test"
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Returns all implementations of SM4 available on the platform,
+// keyed with 'key'.
+func allCiphers(t testing.TB, key []byte) []aes.IAES {
+	var ret []aes.IAES
+	for _, c := range implementations() {
+		if err := c.SetKey(key); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func typeName(b cipher.Block) string {
+	return fmt.Sprintf("%T", b)[1:]
+}
+
+func implementations() []aes.IAES {
+	impls := []aes.IAES{new(SM4CT)}
+	if utils.X86.HasAES && utils.X86.HasSSSE3 {
+		impls = append(impls, new(SM4Asm))
+	}
+	return impls
+}
+
+func mustPanic(t *testing.T, msg string, f func()) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("function did not panic, wanted %q", msg)
+		} else if err != msg {
+			t.Errorf("got panic %v, wanted %q", err, msg)
+		}
+	}()
+	f()
+}
+
+// GB/T 32907-2016, 6.2, table 1
+var sboxTable = test.FromHex("" +
+	"d690e9fecce13db716b614c228fb2c052b679a762abe04c3aa44132649860699" +
+	"9c4250f491ef987a33540b43edcfac62e4b31ca9c908e89580df94fa758f3fa6" +
+	"4707a7fcf37317ba83593c19e6854fa8686b81b27164da8bf8eb0f4b70569d35" +
+	"1e240e5e6358d1a225227c3b01217887d40046579fd327524c3602e7a0c4c89e" +
+	"eabf8ad240c738b5a3f7f2cef96115a1e0ae5da49b341a55ad933230f58cb1e3" +
+	"1df6e22e8266ca60c02923ab0d534e6fd5db3745defd8e2f03ff6a726d6c5b51" +
+	"8d1baf92bbddbc7f11d95c411f105ad80ac13188a5cd7bbd2d74d012b8e5b4b0" +
+	"8969974a0c96777e65b9f109c56ec68418f07dec3adc4d2079ee5f3ed7cb3948")
+
+func TestSbox(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		x := uint32(i) * 0x01010101
+		exp := uint32(sboxTable[i]) * 0x01010101
+		if y := tau(x); y != exp {
+			t.Errorf("S(%02X): got %08X, want %08X", i, y, exp)
+		}
+	}
+	var w [ctBlocks]uint32
+	for i := range w {
+		w[i] = uint32(i*4) | uint32(i*4+1)<<8 | uint32(i*4+2)<<16 | uint32(i*4+3)<<24
+	}
+	subWords(&w)
+	for i := range w {
+		for j := 0; j < 4; j++ {
+			if got := byte(w[i] >> uint(8*j)); got != sboxTable[4*i+j] {
+				t.Errorf("S(%02X): got %02X, want %02X", 4*i+j, got, sboxTable[4*i+j])
+			}
+		}
+	}
+}
+
+// GB/T 32907-2016, Appendix A
+func TestGBT32907(t *testing.T) {
+	key := test.FromHex("0123456789abcdeffedcba9876543210")
+	exp := test.FromHex("681edf34d206965e86b3e94f536e4246")
+	for _, c := range allCiphers(t, key) {
+		var out [BlockSize]byte
+		c.Encrypt(out[:], key)
+		if !bytes.Equal(out[:], exp) {
+			t.Errorf("%s: got %X, want %X", typeName(c), out, exp)
+		}
+		c.Decrypt(out[:], out[:])
+		if !bytes.Equal(out[:], key) {
+			t.Errorf("%s: decryption failed", typeName(c))
+		}
+	}
+
+	// Encrypt 1000000 times
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	exp = test.FromHex("595298c7c6fd271f0402f804c33d3f66")
+	c, _ := NewCipher(key)
+	out := append([]byte(nil), key...)
+	for i := 0; i < 1000000; i++ {
+		c.Encrypt(out, out)
+	}
+	if !bytes.Equal(out, exp) {
+		t.Errorf("%s: got %X, want %X", typeName(c), out, exp)
+	}
+}
+
+// Compares bulk encryption of all implementations with single block
+// operations of the constant-time one.
+func TestEncryptBlocks(t *testing.T) {
+	var ref SM4CT
+	key := make([]byte, KeySize)
+	src := make([]byte, 40*BlockSize)
+	exp := make([]byte, len(src))
+	rand.Read(key)
+	rand.Read(src)
+	ref.SetKey(key)
+	for i := 0; i < len(src); i += BlockSize {
+		ref.Encrypt(exp[i:], src[i:])
+	}
+	for _, c := range allCiphers(t, key) {
+		for n := 0; n <= len(src); n += BlockSize {
+			dst := make([]byte, n)
+			c.EncryptBlocks(dst, src[:n])
+			if !bytes.Equal(dst, exp[:n]) {
+				t.Fatalf("%s: EncryptBlocks of %d bytes failed", typeName(c), n)
+			}
+			for i := 0; i < n; i += BlockSize {
+				c.Decrypt(dst[i:], dst[i:])
+			}
+			if !bytes.Equal(dst, src[:n]) {
+				t.Fatalf("%s: decryption of %d bytes failed", typeName(c), n)
+			}
+		}
+		// In-place
+		dst := append([]byte(nil), src...)
+		c.EncryptBlocks(dst, dst)
+		if !bytes.Equal(dst, exp) {
+			t.Errorf("%s: in-place EncryptBlocks failed", typeName(c))
+		}
+	}
+}
+
+func TestPanics(t *testing.T) {
+	for _, c := range implementations() {
+		if err := c.SetKey(make([]byte, 24)); err == nil {
+			t.Errorf("%s: invalid key size accepted", typeName(c))
+		}
+		c.SetKey(make([]byte, KeySize))
+		buf := make([]byte, 3*BlockSize)
+		mustPanic(t, "sm4: input not full block", func() { c.Encrypt(buf, buf[:1]) })
+		mustPanic(t, "sm4: output not full block", func() { c.Decrypt(buf[:1], buf) })
+		mustPanic(t, "sm4: invalid buffer overlap", func() { c.Encrypt(buf[1:], buf) })
+		mustPanic(t, "sm4: input not full blocks", func() { c.EncryptBlocks(buf, buf[:17]) })
+		mustPanic(t, "sm4: output smaller than input", func() { c.EncryptBlocks(buf[:16], buf[16:]) })
+		mustPanic(t, "sm4: invalid buffer overlap", func() { c.EncryptBlocks(buf[16:], buf[:32]) })
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	var buf [BlockSize]byte
+	for _, c := range allCiphers(b, make([]byte, KeySize)) {
+		b.Run(typeName(c), func(b *testing.B) {
+			b.SetBytes(BlockSize)
+			for i := 0; i < b.N; i++ {
+				c.Encrypt(buf[:], buf[:])
+			}
+		})
+	}
+}
+
+func BenchmarkEncryptBlocks(b *testing.B) {
+	buf := make([]byte, 8192)
+	for _, c := range allCiphers(b, make([]byte, KeySize)) {
+		b.Run(typeName(c), func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				c.EncryptBlocks(buf, buf)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package csidh
 
 import (
 	"bytes"
-	crand "crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"os"
@@ -51,18 +50,7 @@ type TestVector struct {
 	Status string `json:"status"`
 }
 
-var rng *drbg.CtrDrbg
-
-func init() {
-	var tmp [32]byte
-
-	// Init drbg
-	rng = drbg.NewCtrDrbg()
-	crand.Read(tmp[:])
-	if !rng.Init(tmp[:], nil) {
-		panic("Can't initialize DRBG")
-	}
-}
+var rng = drbg.NewReader(nil, nil)
 
 type TestVectors struct {
 	Vectors []TestVector `json:"Vectors"`
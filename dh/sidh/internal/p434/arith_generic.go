@@ -1,7 +1,7 @@
 // Code generated by go generate; DO NOT EDIT.
 // This file was generated by robots.
 
-// +build noasm,arm64 !amd64
+// +build noasm !amd64
 
 package p434
 
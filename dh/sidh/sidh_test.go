@@ -300,6 +300,14 @@ func testImportExport(t *testing.T, v sidhVec) {
 	if !bytes.Equal(aBytes, aBytes2) || !bytes.Equal(bBytes, bBytes2) {
 		t.Fatalf("Second export doesn't match first export")
 	}
+
+	// Ensure that import overwrites previously stored key
+	err = a.Import(bHex)
+	checkErr(t, err, "import failed")
+	a.Export(aBytes2)
+	if !bytes.Equal(aBytes2, bHex) {
+		t.Fatalf("Import into used key failed")
+	}
 }
 
 func testPrivateKeyBelowMax(t *testing.T, vec sidhVec) {
@@ -1,7 +1,6 @@
 package sidh
 
 import (
-	"bufio"
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
@@ -9,10 +8,11 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 	"testing"
 
 	"github.com/henrydcase/nobs/dh/sidh/common"
+	"github.com/henrydcase/nobs/drbg"
+	"github.com/henrydcase/nobs/internal/kat"
 )
 
 type sikeVec struct {
@@ -363,25 +363,6 @@ func testKAT(t *testing.T, v sikeVec) {
 		}
 	}
 
-	readAndCheckLine := func(r *bufio.Reader) []byte {
-		// Read next line from buffer
-		line, isPrefix, err := r.ReadLine()
-		if err != nil || isPrefix {
-			panic("Wrong format of input file")
-		}
-
-		// Function expects that line is in format "KEY = HEX_VALUE". Get
-		// value, which should be a hex string
-		hexst := strings.Split(string(line), "=")[1]
-		hexst = strings.TrimSpace(hexst)
-		// Convert value to byte string
-		ret, err := hex.DecodeString(hexst)
-		if err != nil {
-			panic("Wrong format of input file")
-		}
-		return ret
-	}
-
 	testKeygen := func(pk, sk []byte) bool {
 		// Import provided private key
 		var prvKey = NewPrivateKey(v.id, KeyVariantSike)
@@ -400,34 +381,25 @@ func testKAT(t *testing.T, v sikeVec) {
 		t.Fatal(err)
 	}
 
-	r := bufio.NewReader(f)
+	defer f.Close()
+
+	r := kat.NewReader(f)
 	for {
-		line, isPrefix, err := r.ReadLine()
-		if err != nil || isPrefix {
-			if err == io.EOF {
-				break
-			} else {
-				t.Fatal(err)
-			}
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
 		}
-		if len(strings.TrimSpace(string(line))) == 0 || line[0] == '#' {
-			continue
+		if err != nil {
+			t.Fatal(err)
 		}
 
-		// count
-		_ = strings.Split(string(line), "=")[1]
-		// seed
-		_ = readAndCheckLine(r)
-		// pk
-		pk := readAndCheckLine(r)
+		pk := rec.Bytes("pk")
 		// sk (secret key in test vector is concatenation of
 		// MSG + SECRET_BOB_KEY + PUBLIC_BOB_KEY. We use only MSG+SECRET_BOB_KEY
-		sk := readAndCheckLine(r)
+		sk := rec.Bytes("sk")
 		sk = sk[:v.kem.params.MsgLen+int(v.kem.params.B.SecretByteLen)]
-		// ct
-		ct := readAndCheckLine(r)
-		// ss
-		ss := readAndCheckLine(r)
+		ct := rec.Bytes("ct")
+		ss := rec.Bytes("ss")
 
 		testKeygen(pk, sk)
 		testDecapsulation(pk, sk, ct, ss)
@@ -541,6 +513,32 @@ func benchmarkDecaps(b *testing.B, v sikeVec) {
 	}
 }
 
+// SIKE with the auto-reseeding drbg.Reader as a source of randomness.
+func TestKEMWithDrbgReader(t *testing.T) {
+	r := drbg.NewReader(nil, nil)
+	kem := NewSike434(r)
+	prv := NewPrivateKey(Fp434, KeyVariantSike)
+	pub := NewPublicKey(Fp434, KeyVariantSike)
+	if err := prv.Generate(r); err != nil {
+		t.Fatal(err)
+	}
+	prv.GeneratePublicKey(pub)
+
+	ct := make([]byte, kem.CiphertextSize())
+	ssE := make([]byte, kem.SharedSecretSize())
+	ssD := make([]byte, kem.SharedSecretSize())
+	if err := kem.Encapsulate(ct, ssE, pub); err != nil {
+		t.Fatal(err)
+	}
+	kem.Reset()
+	if err := kem.Decapsulate(ssD, prv, pub, ct); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ssE, ssD) {
+		t.Error("shared secrets differ")
+	}
+}
+
 func BenchmarkKeygen(b *testing.B) { benchSike(b, &tdataSike, benchKeygen) }
 func BenchmarkEncaps(b *testing.B) { benchSike(b, &tdataSike, benchmarkEncaps) }
 func BenchmarkDecaps(b *testing.B) { benchSike(b, &tdataSike, benchmarkDecaps) }
@@ -0,0 +1,12 @@
+// Package x25519 implements Diffie-Hellman function X25519 on the
+// Montgomery curve Curve25519 as specified in RFC 7748.
+//
+// Field elements are represented in radix 2^51 and all the operations,
+// including the Montgomery ladder, are done in constant time. Shared
+// reports whether the result is an all-zero value, which happens when the
+// peer's public key is a point of small order (RFC 7748, Section 6.1).
+//
+// References:
+//   - [RFC7748] Elliptic Curves for Security,
+//     https://www.rfc-editor.org/rfc/rfc7748
+package x25519
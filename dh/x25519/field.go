@@ -0,0 +1,231 @@
+package x25519
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// fe is an element of GF(2^255-19) represented as a0 + a1*2^51 + ... +
+// a4*2^204. Limbs of reduced elements are smaller than 2^52.
+type fe [5]uint64
+
+const mask51 = 1<<51 - 1
+
+// uint128 holds the result of 64x64-bit multiplication.
+type uint128 struct {
+	lo, hi uint64
+}
+
+// mul64 returns a*b.
+func mul64(a, b uint64) uint128 {
+	hi, lo := bits.Mul64(a, b)
+	return uint128{lo, hi}
+}
+
+// addMul64 returns v + a*b.
+func addMul64(v uint128, a, b uint64) uint128 {
+	hi, lo := bits.Mul64(a, b)
+	lo, c := bits.Add64(lo, v.lo, 0)
+	hi, _ = bits.Add64(hi, v.hi, c)
+	return uint128{lo, hi}
+}
+
+// shr51 returns a >> 51, assuming the result fits in 64 bits.
+func shr51(a uint128) uint64 {
+	return a.hi<<13 | a.lo>>51
+}
+
+// fromBytes decodes little-endian in into v, ignoring the most
+// significant bit.
+func (v *fe) fromBytes(in *[32]byte) {
+	w0 := binary.LittleEndian.Uint64(in[0:])
+	w1 := binary.LittleEndian.Uint64(in[8:])
+	w2 := binary.LittleEndian.Uint64(in[16:])
+	w3 := binary.LittleEndian.Uint64(in[24:])
+	v[0] = w0 & mask51
+	v[1] = (w0>>51 | w1<<13) & mask51
+	v[2] = (w1>>38 | w2<<26) & mask51
+	v[3] = (w2>>25 | w3<<39) & mask51
+	v[4] = (w3 >> 12) & mask51
+}
+
+// toBytes writes fully reduced v to out in little-endian order.
+func (v *fe) toBytes(out *[32]byte) {
+	t := *v
+	t.carry()
+
+	// q = 1 iff t >= p, then t = t + 19*q - q*2^255
+	q := (t[0] + 19) >> 51
+	q = (t[1] + q) >> 51
+	q = (t[2] + q) >> 51
+	q = (t[3] + q) >> 51
+	q = (t[4] + q) >> 51
+	t[0] += 19 * q
+	t[1] += t[0] >> 51
+	t[0] &= mask51
+	t[2] += t[1] >> 51
+	t[1] &= mask51
+	t[3] += t[2] >> 51
+	t[2] &= mask51
+	t[4] += t[3] >> 51
+	t[3] &= mask51
+	t[4] &= mask51
+
+	binary.LittleEndian.PutUint64(out[0:], t[0]|t[1]<<51)
+	binary.LittleEndian.PutUint64(out[8:], t[1]>>13|t[2]<<38)
+	binary.LittleEndian.PutUint64(out[16:], t[2]>>26|t[3]<<25)
+	binary.LittleEndian.PutUint64(out[24:], t[3]>>39|t[4]<<12)
+}
+
+// carry reduces limbs of v below 2^52.
+func (v *fe) carry() {
+	c0 := v[0] >> 51
+	c1 := v[1] >> 51
+	c2 := v[2] >> 51
+	c3 := v[3] >> 51
+	c4 := v[4] >> 51
+	v[0] = v[0]&mask51 + c4*19
+	v[1] = v[1]&mask51 + c0
+	v[2] = v[2]&mask51 + c1
+	v[3] = v[3]&mask51 + c2
+	v[4] = v[4]&mask51 + c3
+}
+
+// add sets v = a + b.
+func (v *fe) add(a, b *fe) {
+	v[0] = a[0] + b[0]
+	v[1] = a[1] + b[1]
+	v[2] = a[2] + b[2]
+	v[3] = a[3] + b[3]
+	v[4] = a[4] + b[4]
+	v.carry()
+}
+
+// sub sets v = a - b. Adds 2p to avoid underflow.
+func (v *fe) sub(a, b *fe) {
+	v[0] = a[0] + 0xFFFFFFFFFFFDA - b[0]
+	v[1] = a[1] + 0xFFFFFFFFFFFFE - b[1]
+	v[2] = a[2] + 0xFFFFFFFFFFFFE - b[2]
+	v[3] = a[3] + 0xFFFFFFFFFFFFE - b[3]
+	v[4] = a[4] + 0xFFFFFFFFFFFFE - b[4]
+	v.carry()
+}
+
+// mul sets v = a * b.
+func (v *fe) mul(a, b *fe) {
+	a1 := a[1] * 19
+	a2 := a[2] * 19
+	a3 := a[3] * 19
+	a4 := a[4] * 19
+
+	// Uses 2^255 = 19 mod p
+	r0 := mul64(a[0], b[0])
+	r0 = addMul64(r0, a1, b[4])
+	r0 = addMul64(r0, a2, b[3])
+	r0 = addMul64(r0, a3, b[2])
+	r0 = addMul64(r0, a4, b[1])
+
+	r1 := mul64(a[0], b[1])
+	r1 = addMul64(r1, a[1], b[0])
+	r1 = addMul64(r1, a2, b[4])
+	r1 = addMul64(r1, a3, b[3])
+	r1 = addMul64(r1, a4, b[2])
+
+	r2 := mul64(a[0], b[2])
+	r2 = addMul64(r2, a[1], b[1])
+	r2 = addMul64(r2, a[2], b[0])
+	r2 = addMul64(r2, a3, b[4])
+	r2 = addMul64(r2, a4, b[3])
+
+	r3 := mul64(a[0], b[3])
+	r3 = addMul64(r3, a[1], b[2])
+	r3 = addMul64(r3, a[2], b[1])
+	r3 = addMul64(r3, a[3], b[0])
+	r3 = addMul64(r3, a4, b[4])
+
+	r4 := mul64(a[0], b[4])
+	r4 = addMul64(r4, a[1], b[3])
+	r4 = addMul64(r4, a[2], b[2])
+	r4 = addMul64(r4, a[3], b[1])
+	r4 = addMul64(r4, a[4], b[0])
+
+	c0 := shr51(r0)
+	c1 := shr51(r1)
+	c2 := shr51(r2)
+	c3 := shr51(r3)
+	c4 := shr51(r4)
+	v[0] = r0.lo&mask51 + c4*19
+	v[1] = r1.lo&mask51 + c0
+	v[2] = r2.lo&mask51 + c1
+	v[3] = r3.lo&mask51 + c2
+	v[4] = r4.lo&mask51 + c3
+	v.carry()
+}
+
+// square sets v = a^2.
+func (v *fe) square(a *fe) {
+	v.mul(a, a)
+}
+
+// mulSmall sets v = a * b, where b < 2^32.
+func (v *fe) mulSmall(a *fe, b uint64) {
+	var c [5]uint64
+	for i := range a {
+		r := mul64(a[i], b)
+		v[i] = r.lo & mask51
+		c[i] = shr51(r)
+	}
+	v[0] += c[4] * 19
+	v[1] += c[0]
+	v[2] += c[1]
+	v[3] += c[2]
+	v[4] += c[3]
+	v.carry()
+}
+
+// nsquare sets v = a^(2^n).
+func (v *fe) nsquare(a *fe, n int) {
+	v.square(a)
+	for i := 1; i < n; i++ {
+		v.square(v)
+	}
+}
+
+// invert sets v = a^(p-2) = 1/a. Returns zero for a = 0.
+func (v *fe) invert(a *fe) {
+	var z2, z9, z11, z5, z10, z20, z50, z100, t fe
+
+	z2.square(a)        // 2
+	t.nsquare(&z2, 2)   // 8
+	z9.mul(&t, a)       // 9
+	z11.mul(&z9, &z2)   // 11
+	t.square(&z11)      // 22
+	z5.mul(&t, &z9)     // 2^5 - 1
+	t.nsquare(&z5, 5)   // 2^10 - 2^5
+	z10.mul(&t, &z5)    // 2^10 - 1
+	t.nsquare(&z10, 10) // 2^20 - 2^10
+	z20.mul(&t, &z10)   // 2^20 - 1
+	t.nsquare(&z20, 20) // 2^40 - 2^20
+	t.mul(&t, &z20)     // 2^40 - 1
+	t.nsquare(&t, 10)   // 2^50 - 2^10
+	z50.mul(&t, &z10)   // 2^50 - 1
+	t.nsquare(&z50, 50) // 2^100 - 2^50
+	z100.mul(&t, &z50)  // 2^100 - 1
+	t.nsquare(&z100, 100)
+	t.mul(&t, &z100) // 2^200 - 1
+	t.nsquare(&t, 50)
+	t.mul(&t, &z50) // 2^250 - 1
+	t.nsquare(&t, 5)
+	v.mul(&t, &z11) // 2^255 - 21
+}
+
+// cswap swaps a and b if c == 1 and leaves them unchanged if c == 0.
+// Constant time.
+func cswap(a, b *fe, c uint64) {
+	m := -c
+	for i := range a {
+		t := m & (a[i] ^ b[i])
+		a[i] ^= t
+		b[i] ^= t
+	}
+}
@@ -0,0 +1,82 @@
+package x25519
+
+import "crypto/subtle"
+
+// KeySize is the size of private keys, public keys and shared secrets in
+// bytes.
+const KeySize = 32
+
+// Key represents X25519 private key, public key or shared secret.
+type Key [KeySize]byte
+
+// u-coordinate of the base point
+var basePoint = Key{9}
+
+// KeyGen computes public key pub corresponding to the private key prv.
+func KeyGen(pub, prv *Key) {
+	ladder(pub, prv, &basePoint)
+}
+
+// Shared computes shared secret out from the private key prv and the
+// peer's public key pub. Returns false if the result is all-zero, i.e.
+// pub is a point of small order. Caller decides whether such value is
+// acceptable, see RFC 7748, Section 6.1.
+func Shared(out, prv, pub *Key) bool {
+	var zero Key
+	ladder(out, prv, pub)
+	return subtle.ConstantTimeCompare(out[:], zero[:]) == 0
+}
+
+// ladder computes x-coordinate of [k]u with the Montgomery ladder.
+// RFC 7748, Section 5.
+func ladder(out, k, u *Key) {
+	var x1, x2, z2, x3, z3 fe
+	var a, aa, b, bb, e, c, d, da, cb fe
+	var s Key
+
+	// Clamping
+	s = *k
+	s[0] &= 248
+	s[31] &= 127
+	s[31] |= 64
+
+	x1.fromBytes((*[32]byte)(u))
+	x2 = fe{1}
+	x3 = x1
+	z3 = fe{1}
+
+	var swap uint64
+	for t := 254; t >= 0; t-- {
+		kt := uint64(s[t>>3]>>uint(t&7)) & 1
+		swap ^= kt
+		cswap(&x2, &x3, swap)
+		cswap(&z2, &z3, swap)
+		swap = kt
+
+		a.add(&x2, &z2)
+		aa.square(&a)
+		b.sub(&x2, &z2)
+		bb.square(&b)
+		e.sub(&aa, &bb)
+		c.add(&x3, &z3)
+		d.sub(&x3, &z3)
+		da.mul(&d, &a)
+		cb.mul(&c, &b)
+		x3.add(&da, &cb)
+		x3.square(&x3)
+		z3.sub(&da, &cb)
+		z3.square(&z3)
+		z3.mul(&z3, &x1)
+		x2.mul(&aa, &bb)
+		// z2 = E * (AA + a24 * E), a24 = 121665
+		z2.mulSmall(&e, 121665)
+		z2.add(&z2, &aa)
+		z2.mul(&z2, &e)
+	}
+	cswap(&x2, &x3, swap)
+	cswap(&z2, &z3, swap)
+
+	z2.invert(&z2)
+	x2.mul(&x2, &z2)
+	x2.toBytes((*[32]byte)(out))
+}
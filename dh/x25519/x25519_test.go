@@ -0,0 +1,131 @@
+package x25519
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func hexKey(t *testing.T, s string) *Key {
+	var k Key
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != KeySize {
+		t.Fatalf("wrong test vector %s", s)
+	}
+	copy(k[:], b)
+	return &k
+}
+
+// RFC 7748, Section 5.2
+func TestVectors(t *testing.T) {
+	vectors := []struct {
+		k, u, out string
+	}{
+		{
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+	}
+	for i, v := range vectors {
+		var out Key
+		ladder(&out, hexKey(t, v.k), hexKey(t, v.u))
+		if out != *hexKey(t, v.out) {
+			t.Errorf("vector %d: got %x", i, out)
+		}
+	}
+}
+
+// RFC 7748, Section 5.2, iterated function
+func TestIterated(t *testing.T) {
+	want := map[int]string{
+		1:    "422c8e7a6227d7bca1350b3e2bb7279f7897b87bb6854b783c60e80311ae3079",
+		1000: "684cf59ba83309552800ef566f2f4d3c1c3887c49360e3875f2eb94d99532c51",
+	}
+	k, u := basePoint, basePoint
+	for i := 1; i <= 1000; i++ {
+		var r Key
+		ladder(&r, &k, &u)
+		u, k = k, r
+		if w, ok := want[i]; ok && k != *hexKey(t, w) {
+			t.Errorf("after %d iterations: got %x", i, k)
+		}
+	}
+}
+
+// RFC 7748, Section 6.1
+func TestDH(t *testing.T) {
+	var pa, pb, sa, sb Key
+	a := hexKey(t, "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	b := hexKey(t, "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	KeyGen(&pa, a)
+	KeyGen(&pb, b)
+	if pa != *hexKey(t, "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a") {
+		t.Errorf("wrong public key of Alice: %x", pa)
+	}
+	if pb != *hexKey(t, "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f") {
+		t.Errorf("wrong public key of Bob: %x", pb)
+	}
+	if !Shared(&sa, a, &pb) || !Shared(&sb, b, &pa) {
+		t.Fatal("unexpected zero shared secret")
+	}
+	if sa != sb || sa != *hexKey(t, "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742") {
+		t.Errorf("wrong shared secret: %x %x", sa, sb)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var a, b, pa, pb, sa, sb Key
+	for i := 0; i < 100; i++ {
+		_, _ = rand.Read(a[:])
+		_, _ = rand.Read(b[:])
+		KeyGen(&pa, &a)
+		KeyGen(&pb, &b)
+		Shared(&sa, &a, &pb)
+		Shared(&sb, &b, &pa)
+		if sa != sb {
+			t.Fatal("shared secrets differ")
+		}
+	}
+}
+
+func TestSmallOrder(t *testing.T) {
+	var prv, out Key
+	_, _ = rand.Read(prv[:])
+	// Points of order 1, 2, 4 and 8, and p, p+1 encoding 0 and 1
+	for _, u := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800",
+		"5f9c95bca3508c24b1d0b1559c83ef5b04445cc4581c8e86d8224eddd09f1157",
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	} {
+		if Shared(&out, &prv, hexKey(t, u)) {
+			t.Errorf("point %s of small order accepted", u)
+		}
+	}
+}
+
+func BenchmarkKeyGen(b *testing.B) {
+	var prv, pub Key
+	_, _ = rand.Read(prv[:])
+	for n := 0; n < b.N; n++ {
+		KeyGen(&pub, &prv)
+	}
+}
+
+func BenchmarkShared(b *testing.B) {
+	var prv, pub, out Key
+	_, _ = rand.Read(prv[:])
+	_, _ = rand.Read(pub[:])
+	for n := 0; n < b.N; n++ {
+		Shared(&out, &prv, &pub)
+	}
+}
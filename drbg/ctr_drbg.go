@@ -12,7 +12,10 @@
 package drbg
 
 import (
-	"github.com/henrydcase/nobs/drbg/internal/aes"
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/internal/aestable"
 	"github.com/henrydcase/nobs/utils"
 )
 
@@ -24,31 +27,49 @@ const (
 	SeedLen  = BlockLen + KeyLen
 )
 
+// Number of counter blocks encrypted at once by the generate function.
+const ctrBatch = 8
+
 type CtrDrbg struct {
 	v          [BlockLen]byte
 	key        [KeyLen]byte
 	counter    uint
 	strength   uint
 	resistance bool
-	blockEnc   aes.IAES
-	tmpBlk     [3 * BlockLen]byte
+	// Keeps key schedule for the current value of the key
+	blockEnc aes.IAES
+	tmpBlk   [3 * BlockLen]byte
+	ctrBlk   [ctrBatch * BlockLen]byte
 }
 
+// NewCtrDrbg returns CTR_DRBG. It uses AES-NI if available, otherwise
+// constant-time bitsliced implementation of AES.
 func NewCtrDrbg() *CtrDrbg {
 	if utils.X86.HasAES {
 		return &CtrDrbg{blockEnc: &aes.AESAsm{}}
 	}
-	return &CtrDrbg{blockEnc: &aes.AES{}}
+	return &CtrDrbg{blockEnc: &aes.AESCT{}}
+}
+
+// NewCtrDrbgWithTables returns CTR_DRBG which uses table-based
+// implementation of AES on platforms without AES-NI. It is faster than
+// the bitsliced implementation, but memory access pattern depends on the
+// key, which makes it vulnerable to cache-timing attacks. Use only when
+// such attacks aren't a concern.
+func NewCtrDrbgWithTables() *CtrDrbg {
+	if utils.X86.HasAES {
+		return &CtrDrbg{blockEnc: &aes.AESAsm{}}
+	}
+	return &CtrDrbg{blockEnc: &aestable.Cipher{}}
 }
 
+// inc increments V, treated as 128-bit big-endian integer.
 func (c *CtrDrbg) inc() {
-	for i := BlockLen - 1; i >= 0; i-- {
-		if c.v[i] == 0xff {
-			c.v[i] = 0x00
-		} else {
-			c.v[i]++
-			break
-		}
+	lo := binary.BigEndian.Uint64(c.v[8:]) + 1
+	binary.BigEndian.PutUint64(c.v[8:], lo)
+	if lo == 0 {
+		hi := binary.BigEndian.Uint64(c.v[:8]) + 1
+		binary.BigEndian.PutUint64(c.v[:8], hi)
 	}
 }
 
@@ -79,6 +100,13 @@ func (c *CtrDrbg) Init(entropy, personalization []byte) bool {
 		seedBuf[i] ^= personalization[i]
 	}
 
+	// Key and V are set to zero (SP800-90A, 10.2.1.3.1)
+	for i := range c.key {
+		c.key[i] = 0
+	}
+	for i := range c.v {
+		c.v[i] = 0
+	}
 	c.blockEnc.SetKey(c.key[:])
 	c.update(seedBuf[:])
 	c.counter = 1
@@ -93,9 +121,9 @@ func (c *CtrDrbg) update(data []byte) {
 	// deliberatelly not using len(c.tmpBlk)
 	for i := 0; i < 3*BlockLen; i += BlockLen {
 		c.inc()
-		c.blockEnc.SetKey(c.key[:])
-		c.blockEnc.Encrypt(c.tmpBlk[i:], c.v[:])
+		copy(c.tmpBlk[i:], c.v[:])
 	}
+	c.blockEnc.EncryptBlocks(c.tmpBlk[:], c.tmpBlk[:])
 
 	for i := 0; i < 3*BlockLen; i++ {
 		c.tmpBlk[i] ^= data[i]
@@ -103,6 +131,8 @@ func (c *CtrDrbg) update(data []byte) {
 
 	copy(c.key[:], c.tmpBlk[:KeyLen])
 	copy(c.v[:], c.tmpBlk[KeyLen:])
+	// Key expansion is done once per key update
+	c.blockEnc.SetKey(c.key[:])
 }
 
 func (c *CtrDrbg) Reseed(entropy, data []byte) {
@@ -138,17 +168,28 @@ func (c *CtrDrbg) ReadWithAdditionalData(out, ad []byte) (n int, err error) {
 		c.update(seedBuf[:])
 	}
 
-	// Number of blocks to write minus last one
+	// Encrypt full blocks. Counter blocks are prepared in batches, so that
+	// block cipher can process them in parallel.
 	blocks := len(out) / BlockLen
-	for i := 0; i < blocks; i++ {
-		c.inc()
-		c.blockEnc.SetKey(c.key[:])
-		c.blockEnc.Encrypt(out[i*BlockLen:], c.v[:])
+	for i := 0; i < blocks; i += ctrBatch {
+		n := blocks - i
+		if n > ctrBatch {
+			n = ctrBatch
+		}
+		for j := 0; j < n; j++ {
+			c.inc()
+			copy(c.ctrBlk[j*BlockLen:], c.v[:])
+		}
+		c.blockEnc.EncryptBlocks(out[i*BlockLen:], c.ctrBlk[:n*BlockLen])
 	}
 
-	// Copy remainder - case for out being not block aligned
-	c.blockEnc.Encrypt(c.tmpBlk[:], c.v[:])
-	copy(out[blocks*BlockLen:], c.tmpBlk[:len(out)%BlockLen])
+	// Copy remainder - case for out being not block aligned. Last
+	// block uses next value of V (SP800-90A, 10.2.1.5.1).
+	if len(out)%BlockLen != 0 {
+		c.inc()
+		c.blockEnc.Encrypt(c.tmpBlk[:], c.v[:])
+		copy(out[blocks*BlockLen:], c.tmpBlk[:len(out)%BlockLen])
+	}
 
 	c.update(seedBuf[:])
 	c.counter += 1
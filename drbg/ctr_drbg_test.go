@@ -3,7 +3,12 @@ package drbg
 import (
 	"bytes"
 	"encoding/hex"
+	"strconv"
 	"testing"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/internal/aestable"
+	"github.com/henrydcase/nobs/utils"
 )
 
 func S2H(s string) []byte {
@@ -92,11 +97,92 @@ func BenchmarkInit(b *testing.B) {
 	}
 }
 
-func BenchmarkRead(b *testing.B) {
-	var result [16 * 10]byte
+func TestPartialBlock(t *testing.T) {
+	var b1, b2 [3 * BlockLen]byte
+
+	// Output for non-aligned request is a prefix of output
+	// produced for a request rounded up to full blocks.
+	for i := 1; i < BlockLen; i++ {
+		c := NewCtrDrbg()
+		c.Init(vectors[0].EntropyInput, nil)
+		d := c.Clone()
+		c.Read(b1[:2*BlockLen+i])
+		d.Read(b2[:])
+		if !bytes.Equal(b1[:2*BlockLen+i], b2[:2*BlockLen+i]) {
+			t.Errorf("wrong output for length %d", 2*BlockLen+i)
+		}
+		if bytes.Equal(b1[2*BlockLen:2*BlockLen+i], b1[BlockLen:BlockLen+i]) {
+			t.Errorf("last block repeated for length %d", 2*BlockLen+i)
+		}
+	}
+}
+
+func TestReInit(t *testing.T) {
+	var b1, b2 [BlockLen]byte
+
+	// Init on used object produces the same state as on a new one
 	c := NewCtrDrbg()
-	c.Init(vectors[0].EntropyInput[:], vectors[0].PersonalizationString)
-	for i := 0; i < b.N; i++ {
-		c.ReadWithAdditionalData(result[:], vectors[0].AdditionalInput1)
+	c.Init(vectors[0].EntropyInput, nil)
+	c.Read(b1[:])
+	c.Init(vectors[0].EntropyInput, nil)
+	c.Read(b1[:])
+	d := NewCtrDrbg()
+	d.Init(vectors[0].EntropyInput, nil)
+	d.Read(b2[:])
+	if !bytes.Equal(b1[:], b2[:]) {
+		t.Error("state not cleared by Init")
+	}
+}
+
+func TestImplementations(t *testing.T) {
+	impls := []func() aes.IAES{
+		func() aes.IAES { return &aes.AESCT{} },
+	}
+	if utils.X86.HasAES {
+		impls = append(impls, func() aes.IAES { return &aes.AESAsm{} })
+	}
+	// Lengths cover partial batches and blocks
+	for _, l := range []int{1, 16, 17, 127, 128, 129, 4096 + 5} {
+		exp := make([]byte, l)
+		got := make([]byte, l)
+		for _, impl := range impls {
+			c1 := &CtrDrbg{blockEnc: &aestable.Cipher{}}
+			c2 := &CtrDrbg{blockEnc: impl()}
+			c1.Init(vectors[0].EntropyInput, vectors[1].PersonalizationString)
+			c2.Init(vectors[0].EntropyInput, vectors[1].PersonalizationString)
+			for i := 0; i < 3; i++ {
+				c1.ReadWithAdditionalData(exp, vectors[0].AdditionalInput1)
+				c2.ReadWithAdditionalData(got, vectors[0].AdditionalInput1)
+				if !bytes.Equal(exp, got) {
+					t.Fatalf("%T: outputs differ for length %d", c2.blockEnc, l)
+				}
+			}
+		}
+	}
+}
+
+func TestCloneKeepsImplementation(t *testing.T) {
+	c := NewCtrDrbgWithTables()
+	if _, ok := c.Clone().blockEnc.(*aestable.Cipher); !ok && !utils.X86.HasAES {
+		t.Error("clone doesn't use table-based AES")
+	}
+	c = NewCtrDrbg()
+	if _, ok := c.Clone().blockEnc.(*aestable.Cipher); ok {
+		t.Error("clone uses table-based AES")
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	for _, l := range []int{16, 160, 1024, 8192, 65536} {
+		b.Run(strconv.Itoa(l), func(b *testing.B) {
+			result := make([]byte, l)
+			c := NewCtrDrbg()
+			c.Init(vectors[0].EntropyInput[:], vectors[0].PersonalizationString)
+			b.SetBytes(int64(l))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.ReadWithAdditionalData(result[:], vectors[0].AdditionalInput1)
+			}
+		})
 	}
 }
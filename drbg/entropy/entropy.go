@@ -0,0 +1,187 @@
+// Package entropy implements entropy sources used for seeding and reseeding
+// of the DRBG. The raw output of a noise source is checked by continuous
+// health tests described in NIST SP800-90B (section 4.4): the repetition
+// count test and the adaptive proportion test. When noise source isn't
+// assessed to provide full entropy, its output is conditioned with SHA3-256.
+//
+// Source implements drbg.EntropySource and can be used with drbg.NewReader.
+package entropy
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/henrydcase/nobs/drbg"
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// Min-entropy (in bits per byte) assessed for the noise sources. RDSEED
+// returns output of conditioned entropy source, RDRAND returns output of
+// the DRBG which is reseeded by the hardware, hence is treated more
+// conservatively.
+const (
+	rdseedEntropy  = 8
+	rdrandEntropy  = 4
+	urandomEntropy = 8
+)
+
+var (
+	// ErrHealthTest is returned when raw output of the noise source
+	// failed health test. Source can't be used after that.
+	ErrHealthTest = errors.New("entropy: health test failed")
+	// ErrNoEntropy is returned when the noise source failed to provide data.
+	ErrNoEntropy = errors.New("entropy: noise source failed")
+)
+
+// Source is an entropy source. It is safe for concurrent use.
+type Source struct {
+	mu    sync.Mutex
+	noise io.Reader
+	// min-entropy per byte of the noise source output
+	h float64
+	// set after start-up tests are done
+	started bool
+	// set when health tests fail
+	failed bool
+	rct    repetitionCountTest
+	apt    adaptiveProportionTest
+	buf    []byte
+}
+
+// New returns Source which reads samples from 'noise'. Each byte read from
+// 'noise' is assessed to provide 'h' bits of min-entropy, 'h' must be
+// in range (0,8].
+func New(noise io.Reader, h float64) *Source {
+	if h <= 0 || h > 8 {
+		panic("entropy: min-entropy per byte must be in (0,8]")
+	}
+	s := &Source{noise: noise, h: h}
+	s.rct.init(h)
+	s.apt.init(h)
+	return s
+}
+
+// NewSource returns Source using the best noise source available on the
+// platform. It uses RDSEED if available, otherwise RDRAND and falls back
+// to /dev/urandom.
+func NewSource() *Source {
+	switch {
+	case hasRDSEED:
+		return New(hwReader(rdseed64), rdseedEntropy)
+	case hasRDRAND:
+		return New(hwReader(rdrand64), rdrandEntropy)
+	default:
+		return New(fileReader("/dev/urandom"), urandomEntropy)
+	}
+}
+
+// read reads 'out' from the noise source and runs health tests on it.
+func (s *Source) read(out []byte) error {
+	if s.failed {
+		return ErrHealthTest
+	}
+
+	if _, err := io.ReadFull(s.noise, out); err != nil {
+		return ErrNoEntropy
+	}
+
+	for _, b := range out {
+		// Both tests must always be run
+		rct, apt := s.rct.feed(b), s.apt.feed(b)
+		if !rct || !apt {
+			s.failed = true
+			return ErrHealthTest
+		}
+	}
+	return nil
+}
+
+// startup runs start-up health tests. Samples used by the tests are
+// discarded.
+func (s *Source) startup() error {
+	var samples [startupSamples]byte
+	if err := s.read(samples[:]); err != nil {
+		return err
+	}
+	s.started = true
+	return nil
+}
+
+// Entropy fills 'out' with full entropy bits. Returns an error in case noise
+// source failed or its output didn't pass health tests. Once health test
+// fails, Source becomes unusable.
+func (s *Source) Entropy(out []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		if err := s.startup(); err != nil {
+			return err
+		}
+	}
+
+	if s.h == 8 {
+		return s.read(out)
+	}
+
+	// Output of the vetted conditioning component has full entropy if
+	// it gets at least 64 bits more of entropy than it outputs
+	// (SP800-90B, 3.1.5.1.2).
+	var digest [32]byte
+	h := sha3.New256()
+	for len(out) > 0 {
+		n := int(math.Ceil(float64(8*len(digest)+64) / s.h))
+		if cap(s.buf) < n {
+			s.buf = make([]byte, n)
+		}
+		if err := s.read(s.buf[:n]); err != nil {
+			return err
+		}
+		h.Reset()
+		h.Write(s.buf[:n])
+		out = out[copy(out, h.Sum(digest[:0])):]
+	}
+	return nil
+}
+
+// Read implements io.Reader interface. It returns full entropy bits.
+func (s *Source) Read(out []byte) (n int, err error) {
+	if err = s.Entropy(out); err != nil {
+		return 0, err
+	}
+	return len(out), nil
+}
+
+// Init instantiates CTR_DRBG with entropy input read from the Source.
+func (s *Source) Init(c *drbg.CtrDrbg, personalization []byte) error {
+	var seed [drbg.SeedLen]byte
+	defer zeroize(seed[:])
+
+	if err := s.Entropy(seed[:]); err != nil {
+		return err
+	}
+	if !c.Init(seed[:], personalization) {
+		return ErrNoEntropy
+	}
+	return nil
+}
+
+// Reseed reseeds CTR_DRBG with entropy input read from the Source.
+func (s *Source) Reseed(c *drbg.CtrDrbg, additionalInput []byte) error {
+	var seed [drbg.SeedLen]byte
+	defer zeroize(seed[:])
+
+	if err := s.Entropy(seed[:]); err != nil {
+		return err
+	}
+	c.Reseed(seed[:], additionalInput)
+	return nil
+}
+
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
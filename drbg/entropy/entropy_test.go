@@ -0,0 +1,206 @@
+package entropy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/henrydcase/nobs/drbg"
+)
+
+// fakeNoise returns data generated by 'f' function, which gets
+// index of the sample as an input.
+type fakeNoise struct {
+	i int
+	f func(i int) byte
+}
+
+func (n *fakeNoise) Read(out []byte) (int, error) {
+	for j := range out {
+		out[j] = n.f(n.i)
+		n.i++
+	}
+	return len(out), nil
+}
+
+// brokenNoise fails after returning 'n' bytes.
+type brokenNoise struct {
+	n int
+}
+
+func (b *brokenNoise) Read(out []byte) (int, error) {
+	if b.n < len(out) {
+		return 0, errors.New("broken")
+	}
+	b.n -= len(out)
+	return rand.Read(out)
+}
+
+func TestNominal(t *testing.T) {
+	var buf [drbg.SeedLen]byte
+	for _, h := range []float64{8, 6.5, 1} {
+		s := New(rand.Reader, h)
+		if err := s.Entropy(buf[:]); err != nil {
+			t.Errorf("h=%v: %v", h, err)
+		}
+		if bytes.Equal(buf[:], make([]byte, len(buf))) {
+			t.Errorf("h=%v: no data", h)
+		}
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	var buf [64]byte
+	s := NewSource()
+	for i := 0; i < 100; i++ {
+		if _, err := s.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNoiseSources(t *testing.T) {
+	var buf [4096]byte
+	for name, r := range map[string]io.Reader{
+		"RDSEED":  hwReader(rdseed64),
+		"RDRAND":  hwReader(rdrand64),
+		"urandom": fileReader("/dev/urandom"),
+	} {
+		if (name == "RDSEED" && !hasRDSEED) || (name == "RDRAND" && !hasRDRAND) {
+			continue
+		}
+		s := New(r, 8)
+		if err := s.Entropy(buf[:]); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRepetitionCountFailure(t *testing.T) {
+	var buf [32]byte
+	// Noise source gets stuck after 2000 samples
+	s := New(&fakeNoise{f: func(i int) byte {
+		if i < 2000 {
+			return byte(i)
+		}
+		return 0xAA
+	}}, 8)
+
+	if err := s.Entropy(buf[:]); err != nil {
+		t.Fatal("start-up test failed")
+	}
+	if err := s.Entropy(make([]byte, 1024)); err != ErrHealthTest {
+		t.Fatal("expected health test failure")
+	}
+	// Source must stay in error state
+	s.noise = rand.Reader
+	if err := s.Entropy(buf[:]); err != ErrHealthTest {
+		t.Error("expected source to be unusable")
+	}
+}
+
+func TestAdaptiveProportionFailure(t *testing.T) {
+	// Each second byte has same value. Repetition count test
+	// doesn't detect it.
+	s := New(&fakeNoise{f: func(i int) byte {
+		if i%2 == 0 {
+			return 0x01
+		}
+		return byte(i)
+	}}, 8)
+
+	var rct repetitionCountTest
+	rct.init(8)
+	for i := 0; i < startupSamples; i++ {
+		if !rct.feed(s.noise.(*fakeNoise).f(i)) {
+			t.Fatal("test implementation error")
+		}
+	}
+
+	if err := s.Entropy(make([]byte, 16)); err != ErrHealthTest {
+		t.Error("expected health test failure")
+	}
+}
+
+func TestAdaptiveProportionLowEntropy(t *testing.T) {
+	// Same source as above passes when claimed entropy is low
+	s := New(&fakeNoise{f: func(i int) byte {
+		if i%2 == 0 {
+			return 0x01
+		}
+		return byte(i)
+	}}, 0.5)
+	if err := s.Entropy(make([]byte, 16)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStartupFailure(t *testing.T) {
+	s := New(&fakeNoise{f: func(i int) byte { return 0 }}, 8)
+	if err := s.Entropy(make([]byte, 1)); err != ErrHealthTest {
+		t.Error("expected start-up test failure")
+	}
+}
+
+func TestNoiseSourceFailure(t *testing.T) {
+	c := drbg.NewCtrDrbg()
+	s := New(&brokenNoise{n: startupSamples}, 8)
+	if err := s.Init(c, nil); err != ErrNoEntropy {
+		t.Error("expected noise source failure")
+	}
+}
+
+func TestCutoffs(t *testing.T) {
+	// Values for alpha = 2^-40
+	for _, v := range []struct {
+		h        float64
+		rct, apt int
+	}{{8, 6, 19}, {4, 11, 78}, {1, 41, 336}} {
+		if c := rctCutoff(v.h); c != v.rct {
+			t.Errorf("RCT cutoff for H=%v: got %d exp %d", v.h, c, v.rct)
+		}
+		if c := aptCutoff(v.h); c != v.apt {
+			t.Errorf("APT cutoff for H=%v: got %d exp %d", v.h, c, v.apt)
+		}
+	}
+}
+
+func TestDrbg(t *testing.T) {
+	var out [64]byte
+	seed := make([]byte, startupSamples+2*drbg.SeedLen)
+	rand.Read(seed)
+
+	// Two DRBGs instantiated with same entropy must return same data
+	c1, c2 := drbg.NewCtrDrbg(), drbg.NewCtrDrbg()
+	if err := New(bytes.NewReader(seed), 8).Init(c1, nil); err != nil {
+		t.Fatal(err)
+	}
+	c2.Init(seed[startupSamples:startupSamples+drbg.SeedLen], nil)
+
+	c1.Read(out[:32])
+	c2.Read(out[32:])
+	if !bytes.Equal(out[:32], out[32:]) {
+		t.Error("DRBG not seeded with entropy source")
+	}
+
+	s := New(rand.Reader, 8)
+	if err := s.Reseed(c1, []byte("additional input")); err != nil {
+		t.Error(err)
+	}
+
+	r := drbg.NewReader(s, nil)
+	if _, err := r.Read(out[:]); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkEntropy(b *testing.B) {
+	var buf [drbg.SeedLen]byte
+	s := NewSource()
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		_ = s.Entropy(buf[:])
+	}
+}
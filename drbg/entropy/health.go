@@ -0,0 +1,106 @@
+package entropy
+
+import (
+	"math"
+)
+
+const (
+	// False positive probability of health tests is alpha = 2^-alphaExp.
+	// SP800-90B recommends alpha to be between 2^-20 and 2^-40.
+	alphaExp = 40
+	// Window size of the adaptive proportion test for non-binary
+	// noise sources (SP800-90B, 4.4.2)
+	aptWindow = 512
+	// Number of samples used by start-up tests (SP800-90B, 4.3)
+	startupSamples = 1024
+)
+
+// Repetition Count Test (SP800-90B, 4.4.1). Detects catastrophic failure
+// causing noise source to get stuck on a single value.
+type repetitionCountTest struct {
+	cutoff int
+	a      byte
+	b      int
+}
+
+// Adaptive Proportion Test (SP800-90B, 4.4.2). Detects large loss of
+// entropy which causes some sample value to become too common.
+type adaptiveProportionTest struct {
+	cutoff int
+	a      byte
+	b      int
+	// number of samples seen in the current window
+	i int
+}
+
+// rctCutoff returns cutoff value C = 1 + ceil(-log2(alpha)/H) for
+// the repetition count test.
+func rctCutoff(h float64) int {
+	return 1 + int(math.Ceil(alphaExp/h))
+}
+
+// aptCutoff returns cutoff value C = 1 + CRITBINOM(W, 2^-H, 1-alpha)
+// for the adaptive proportion test, where CRITBINOM is the inverse of
+// the binomial cumulative distribution function.
+func aptCutoff(h float64) int {
+	p := math.Exp2(-h)
+	alpha := math.Exp2(-alphaExp)
+
+	// P(X = k) for X ~ B(W, p), computed in log domain.
+	lpmf := func(k int) float64 {
+		n := float64(aptWindow)
+		a, _ := math.Lgamma(n + 1)
+		b, _ := math.Lgamma(float64(k) + 1)
+		c, _ := math.Lgamma(n - float64(k) + 1)
+		return a - b - c + float64(k)*math.Log(p) + (n-float64(k))*math.Log1p(-p)
+	}
+
+	// Find smallest k such that P(X > k) <= alpha. Summing the upper
+	// tail avoids precision loss of 1-CDF.
+	tail := 0.0
+	k := aptWindow
+	for ; k > 0; k-- {
+		tail += math.Exp(lpmf(k))
+		if tail > alpha {
+			break
+		}
+	}
+	return 1 + k
+}
+
+func (t *repetitionCountTest) init(h float64) {
+	t.cutoff = rctCutoff(h)
+	t.b = 0
+}
+
+// feed processes the sample and returns false if the test fails.
+func (t *repetitionCountTest) feed(s byte) bool {
+	if t.b != 0 && s == t.a {
+		t.b++
+		return t.b < t.cutoff
+	}
+	t.a = s
+	t.b = 1
+	return true
+}
+
+func (t *adaptiveProportionTest) init(h float64) {
+	t.cutoff = aptCutoff(h)
+	if t.cutoff > aptWindow {
+		// Test would never fail
+		t.cutoff = aptWindow
+	}
+	t.i = 0
+}
+
+// feed processes the sample and returns false if the test fails.
+func (t *adaptiveProportionTest) feed(s byte) bool {
+	if t.i == 0 {
+		t.a = s
+		t.b = 1
+	} else if s == t.a {
+		t.b++
+	}
+	t.i = (t.i + 1) % aptWindow
+	return t.b < t.cutoff
+}
@@ -0,0 +1,49 @@
+package entropy
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// Number of times RDSEED/RDRAND is retried before reporting failure. RDSEED
+// may return no data when it is called too often, which is expected.
+const retries = 512
+
+// hwReader reads noise using either RDSEED or RDRAND instruction.
+type hwReader func() (uint64, bool)
+
+func (f hwReader) Read(out []byte) (n int, err error) {
+	var buf [8]byte
+	for n < len(out) {
+		var r uint64
+		var ok bool
+		for i := 0; i < retries && !ok; i++ {
+			r, ok = f()
+		}
+		if !ok {
+			return n, ErrNoEntropy
+		}
+		binary.LittleEndian.PutUint64(buf[:], r)
+		n += copy(out[n:], buf[:])
+	}
+	return n, nil
+}
+
+// fileReader reads noise from a file (i.e. /dev/urandom). File is opened
+// on every read, which makes the reader safe to use after fork.
+type fileReader string
+
+func (f fileReader) Read(out []byte) (n int, err error) {
+	fd, err := os.Open(string(f))
+	if err != nil {
+		return 0, err
+	}
+	defer fd.Close()
+
+	for n < len(out) && err == nil {
+		var l int
+		l, err = fd.Read(out[n:])
+		n += l
+	}
+	return n, err
+}
@@ -0,0 +1,20 @@
+// +build amd64,!noasm
+
+package entropy
+
+import (
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Executes RDSEED. Returns false if instruction didn't return data.
+//go:noescape
+func rdseed64() (r uint64, ok bool)
+
+// Executes RDRAND. Returns false if instruction didn't return data.
+//go:noescape
+func rdrand64() (r uint64, ok bool)
+
+var (
+	hasRDSEED = utils.X86.HasRDSEED
+	hasRDRAND = utils.X86.HasRDRAND
+)
@@ -0,0 +1,17 @@
+// +build amd64,!noasm
+
+#include "textflag.h"
+
+// func rdseed64() (r uint64, ok bool)
+TEXT ·rdseed64(SB), NOSPLIT, $0-9
+	RDSEEDQ AX
+	SETCS   ok+8(FP)
+	MOVQ    AX, r+0(FP)
+	RET
+
+// func rdrand64() (r uint64, ok bool)
+TEXT ·rdrand64(SB), NOSPLIT, $0-9
+	RDRANDQ AX
+	SETCS   ok+8(FP)
+	MOVQ    AX, r+0(FP)
+	RET
@@ -0,0 +1,11 @@
+// +build !amd64 noasm
+
+package entropy
+
+func rdseed64() (r uint64, ok bool) { return 0, false }
+func rdrand64() (r uint64, ok bool) { return 0, false }
+
+var (
+	hasRDSEED = false
+	hasRDRAND = false
+)
@@ -0,0 +1,140 @@
+package drbg
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// Maximal number of bytes returned by single generate request
+	// (SP800-90A, table 3: max_number_of_bits_per_request = 2^19).
+	MaxRequestLen = (1 << 19) / 8
+	// Number of generate requests after which instance is reseeded. SP800-90A
+	// allows up to 2^48, Reader uses much lower value.
+	ReseedInterval = 1 << 16
+)
+
+// EntropySource provides entropy input used for instantiating and reseeding
+// the DRBG. Implementation must fill whole buffer with full entropy bits
+// or return an error.
+type EntropySource interface {
+	Entropy(out []byte) error
+}
+
+// systemEntropy uses random number generator provided by operating system.
+type systemEntropy struct{}
+
+func (systemEntropy) Entropy(out []byte) error {
+	_, err := io.ReadFull(rand.Reader, out)
+	return err
+}
+
+// SystemEntropy is an EntropySource which reads from crypto/rand.Reader.
+var SystemEntropy EntropySource = systemEntropy{}
+
+var errInstantiate = errors.New("drbg: instantiation failed")
+
+// instance keeps single CTR_DRBG guarded by a mutex.
+type instance struct {
+	mu sync.Mutex
+	c  *CtrDrbg
+	// ID of the process in which c was last seeded
+	pid int
+}
+
+// Reader is an io.Reader returning output of CTR_DRBG. It is safe for
+// concurrent use. Reader keeps a fixed set of independently seeded CtrDrbg
+// instances, as many as GOMAXPROCS at the time of creation, and picks them
+// round-robin for consecutive requests, so that concurrent goroutines
+// rarely contend on the same lock. Instances live as long as the Reader.
+// Each instance is seeded from the EntropySource on first use and reseeded
+// after ReseedInterval requests.
+//
+// Reader is fork-safe: an instance is reseeded before use if the process
+// ID differs from the one in which it was seeded, so that a child process
+// doesn't repeat output of its parent.
+type Reader struct {
+	src       EntropySource
+	pers      []byte
+	instances []instance
+	// Index of the instance used by the next request
+	next uint32
+}
+
+// NewReader creates Reader which seeds DRBG instances from the 'src'. If 'src'
+// is nil, SystemEntropy is used. Personalization string is optional and only
+// first SeedLen bytes are used.
+func NewReader(src EntropySource, personalization []byte) *Reader {
+	if src == nil {
+		src = SystemEntropy
+	}
+	r := &Reader{src: src, instances: make([]instance, runtime.GOMAXPROCS(0))}
+	r.pers = append(r.pers, personalization...)
+	return r
+}
+
+// reseed instantiates or reseeds the instance with fresh entropy input.
+func (r *Reader) reseed(in *instance) error {
+	var seed [SeedLen]byte
+	defer zeroize(seed[:])
+
+	if err := r.src.Entropy(seed[:]); err != nil {
+		return err
+	}
+	in.pid = os.Getpid()
+	if in.c != nil {
+		in.c.Reseed(seed[:], nil)
+		return nil
+	}
+	c := NewCtrDrbg()
+	if !c.Init(seed[:], r.pers) {
+		return errInstantiate
+	}
+	in.c = c
+	return nil
+}
+
+// Read fills 'out' with random bytes. Error is returned only in case entropy
+// source fails or DRBG can't be instantiated.
+func (r *Reader) Read(out []byte) (n int, err error) {
+	i := atomic.AddUint32(&r.next, 1) % uint32(len(r.instances))
+	in := &r.instances[i]
+	in.mu.Lock()
+	n, err = r.generate(in, out)
+	in.mu.Unlock()
+	return n, err
+}
+
+// generate fills 'out' with random bytes generated by the instance 'in'.
+// Caller must hold the lock of the instance.
+func (r *Reader) generate(in *instance, out []byte) (n int, err error) {
+	for len(out) > 0 {
+		// Instance is used for the first time, reseed interval
+		// has been reached or the process has been forked.
+		if in.c == nil || in.c.counter > ReseedInterval || in.pid != os.Getpid() {
+			if err = r.reseed(in); err != nil {
+				return n, err
+			}
+		}
+
+		l := len(out)
+		if l > MaxRequestLen {
+			l = MaxRequestLen
+		}
+		in.c.ReadWithAdditionalData(out[:l], nil)
+		out = out[l:]
+		n += l
+	}
+	return n, nil
+}
+
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
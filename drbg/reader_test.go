@@ -0,0 +1,138 @@
+package drbg
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeEntropy returns consecutive bytes and counts number of calls.
+type fakeEntropy struct {
+	mu    sync.Mutex
+	b     byte
+	calls int
+	err   error
+}
+
+func (f *fakeEntropy) Entropy(out []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	for i := range out {
+		out[i] = f.b
+		f.b++
+	}
+	f.calls++
+	return nil
+}
+
+func TestReaderMatchesCtrDrbg(t *testing.T) {
+	var seed [SeedLen]byte
+	var exp, got [3 * BlockLen]byte
+
+	// seeding is deterministic for fakeEntropy
+	(&fakeEntropy{}).Entropy(seed[:])
+	c := NewCtrDrbg()
+	c.Init(seed[:], []byte("pers"))
+	c.Read(exp[:])
+
+	r := NewReader(&fakeEntropy{}, []byte("pers"))
+	if _, err := r.Read(got[:]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exp[:], got[:]) {
+		t.Errorf("wrong output\nexp: %X\ngot: %X\n", exp, got)
+	}
+}
+
+func TestReaderReseed(t *testing.T) {
+	var buf [1]byte
+	var in instance
+	src := &fakeEntropy{}
+	r := NewReader(src, nil)
+
+	for i := 0; i < ReseedInterval+1; i++ {
+		r.generate(&in, buf[:])
+	}
+	if src.calls != 2 {
+		t.Errorf("instance not reseeded")
+	}
+}
+
+func TestReaderFork(t *testing.T) {
+	var buf [1]byte
+	var in instance
+	src := &fakeEntropy{}
+	r := NewReader(src, nil)
+
+	r.generate(&in, buf[:])
+	// Simulates instance seeded by the parent process
+	in.pid++
+	r.generate(&in, buf[:])
+	if src.calls != 2 {
+		t.Errorf("instance not reseeded after fork")
+	}
+}
+
+func TestReaderLongRead(t *testing.T) {
+	r := NewReader(nil, nil)
+	out := make([]byte, 2*MaxRequestLen+5)
+	n, err := r.Read(out)
+	if err != nil || n != len(out) {
+		t.Fatal("read failed")
+	}
+	if bytes.Equal(out[:MaxRequestLen], out[MaxRequestLen:2*MaxRequestLen]) {
+		t.Error("output repeats")
+	}
+}
+
+func TestReaderEntropyFailure(t *testing.T) {
+	var buf [16]byte
+	src := &fakeEntropy{err: errors.New("entropy source failed")}
+	r := NewReader(src, nil)
+	if n, err := r.Read(buf[:]); err != src.err || n != 0 {
+		t.Error("expected error")
+	}
+}
+
+func TestReaderConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[[16]byte]bool)
+	r := NewReader(nil, nil)
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var buf [16]byte
+				if _, err := r.Read(buf[:]); err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[buf] {
+					t.Error("repeated output")
+				}
+				seen[buf] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkReader(b *testing.B) {
+	var buf [32]byte
+	r := NewReader(nil, nil)
+	b.SetBytes(int64(len(buf)))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Read(buf[:])
+		}
+	})
+}
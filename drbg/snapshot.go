@@ -0,0 +1,122 @@
+package drbg
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/internal/aestable"
+)
+
+// Snapshot format:
+//
+//	magic (4) || version (1) || cipher (1) || flags (1) || strength (2) ||
+//	reseed counter (8) || V (BlockLen) || Key (KeyLen) || checksum (32)
+//
+// Integers are big-endian. Checksum is SHA3-256 of all preceding bytes.
+const (
+	snapshotVersion = 1
+	// Identifies CTR_DRBG with AES-256 without derivation function
+	snapshotCipherAES256 = 1
+	// Set when prediction resistance is enabled
+	snapshotFlagResistance = 1 << 0
+
+	snapshotHdrLen   = 4 + 1 + 1 + 1 + 2 + 8
+	snapshotSumLen   = 32
+	snapshotStateLen = snapshotHdrLen + BlockLen + KeyLen
+	SnapshotLen      = snapshotStateLen + snapshotSumLen
+)
+
+var snapshotMagic = []byte("CDRB")
+
+var (
+	ErrSnapshotFormat   = errors.New("drbg: wrong format of the snapshot")
+	ErrSnapshotVersion  = errors.New("drbg: unsupported snapshot version")
+	ErrSnapshotChecksum = errors.New("drbg: snapshot integrity check failed")
+)
+
+func snapshotChecksum(out, in []byte) {
+	h := sha3.New256()
+	h.Write(in)
+	h.Sum(out[:0])
+}
+
+// MarshalBinary serializes internal state of the DRBG. Returned snapshot
+// contains secret values and must be protected accordingly. It implements
+// encoding.BinaryMarshaler.
+func (c *CtrDrbg) MarshalBinary() ([]byte, error) {
+	var flags byte
+	out := make([]byte, SnapshotLen)
+	if c.resistance {
+		flags |= snapshotFlagResistance
+	}
+
+	copy(out, snapshotMagic)
+	out[4] = snapshotVersion
+	out[5] = snapshotCipherAES256
+	out[6] = flags
+	binary.BigEndian.PutUint16(out[7:], uint16(c.strength))
+	binary.BigEndian.PutUint64(out[9:], uint64(c.counter))
+	copy(out[snapshotHdrLen:], c.v[:])
+	copy(out[snapshotHdrLen+BlockLen:], c.key[:])
+	snapshotChecksum(out[snapshotStateLen:], out[:snapshotStateLen])
+	return out, nil
+}
+
+// UnmarshalBinary restores state of the DRBG from the snapshot created by
+// MarshalBinary. It implements encoding.BinaryUnmarshaler.
+func (c *CtrDrbg) UnmarshalBinary(data []byte) error {
+	var sum [snapshotSumLen]byte
+
+	if len(data) < snapshotHdrLen || !bytes.Equal(data[:4], snapshotMagic) {
+		return ErrSnapshotFormat
+	}
+	if data[4] != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	if len(data) != SnapshotLen {
+		return ErrSnapshotFormat
+	}
+
+	snapshotChecksum(sum[:], data[:snapshotStateLen])
+	if subtle.ConstantTimeCompare(sum[:], data[snapshotStateLen:]) != 1 {
+		return ErrSnapshotChecksum
+	}
+
+	if data[5] != snapshotCipherAES256 || data[6]&^snapshotFlagResistance != 0 {
+		return ErrSnapshotFormat
+	}
+	// Security strength of CTR_DRBG with AES-256, the only one supported
+	if binary.BigEndian.Uint16(data[7:]) != 256 {
+		return ErrSnapshotFormat
+	}
+
+	if c.blockEnc == nil {
+		c.blockEnc = NewCtrDrbg().blockEnc
+	}
+	c.resistance = data[6]&snapshotFlagResistance != 0
+	c.strength = uint(binary.BigEndian.Uint16(data[7:]))
+	c.counter = uint(binary.BigEndian.Uint64(data[9:]))
+	copy(c.v[:], data[snapshotHdrLen:])
+	copy(c.key[:], data[snapshotHdrLen+BlockLen:])
+	c.blockEnc.SetKey(c.key[:])
+	return nil
+}
+
+// Clone returns a copy of the DRBG in its current state. Both DRBGs
+// generate the same sequence of bytes afterwards.
+func (c *CtrDrbg) Clone() *CtrDrbg {
+	dup := NewCtrDrbg()
+	if _, ok := c.blockEnc.(*aestable.Cipher); ok {
+		dup = NewCtrDrbgWithTables()
+	}
+	dup.v = c.v
+	dup.key = c.key
+	dup.counter = c.counter
+	dup.strength = c.strength
+	dup.resistance = c.resistance
+	dup.blockEnc.SetKey(dup.key[:])
+	return dup
+}
@@ -0,0 +1,111 @@
+package drbg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newTestDrbg(t testing.TB) *CtrDrbg {
+	c := NewCtrDrbg()
+	if !c.Init(vectors[0].EntropyInput, vectors[0].PersonalizationString) {
+		t.Fatal("Init failed")
+	}
+	return c
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	var exp, got [100]byte
+
+	c := newTestDrbg(t)
+	c.Read(exp[:10])
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != SnapshotLen {
+		t.Fatalf("wrong snapshot length %d", len(data))
+	}
+	c.ReadWithAdditionalData(exp[:], vectors[0].AdditionalInput1)
+
+	var r CtrDrbg
+	if err := r.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	r.ReadWithAdditionalData(got[:], vectors[0].AdditionalInput1)
+	if !bytes.Equal(exp[:], got[:]) {
+		t.Errorf("restored DRBG generates different output\nexp: %X\ngot: %X", exp, got)
+	}
+	if r.counter != c.counter || r.strength != c.strength {
+		t.Error("configuration not restored")
+	}
+}
+
+func TestSnapshotNegative(t *testing.T) {
+	var r CtrDrbg
+	c := newTestDrbg(t)
+	data, _ := c.MarshalBinary()
+
+	corrupt := func(i int, v byte) []byte {
+		d := append([]byte{}, data...)
+		d[i] ^= v
+		return d
+	}
+	// Corrupts the snapshot and recomputes its checksum
+	corruptValid := func(i int, v byte) []byte {
+		d := corrupt(i, v)
+		snapshotChecksum(d[snapshotStateLen:], d[:snapshotStateLen])
+		return d
+	}
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrSnapshotFormat},
+		{"truncated", data[:len(data)-1], ErrSnapshotFormat},
+		{"magic", corrupt(0, 1), ErrSnapshotFormat},
+		{"version", corrupt(4, 0xFF), ErrSnapshotVersion},
+		{"counter", corrupt(16, 1), ErrSnapshotChecksum},
+		{"key", corrupt(SnapshotLen-snapshotSumLen-1, 0x80), ErrSnapshotChecksum},
+		{"checksum", corrupt(SnapshotLen-1, 1), ErrSnapshotChecksum},
+		{"cipher", corruptValid(5, 2), ErrSnapshotFormat},
+		{"flags", corruptValid(6, 2), ErrSnapshotFormat},
+		{"strength", corruptValid(8, 1), ErrSnapshotFormat},
+	} {
+		if err := r.UnmarshalBinary(tc.data); err != tc.err {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	var b1, b2 [64]byte
+
+	c := newTestDrbg(t)
+	c.Read(b1[:7])
+	d := c.Clone()
+
+	// Both branches replay same output
+	c.Read(b1[:])
+	d.Read(b2[:])
+	if !bytes.Equal(b1[:], b2[:]) {
+		t.Error("clone generates different output")
+	}
+
+	// Branches are independent
+	c.ReadWithAdditionalData(b1[:], []byte("branch 1"))
+	d.ReadWithAdditionalData(b2[:], []byte("branch 2"))
+	if bytes.Equal(b1[:], b2[:]) {
+		t.Error("clone shares state with the original")
+	}
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	var r CtrDrbg
+	c := newTestDrbg(b)
+	for i := 0; i < b.N; i++ {
+		data, _ := c.MarshalBinary()
+		_ = r.UnmarshalBinary(data)
+	}
+}
@@ -14,6 +14,20 @@
 //
 // The SHA-3 and SHAKE are documented in FIPS-PUB-202 [1] and
 // cSHAKE specification can be found in NIST-SP-800-185 [2].
+// Package also implements TurboSHAKE and KangarooTwelve, which
+// use Keccak-p[1600, 12], reduced round variant of the
+// permutation. Both are specified in RFC 9861 [3].
+// Sponge and Duplex types allow to use the permutation with
+// custom rate, domain separation byte and number of rounds, as
+// building blocks of other schemes, like SpongeWrap AEAD or protocol
+// transcripts. Sponge and duplex constructions are described in
+// "Cryptographic sponge functions" and "Duplexing the sponge:
+// single-pass authenticated encryption and other applications" by
+// G. Bertoni, J. Daemen, M. Peeters and G. Van Assche.
+//
+// SHA-3, SHAKE and cSHAKE implement encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler, which allows to checkpoint a long-running
+// hash and resume it later.
 //
 // Implementation was initially based on
 // https://godoc.org/golang.org/x/crypto/sha3
@@ -0,0 +1,172 @@
+package sha3
+
+// TurboSHAKE and KangarooTwelve are XOFs based on Keccak-p[1600, 12]
+// permutation, described in RFC 9861.
+
+// Consts for TurboSHAKE and KangarooTwelve
+const (
+	// Default domain separation byte of TurboSHAKE
+	sfxTurboShake = 0x1f
+	// Domain separation bytes used by KangarooTwelve: single node,
+	// final node and leaf (chaining value).
+	sfxK12Single = 0x07
+	sfxK12Final  = 0x06
+	sfxK12Leaf   = 0x0b
+	// Size of the chunk processed by a single leaf
+	k12ChunkSize = 8192
+)
+
+func newTurboShake(D byte, shaId uint8) *state {
+	if D < 0x01 || D > 0x7f {
+		panic("sha3: TurboSHAKE domain byte must be in range [0x01, 0x7F]")
+	}
+	return &state{sfx: D, desc: Sha3Desc[shaId], rounds: 12}
+}
+
+// NewTurboShake128 creates a new TurboSHAKE128 with domain separation byte
+// D, which must be in range [0x01, 0x7F]. Default value is 0x1F.
+func NewTurboShake128(D byte) ShakeHash {
+	return newTurboShake(D, SHAKE128)
+}
+
+// NewTurboShake256 creates a new TurboSHAKE256 with domain separation byte
+// D, which must be in range [0x01, 0x7F]. Default value is 0x1F.
+func NewTurboShake256(D byte) ShakeHash {
+	return newTurboShake(D, SHAKE256)
+}
+
+// lengthEncode encodes x as described in RFC 9861, 3.3.
+func lengthEncode(x uint64) []byte {
+	var b [9]byte
+	n := 0
+	for v := x; v > 0; v >>= 8 {
+		n++
+	}
+	for i := 0; i < n; i++ {
+		b[i] = byte(x >> uint(8*(n-1-i)))
+	}
+	b[n] = byte(n)
+	return b[:n+1]
+}
+
+// kangarooTwelve implements tree hashing mode of KangarooTwelve. First
+// chunk of the input is absorbed by the final node directly. In case input
+// is longer than a chunk, each subsequent chunk is hashed by a leaf and
+// its chaining value is absorbed by the final node.
+type kangarooTwelve struct {
+	final state
+	leaf  state
+	// Customization string
+	custom []byte
+	// Size of the chaining value
+	cvLen int
+	// Set when input is longer than a single chunk
+	tree bool
+	// Number of bytes absorbed into the current chunk
+	chunkLen int
+	// Number of chaining values absorbed by the final node
+	leaves uint64
+}
+
+func newKangarooTwelve(C []byte, shaId uint8) *kangarooTwelve {
+	k := &kangarooTwelve{
+		final:  state{sfx: sfxK12Single, desc: Sha3Desc[shaId], rounds: 12},
+		leaf:   state{sfx: sfxK12Leaf, desc: Sha3Desc[shaId], rounds: 12},
+		custom: append([]byte(nil), C...),
+		cvLen:  32,
+	}
+	if shaId == SHAKE256 {
+		k.cvLen = 64
+	}
+	return k
+}
+
+// NewKT128 creates a new KT128 (KangarooTwelve) XOF with customization
+// string C, which may be empty.
+func NewKT128(C []byte) ShakeHash {
+	return newKangarooTwelve(C, SHAKE128)
+}
+
+// NewKT256 creates a new KT256 XOF with customization string C, which may
+// be empty.
+func NewKT256(C []byte) ShakeHash {
+	return newKangarooTwelve(C, SHAKE256)
+}
+
+// absorbLeaf finalizes current leaf and absorbs its chaining value.
+func (k *kangarooTwelve) absorbLeaf() {
+	var cv [64]byte
+	k.leaf.Read(cv[:k.cvLen])
+	k.final.Write(cv[:k.cvLen])
+	k.leaf.Reset()
+	k.leaves++
+	k.chunkLen = 0
+}
+
+func (k *kangarooTwelve) write(in []byte) {
+	for len(in) > 0 {
+		if k.chunkLen == k12ChunkSize {
+			if !k.tree {
+				// First chunk is followed by 0x03 || 0x00^7
+				k.final.Write([]byte{0x03, 0, 0, 0, 0, 0, 0, 0})
+				k.tree = true
+				k.chunkLen = 0
+			} else {
+				k.absorbLeaf()
+			}
+		}
+
+		l := k12ChunkSize - k.chunkLen
+		if l > len(in) {
+			l = len(in)
+		}
+		if k.tree {
+			k.leaf.Write(in[:l])
+		} else {
+			k.final.Write(in[:l])
+		}
+		k.chunkLen += l
+		in = in[l:]
+	}
+}
+
+// Write absorbs more data. It returns an error if called after Read.
+func (k *kangarooTwelve) Write(in []byte) (int, error) {
+	if k.final.isSquezing {
+		return 0, ErrWriteAfterRead
+	}
+	k.write(in)
+	return len(in), nil
+}
+
+// Read finalizes the hash if called first time and squeezes out
+// len(out) bytes.
+func (k *kangarooTwelve) Read(out []byte) (int, error) {
+	if !k.final.isSquezing {
+		k.write(k.custom)
+		k.write(lengthEncode(uint64(len(k.custom))))
+		if k.tree {
+			k.absorbLeaf()
+			k.final.Write(lengthEncode(k.leaves))
+			k.final.Write([]byte{0xff, 0xff})
+			k.final.sfx = sfxK12Final
+		}
+	}
+	return k.final.Read(out)
+}
+
+// Reset resets the hash to its initial state.
+func (k *kangarooTwelve) Reset() {
+	k.final.Reset()
+	k.final.sfx = sfxK12Single
+	k.leaf.Reset()
+	k.tree = false
+	k.chunkLen = 0
+	k.leaves = 0
+}
+
+// Clone returns a copy of the hash in its current state.
+func (k *kangarooTwelve) Clone() ShakeHash {
+	dup := *k
+	return &dup
+}
@@ -0,0 +1,221 @@
+package sha3
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ptn returns n bytes of the pattern 00 01 .. FA 00 01 .., used by RFC 9861
+func ptn(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+// Test vectors from RFC 9861, section 5. Long outputs are truncated.
+var turboShakeTests = []struct {
+	bits int
+	D    byte
+	msg  []byte
+	out  string
+}{
+	{128, 0x1F, nil, "1E415F1C5983AFF2169217277D17BB538CD945A397DDEC541F1CE41AF2C1B74C" +
+		"3E8CCAE2A4DAE56C84A04C2385C03C15E8193BDF58737363321691C05462C8DF"},
+	{128, 0x1F, ptn(17), "9C97D036A3BAC819DB70EDE0CA554EC6E4C2A1A4FFBFD9EC269CA6A111161233"},
+	{128, 0x1F, ptn(17 * 17), "96C77C279E0126F7FC07C9B07F5CDAE1E0BE60BDBE10620040E75D7223A624D2"},
+	{128, 0x1F, ptn(17 * 17 * 17), "D4976EB56BCF118520582B709F73E1D6853E001FDAF80E1B13E0D0599D5FB372"},
+	{128, 0x01, []byte{0xFF}, "012AD664922CE3F81B058735B50AACBDE383F1A9A75180B4B9F929550A5552B5"},
+	{128, 0x06, bytes.Repeat([]byte{0xFF}, 3), "3D03988BB59E681851A192F429AE03988E8F444BC06036A3F1A7D2CCD758D174"},
+	{128, 0x0B, bytes.Repeat([]byte{0xFF}, 7), "8DEEAA1AEC47CCEE569F659C21DFA8E112DB3CEE37B18178B2ACD805B799CC37"},
+	{256, 0x1F, nil, "367A329DAFEA871C7802EC67F905AE13C57695DC2C6663C61035F59A18F8E7DB" +
+		"11EDC0E12E91EA60EB6B32DF06DD7F002FBAFABB6E13EC1CC20D995547600DB0"},
+	{256, 0x1F, ptn(17), "B3BAB0300E6A191FBE6137939835923578794EA54843F5011090FA2F3780A9E5" +
+		"CB22C59D78B40A0FBFF9E672C0FBE0970BD2C845091C6044D687054DA5D8E9C7"},
+	{256, 0x1F, ptn(17 * 17), "66B810DB8E90780424C0847372FDC95710882FDE31C6DF75BEB9D4CD9305CFCA" +
+		"E35E7B83E8B7E6EB4B78605880116316FE2C078A09B94AD7B8213C0A738B65C0"},
+	{256, 0x01, []byte{0xFF}, "403108CF3FA80ED8A5C80228381E4D0B1A563B11E7A07CC65D175F37CBC6C9A2" +
+		"3746B5FC21EC1E6849BD0504CD05C0FD4CAD3141DA35905F5E3A84DF5EC80864"},
+	{256, 0x0B, bytes.Repeat([]byte{0xFF}, 7), "BB36764951EC97E9D85F7EE9A67A7718FC005CF42556BE79CE12C0BDE50E5736" +
+		"D6632B0D0DFB202D1BBB8FFE3DD74CB00834FA756CB03471BAB13A1E2C16B3C0"},
+}
+
+var ktTests = []struct {
+	bits int
+	msg  []byte
+	C    []byte
+	out  string
+}{
+	{128, nil, nil, "1AC2D450FC3B4205D19DA7BFCA1B37513C0803577AC7167F06FE2CE1F0EF39E5" +
+		"4269C056B8C82E48276038B6D292966CC07A3D4645272E31FF38508139EB0A71"},
+	{128, ptn(17), nil, "6BF75FA2239198DB4772E36478F8E19B0F371205F6A9A93A273F51DF37122888"},
+	{128, ptn(17 * 17), nil, "0C315EBCDEDBF61426DE7DCF8FB725D1E74675D7F5327A5067F367B108ECB67C"},
+	{128, ptn(17 * 17 * 17), nil, "CB552E2EC77D9910701D578B457DDF772C12E322E4EE7FE417F92C758F0D59D0"},
+	{128, ptn(17 * 17 * 17 * 17), nil, "8701045E22205345FF4DDA05555CBB5C3AF1A771C2B89BAEF37DB43D9998B9FE"},
+	{128, ptn(17 * 17 * 17 * 17 * 17), nil, "844D610933B1B9963CBDEB5AE3B6B05CC7CBD67CEEDF883EB678A0A8E0371682"},
+	{128, nil, ptn(1), "FAB658DB63E94A246188BF7AF69A133045F46EE984C56E3C3328CAAF1AA1A583"},
+	{128, []byte{0xFF}, ptn(41), "D848C5068CED736F4462159B9867FD4C20B808ACC3D5BC48E0B06BA0A3762EC4"},
+	{128, bytes.Repeat([]byte{0xFF}, 3), ptn(41 * 41), "C389E5009AE57120854C2E8C64670AC01358CF4C1BAF89447A724234DC7CED74"},
+	{128, bytes.Repeat([]byte{0xFF}, 7), ptn(41 * 41 * 41), "75D2F86A2E644566726B4FBCFC5657B9DBCF070C7B0DCA06450AB291D7443BCF"},
+	{128, ptn(8191), nil, "1B577636F723643E990CC7D6A659837436FD6A103626600EB8301CD1DBE553D6"},
+	{128, ptn(8192), nil, "48F256F6772F9EDFB6A8B661EC92DC93B95EBD05A08A17B39AE3490870C926C3"},
+	{128, ptn(8192), ptn(8191), "7D65682E84874049E95C53D333841CB03A6E13DFC58A2B0EF9EFCC1D55BE0C96"},
+	{128, ptn(8192), ptn(8192), "90E81832FF83E1C18F4C3D10DCC644688A077E272718BBB2E01C41785982D697"},
+	{256, nil, nil, "B23D2E9CEA9F4904E02BEC06817FC10CE38CE8E93EF4C89E6537076AF8646404" +
+		"E3E8B68107B8833A5D30490AA33482353FD4ADC7148ECB782855003AAEBDE4A9"},
+	{256, ptn(17), nil, "1BA3C02B1FC514474F06C8979978A9056C8483F4A1B63D0DCCEFE3A28A2F323E" +
+		"1CDCCA40EBF006AC76EF0397152346837B1277D3E7FAA9C9653B19075098527B"},
+	{256, ptn(17 * 17), nil, "DE8CCBC63E0F133EBB4416814D4C66F691BBF8B6A61EC0A7700F836B086CB029" +
+		"D54F12AC7159472C72DB118C35B4E6AA213C6562CAAA9DCC518959E69B10F3BA"},
+	{256, ptn(17 * 17 * 17), nil, "647EFB49FE9D717500171B41E7F11BD491544443209997CE1C2530D15EB1FFBB" +
+		"598935EF954528FFC152B1E4D731EE2683680674365CD191D562BAE753B84AA5"},
+	{256, ptn(17 * 17 * 17 * 17), nil, "B06275D284CD1CF205BCBE57DCCD3EC1FF6686E3ED15776383E1F2FA3C6AC8F0" +
+		"8BF8A162829DB1A44B2A43FF83DD89C3CF1CEB61EDE659766D5CCF817A62BA8D"},
+	{256, bytes.Repeat([]byte{0xFF}, 7), ptn(41 * 41 * 41), "E0911CC00025E1540831E266D94ADD9B98712142B80D2629E643AAC4EFAF5A3A" +
+		"30A88CBF4AC2A91A2432743054FBCC9897670E86BA8CEC2FC2ACE9C966369724"},
+	{256, ptn(8192), ptn(8189), "74E47879F10A9C5D11BD2DA7E194FE57E86378BF3C3F7448EFF3C576A0F18C5C" +
+		"AAE0999979512090A7F348AF4260D4DE3C37F1ECAF8D2C2C96C1D16C64B12496"},
+}
+
+// checkXOF absorbs msg in one go and in chunks of varying sizes and
+// compares the output with exp.
+func checkXOF(t *testing.T, i int, h ShakeHash, msg, exp []byte) {
+	out := make([]byte, len(exp))
+	c := h.Clone()
+	h.Write(msg)
+	h.Read(out)
+	if !bytes.Equal(out, exp) {
+		t.Errorf("#%d: got %X, want %X", i, out, exp)
+	}
+
+	// Absorb in chunks which are not aligned with rate nor chunk size
+	for m, l := msg, 1; len(m) > 0; l = 3*l + 1 {
+		if l > len(m) {
+			l = len(m)
+		}
+		c.Write(m[:l])
+		m = m[l:]
+	}
+	// Squeeze byte by byte
+	for j := range out {
+		c.Read(out[j : j+1])
+	}
+	if !bytes.Equal(out, exp) {
+		t.Errorf("#%d: chunked: got %X, want %X", i, out, exp)
+	}
+
+	if _, err := c.Write([]byte{0}); err != ErrWriteAfterRead {
+		t.Errorf("#%d: expected ErrWriteAfterRead, got %v", i, err)
+	}
+	c.Reset()
+	c.Write(msg)
+	c.Read(out)
+	if !bytes.Equal(out, exp) {
+		t.Errorf("#%d: after Reset: got %X, want %X", i, out, exp)
+	}
+}
+
+func TestTurboShake(t *testing.T) {
+	for i, v := range turboShakeTests {
+		var h ShakeHash
+		if v.bits == 128 {
+			h = NewTurboShake128(v.D)
+		} else {
+			h = NewTurboShake256(v.D)
+		}
+		checkXOF(t, i, h, v.msg, decodeHex(v.out))
+	}
+}
+
+func TestTurboShakeDomain(t *testing.T) {
+	for _, D := range []byte{0x00, 0x80, 0xFF} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for D=%02X", D)
+				}
+			}()
+			NewTurboShake128(D)
+		}()
+	}
+}
+
+// RFC 9861, 5: KT128(M=`00`^0, C=`00`^0, 10032), last 32 bytes
+func TestKT128LongOutput(t *testing.T) {
+	exp := decodeHex("E8DC563642F7228C84684C898405D3A834799158C079B12880277A1D28E2FF6D")
+	out := make([]byte, 10032)
+	NewKT128(nil).Read(out)
+	if !bytes.Equal(out[10000:], exp) {
+		t.Errorf("got %X, want %X", out[10000:], exp)
+	}
+}
+
+func TestKangarooTwelve(t *testing.T) {
+	for i, v := range ktTests {
+		var h ShakeHash
+		if v.bits == 128 {
+			h = NewKT128(v.C)
+		} else {
+			h = NewKT256(v.C)
+		}
+		checkXOF(t, i, h, v.msg, decodeHex(v.out))
+	}
+}
+
+func TestKeccakP1600r12(t *testing.T) {
+	var a, b [25]uint64
+	for i := range a {
+		a[i] = uint64(i) * 0x0123456789ABCDEF
+	}
+	b = a
+	for i := 0; i < 100; i++ {
+		keccakP1600r12(&a)
+		keccakP1600Generic(&b, 12)
+		if a != b {
+			t.Fatalf("#%d: permutations differ", i)
+		}
+		keccakF1600(&a)
+		keccakP1600Generic(&b, 24)
+		if a != b {
+			t.Fatalf("#%d: permutations differ", i)
+		}
+	}
+}
+
+func TestLengthEncode(t *testing.T) {
+	for _, v := range []struct {
+		x   uint64
+		out []byte
+	}{
+		{0, []byte{0x00}},
+		{12, []byte{0x0C, 0x01}},
+		{65538, []byte{0x01, 0x00, 0x02, 0x03}},
+	} {
+		if out := lengthEncode(v.x); !bytes.Equal(out, v.out) {
+			t.Errorf("lengthEncode(%d): got %X, want %X", v.x, out, v.out)
+		}
+	}
+}
+
+func BenchmarkKT128(b *testing.B) {
+	buf := make([]byte, 64*1024)
+	out := make([]byte, 32)
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		h := NewKT128(nil)
+		h.Write(buf)
+		h.Read(out)
+	}
+}
+
+func BenchmarkTurboShake128(b *testing.B) {
+	buf := make([]byte, 64*1024)
+	out := make([]byte, 32)
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		h := NewTurboShake128(sfxTurboShake)
+		h.Write(buf)
+		h.Read(out)
+	}
+}
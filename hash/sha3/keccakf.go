@@ -2,8 +2,6 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-//  +build !amd64 appengine gccgo noasm
-
 package sha3
 
 import "math/bits"
@@ -36,14 +34,64 @@ var rc = [24]uint64{
 	0x8000000080008008,
 }
 
-// keccakF1600 applies the Keccak permutation to a 1600b-wide
-// state represented as a slice of 25 uint64s.
-func keccakF1600(a *[25]uint64) {
+// rotc stores the rotation offsets of the lanes used in the ρ step.
+var rotc = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// keccakRound applies a single round of the Keccak-p[1600] with
+// the round constant rc. Lane (x, y) is stored at a[x+5*y].
+func keccakRound(a *[25]uint64, rc uint64) {
+	var c [5]uint64
+	var b [25]uint64
+
+	// θ step
+	for x := 0; x < 5; x++ {
+		c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+	}
+	for x := 0; x < 5; x++ {
+		d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+		for y := 0; y < 25; y += 5 {
+			a[x+y] ^= d
+		}
+	}
+	// ρ and π steps
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 5; x++ {
+			b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], rotc[x+5*y])
+		}
+	}
+	// χ step
+	for y := 0; y < 25; y += 5 {
+		for x := 0; x < 5; x++ {
+			a[x+y] = b[x+y] ^ (^b[(x+1)%5+y] & b[(x+2)%5+y])
+		}
+	}
+	// ι step
+	a[0] ^= rc
+}
+
+// keccakP1600Generic applies the Keccak-p[1600, rounds] permutation to
+// a 1600b-wide state represented as a slice of 25 uint64s. Those are the
+// last 'rounds' rounds of the Keccak-f[1600], number of rounds must be
+// in range [1, 24].
+func keccakP1600Generic(a *[25]uint64, rounds int) {
 	// Implementation translated from Keccak-inplace.c
 	// in the keccak reference code.
 	var t, bc0, bc1, bc2, bc3, bc4, d0, d1, d2, d3, d4 uint64
 
-	for i := 0; i < 24; i += 4 {
+	// The main loop processes 4 rounds at a time, the rest is done
+	// round by round.
+	first := 24 - rounds
+	for i := first; i < first+rounds%4; i++ {
+		keccakRound(a, rc[i])
+	}
+
+	for i := first + rounds%4; i < 24; i += 4 {
 		// Combines the 5 steps in each round into 2 steps.
 		// Unrolls 4 rounds per loop and spreads some steps across rounds.
 
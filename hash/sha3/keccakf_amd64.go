@@ -10,3 +10,6 @@ package sha3
 
 //go:noescape
 func keccakF1600(a *[25]uint64)
+
+//go:noescape
+func keccakP1600r12(state *[25]uint64)
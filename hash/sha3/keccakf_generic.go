@@ -0,0 +1,17 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !amd64 appengine gccgo noasm
+
+package sha3
+
+// keccakF1600 applies the Keccak-f[1600] permutation.
+func keccakF1600(a *[25]uint64) {
+	keccakP1600Generic(a, 24)
+}
+
+// keccakP1600r12 applies the Keccak-p[1600, 12] permutation.
+func keccakP1600r12(a *[25]uint64) {
+	keccakP1600Generic(a, 12)
+}
@@ -0,0 +1,166 @@
+package sha3
+
+// ShakeX4 computes four independent SHAKE128 or SHAKE256 instances in
+// lockstep. On CPUs supporting AVX2 the four permutations are computed
+// in parallel, which is faster than computing them one by one.
+import (
+	"encoding/binary"
+)
+
+// keccakF1600x4 applies Keccak-f[1600] to four states stored in
+// interleaved form: lane i of state j is at index 4*i+j. Implementation is
+// selected at runtime.
+var keccakF1600x4 = keccakF1600x4Generic
+
+func keccakF1600x4Generic(a *[100]uint64) {
+	var s [25]uint64
+	for j := 0; j < 4; j++ {
+		for i := range s {
+			s[i] = a[4*i+j]
+		}
+		keccakF1600(&s)
+		for i := range s {
+			a[4*i+j] = s[i]
+		}
+	}
+}
+
+// ShakeX4 is a state of four SHAKE instances. All instances absorb and
+// squeeze the same number of bytes.
+type ShakeX4 struct {
+	// Interleaved permutation states
+	a [100]uint64
+	// Rate in bytes
+	rate int
+	// Data buffers of the instances. While absorbing, holds input which
+	// doesn't form a full block. While squeezing, holds output.
+	buf [4][maxRate]byte
+	// Index in the buffers, meaning is the same as in the state
+	idx        int
+	isSquezing bool
+}
+
+// NewShake128X4 creates four SHAKE128 instances computed in parallel.
+func NewShake128X4() *ShakeX4 {
+	return &ShakeX4{rate: Sha3Desc[SHAKE128].r}
+}
+
+// NewShake256X4 creates four SHAKE256 instances computed in parallel.
+func NewShake256X4() *ShakeX4 {
+	return &ShakeX4{rate: Sha3Desc[SHAKE256].r}
+}
+
+// absorb XORs one block of each input into the state and applies the
+// permutation.
+func (s *ShakeX4) absorb(in0, in1, in2, in3 []byte) {
+	for i := 0; i < s.rate/8; i++ {
+		s.a[4*i+0] ^= binary.LittleEndian.Uint64(in0[8*i:])
+		s.a[4*i+1] ^= binary.LittleEndian.Uint64(in1[8*i:])
+		s.a[4*i+2] ^= binary.LittleEndian.Uint64(in2[8*i:])
+		s.a[4*i+3] ^= binary.LittleEndian.Uint64(in3[8*i:])
+	}
+	keccakF1600x4(&s.a)
+}
+
+// squeeze copies one block of the output of each instance to the buffers.
+func (s *ShakeX4) squeeze() {
+	for i := 0; i < s.rate/8; i++ {
+		for j := 0; j < 4; j++ {
+			binary.LittleEndian.PutUint64(s.buf[j][8*i:], s.a[4*i+j])
+		}
+	}
+	s.idx = s.rate
+}
+
+// Write absorbs in0, in1, in2 and in3 into corresponding instances. All
+// inputs must have the same length. It returns an error if called
+// after Read.
+func (s *ShakeX4) Write(in0, in1, in2, in3 []byte) (int, error) {
+	if s.isSquezing {
+		return 0, ErrWriteAfterRead
+	}
+	n := len(in0)
+	if len(in1) != n || len(in2) != n || len(in3) != n {
+		panic("sha3: inputs of ShakeX4 must have the same length")
+	}
+
+	for len(in0) > 0 {
+		// Absorb directly from the input if nothing is buffered
+		if s.idx == 0 && len(in0) >= s.rate {
+			s.absorb(in0, in1, in2, in3)
+			in0, in1, in2, in3 = in0[s.rate:], in1[s.rate:], in2[s.rate:], in3[s.rate:]
+			continue
+		}
+
+		l := min(s.rate-s.idx, len(in0))
+		copy(s.buf[0][s.idx:], in0[:l])
+		copy(s.buf[1][s.idx:], in1[:l])
+		copy(s.buf[2][s.idx:], in2[:l])
+		copy(s.buf[3][s.idx:], in3[:l])
+		in0, in1, in2, in3 = in0[l:], in1[l:], in2[l:], in3[l:]
+		s.idx += l
+		if s.idx == s.rate {
+			s.absorb(s.buf[0][:], s.buf[1][:], s.buf[2][:], s.buf[3][:])
+			s.idx = 0
+		}
+	}
+	return n, nil
+}
+
+// Read finalizes the instances if called first time and squeezes out
+// len(out0) bytes of each of them. All outputs must have the same length.
+func (s *ShakeX4) Read(out0, out1, out2, out3 []byte) (int, error) {
+	n := len(out0)
+	if len(out1) != n || len(out2) != n || len(out3) != n {
+		panic("sha3: outputs of ShakeX4 must have the same length")
+	}
+
+	if !s.isSquezing {
+		for j := range s.buf {
+			b := s.buf[j][:s.rate]
+			for i := s.idx; i < len(b); i++ {
+				b[i] = 0
+			}
+			b[s.idx] = sfxShake
+			b[len(b)-1] |= 0x80
+		}
+		s.absorb(s.buf[0][:], s.buf[1][:], s.buf[2][:], s.buf[3][:])
+		s.squeeze()
+		s.isSquezing = true
+	}
+
+	for len(out0) > 0 {
+		if s.idx == 0 {
+			keccakF1600x4(&s.a)
+			s.squeeze()
+		}
+		off := s.rate - s.idx
+		l := copy(out0, s.buf[0][off:s.rate])
+		copy(out1, s.buf[1][off:off+l])
+		copy(out2, s.buf[2][off:off+l])
+		copy(out3, s.buf[3][off:off+l])
+		out0, out1, out2, out3 = out0[l:], out1[l:], out2[l:], out3[l:]
+		s.idx -= l
+	}
+	return n, nil
+}
+
+// Reset resets the instances to their initial state.
+func (s *ShakeX4) Reset() {
+	for i := range s.a {
+		s.a[i] = 0
+	}
+	for j := range s.buf {
+		for i := range s.buf[j] {
+			s.buf[j][i] = 0
+		}
+	}
+	s.idx = 0
+	s.isSquezing = false
+}
+
+// Clone returns a copy of the instances in their current state.
+func (s *ShakeX4) Clone() *ShakeX4 {
+	dup := *s
+	return &dup
+}
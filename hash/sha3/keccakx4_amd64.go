@@ -0,0 +1,16 @@
+// +build amd64,!appengine,!gccgo,!noasm
+
+package sha3
+
+import "github.com/henrydcase/nobs/utils"
+
+// This function is implemented in keccakx4_amd64.s.
+
+//go:noescape
+func keccakF1600x4AVX2(state *[100]uint64)
+
+func init() {
+	if utils.X86.HasAVX2 {
+		keccakF1600x4 = keccakF1600x4AVX2
+	}
+}
@@ -0,0 +1,271 @@
+// +build amd64,!appengine,!gccgo,!noasm
+
+// Keccak-f[1600] applied to four independent states at once. Lane i of
+// the state j is stored at index 4*i+j, so that each 256-bit load gets
+// the same lane of all four states.
+
+#include "textflag.h"
+
+DATA ·roundConstsX4<>+0(SB)/8, $0x0000000000000001
+DATA ·roundConstsX4<>+8(SB)/8, $0x0000000000008082
+DATA ·roundConstsX4<>+16(SB)/8, $0x800000000000808A
+DATA ·roundConstsX4<>+24(SB)/8, $0x8000000080008000
+DATA ·roundConstsX4<>+32(SB)/8, $0x000000000000808B
+DATA ·roundConstsX4<>+40(SB)/8, $0x0000000080000001
+DATA ·roundConstsX4<>+48(SB)/8, $0x8000000080008081
+DATA ·roundConstsX4<>+56(SB)/8, $0x8000000000008009
+DATA ·roundConstsX4<>+64(SB)/8, $0x000000000000008A
+DATA ·roundConstsX4<>+72(SB)/8, $0x0000000000000088
+DATA ·roundConstsX4<>+80(SB)/8, $0x0000000080008009
+DATA ·roundConstsX4<>+88(SB)/8, $0x000000008000000A
+DATA ·roundConstsX4<>+96(SB)/8, $0x000000008000808B
+DATA ·roundConstsX4<>+104(SB)/8, $0x800000000000008B
+DATA ·roundConstsX4<>+112(SB)/8, $0x8000000000008089
+DATA ·roundConstsX4<>+120(SB)/8, $0x8000000000008003
+DATA ·roundConstsX4<>+128(SB)/8, $0x8000000000008002
+DATA ·roundConstsX4<>+136(SB)/8, $0x8000000000000080
+DATA ·roundConstsX4<>+144(SB)/8, $0x000000000000800A
+DATA ·roundConstsX4<>+152(SB)/8, $0x800000008000000A
+DATA ·roundConstsX4<>+160(SB)/8, $0x8000000080008081
+DATA ·roundConstsX4<>+168(SB)/8, $0x8000000000008080
+DATA ·roundConstsX4<>+176(SB)/8, $0x0000000080000001
+DATA ·roundConstsX4<>+184(SB)/8, $0x8000000080008008
+GLOBL ·roundConstsX4<>(SB), RODATA|NOPTR, $192
+
+// Rotates each 64-bit word of 'src' left by 'n' bits into 'dst', 't' is
+// a temporary register
+#define ROL(n, src, dst, t) \
+	VPSLLQ $(n), src, t; \
+	VPSRLQ $(64-(n)), src, dst; \
+	VPOR t, dst, dst
+
+// func keccakF1600x4AVX2(state *[100]uint64)
+TEXT ·keccakF1600x4AVX2(SB), 0, $800-8
+	MOVQ state+0(FP), DI
+	LEAQ ·roundConstsX4<>(SB), SI
+	MOVQ $24, CX
+
+loop:
+	// Theta: C[x] = A[x,0]^..^A[x,4]
+	VMOVDQU 0(DI), Y0
+	VPXOR 160(DI), Y0, Y0
+	VPXOR 320(DI), Y0, Y0
+	VPXOR 480(DI), Y0, Y0
+	VPXOR 640(DI), Y0, Y0
+	VMOVDQU 32(DI), Y1
+	VPXOR 192(DI), Y1, Y1
+	VPXOR 352(DI), Y1, Y1
+	VPXOR 512(DI), Y1, Y1
+	VPXOR 672(DI), Y1, Y1
+	VMOVDQU 64(DI), Y2
+	VPXOR 224(DI), Y2, Y2
+	VPXOR 384(DI), Y2, Y2
+	VPXOR 544(DI), Y2, Y2
+	VPXOR 704(DI), Y2, Y2
+	VMOVDQU 96(DI), Y3
+	VPXOR 256(DI), Y3, Y3
+	VPXOR 416(DI), Y3, Y3
+	VPXOR 576(DI), Y3, Y3
+	VPXOR 736(DI), Y3, Y3
+	VMOVDQU 128(DI), Y4
+	VPXOR 288(DI), Y4, Y4
+	VPXOR 448(DI), Y4, Y4
+	VPXOR 608(DI), Y4, Y4
+	VPXOR 768(DI), Y4, Y4
+	// D[x] = C[x-1] ^ rol(C[x+1], 1)
+	ROL(1, Y1, Y5, Y10)
+	VPXOR Y4, Y5, Y5
+	ROL(1, Y2, Y6, Y10)
+	VPXOR Y0, Y6, Y6
+	ROL(1, Y3, Y7, Y10)
+	VPXOR Y1, Y7, Y7
+	ROL(1, Y4, Y8, Y10)
+	VPXOR Y2, Y8, Y8
+	ROL(1, Y0, Y9, Y10)
+	VPXOR Y3, Y9, Y9
+	// Rho and Pi: B[y, 2x+3y] = rol(A[x,y]^D[x], r[x,y])
+	VPXOR 0(DI), Y5, Y11
+	VMOVDQU Y11, 0(SP)
+	VPXOR 160(DI), Y5, Y11
+	ROL(36, Y11, Y11, Y10)
+	VMOVDQU Y11, 512(SP)
+	VPXOR 320(DI), Y5, Y11
+	ROL(3, Y11, Y11, Y10)
+	VMOVDQU Y11, 224(SP)
+	VPXOR 480(DI), Y5, Y11
+	ROL(41, Y11, Y11, Y10)
+	VMOVDQU Y11, 736(SP)
+	VPXOR 640(DI), Y5, Y11
+	ROL(18, Y11, Y11, Y10)
+	VMOVDQU Y11, 448(SP)
+	VPXOR 32(DI), Y6, Y11
+	ROL(1, Y11, Y11, Y10)
+	VMOVDQU Y11, 320(SP)
+	VPXOR 192(DI), Y6, Y11
+	ROL(44, Y11, Y11, Y10)
+	VMOVDQU Y11, 32(SP)
+	VPXOR 352(DI), Y6, Y11
+	ROL(10, Y11, Y11, Y10)
+	VMOVDQU Y11, 544(SP)
+	VPXOR 512(DI), Y6, Y11
+	ROL(45, Y11, Y11, Y10)
+	VMOVDQU Y11, 256(SP)
+	VPXOR 672(DI), Y6, Y11
+	ROL(2, Y11, Y11, Y10)
+	VMOVDQU Y11, 768(SP)
+	VPXOR 64(DI), Y7, Y11
+	ROL(62, Y11, Y11, Y10)
+	VMOVDQU Y11, 640(SP)
+	VPXOR 224(DI), Y7, Y11
+	ROL(6, Y11, Y11, Y10)
+	VMOVDQU Y11, 352(SP)
+	VPXOR 384(DI), Y7, Y11
+	ROL(43, Y11, Y11, Y10)
+	VMOVDQU Y11, 64(SP)
+	VPXOR 544(DI), Y7, Y11
+	ROL(15, Y11, Y11, Y10)
+	VMOVDQU Y11, 576(SP)
+	VPXOR 704(DI), Y7, Y11
+	ROL(61, Y11, Y11, Y10)
+	VMOVDQU Y11, 288(SP)
+	VPXOR 96(DI), Y8, Y11
+	ROL(28, Y11, Y11, Y10)
+	VMOVDQU Y11, 160(SP)
+	VPXOR 256(DI), Y8, Y11
+	ROL(55, Y11, Y11, Y10)
+	VMOVDQU Y11, 672(SP)
+	VPXOR 416(DI), Y8, Y11
+	ROL(25, Y11, Y11, Y10)
+	VMOVDQU Y11, 384(SP)
+	VPXOR 576(DI), Y8, Y11
+	ROL(21, Y11, Y11, Y10)
+	VMOVDQU Y11, 96(SP)
+	VPXOR 736(DI), Y8, Y11
+	ROL(56, Y11, Y11, Y10)
+	VMOVDQU Y11, 608(SP)
+	VPXOR 128(DI), Y9, Y11
+	ROL(27, Y11, Y11, Y10)
+	VMOVDQU Y11, 480(SP)
+	VPXOR 288(DI), Y9, Y11
+	ROL(20, Y11, Y11, Y10)
+	VMOVDQU Y11, 192(SP)
+	VPXOR 448(DI), Y9, Y11
+	ROL(39, Y11, Y11, Y10)
+	VMOVDQU Y11, 704(SP)
+	VPXOR 608(DI), Y9, Y11
+	ROL(8, Y11, Y11, Y10)
+	VMOVDQU Y11, 416(SP)
+	VPXOR 768(DI), Y9, Y11
+	ROL(14, Y11, Y11, Y10)
+	VMOVDQU Y11, 128(SP)
+	// Chi: A[x,y] = B[x,y] ^ (~B[x+1,y] & B[x+2,y])
+	VMOVDQU 0(SP), Y0
+	VMOVDQU 32(SP), Y1
+	VMOVDQU 64(SP), Y2
+	VMOVDQU 96(SP), Y3
+	VMOVDQU 128(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	// Iota
+	VPBROADCASTQ (SI), Y6
+	VPXOR Y6, Y5, Y5
+	VMOVDQU Y5, 0(DI)
+	VPANDN Y3, Y2, Y5
+	VPXOR Y1, Y5, Y5
+	VMOVDQU Y5, 32(DI)
+	VPANDN Y4, Y3, Y5
+	VPXOR Y2, Y5, Y5
+	VMOVDQU Y5, 64(DI)
+	VPANDN Y0, Y4, Y5
+	VPXOR Y3, Y5, Y5
+	VMOVDQU Y5, 96(DI)
+	VPANDN Y1, Y0, Y5
+	VPXOR Y4, Y5, Y5
+	VMOVDQU Y5, 128(DI)
+	VMOVDQU 160(SP), Y0
+	VMOVDQU 192(SP), Y1
+	VMOVDQU 224(SP), Y2
+	VMOVDQU 256(SP), Y3
+	VMOVDQU 288(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VMOVDQU Y5, 160(DI)
+	VPANDN Y3, Y2, Y5
+	VPXOR Y1, Y5, Y5
+	VMOVDQU Y5, 192(DI)
+	VPANDN Y4, Y3, Y5
+	VPXOR Y2, Y5, Y5
+	VMOVDQU Y5, 224(DI)
+	VPANDN Y0, Y4, Y5
+	VPXOR Y3, Y5, Y5
+	VMOVDQU Y5, 256(DI)
+	VPANDN Y1, Y0, Y5
+	VPXOR Y4, Y5, Y5
+	VMOVDQU Y5, 288(DI)
+	VMOVDQU 320(SP), Y0
+	VMOVDQU 352(SP), Y1
+	VMOVDQU 384(SP), Y2
+	VMOVDQU 416(SP), Y3
+	VMOVDQU 448(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VMOVDQU Y5, 320(DI)
+	VPANDN Y3, Y2, Y5
+	VPXOR Y1, Y5, Y5
+	VMOVDQU Y5, 352(DI)
+	VPANDN Y4, Y3, Y5
+	VPXOR Y2, Y5, Y5
+	VMOVDQU Y5, 384(DI)
+	VPANDN Y0, Y4, Y5
+	VPXOR Y3, Y5, Y5
+	VMOVDQU Y5, 416(DI)
+	VPANDN Y1, Y0, Y5
+	VPXOR Y4, Y5, Y5
+	VMOVDQU Y5, 448(DI)
+	VMOVDQU 480(SP), Y0
+	VMOVDQU 512(SP), Y1
+	VMOVDQU 544(SP), Y2
+	VMOVDQU 576(SP), Y3
+	VMOVDQU 608(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VMOVDQU Y5, 480(DI)
+	VPANDN Y3, Y2, Y5
+	VPXOR Y1, Y5, Y5
+	VMOVDQU Y5, 512(DI)
+	VPANDN Y4, Y3, Y5
+	VPXOR Y2, Y5, Y5
+	VMOVDQU Y5, 544(DI)
+	VPANDN Y0, Y4, Y5
+	VPXOR Y3, Y5, Y5
+	VMOVDQU Y5, 576(DI)
+	VPANDN Y1, Y0, Y5
+	VPXOR Y4, Y5, Y5
+	VMOVDQU Y5, 608(DI)
+	VMOVDQU 640(SP), Y0
+	VMOVDQU 672(SP), Y1
+	VMOVDQU 704(SP), Y2
+	VMOVDQU 736(SP), Y3
+	VMOVDQU 768(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VMOVDQU Y5, 640(DI)
+	VPANDN Y3, Y2, Y5
+	VPXOR Y1, Y5, Y5
+	VMOVDQU Y5, 672(DI)
+	VPANDN Y4, Y3, Y5
+	VPXOR Y2, Y5, Y5
+	VMOVDQU Y5, 704(DI)
+	VPANDN Y0, Y4, Y5
+	VPXOR Y3, Y5, Y5
+	VMOVDQU Y5, 736(DI)
+	VPANDN Y1, Y0, Y5
+	VPXOR Y4, Y5, Y5
+	VMOVDQU Y5, 768(DI)
+
+	ADDQ $8, SI
+	DECQ CX
+	JNZ  loop
+
+	VZEROUPPER
+	RET
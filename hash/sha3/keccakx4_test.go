@@ -0,0 +1,133 @@
+package sha3
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeccakF1600x4(t *testing.T) {
+	var a, b [100]uint64
+	for i := range a {
+		a[i] = uint64(i+1) * 0x9E3779B97F4A7C15
+	}
+	b = a
+
+	keccakF1600x4(&a)
+	// Each state is permuted separately
+	var s [25]uint64
+	for j := 0; j < 4; j++ {
+		for i := range s {
+			s[i] = b[4*i+j]
+		}
+		keccakF1600(&s)
+		for i := range s {
+			if s[i] != a[4*i+j] {
+				t.Fatalf("state %d: lane %d differs", j, i)
+			}
+		}
+	}
+
+	b = a
+	for i := 0; i < 10; i++ {
+		keccakF1600x4(&a)
+		keccakF1600x4Generic(&b)
+		if a != b {
+			t.Fatalf("#%d: permutations differ", i)
+		}
+	}
+}
+
+func TestShakeX4(t *testing.T) {
+	for _, v := range []struct {
+		x4  func() *ShakeX4
+		one func() ShakeHash
+	}{
+		{NewShake128X4, NewShake128},
+		{NewShake256X4, NewShake256},
+	} {
+		// Lengths below, equal to and above the rate
+		for _, n := range []int{0, 1, 135, 136, 137, 167, 168, 169, 500, 1000} {
+			var in [4][]byte
+			for j := range in {
+				in[j] = make([]byte, n)
+				for i := range in[j] {
+					in[j][i] = byte(i*7 + j*31)
+				}
+			}
+
+			var out [4][]byte
+			for j := range out {
+				out[j] = make([]byte, 2*n+50)
+			}
+			h := v.x4()
+			c := h.Clone()
+			h.Write(in[0], in[1], in[2], in[3])
+			h.Read(out[0], out[1], out[2], out[3])
+
+			// Absorb and squeeze in uneven chunks
+			var outc [4][]byte
+			for j := range outc {
+				outc[j] = make([]byte, len(out[j]))
+			}
+			for off, l := 0, 1; off < n; l = 2*l + 3 {
+				if off+l > n {
+					l = n - off
+				}
+				c.Write(in[0][off:off+l], in[1][off:off+l], in[2][off:off+l], in[3][off:off+l])
+				off += l
+			}
+			for off, l := 0, 1; off < len(outc[0]); l = 3*l + 1 {
+				if off+l > len(outc[0]) {
+					l = len(outc[0]) - off
+				}
+				c.Read(outc[0][off:off+l], outc[1][off:off+l], outc[2][off:off+l], outc[3][off:off+l])
+				off += l
+			}
+
+			for j := range in {
+				exp := make([]byte, len(out[j]))
+				s := v.one()
+				s.Write(in[j])
+				s.Read(exp)
+				if !bytes.Equal(out[j], exp) {
+					t.Errorf("len=%d, instance %d: got %X, want %X", n, j, out[j], exp)
+				}
+				if !bytes.Equal(outc[j], exp) {
+					t.Errorf("len=%d, instance %d: chunked: got %X, want %X", n, j, outc[j], exp)
+				}
+			}
+
+			if _, err := c.Write(nil, nil, nil, nil); err != ErrWriteAfterRead {
+				t.Errorf("expected ErrWriteAfterRead, got %v", err)
+			}
+			c.Reset()
+			c.Write(in[0], in[1], in[2], in[3])
+			c.Read(outc[0], outc[1], outc[2], outc[3])
+			for j := range out {
+				if !bytes.Equal(outc[j], out[j]) {
+					t.Errorf("len=%d, instance %d: wrong output after Reset", n, j)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkPermutationFunctionX4(b *testing.B) {
+	var a [100]uint64
+	b.SetBytes(int64(len(a) * 8))
+	for i := 0; i < b.N; i++ {
+		keccakF1600x4(&a)
+	}
+}
+
+func BenchmarkShake128X4(b *testing.B) {
+	buf := make([]byte, 1024)
+	out := make([]byte, 1024)
+	b.SetBytes(int64(4 * (len(buf) + len(out))))
+	h := NewShake128X4()
+	for i := 0; i < b.N; i++ {
+		h.Reset()
+		h.Write(buf, buf, buf, buf)
+		h.Read(out, out, out, out)
+	}
+}
@@ -0,0 +1,35 @@
+package sha3
+
+// KMAC is a keyed hash function based on cSHAKE, described in
+// NIST-SP-800-185, section 4.
+import (
+	"hash"
+)
+
+var kmacName = []byte("KMAC")
+
+// NewKMAC128 returns KMAC128 keyed with 'key', which returns 'size' bytes
+// of output. S is an optional customization string. The key should be
+// at least 16 bytes long.
+func NewKMAC128(key []byte, size int, S []byte) hash.Hash {
+	return newCShakeFn(kmacName, S, encodeString(key), size, false, SHAKE128)
+}
+
+// NewKMAC256 returns KMAC256 keyed with 'key', which returns 'size' bytes
+// of output. S is an optional customization string. The key should be
+// at least 32 bytes long.
+func NewKMAC256(key []byte, size int, S []byte) hash.Hash {
+	return newCShakeFn(kmacName, S, encodeString(key), size, false, SHAKE256)
+}
+
+// NewKMACXOF128 returns KMACXOF128, a variant of KMAC128 with arbitrary
+// output length. Sum returns 'size' bytes of the output.
+func NewKMACXOF128(key []byte, size int, S []byte) XOF {
+	return newCShakeFn(kmacName, S, encodeString(key), size, true, SHAKE128)
+}
+
+// NewKMACXOF256 returns KMACXOF256, a variant of KMAC256 with arbitrary
+// output length. Sum returns 'size' bytes of the output.
+func NewKMACXOF256(key []byte, size int, S []byte) XOF {
+	return newCShakeFn(kmacName, S, encodeString(key), size, true, SHAKE256)
+}
@@ -0,0 +1,144 @@
+package sha3
+
+import (
+	"bytes"
+	"hash"
+	"testing"
+)
+
+// Samples from NIST, "KMAC_samples.pdf" and "KMACXOF_samples.pdf".
+var kmacTests = []struct {
+	// Security strength: 128 or 256
+	bits    int
+	xof     bool
+	dataLen int
+	S       string
+	out     string
+}{
+	{128, false, 4, "", "E5780B0D3EA6F7D3A429C5706AA43A00FADBD7D49628839E3187243F456EE14E"},
+	{128, false, 4, "My Tagged Application", "3B1FBA963CD8B0B59E8C1A6D71888B7143651AF8BA0A7070C0979E2811324AA5"},
+	{128, false, 200, "My Tagged Application", "1F5B4E6CCA02209E0DCB5CA635B89A15E271ECC760071DFD805FAA38F9729230"},
+	{256, false, 4, "My Tagged Application", "20C570C31346F703C9AC36C61C03CB64C3970D0CFC787E9B79599D273A68D2F7" +
+		"F69D4CC3DE9D104A351689F27CF6F5951F0103F33F4F24871024D9C27773A8DD"},
+	{256, false, 200, "", "75358CF39E41494E949707927CEE0AF20A3FF553904C86B08F21CC414BCFD691" +
+		"589D27CF5E15369CBBFF8B9A4C2EB17800855D0235FF635DA82533EC6B759B69"},
+	{256, false, 200, "My Tagged Application", "B58618F71F92E1D56C1B8C55DDD7CD188B97B4CA4D99831EB2699A837DA2E4D9" +
+		"70FBACFDE50033AEA585F1A2708510C32D07880801BD182898FE476876FC8965"},
+	{128, true, 4, "", "CD83740BBD92CCC8CF032B1481A0F4460E7CA9DD12B08A0C4031178BACD6EC35"},
+	{128, true, 4, "My Tagged Application", "31A44527B4ED9F5C6101D11DE6D26F0620AA5C341DEF41299657FE9DF1A3B16C"},
+	{128, true, 200, "My Tagged Application", "47026C7CD793084AA0283C253EF658490C0DB61438B8326FE9BDDF281B83AE0F"},
+	{256, true, 4, "My Tagged Application", "1755133F1534752AAD0748F2C706FB5C784512CAB835CD15676B16C0C6647FA9" +
+		"6FAA7AF634A0BF8FF6DF39374FA00FAD9A39E322A7C92065A64EB1FB0801EB2B"},
+	{256, true, 200, "", "FF7B171F1E8A2B24683EED37830EE797538BA8DC563F6DA1E667391A75EDC02C" +
+		"A633079F81CE12A25F45615EC89972031D18337331D24CEB8F8CA8E6A19FD98B"},
+	{256, true, 200, "My Tagged Application", "D5BE731C954ED7732846BB59DBE3A8E30F83E77A4BFF4459F2F1C2B4ECEBB8CE" +
+		"67BA01C62E8AB8578D2D499BD1BB276768781190020A306A97DE281DCC30305D"},
+}
+
+func newTestKMAC(bits int, xof bool, key []byte, size int, S []byte) hash.Hash {
+	switch {
+	case bits == 128 && !xof:
+		return NewKMAC128(key, size, S)
+	case bits == 256 && !xof:
+		return NewKMAC256(key, size, S)
+	case bits == 128:
+		return NewKMACXOF128(key, size, S)
+	default:
+		return NewKMACXOF256(key, size, S)
+	}
+}
+
+func TestKMAC(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(0x40 + i)
+	}
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	for i, v := range kmacTests {
+		exp := decodeHex(v.out)
+		h := newTestKMAC(v.bits, v.xof, key, len(exp), []byte(v.S))
+		if h.Size() != len(exp) {
+			t.Errorf("#%d: wrong size %d", i, h.Size())
+		}
+
+		// Sum doesn't change the state
+		h.Write(data[:v.dataLen/2])
+		h.Sum(nil)
+		h.Write(data[v.dataLen/2 : v.dataLen])
+		if out := h.Sum([]byte{0xAA}); !bytes.Equal(out[1:], exp) || out[0] != 0xAA {
+			t.Errorf("#%d: got %X, want %X", i, out[1:], exp)
+		}
+
+		h.Reset()
+		h.Write(data[:v.dataLen])
+		out := make([]byte, len(exp))
+		h.(ShakeHash).Read(out)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: Read: got %X, want %X", i, out, exp)
+		}
+	}
+}
+
+func TestKMACXOF(t *testing.T) {
+	key := []byte("secret key with at least 32 bytes")
+	h := NewKMACXOF256(key, 32, nil)
+	h.Write([]byte(testString))
+	c := h.(ShakeHash).Clone()
+
+	// Streaming output equals output read at once
+	exp := make([]byte, 1000)
+	c.Read(exp)
+	out := make([]byte, 1000)
+	for i := 0; i < len(out); i += 100 {
+		h.Read(out[i : i+100])
+	}
+	if !bytes.Equal(out, exp) {
+		t.Error("streaming output differs")
+	}
+	if _, err := h.Write([]byte{1}); err != ErrWriteAfterRead {
+		t.Error("write after read must fail")
+	}
+
+	// Output length is a parameter of KMAC, but not KMACXOF
+	a, b := NewKMAC128(key, 32, nil), NewKMAC128(key, 64, nil)
+	if bytes.Equal(a.Sum(nil), b.Sum(nil)[:32]) {
+		t.Error("KMAC output doesn't depend on length")
+	}
+	x, y := NewKMACXOF128(key, 32, nil), NewKMACXOF128(key, 64, nil)
+	if !bytes.Equal(x.Sum(nil), y.Sum(nil)[:32]) {
+		t.Error("KMACXOF output depends on length")
+	}
+}
+
+func TestRightEncode(t *testing.T) {
+	for _, v := range []struct {
+		in  uint64
+		out []byte
+	}{
+		{0, []byte{0, 1}},
+		{1, []byte{1, 1}},
+		{256, []byte{1, 0, 2}},
+		{1<<64 - 1, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 8}},
+	} {
+		if got := rightEncode(v.in); !bytes.Equal(got, v.out) {
+			t.Errorf("rightEncode(%d) = %X, want %X", v.in, got, v.out)
+		}
+	}
+}
+
+func BenchmarkKMAC(b *testing.B) {
+	key := make([]byte, 32)
+	data := make([]byte, 1024)
+	h := NewKMAC256(key, 32, nil)
+	out := make([]byte, 0, 32)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		h.Reset()
+		h.Write(data)
+		h.Sum(out)
+	}
+}
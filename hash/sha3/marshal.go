@@ -0,0 +1,138 @@
+package sha3
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Serialized state starts with a magic string, which identifies the type
+// of the object, followed by version of the format.
+const (
+	magicSha3   = "sha3\x01"
+	magicCShake = "cshk\x01"
+	magicLen    = 5
+	// Length of the serialized state: magic, rate, output size, domain
+	// byte, number of rounds, flags, buffer index, permutation state and
+	// the buffer. Buffer is stored only up to rate bytes.
+	marshaledSize = magicLen + 6 + 25*8
+)
+
+var (
+	errInvalidStateId   = errors.New("sha3: invalid hash state identifier")
+	errInvalidStateSize = errors.New("sha3: invalid hash state size")
+	errInvalidState     = errors.New("sha3: invalid hash state")
+)
+
+// marshal appends serialized sponge to b.
+func (c *state) marshal(b []byte) []byte {
+	var flags byte
+	if c.isSquezing {
+		flags = 1
+	}
+	var a [25 * 8]byte
+	for i := range c.a {
+		binary.LittleEndian.PutUint64(a[8*i:], c.a[i])
+	}
+	b = append(b, byte(c.desc.r), byte(c.desc.d), c.sfx, byte(c.rounds), flags, byte(c.idx))
+	b = append(b, a[:]...)
+	return append(b, c.data.asBytes()[:c.desc.r]...)
+}
+
+// unmarshal restores sponge from b. The sponge parameters stored in b must
+// match the parameters of c. It returns remaining bytes of b.
+func (c *state) unmarshal(b []byte) ([]byte, error) {
+	if len(b) < marshaledSize-magicLen {
+		return nil, errInvalidStateSize
+	}
+	if int(b[0]) != c.desc.r || int(b[1]) != c.desc.d ||
+		b[2] != c.sfx || int(b[3]) != c.rounds {
+		return nil, errInvalidStateId
+	}
+	if b[4] > 1 {
+		return nil, errInvalidState
+	}
+	squeezing := b[4] == 1
+	idx := int(b[5])
+	// When absorbing, buffer can't be full
+	if (!squeezing && idx >= c.desc.r) || idx > c.desc.r {
+		return nil, errInvalidState
+	}
+	b = b[6:]
+	if len(b) < 25*8+c.desc.r {
+		return nil, errInvalidStateSize
+	}
+
+	for i := range c.a {
+		c.a[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	b = b[25*8:]
+	buf := c.data.asBytes()
+	copy(buf[:], b[:c.desc.r])
+	for i := c.desc.r; i < len(buf); i++ {
+		buf[i] = 0
+	}
+	c.isSquezing = squeezing
+	c.idx = idx
+	return b[c.desc.r:], nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (c *state) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, marshaledSize+c.desc.r)
+	b = append(b, magicSha3...)
+	return c.marshal(b), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The state must
+// be serialized by the same function as c.
+func (c *state) UnmarshalBinary(b []byte) error {
+	if len(b) < magicLen || string(b[:magicLen]) != magicSha3 {
+		return errInvalidStateId
+	}
+	b, err := c.unmarshal(b[magicLen:])
+	if err != nil {
+		return err
+	}
+	if len(b) != 0 {
+		return errInvalidStateSize
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. Serialized state
+// includes the function name and customization string.
+func (c *cshakeState) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, marshaledSize+c.desc.r+4+len(c.initBlock))
+	b = append(b, magicCShake...)
+	b = c.marshal(b)
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(c.initBlock)))
+	b = append(b, l[:]...)
+	return append(b, c.initBlock...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The state must
+// be serialized by cSHAKE with the same security strength as c. The
+// function name and customization string are restored from b.
+func (c *cshakeState) UnmarshalBinary(b []byte) error {
+	if len(b) < magicLen || string(b[:magicLen]) != magicCShake {
+		return errInvalidStateId
+	}
+	// Don't modify c in case of error
+	s := c.state
+	b, err := s.unmarshal(b[magicLen:])
+	if err != nil {
+		return err
+	}
+	if len(b) < 4 {
+		return errInvalidStateSize
+	}
+	l := binary.BigEndian.Uint32(b)
+	b = b[4:]
+	if uint64(len(b)) != uint64(l) || l%uint32(c.desc.r) != 0 {
+		return errInvalidStateSize
+	}
+	c.state = s
+	c.initBlock = append([]byte(nil), b...)
+	return nil
+}
@@ -0,0 +1,151 @@
+package sha3
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+)
+
+var marshalTests = []struct {
+	name string
+	fn   func() ShakeHash
+}{
+	{"SHA3-224", func() ShakeHash { return New224().(ShakeHash) }},
+	{"SHA3-256", func() ShakeHash { return New256().(ShakeHash) }},
+	{"SHA3-384", func() ShakeHash { return New384().(ShakeHash) }},
+	{"SHA3-512", func() ShakeHash { return New512().(ShakeHash) }},
+	{"Keccak-256", func() ShakeHash { return NewLegacyKeccak256().(ShakeHash) }},
+	{"SHAKE128", NewShake128},
+	{"SHAKE256", NewShake256},
+	{"cSHAKE128", func() ShakeHash { return NewCShake128([]byte("N"), []byte("Custom")) }},
+	{"cSHAKE256", func() ShakeHash { return NewCShake256(nil, []byte("Custom")) }},
+	{"TurboSHAKE128", func() ShakeHash { return NewTurboShake128(0x1F) }},
+}
+
+// resume serializes h and restores it into a fresh instance created by fn.
+func resume(t *testing.T, h ShakeHash, fn func() ShakeHash) ShakeHash {
+	b, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := fn()
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMarshalResume(t *testing.T) {
+	msg := generateData(1000)
+	for _, v := range marshalTests {
+		exp := make([]byte, 500)
+		h := v.fn()
+		h.Write(msg)
+		h.Read(exp)
+
+		for _, n := range []int{0, 1, 71, 72, 73, 135, 136, 137, 168, 169, 1000} {
+			h := v.fn()
+			h.Write(msg[:n])
+			r := resume(t, h, v.fn)
+			r.Write(msg[n:])
+			out := make([]byte, len(exp))
+			r.Read(out)
+			if !bytes.Equal(out, exp) {
+				t.Errorf("%s: resumed after absorbing %d bytes: got %X, want %X", v.name, n, out, exp)
+			}
+
+			// Resume while squeezing
+			h = v.fn()
+			h.Write(msg)
+			h.Read(out[:n%len(out)+1])
+			r = resume(t, h, v.fn)
+			r.Read(out[n%len(out)+1:])
+			if !bytes.Equal(out, exp) {
+				t.Errorf("%s: resumed after squeezing %d bytes: got %X, want %X", v.name, n%len(out)+1, out, exp)
+			}
+			if _, err := r.Write(msg); err != ErrWriteAfterRead {
+				t.Errorf("%s: expected ErrWriteAfterRead, got %v", v.name, err)
+			}
+		}
+
+		// Reset restores initial state of the resumed hash
+		r := resume(t, v.fn(), v.fn)
+		r.Write(msg[:10])
+		r = resume(t, r, v.fn)
+		r.Reset()
+		r.Write(msg)
+		out := make([]byte, len(exp))
+		r.Read(out)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("%s: wrong output after Reset: got %X, want %X", v.name, out, exp)
+		}
+	}
+}
+
+func TestMarshalCShakeCustomization(t *testing.T) {
+	msg := generateData(100)
+	h := NewCShake128([]byte("N"), []byte("S1"))
+	h.Write(msg)
+	b, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	// Customization is restored from the serialized state
+	r := NewCShake128(nil, []byte("S2"))
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	r.Reset()
+	h.Reset()
+	h.Write(msg)
+	r.Write(msg)
+	exp, out := make([]byte, 32), make([]byte, 32)
+	h.Read(exp)
+	r.Read(out)
+	if !bytes.Equal(out, exp) {
+		t.Errorf("got %X, want %X", out, exp)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	state := func(h interface{}) []byte {
+		b, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	sha256 := state(New256())
+	cshake := state(NewCShake128(nil, []byte("S")))
+
+	for i, v := range []struct {
+		h   interface{}
+		b   []byte
+		err error
+	}{
+		{New256(), nil, errInvalidStateId},
+		{New256(), sha256[:3], errInvalidStateId},
+		{New256(), sha256[:magicLen+10], errInvalidStateSize},
+		{New256(), sha256[:len(sha256)-1], errInvalidStateSize},
+		{New256(), append(sha256, 0), errInvalidStateSize},
+		{New512(), sha256, errInvalidStateId},
+		{NewLegacyKeccak256(), sha256, errInvalidStateId},
+		{NewShake256(), sha256, errInvalidStateId},
+		{NewTurboShake128(0x1F), state(NewShake128()), errInvalidStateId},
+		{NewShake128(), cshake, errInvalidStateId},
+		{NewCShake128(nil, []byte("S")), state(NewShake128()), errInvalidStateId},
+		{NewCShake128(nil, []byte("S")), cshake[:len(cshake)-1], errInvalidStateSize},
+		{NewCShake256(nil, []byte("S")), cshake, errInvalidStateId},
+	} {
+		if err := v.h.(encoding.BinaryUnmarshaler).UnmarshalBinary(v.b); err != v.err {
+			t.Errorf("#%d: got %v, want %v", i, err, v.err)
+		}
+	}
+
+	// Flags and buffer index are checked
+	for _, off := range []int{magicLen + 4, magicLen + 5} {
+		b := append([]byte(nil), sha256...)
+		b[off] = 0xFF
+		if err := New256().(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != errInvalidState {
+			t.Errorf("offset %d: got %v, want %v", off, err, errInvalidState)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package sha3
+
+// ParallelHash is a hash function described in NIST-SP-800-185, section 6.
+// Input is split into blocks of B bytes, each block is hashed separately
+// and results are hashed with cSHAKE. Hashing of the blocks is done by
+// multiple goroutines for large inputs.
+import (
+	"runtime"
+	"sync"
+
+	"github.com/henrydcase/nobs/internal/block"
+)
+
+var parallelHashName = []byte("ParallelHash")
+
+const (
+	// Maximal amount of data buffered before its blocks are hashed
+	parallelBatch = 64 * 1024
+	// Minimal amount of data hashed by a single goroutine
+	parallelMinWork = 8 * 1024
+)
+
+// ParallelHash computes ParallelHash or ParallelHashXOF. It implements
+// hash.Hash.
+type ParallelHash struct {
+	f cshakeFn
+	// SHAKE used for blocks
+	shaId uint8
+	// Block size B in bytes
+	b int
+	// Data buffered until there is a batch of blocks
+	buf []byte
+	// Number of blocks processed so far
+	n uint64
+}
+
+func newParallelHash(blockSize, size int, S []byte, xof bool, shaId uint8) *ParallelHash {
+	if blockSize <= 0 {
+		panic("sha3: block size of ParallelHash must be positive")
+	}
+	p := &ParallelHash{
+		f:     *newCShakeFn(parallelHashName, S, nil, size, xof, shaId),
+		shaId: shaId,
+		b:     blockSize,
+	}
+	p.f.Write(leftEncode(uint64(blockSize)))
+	return p
+}
+
+// NewParallelHash128 returns ParallelHash128 with block size of 'blockSize'
+// bytes, which returns 'size' bytes of output. S is an optional
+// customization string.
+func NewParallelHash128(blockSize, size int, S []byte) *ParallelHash {
+	return newParallelHash(blockSize, size, S, false, SHAKE128)
+}
+
+// NewParallelHash256 returns ParallelHash256 with block size of 'blockSize'
+// bytes, which returns 'size' bytes of output. S is an optional
+// customization string.
+func NewParallelHash256(blockSize, size int, S []byte) *ParallelHash {
+	return newParallelHash(blockSize, size, S, false, SHAKE256)
+}
+
+// NewParallelHashXOF128 returns ParallelHashXOF128, a variant of
+// ParallelHash128 with arbitrary output length. Sum returns 'size' bytes
+// of the output.
+func NewParallelHashXOF128(blockSize, size int, S []byte) *ParallelHash {
+	return newParallelHash(blockSize, size, S, true, SHAKE128)
+}
+
+// NewParallelHashXOF256 returns ParallelHashXOF256, a variant of
+// ParallelHash256 with arbitrary output length. Sum returns 'size' bytes
+// of the output.
+func NewParallelHashXOF256(blockSize, size int, S []byte) *ParallelHash {
+	return newParallelHash(blockSize, size, S, true, SHAKE256)
+}
+
+// leafSize returns size of the block hash: 2*security strength.
+func (p *ParallelHash) leafSize() int {
+	if p.shaId == SHAKE128 {
+		return 32
+	}
+	return 64
+}
+
+// batchSize returns number of bytes processed at once, multiple of
+// block size.
+func (p *ParallelHash) batchSize() int {
+	if p.b >= parallelBatch {
+		return p.b
+	}
+	return parallelBatch - parallelBatch%p.b
+}
+
+// hashBlocks hashes blocks from 'in' and absorbs the results. Length of
+// 'in' must be a multiple of the block size, except the last block.
+func (p *ParallelHash) hashBlocks(in []byte) {
+	if len(in) == 0 {
+		return
+	}
+
+	ls := p.leafSize()
+	nb := (len(in) + p.b - 1) / p.b
+	leaves := make([]byte, nb*ls)
+
+	hashRange := func(from, to int) {
+		h := state{sfx: sfxShake, desc: Sha3Desc[p.shaId]}
+		for i := from; i < to; i++ {
+			end := (i + 1) * p.b
+			if end > len(in) {
+				end = len(in)
+			}
+			h.Reset()
+			h.Write(in[i*p.b : end])
+			h.Read(leaves[i*ls : (i+1)*ls])
+		}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if w := len(in) / parallelMinWork; w < workers {
+		workers = w
+	}
+	if workers > nb {
+		workers = nb
+	}
+
+	if workers <= 1 {
+		hashRange(0, nb)
+	} else {
+		var wg sync.WaitGroup
+		per := (nb + workers - 1) / workers
+		for from := 0; from < nb; from += per {
+			to := from + per
+			if to > nb {
+				to = nb
+			}
+			wg.Add(1)
+			go func(from, to int) {
+				defer wg.Done()
+				hashRange(from, to)
+			}(from, to)
+		}
+		wg.Wait()
+	}
+
+	p.f.Write(leaves)
+	p.n += uint64(nb)
+}
+
+// Write absorbs more data. It returns an error if called after Read.
+func (p *ParallelHash) Write(in []byte) (int, error) {
+	if p.f.isSquezing {
+		return 0, ErrWriteAfterRead
+	}
+
+	n := len(in)
+	batch := p.batchSize()
+	for len(in) > 0 {
+		// Avoid copying if nothing is buffered
+		if len(p.buf) == 0 && len(in) >= batch {
+			l := len(in) - len(in)%p.b
+			p.hashBlocks(in[:l])
+			in = in[l:]
+			continue
+		}
+
+		l := batch - len(p.buf)
+		if l > len(in) {
+			l = len(in)
+		}
+		p.buf = append(p.buf, in[:l]...)
+		in = in[l:]
+		if len(p.buf) == batch {
+			p.hashBlocks(p.buf)
+			p.buf = p.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+// Read finalizes the hash if called first time and squeezes out
+// len(out) bytes. For ParallelHash (not XOF variant), only first
+// Size() bytes are meaningful.
+func (p *ParallelHash) Read(out []byte) (int, error) {
+	if !p.f.isSquezing {
+		p.hashBlocks(p.buf)
+		p.buf = p.buf[:0]
+		p.f.Write(rightEncode(p.n))
+	}
+	return p.f.Read(out)
+}
+
+// Sum appends Size() bytes of the hash to b. It doesn't change the
+// underlying state.
+func (p *ParallelHash) Sum(b []byte) []byte {
+	ret, out := block.SliceForAppend(b, p.Size())
+	p.Clone().Read(out)
+	return ret
+}
+
+// Size returns the output size of Sum in bytes.
+func (p *ParallelHash) Size() int { return p.f.Size() }
+
+// BlockSize returns the block size B.
+func (p *ParallelHash) BlockSize() int { return p.b }
+
+// Reset resets the hash to its initial state.
+func (p *ParallelHash) Reset() {
+	p.f.Reset()
+	p.f.Write(leftEncode(uint64(p.b)))
+	p.buf = p.buf[:0]
+	p.n = 0
+}
+
+// Clone returns a copy of the hash in its current state.
+func (p *ParallelHash) Clone() *ParallelHash {
+	dup := *p
+	dup.buf = append([]byte(nil), p.buf...)
+	return &dup
+}
@@ -59,6 +59,10 @@ type state struct {
 	// Indicates state of the sponge function. Whether it is absorbing
 	// or squezing
 	isSquezing bool
+	// Number of rounds of the permutation. Zero means full Keccak-f[1600]
+	// with 24 rounds. Optimized implementation is used for 12 and 24
+	// rounds.
+	rounds int
 }
 
 func min(a, b int) int {
@@ -68,6 +72,18 @@ func min(a, b int) int {
 	return b
 }
 
+// permute applies the permutation to the sponge state.
+func (c *state) permute() {
+	switch c.rounds {
+	case 0, 24:
+		keccakF1600(&c.a)
+	case 12:
+		keccakP1600r12(&c.a)
+	default:
+		keccakP1600Generic(&c.a, c.rounds)
+	}
+}
+
 // BlockSize returns block size in bytes. Corresponds to the input
 // block size B of the HMAC
 func (d *state) BlockSize() int { return d.desc.r }
@@ -115,13 +131,13 @@ func (c *state) Write(in []byte) (nwrite int, err error) {
 	fbLen := rate - c.idx
 	copy(buf[c.idx:], in[:fbLen])
 	xorIn(c, buf[:])
-	keccakF1600(&c.a)
+	c.permute()
 
 	// process remaining blocks
 	in = in[fbLen:]
 	for len(in) >= rate {
 		xorIn(c, in[:rate])
-		keccakF1600(&c.a)
+		c.permute()
 		in = in[rate:]
 	}
 
@@ -144,7 +160,7 @@ func (c *state) finalize_sha3() {
 	buf[c.idx] = c.sfx
 	buf[rate-1] |= 0x80
 	xorIn(c, buf[:rate])
-	keccakF1600(&c.a)
+	c.permute()
 	copyOut(c, buf[:rate])
 	c.idx = rate // now, idx indicates unconsumed amount of data
 	c.isSquezing = true
@@ -177,13 +193,13 @@ func (c *state) Read(out []byte) (nread int, err error) {
 	// there is no more data in the buffer.
 	nblocks := len(out) / rate
 	for nblocks > 0 {
-		keccakF1600(&c.a)
+		c.permute()
 		copyOut(c, out[:rate])
 		out = out[rate:]
 		nblocks--
 	}
 
-	keccakF1600(&c.a)
+	c.permute()
 	copyOut(c, buf)
 	copy(out, buf[:len(out)])
 	c.idx = rate - len(out)
@@ -217,11 +233,11 @@ func (c *state) digest(out, in []byte) {
 	c.Write(in)
 	c.finalize_sha3()
 	for i := 0; i < nblocks-1; i++ {
-		keccakF1600(&c.a)
+		c.permute()
 		copyOut(c, out[:])
 		out = out[rate:]
 	}
-	keccakF1600(&c.a)
+	c.permute()
 	copyOut(c, out[:len(out)])
 }
 
@@ -252,3 +268,19 @@ func New384() hash.Hash {
 func New512() hash.Hash {
 	return &state{sfx: 0x06, desc: Sha3Desc[SHA3_512]}
 }
+
+// NewLegacyKeccak256 creates a new Keccak-256 hash. It uses the original
+// Keccak padding, as submitted to the SHA-3 competition, instead of the one
+// from FIPS-202. Only use it for compatibility with existing protocols
+// (like Ethereum), otherwise use New256.
+func NewLegacyKeccak256() hash.Hash {
+	return &state{sfx: 0x01, desc: Sha3Desc[SHA3_256]}
+}
+
+// NewLegacyKeccak512 creates a new Keccak-512 hash. It uses the original
+// Keccak padding, as submitted to the SHA-3 competition, instead of the one
+// from FIPS-202. Only use it for compatibility with existing protocols,
+// otherwise use New512.
+func NewLegacyKeccak512() hash.Hash {
+	return &state{sfx: 0x01, desc: Sha3Desc[SHA3_512]}
+}
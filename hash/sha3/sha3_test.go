@@ -31,10 +31,12 @@ const (
 // with output-length equal to the KAT length for SHA-3, Keccak
 // and SHAKE instances.
 var testDigests = map[string]func() hash.Hash{
-	"SHA3-224": New224,
-	"SHA3-256": New256,
-	"SHA3-384": New384,
-	"SHA3-512": New512,
+	"SHA3-224":   New224,
+	"SHA3-256":   New256,
+	"SHA3-384":   New384,
+	"SHA3-512":   New512,
+	"Keccak-256": NewLegacyKeccak256,
+	"Keccak-512": NewLegacyKeccak512,
 }
 
 // testShakes contains functions that return sha3.ShakeHash instances for
@@ -154,6 +156,35 @@ func TestKeccakKats(t *testing.T) {
 	})
 }
 
+// TestLegacyKeccak checks Keccak with original padding, as used
+// by Ethereum.
+func TestLegacyKeccak(t *testing.T) {
+	tests := []struct {
+		fn  func() hash.Hash
+		in  string
+		out string
+	}{
+		{NewLegacyKeccak256, "", "C5D2460186F7233C927E7DB2DCC703C0E500B653CA82273B7BFAD8045D85A470"},
+		{NewLegacyKeccak256, "abc", "4E03657AEA45A94FC7D47BA826C8D667C0D1E6E33A64A036EC44F58FA12D6C45"},
+		{NewLegacyKeccak256, "The quick brown fox jumps over the lazy dog",
+			"4D741B6F1EB29CB2A9B9911C82F56FA8D73B04959D3D9D222895DF6C0B28AA15"},
+		{NewLegacyKeccak512, "", "0EAB42DE4C3CEB9235FC91ACFFE746B29C29A8C366B7C60E4E67C466F36A4304" +
+			"C00FA9CAF9D87976BA469BCBE06713B435F091EF2769FB160CDAB33D3670680E"},
+		{NewLegacyKeccak512, "abc", "18587DC2EA106B9A1563E32B3312421CA164C7F1F07BC922A9C83D77CEA3A1E5" +
+			"D0C69910739025372DC14AC9642629379540C17E2A65B19D77AA511A9D00BB96"},
+		{NewLegacyKeccak512, "The quick brown fox jumps over the lazy dog",
+			"D135BB84D0439DBAC432247EE573A23EA7D3C9DEB2A968EB31D47C4FB45F1EF4" +
+				"422D6C531B5B9BD6F449EBCC449EA94D0A8F05F62130FDA612DA53C79659F609"},
+	}
+	for i, v := range tests {
+		h := v.fn()
+		h.Write([]byte(v.in))
+		if got := strings.ToUpper(hex.EncodeToString(h.Sum(nil))); got != v.out {
+			t.Errorf("#%d: got %s, want %s", i, got, v.out)
+		}
+	}
+}
+
 // TestUnalignedWrite tests that writing data in an arbitrary pattern with
 // small input buffers.
 func TestUnalignedWrite(t *testing.T) {
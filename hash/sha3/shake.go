@@ -71,6 +71,24 @@ func leftEncode(value uint64) []byte {
 	return b[i-1:]
 }
 
+func rightEncode(value uint64) []byte {
+	var b [9]byte
+	binary.BigEndian.PutUint64(b[:8], value)
+	// Trim all but last leading zero bytes
+	i := byte(0)
+	for i < 7 && b[i] == 0 {
+		i++
+	}
+	// Append number of encoded bytes
+	b[8] = 8 - i
+	return b[i:]
+}
+
+// encodeString encodes bit string as described in SP800-185, 2.3.2
+func encodeString(s []byte) []byte {
+	return append(leftEncode(uint64(len(s)*8)), s...)
+}
+
 func newCShake(N, S []byte, sfx byte, shaId uint8) ShakeHash {
 	c := cshakeState{state: state{sfx: sfx, desc: Sha3Desc[shaId]}}
 
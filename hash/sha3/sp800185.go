@@ -0,0 +1,85 @@
+package sha3
+
+// Functions derived from cSHAKE, described in NIST-SP-800-185.
+import (
+	"hash"
+
+	"github.com/henrydcase/nobs/internal/block"
+)
+
+// XOF is a hash function with arbitrary-length output, which can also be
+// used as hash.Hash. Sum returns Size() bytes, while Read can be used to
+// get output of any length.
+type XOF interface {
+	hash.Hash
+
+	// Read reads more output from the hash. It finalizes the hash, writing
+	// to it afterwards returns an error. It never returns an error.
+	Read(out []byte) (n int, err error)
+}
+
+// cshakeFn implements functions which compute cSHAKE on the input followed
+// by right_encode(L), where L is a length of the output in bits, or 0 in
+// case of the XOF variant. Used by KMAC and TupleHash.
+type cshakeFn struct {
+	cshakeState
+
+	// Absorbed after initialization and on Reset
+	prefix []byte
+	// Size of the output returned by Sum
+	size int
+	// Set for the XOF variant
+	xof bool
+}
+
+func newCShakeFn(N, S, prefix []byte, size int, xof bool, shaId uint8) *cshakeFn {
+	f := &cshakeFn{
+		cshakeState: *newCShake(N, S, sfxCShake, shaId).(*cshakeState),
+		size:        size,
+		xof:         xof,
+	}
+	if len(prefix) != 0 {
+		f.prefix = bytepad(prefix, f.BlockSize())
+		f.cshakeState.Write(f.prefix)
+	}
+	return f
+}
+
+// Size returns the output size of Sum in bytes.
+func (f *cshakeFn) Size() int { return f.size }
+
+// Reset resets the hash to its initial state.
+func (f *cshakeFn) Reset() {
+	f.cshakeState.Reset()
+	if len(f.prefix) != 0 {
+		f.cshakeState.Write(f.prefix)
+	}
+}
+
+// Read absorbs encoded length of the output if called first time and
+// squeezes out len(out) bytes.
+func (f *cshakeFn) Read(out []byte) (int, error) {
+	if !f.isSquezing {
+		l := uint64(f.size) * 8
+		if f.xof {
+			l = 0
+		}
+		f.cshakeState.Write(rightEncode(l))
+	}
+	return f.cshakeState.Read(out)
+}
+
+// Sum appends Size() bytes of the output to b. It doesn't change
+// the underlying state.
+func (f *cshakeFn) Sum(b []byte) []byte {
+	dup := *f
+	ret, out := block.SliceForAppend(b, f.size)
+	dup.Read(out)
+	return ret
+}
+
+// Clone returns a copy of the hash in its current state.
+func (f *cshakeFn) Clone() ShakeHash {
+	dup := *f
+	return &dup
+}
@@ -0,0 +1,139 @@
+package sha3
+
+import (
+	"fmt"
+)
+
+// Width of the permutation in bytes
+const keccakWidth = 200
+
+// Limits of the parameters of custom sponge.
+const (
+	// Minimal capacity in bytes, it gives 128-bit security level
+	MinCapacity = 32
+	// Maximal number of rounds of Keccak-p[1600]
+	MaxRounds = 24
+)
+
+// newSpongeState checks parameters of a sponge and returns initialized
+// state. It panics if parameters are invalid.
+func newSpongeState(rate int, domain byte, rounds int) state {
+	if rate <= 0 || rate%8 != 0 || rate > keccakWidth-MinCapacity {
+		panic(fmt.Sprintf("sha3: invalid sponge rate %d", rate))
+	}
+	if domain < 0x01 || domain > 0x7f {
+		panic("sha3: domain byte must be in range [0x01, 0x7F]")
+	}
+	if rounds < 1 || rounds > MaxRounds {
+		panic(fmt.Sprintf("sha3: invalid number of rounds %d", rounds))
+	}
+	return state{
+		desc:   spongeDesc{r: rate, name: "Keccak-p sponge"},
+		sfx:    domain,
+		rounds: rounds,
+	}
+}
+
+// Sponge is a sponge construction on top of Keccak-p[1600, rounds]
+// permutation with pad10*1 padding. It implements ShakeHash.
+type Sponge struct {
+	s state
+}
+
+// NewSponge creates a sponge with rate of 'rate' bytes, the capacity is
+// 200-rate bytes. The rate must be a multiple of 8 and capacity must be
+// at least MinCapacity bytes. The domain byte contains domain separation
+// bits followed by the first bit of the padding, as used by SHAKE (0x1F)
+// or SHA-3 (0x06), it must be in range [0x01, 0x7F]. The permutation
+// uses the last 'rounds' rounds of Keccak-f[1600], where rounds is in
+// range [1, 24]. Function panics if parameters are invalid.
+func NewSponge(rate int, domain byte, rounds int) *Sponge {
+	return &Sponge{s: newSpongeState(rate, domain, rounds)}
+}
+
+// Write absorbs more data. It returns an error if called after Read.
+func (s *Sponge) Write(in []byte) (int, error) { return s.s.Write(in) }
+
+// Read pads the input if called first time and squeezes out len(out)
+// bytes.
+func (s *Sponge) Read(out []byte) (int, error) { return s.s.Read(out) }
+
+// Reset resets the sponge to its initial state.
+func (s *Sponge) Reset() { s.s.Reset() }
+
+// Clone returns a copy of the sponge in its current state.
+func (s *Sponge) Clone() ShakeHash {
+	dup := *s
+	return &dup
+}
+
+// Rate returns the rate in bytes.
+func (s *Sponge) Rate() int { return s.s.desc.r }
+
+// Capacity returns the capacity in bytes.
+func (s *Sponge) Capacity() int { return keccakWidth - s.s.desc.r }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *Sponge) MarshalBinary() ([]byte, error) { return s.s.MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The state must
+// be serialized by a sponge with the same parameters as s.
+func (s *Sponge) UnmarshalBinary(b []byte) error { return s.s.UnmarshalBinary(b) }
+
+// Duplex is a duplex construction on top of Keccak-p[1600, rounds]
+// permutation with pad10*1 padding. Each call to Duplex absorbs one padded
+// block of input and squeezes up to rate bytes of output.
+type Duplex struct {
+	s state
+}
+
+// NewDuplex creates a duplex object. Parameters have the same meaning
+// and restrictions as in NewSponge. Function panics if parameters are
+// invalid.
+func NewDuplex(rate int, domain byte, rounds int) *Duplex {
+	return &Duplex{s: newSpongeState(rate, domain, rounds)}
+}
+
+// Duplex absorbs 'in' and writes len(out) bytes of the output to 'out'.
+// Input can have at most MaxInputSize() bytes and output at most Rate()
+// bytes. Buffers 'in' and 'out' may overlap entirely.
+func (d *Duplex) Duplex(out, in []byte) {
+	rate := d.s.desc.r
+	if len(in) > rate-1 {
+		panic("sha3: duplex input too long")
+	}
+	if len(out) > rate {
+		panic("sha3: duplex output too long")
+	}
+
+	buf := d.s.data.asBytes()[:rate]
+	copy(buf, in)
+	buf[len(in)] = d.s.sfx
+	for i := len(in) + 1; i < rate; i++ {
+		buf[i] = 0
+	}
+	buf[rate-1] |= 0x80
+	xorIn(&d.s, buf)
+	d.s.permute()
+	copyOut(&d.s, buf)
+	copy(out, buf)
+}
+
+// Rate returns the rate in bytes.
+func (d *Duplex) Rate() int { return d.s.desc.r }
+
+// Capacity returns the capacity in bytes.
+func (d *Duplex) Capacity() int { return keccakWidth - d.s.desc.r }
+
+// MaxInputSize returns maximal size of the input accepted by Duplex.
+// One byte of the block is reserved for the domain byte and padding.
+func (d *Duplex) MaxInputSize() int { return d.s.desc.r - 1 }
+
+// Reset resets the duplex object to its initial state.
+func (d *Duplex) Reset() { d.s.Reset() }
+
+// Clone returns a copy of the duplex object in its current state.
+func (d *Duplex) Clone() *Duplex {
+	dup := *d
+	return &dup
+}
@@ -0,0 +1,176 @@
+package sha3
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+)
+
+func TestSpongeStandard(t *testing.T) {
+	msg := generateData(1000)
+	for i, v := range []struct {
+		s   *Sponge
+		ref ShakeHash
+	}{
+		{NewSponge(168, 0x1F, 24), NewShake128()},
+		{NewSponge(136, 0x1F, 24), NewShake256()},
+		{NewSponge(136, 0x06, 24), New256().(ShakeHash)},
+		{NewSponge(72, 0x01, 24), NewLegacyKeccak512().(ShakeHash)},
+		{NewSponge(168, 0x0B, 12), NewTurboShake128(0x0B)},
+	} {
+		exp, out := make([]byte, 300), make([]byte, 300)
+		v.ref.Write(msg)
+		v.ref.Read(exp)
+		v.s.Write(msg)
+		v.s.Read(out)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: got %X, want %X", i, out, exp)
+		}
+	}
+}
+
+func TestSpongeCustom(t *testing.T) {
+	for i, v := range []struct {
+		rate   int
+		domain byte
+		rounds int
+		msg    []byte
+		out    string
+	}{
+		{64, 0x03, 6, ptn(200), "F0C92CD666752C4A49E78A8B8F4E9D0C4A4F0FE252BE2C9DDD11B9341390E559" +
+			"34B1625690159051F98695EB59ED84395C153F44EBD196347626B0BC25D8E10D" +
+			"B1975E9ADABBB7F2E3BAE55B30E0DC551D38B8019E7609A484827042F1C9CEDC" +
+			"37A4613F"},
+		{160, 0x1F, 13, ptn(500), "DBB54C871E541D943404768F9482E6821EF2FCA01FE0ECD8A7E1FE4E0433E162" +
+			"319EA954D60F74FE85674BFDF583B2427A9D1DD5EDF62B1A0AAF2EA052DD4E4C"},
+		{8, 0x7F, 1, ptn(20), "E7F590931319049CB050AE2873ABF4C7E3D49880"},
+	} {
+		exp := decodeHex(v.out)
+		s := NewSponge(v.rate, v.domain, v.rounds)
+		if s.Rate() != v.rate || s.Capacity() != 200-v.rate {
+			t.Errorf("#%d: wrong rate or capacity", i)
+		}
+		checkXOF(t, i, s, v.msg, exp)
+
+		// Resume serialized sponge
+		s.Reset()
+		s.Write(v.msg[:len(v.msg)/2])
+		b, _ := s.MarshalBinary()
+		r := NewSponge(v.rate, v.domain, v.rounds)
+		if err := r.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		r.Write(v.msg[len(v.msg)/2:])
+		out := make([]byte, len(exp))
+		r.Read(out)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: resumed: got %X, want %X", i, out, exp)
+		}
+		var u encoding.BinaryUnmarshaler = NewSponge(v.rate, v.domain, v.rounds%24+1)
+		if err := u.UnmarshalBinary(b); err == nil {
+			t.Errorf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestDuplex(t *testing.T) {
+	for i, v := range []struct {
+		rate   int
+		domain byte
+		rounds int
+		in     [][]byte
+		outLen int
+		out    string
+	}{
+		{136, 0x01, 12, [][]byte{nil, ptn(10), ptn(135)}, 32,
+			"E3DD2DF0943BDE6D82E39EC36059F35CD76720E2DF38CC6B10B69FDDFCAA3A4A" +
+				"5232C022CB067FBB46559D269C2BCB4846BF7F5D2FF1A3BD4F72FD30C1EF5299" +
+				"41C96C9A6F0B49FC3236ACB6AE6083E37ACFB1D2CC2B3E07BFBFD61259DC5DE7"},
+		{64, 0x02, 23, [][]byte{ptn(63), nil, ptn(1)}, 64,
+			"62EAE6682EA0F2827187AFB633CB76F7CDA837E729C5D1A9F7A00354E9724401" +
+				"9FE508EB38F8DCC4FFF2E4D01EDC6272073D10A7A00C7090B3563256A0EB5902" +
+				"2220A396DAD119936A21480B98DEBCDC091B360EF09D4C8D8BB99E1D84ECB288" +
+				"B30663F63180589BC2991CD5799BB93A53598A367BEF51D3AC2A9EBC02EEA0DB" +
+				"CBDEEDE0342F01FF7CC90E91E40CF36CCDAAB7F4939645AA8CB82CFFDDDDA734" +
+				"7952CD2051CA358A88D329FEBFF90F852F211E746A78605111A118342CE4A4A7"},
+	} {
+		exp := decodeHex(v.out)
+		d := NewDuplex(v.rate, v.domain, v.rounds)
+		if d.MaxInputSize() != v.rate-1 || d.Capacity() != 200-v.rate {
+			t.Errorf("#%d: wrong sizes", i)
+		}
+		c := d.Clone()
+		var out []byte
+		for _, in := range v.in {
+			o := make([]byte, v.outLen)
+			d.Duplex(o, in)
+			out = append(out, o...)
+		}
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: got %X, want %X", i, out, exp)
+		}
+
+		// In-place operation
+		out = out[:0]
+		for _, in := range v.in {
+			o := make([]byte, v.rate)
+			copy(o, in)
+			c.Duplex(o[:v.outLen], o[:len(in)])
+			out = append(out, o[:v.outLen]...)
+		}
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: in-place: got %X, want %X", i, out, exp)
+		}
+	}
+}
+
+func TestSpongeParams(t *testing.T) {
+	for _, v := range []struct {
+		rate   int
+		domain byte
+		rounds int
+	}{
+		{0, 0x1F, 24}, {12, 0x1F, 24}, {176, 0x1F, 24}, {200, 0x1F, 24},
+		{168, 0x00, 24}, {168, 0x80, 24}, {168, 0x1F, 0}, {168, 0x1F, 25},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for %+v", v)
+				}
+			}()
+			NewSponge(v.rate, v.domain, v.rounds)
+		}()
+	}
+
+	d := NewDuplex(64, 0x1F, 12)
+	for _, f := range []func(){
+		func() { d.Duplex(nil, make([]byte, 64)) },
+		func() { d.Duplex(make([]byte, 65), nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestKeccakP1600Rounds(t *testing.T) {
+	// Applying single rounds gives the same result as the unrolled loop
+	var a, b [25]uint64
+	for i := range a {
+		a[i] = uint64(i) * 0x0123456789ABCDEF
+	}
+	b = a
+	keccakP1600Generic(&a, 24)
+	for i := 0; i < 24; i++ {
+		keccakRound(&b, rc[i])
+	}
+	if a != b {
+		t.Error("permutations differ")
+	}
+}
@@ -0,0 +1,97 @@
+package sha3
+
+// TupleHash is a hash function for tuples of byte strings, described in
+// NIST-SP-800-185, section 5. Each element of the tuple is encoded with
+// its length, so that tuples ("ab", "c") and ("a", "bc") hash to
+// unrelated values.
+
+var tupleHashName = []byte("TupleHash")
+
+// TupleHash computes TupleHash or TupleHashXOF of a tuple. Elements of
+// the tuple are added with WriteElement.
+type TupleHash struct {
+	f cshakeFn
+}
+
+func newTupleHash(size int, S []byte, xof bool, shaId uint8) *TupleHash {
+	return &TupleHash{f: *newCShakeFn(tupleHashName, S, nil, size, xof, shaId)}
+}
+
+// NewTupleHash128 returns TupleHash128, which returns 'size' bytes of
+// output. S is an optional customization string.
+func NewTupleHash128(size int, S []byte) *TupleHash {
+	return newTupleHash(size, S, false, SHAKE128)
+}
+
+// NewTupleHash256 returns TupleHash256, which returns 'size' bytes of
+// output. S is an optional customization string.
+func NewTupleHash256(size int, S []byte) *TupleHash {
+	return newTupleHash(size, S, false, SHAKE256)
+}
+
+// NewTupleHashXOF128 returns TupleHashXOF128, a variant of TupleHash128
+// with arbitrary output length. Sum returns 'size' bytes of the output.
+func NewTupleHashXOF128(size int, S []byte) *TupleHash {
+	return newTupleHash(size, S, true, SHAKE128)
+}
+
+// NewTupleHashXOF256 returns TupleHashXOF256, a variant of TupleHash256
+// with arbitrary output length. Sum returns 'size' bytes of the output.
+func NewTupleHashXOF256(size int, S []byte) *TupleHash {
+	return newTupleHash(size, S, true, SHAKE256)
+}
+
+// WriteElement absorbs next element of the tuple. It returns an error
+// if called after Read.
+func (t *TupleHash) WriteElement(e []byte) error {
+	if t.f.isSquezing {
+		return ErrWriteAfterRead
+	}
+	t.f.Write(leftEncode(uint64(len(e)) * 8))
+	t.f.Write(e)
+	return nil
+}
+
+// Read finalizes the hash if called first time and squeezes out
+// len(out) bytes. For TupleHash (not XOF variant), only first Size()
+// bytes are meaningful.
+func (t *TupleHash) Read(out []byte) (int, error) { return t.f.Read(out) }
+
+// Sum appends Size() bytes of the hash of the tuple to b. It doesn't
+// change the underlying state.
+func (t *TupleHash) Sum(b []byte) []byte { return t.f.Sum(b) }
+
+// Size returns the output size of Sum in bytes.
+func (t *TupleHash) Size() int { return t.f.Size() }
+
+// BlockSize returns the rate of the underlying sponge.
+func (t *TupleHash) BlockSize() int { return t.f.BlockSize() }
+
+// Reset resets the hash to its initial state.
+func (t *TupleHash) Reset() { t.f.Reset() }
+
+// Clone returns a copy of the hash in its current state.
+func (t *TupleHash) Clone() *TupleHash {
+	dup := *t
+	return &dup
+}
+
+// TupleHashSum128 writes TupleHash128 of the tuple to out. Length of the
+// output is a parameter of the function.
+func TupleHashSum128(out, S []byte, tuple ...[]byte) {
+	t := NewTupleHash128(len(out), S)
+	for _, e := range tuple {
+		t.WriteElement(e)
+	}
+	t.Read(out)
+}
+
+// TupleHashSum256 writes TupleHash256 of the tuple to out. Length of the
+// output is a parameter of the function.
+func TupleHashSum256(out, S []byte, tuple ...[]byte) {
+	t := NewTupleHash256(len(out), S)
+	for _, e := range tuple {
+		t.WriteElement(e)
+	}
+	t.Read(out)
+}
@@ -0,0 +1,245 @@
+package sha3
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Samples from NIST, "TupleHash_samples.pdf" and "TupleHashXOF_samples.pdf".
+var tupleHashTests = []struct {
+	bits  int
+	xof   bool
+	tuple []string
+	S     string
+	out   string
+}{
+	{128, false, []string{"000102", "101112131415"}, "",
+		"C5D8786C1AFB9B82111AB34B65B2C0048FA64E6D48E263264CE1707D3FFC8ED1"},
+	{128, false, []string{"000102", "101112131415"}, "My Tuple App",
+		"75CDB20FF4DB1154E841D758E24160C54BAE86EB8C13E7F5F40EB35588E96DFB"},
+	{128, false, []string{"000102", "101112131415", "202122232425262728"}, "My Tuple App",
+		"E60F202C89A2631EDA8D4C588CA5FD07F39E5151998DECCF973ADB3804BB6E84"},
+	{128, true, []string{"000102", "101112131415"}, "",
+		"2F103CD7C32320353495C68DE1A8129245C6325F6F2A3D608D92179C96E68488"},
+	{128, true, []string{"000102", "101112131415"}, "My Tuple App",
+		"3FC8AD69453128292859A18B6C67D7AD85F01B32815E22CE839C49EC374E9B9A"},
+	{128, true, []string{"000102", "101112131415", "202122232425262728"}, "My Tuple App",
+		"900FE16CAD098D28E74D632ED852F99DAAB7F7DF4D99E775657885B4BF76D6F8"},
+	{256, false, []string{"000102", "101112131415"}, "",
+		"CFB7058CACA5E668F81A12A20A2195CE97A925F1DBA3E7449A56F82201EC6073" +
+			"11AC2696B1AB5EA2352DF1423BDE7BD4BB78C9AED1A853C78672F9EB23BBE194"},
+	{256, false, []string{"000102", "101112131415"}, "My Tuple App",
+		"147C2191D5ED7EFD98DBD96D7AB5A11692576F5FE2A5065F3E33DE6BBA9F3AA1" +
+			"C4E9A068A289C61C95AAB30AEE1E410B0B607DE3620E24A4E3BF9852A1D4367E"},
+	{256, false, []string{"000102", "101112131415", "202122232425262728"}, "My Tuple App",
+		"45000BE63F9B6BFD89F54717670F69A9BC763591A4F05C50D68891A744BCC6E7" +
+			"D6D5B5E82C018DA999ED35B0BB49C9678E526ABD8E85C13ED254021DB9E790CE"},
+	{256, true, []string{"000102", "101112131415"}, "",
+		"03DED4610ED6450A1E3F8BC44951D14FBC384AB0EFE57B000DF6B6DF5AAE7CD5" +
+			"68E77377DAF13F37EC75CF5FC598B6841D51DD207C991CD45D210BA60AC52EB9"},
+	{256, true, []string{"000102", "101112131415"}, "My Tuple App",
+		"6483CB3C9952EB20E830AF4785851FC597EE3BF93BB7602C0EF6A65D741AECA7" +
+			"E63C3B128981AA05C6D27438C79D2754BB1B7191F125D6620FCA12CE658B2442"},
+	{256, true, []string{"000102", "101112131415", "202122232425262728"}, "My Tuple App",
+		"0C59B11464F2336C34663ED51B2B950BEC743610856F36C28D1D088D8A244628" +
+			"4DD09830A6A178DC752376199FAE935D86CFDEE5913D4922DFD369B66A53C897"},
+}
+
+func newTestTupleHash(bits int, xof bool, size int, S []byte) *TupleHash {
+	switch {
+	case bits == 128 && !xof:
+		return NewTupleHash128(size, S)
+	case bits == 256 && !xof:
+		return NewTupleHash256(size, S)
+	case bits == 128:
+		return NewTupleHashXOF128(size, S)
+	default:
+		return NewTupleHashXOF256(size, S)
+	}
+}
+
+func TestTupleHash(t *testing.T) {
+	for i, v := range tupleHashTests {
+		exp := decodeHex(v.out)
+		h := newTestTupleHash(v.bits, v.xof, len(exp), []byte(v.S))
+		for _, e := range v.tuple {
+			h.WriteElement(decodeHex(e))
+		}
+		if out := h.Sum(nil); !bytes.Equal(out, exp) {
+			t.Errorf("#%d: got %X, want %X", i, out, exp)
+		}
+		out := make([]byte, len(exp))
+		h.Read(out)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: Read: got %X, want %X", i, out, exp)
+		}
+		if err := h.WriteElement(nil); err != ErrWriteAfterRead {
+			t.Errorf("#%d: write after read must fail", i)
+		}
+	}
+}
+
+func TestTupleHashUnambiguous(t *testing.T) {
+	var out1, out2 [32]byte
+	TupleHashSum128(out1[:], nil, []byte("ab"), []byte("c"))
+	TupleHashSum128(out2[:], nil, []byte("a"), []byte("bc"))
+	if out1 == out2 {
+		t.Error("different tuples hash to same value")
+	}
+	TupleHashSum128(out2[:], nil, []byte("abc"))
+	if out1 == out2 {
+		t.Error("different tuples hash to same value")
+	}
+
+	h := NewTupleHash128(32, nil)
+	h.WriteElement([]byte("ab"))
+	c := h.Clone()
+	h.WriteElement([]byte("c"))
+	c.WriteElement([]byte("c"))
+	h.Reset()
+	h.WriteElement([]byte("ab"))
+	h.WriteElement([]byte("c"))
+	if !bytes.Equal(c.Sum(nil), out1[:]) || !bytes.Equal(h.Sum(nil), out1[:]) {
+		t.Error("Clone or Reset failed")
+	}
+}
+
+// Samples from NIST, "ParallelHash_samples.pdf" and
+// "ParallelHashXOF_samples.pdf", block size is 8 bytes.
+var parallelHashTests = []struct {
+	bits int
+	xof  bool
+	S    string
+	out  string
+}{
+	{128, false, "",
+		"BA8DC1D1D979331D3F813603C67F72609AB5E44B94A0B8F9AF46514454A2B4F5"},
+	{128, false, "Parallel Data",
+		"FC484DCB3F84DCEEDC353438151BEE58157D6EFED0445A81F165E495795B7206"},
+	{128, true, "",
+		"FE47D661E49FFE5B7D999922C062356750CAF552985B8E8CE6667F2727C3C8D3"},
+	{128, true, "Parallel Data",
+		"EA2A793140820F7A128B8EB70A9439F93257C6E6E79B4A540D291D6DAE7098D7"},
+	{256, false, "",
+		"BC1EF124DA34495E948EAD207DD9842235DA432D2BBC54B4C110E64C45110553" +
+			"1B7F2A3E0CE055C02805E7C2DE1FB746AF97A1DD01F43B824E31B87612410429"},
+	{256, false, "Parallel Data",
+		"CDF15289B54F6212B4BC270528B49526006DD9B54E2B6ADD1EF6900DDA3963BB" +
+			"33A72491F236969CA8AFAEA29C682D47A393C065B38E29FAE651A2091C833110"},
+	{256, true, "",
+		"C10A052722614684144D28474850B410757E3CBA87651BA167A5CBDDFF7F4666" +
+			"75FBF84BCAE7378AC444BE681D729499AFCA667FB879348BFDDA427863C82F1C"},
+	{256, true, "Parallel Data",
+		"538E105F1A22F44ED2F5CC1674FBD40BE803D9C99BF5F8D90A2C8193F3FE6EA7" +
+			"68E5C1A20987E2C9C65FEBED03887A51D35624ED12377594B5585541DC377EFC"},
+}
+
+func newTestParallelHash(bits int, xof bool, b, size int, S []byte) *ParallelHash {
+	switch {
+	case bits == 128 && !xof:
+		return NewParallelHash128(b, size, S)
+	case bits == 256 && !xof:
+		return NewParallelHash256(b, size, S)
+	case bits == 128:
+		return NewParallelHashXOF128(b, size, S)
+	default:
+		return NewParallelHashXOF256(b, size, S)
+	}
+}
+
+func TestParallelHash(t *testing.T) {
+	in := decodeHex("000102030405060710111213141516172021222324252627")
+	for i, v := range parallelHashTests {
+		exp := decodeHex(v.out)
+		h := newTestParallelHash(v.bits, v.xof, 8, len(exp), []byte(v.S))
+		h.Write(in[:5])
+		h.Write(in[5:])
+		if out := h.Sum(nil); !bytes.Equal(out, exp) {
+			t.Errorf("#%d: got %X, want %X", i, out, exp)
+		}
+		out := make([]byte, len(exp))
+		h.Read(out)
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: Read: got %X, want %X", i, out, exp)
+		}
+		if _, err := h.Write(in); err != ErrWriteAfterRead {
+			t.Errorf("#%d: write after read must fail", i)
+		}
+	}
+}
+
+// parallelHashRef is a straightforward implementation of ParallelHash
+// used to check parallel processing of large inputs.
+func parallelHashRef(bits int, b int, in []byte, out []byte, S []byte) {
+	leaf := 2 * bits / 8
+	c := NewCShake256([]byte("ParallelHash"), S)
+	if bits == 128 {
+		c = NewCShake128([]byte("ParallelHash"), S)
+	}
+	c.Write(leftEncode(uint64(b)))
+	n := 0
+	for ; len(in) > 0; n++ {
+		l := b
+		if l > len(in) {
+			l = len(in)
+		}
+		z := make([]byte, leaf)
+		if bits == 128 {
+			ShakeSum128(z, in[:l])
+		} else {
+			ShakeSum256(z, in[:l])
+		}
+		c.Write(z)
+		in = in[l:]
+	}
+	c.Write(rightEncode(uint64(n)))
+	c.Write(rightEncode(uint64(len(out)) * 8))
+	c.Read(out)
+}
+
+func TestParallelHashLarge(t *testing.T) {
+	in := generateData(3*parallelBatch + 12345)
+	for _, bits := range []int{128, 256} {
+		for _, b := range []int{1, 1000, 8192, 100000, 1 << 20} {
+			exp := make([]byte, 32)
+			parallelHashRef(bits, b, in, exp, []byte("S"))
+
+			h := newTestParallelHash(bits, false, b, 32, []byte("S"))
+			h.Write(in)
+			if out := h.Sum(nil); !bytes.Equal(out, exp) {
+				t.Errorf("%d/%d: got %X, want %X", bits, b, out, exp)
+			}
+
+			// Unaligned writes
+			h.Reset()
+			for i, l := 0, 1; i < len(in); i, l = i+l, l*3+7 {
+				if i+l > len(in) {
+					l = len(in) - i
+				}
+				h.Write(in[i : i+l])
+				if i == 0 {
+					c := h.Clone()
+					c.Write(in[l:])
+					if out := c.Sum(nil); !bytes.Equal(out, exp) {
+						t.Errorf("%d/%d: Clone failed", bits, b)
+					}
+				}
+			}
+			if out := h.Sum(nil); !bytes.Equal(out, exp) {
+				t.Errorf("%d/%d: unaligned writes: got %X, want %X", bits, b, out, exp)
+			}
+		}
+	}
+}
+
+func BenchmarkParallelHash(b *testing.B) {
+	data := make([]byte, 1<<20)
+	h := NewParallelHash256(8192, 64, nil)
+	out := make([]byte, 0, 64)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		h.Reset()
+		h.Write(data)
+		h.Sum(out)
+	}
+}
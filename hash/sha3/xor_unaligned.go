@@ -21,6 +21,15 @@ func (b *storageBuf) asBytes() *[maxRate]byte {
 func xorInUnaligned(d *state, buf []byte) {
 	n := len(buf)
 	bw := (*[maxRate / 8]uint64)(unsafe.Pointer(&buf[0]))[: n/8 : n/8]
+	switch n {
+	case 72, 104, 136, 144, 168:
+	default:
+		// Rate used by a custom sponge
+		for i := range bw {
+			d.a[i] ^= bw[i]
+		}
+		return
+	}
 	if n >= 72 {
 		d.a[0] ^= bw[0]
 		d.a[1] ^= bw[1]
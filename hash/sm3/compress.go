@@ -87,18 +87,26 @@ func store32Be(val []byte, x uint32) {
 	val[3] = byte(x >> 0)
 }
 
+// Compression function processing consecutive blocks of p, selected at
+// runtime.
+var block = blockGeneric
+
 func (d *digest) compress(input []byte, blocks int) {
-	A := d.h[0]
-	B := d.h[1]
-	C := d.h[2]
-	D := d.h[3]
-	E := d.h[4]
-	F := d.h[5]
-	G := d.h[6]
-	H := d.h[7]
-
-	for i := 0; i < blocks; i++ {
-		next64Block := input[i*64:]
+	block(&d.h, input[:blocks*BlockSize])
+}
+
+func blockGeneric(h *[8]uint32, p []byte) {
+	A := h[0]
+	B := h[1]
+	C := h[2]
+	D := h[3]
+	E := h[4]
+	F := h[5]
+	G := h[6]
+	H := h[7]
+
+	for i := 0; i < len(p)/BlockSize; i++ {
+		next64Block := p[i*64:]
 
 		W00 := loadBe32(next64Block[0:])
 		W01 := loadBe32(next64Block[4:])
@@ -233,22 +241,22 @@ func (d *digest) compress(input []byte, blocks int) {
 		r2(C, &D, A, &B, G, &H, E, &F, 0x9EA1E762, W14, W14^W02)
 		r2(B, &C, D, &A, F, &G, H, &E, 0x3D43CEC5, W15, W15^W03)
 
-		d.h[0] ^= A
-		d.h[1] ^= B
-		d.h[2] ^= C
-		d.h[3] ^= D
-		d.h[4] ^= E
-		d.h[5] ^= F
-		d.h[6] ^= G
-		d.h[7] ^= H
-
-		A = d.h[0]
-		B = d.h[1]
-		C = d.h[2]
-		D = d.h[3]
-		E = d.h[4]
-		F = d.h[5]
-		G = d.h[6]
-		H = d.h[7]
+		h[0] ^= A
+		h[1] ^= B
+		h[2] ^= C
+		h[3] ^= D
+		h[4] ^= E
+		h[5] ^= F
+		h[6] ^= G
+		h[7] ^= H
+
+		A = h[0]
+		B = h[1]
+		C = h[2]
+		D = h[3]
+		E = h[4]
+		F = h[5]
+		G = h[6]
+		H = h[7]
 	}
 }
@@ -0,0 +1,16 @@
+// +build amd64,!noasm
+
+package sm3
+
+import "github.com/henrydcase/nobs/utils"
+
+// This function is implemented in compress_amd64.s.
+
+//go:noescape
+func blockAVX2(h *[8]uint32, p []byte)
+
+func init() {
+	if utils.X86.HasAVX2 && utils.X86.HasBMI2 {
+		block = blockAVX2
+	}
+}
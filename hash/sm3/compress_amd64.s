@@ -0,0 +1,241 @@
+// +build amd64,!noasm
+
+// SM3 compression function of a single message. Message expansion is
+// vectorized with AVX, three words are computed at a time. Rounds use
+// general purpose registers and BMI2 RORX, which rotates without
+// modifying flags nor the source register.
+
+#include "textflag.h"
+
+DATA ·bswapMask<>+0(SB)/8, $0x0405060700010203
+DATA ·bswapMask<>+8(SB)/8, $0x0C0D0E0F08090A0B
+GLOBL ·bswapMask<>(SB), RODATA|NOPTR, $16
+
+// Rotates each 32-bit word of 'src' left by 'n' bits into 'dst', 't' is
+// a temporary register
+#define ROL(n, src, dst, t) \
+	VPSLLD $(n), src, t; \
+	VPSRLD $(32-(n)), src, dst; \
+	VPOR t, dst, dst
+
+// Computes W[j], W[j+1] and W[j+2], which are stored on the stack:
+// W[j] = P1(W[j-16] ^ W[j-9] ^ (W[j-3] <<< 15)) ^ (W[j-13] <<< 7) ^ W[j-6]
+// Fourth word computed from W[j] is incorrect, as W[j] isn't known yet.
+// It is stored to W[j+3] and overwritten by the next step.
+#define EXPAND(j) \
+	VMOVDQU (((j)-16)*4)(SP), X0; \
+	VPXOR (((j)-9)*4)(SP), X0, X0; \
+	VMOVDQU (((j)-3)*4)(SP), X1; \
+	ROL(15, X1, X2, X3); \
+	VPXOR X2, X0, X0; \
+	ROL(15, X0, X1, X3); \
+	ROL(23, X0, X2, X3); \
+	VPXOR X1, X0, X0; \
+	VPXOR X2, X0, X0; \
+	VMOVDQU (((j)-13)*4)(SP), X1; \
+	ROL(7, X1, X2, X3); \
+	VPXOR X2, X0, X0; \
+	VPXOR (((j)-6)*4)(SP), X0, X0; \
+	VMOVDQU X0, ((j)*4)(SP)
+
+// Boolean functions of the rounds 0 <= j < 16, result in CX
+#define FF0(a, b, c) \
+	MOVL a, CX; \
+	XORL b, CX; \
+	XORL c, CX
+
+#define GG0(e, f, g) \
+	MOVL e, CX; \
+	XORL f, CX; \
+	XORL g, CX
+
+// Boolean functions of the rounds 16 <= j < 64, result in CX
+#define FF1(a, b, c) \
+	MOVL a, CX; \
+	ORL b, CX; \
+	ANDL c, CX; \
+	MOVL a, DX; \
+	ANDL b, DX; \
+	ORL DX, CX
+
+#define GG1(e, f, g) \
+	MOVL f, CX; \
+	XORL g, CX; \
+	ANDL e, CX; \
+	XORL g, CX
+
+// Round j. Stores TT1 in d and P0(TT2) in h, rotates b and f. tj is the
+// round constant T_j <<< j, ff and gg are boolean functions of the round.
+#define ROUND(j, a, b, c, d, e, f, g, h, tj, ff, gg) \
+	RORXL $20, a, AX; \
+	MOVL e, BX; \
+	ADDL AX, BX; \
+	ADDL $(tj), BX; \
+	RORXL $25, BX, BX; \
+	XORL BX, AX; \
+	ADDL d, AX; \
+	MOVL ((j)*4)(SP), DI; \
+	XORL (((j)+4)*4)(SP), DI; \
+	ADDL DI, AX; \
+	ff(a, b, c); \
+	ADDL CX, AX; \
+	ADDL h, BX; \
+	ADDL ((j)*4)(SP), BX; \
+	gg(e, f, g); \
+	ADDL CX, BX; \
+	RORXL $23, b, b; \
+	RORXL $13, f, f; \
+	MOVL AX, d; \
+	RORXL $23, BX, CX; \
+	RORXL $15, BX, DX; \
+	XORL CX, DX; \
+	XORL BX, DX; \
+	MOVL DX, h
+
+// func blockAVX2(h *[8]uint32, p []byte)
+// Stack holds W[0..71] and the end of the input. Words W[68..71] are
+// computed by the last step of the expansion, but not used.
+TEXT ·blockAVX2(SB), 0, $296-32
+	MOVQ h+0(FP), DI
+	MOVQ p_base+8(FP), SI
+	MOVQ p_len+16(FP), DX
+	SHRQ $6, DX
+	SHLQ $6, DX
+	JZ   done
+	ADDQ SI, DX
+	MOVQ DX, 288(SP)
+
+	MOVL 0(DI), R8
+	MOVL 4(DI), R9
+	MOVL 8(DI), R10
+	MOVL 12(DI), R11
+	MOVL 16(DI), R12
+	MOVL 20(DI), R13
+	MOVL 24(DI), R14
+	MOVL 28(DI), R15
+
+	VMOVDQU ·bswapMask<>(SB), X4
+
+loop:
+	VMOVDQU 0(SI), X0
+	VPSHUFB X4, X0, X0
+	VMOVDQU X0, 0(SP)
+	VMOVDQU 16(SI), X0
+	VPSHUFB X4, X0, X0
+	VMOVDQU X0, 16(SP)
+	VMOVDQU 32(SI), X0
+	VPSHUFB X4, X0, X0
+	VMOVDQU X0, 32(SP)
+	VMOVDQU 48(SI), X0
+	VPSHUFB X4, X0, X0
+	VMOVDQU X0, 48(SP)
+	EXPAND(16)
+	EXPAND(19)
+	EXPAND(22)
+	EXPAND(25)
+	EXPAND(28)
+	EXPAND(31)
+	EXPAND(34)
+	EXPAND(37)
+	EXPAND(40)
+	EXPAND(43)
+	EXPAND(46)
+	EXPAND(49)
+	EXPAND(52)
+	EXPAND(55)
+	EXPAND(58)
+	EXPAND(61)
+	EXPAND(64)
+	EXPAND(67)
+
+	ROUND(0, R8, R9, R10, R11, R12, R13, R14, R15, 0x79CC4519, FF0, GG0)
+	ROUND(1, R11, R8, R9, R10, R15, R12, R13, R14, 0xF3988A32, FF0, GG0)
+	ROUND(2, R10, R11, R8, R9, R14, R15, R12, R13, 0xE7311465, FF0, GG0)
+	ROUND(3, R9, R10, R11, R8, R13, R14, R15, R12, 0xCE6228CB, FF0, GG0)
+	ROUND(4, R8, R9, R10, R11, R12, R13, R14, R15, 0x9CC45197, FF0, GG0)
+	ROUND(5, R11, R8, R9, R10, R15, R12, R13, R14, 0x3988A32F, FF0, GG0)
+	ROUND(6, R10, R11, R8, R9, R14, R15, R12, R13, 0x7311465E, FF0, GG0)
+	ROUND(7, R9, R10, R11, R8, R13, R14, R15, R12, 0xE6228CBC, FF0, GG0)
+	ROUND(8, R8, R9, R10, R11, R12, R13, R14, R15, 0xCC451979, FF0, GG0)
+	ROUND(9, R11, R8, R9, R10, R15, R12, R13, R14, 0x988A32F3, FF0, GG0)
+	ROUND(10, R10, R11, R8, R9, R14, R15, R12, R13, 0x311465E7, FF0, GG0)
+	ROUND(11, R9, R10, R11, R8, R13, R14, R15, R12, 0x6228CBCE, FF0, GG0)
+	ROUND(12, R8, R9, R10, R11, R12, R13, R14, R15, 0xC451979C, FF0, GG0)
+	ROUND(13, R11, R8, R9, R10, R15, R12, R13, R14, 0x88A32F39, FF0, GG0)
+	ROUND(14, R10, R11, R8, R9, R14, R15, R12, R13, 0x11465E73, FF0, GG0)
+	ROUND(15, R9, R10, R11, R8, R13, R14, R15, R12, 0x228CBCE6, FF0, GG0)
+	ROUND(16, R8, R9, R10, R11, R12, R13, R14, R15, 0x9D8A7A87, FF1, GG1)
+	ROUND(17, R11, R8, R9, R10, R15, R12, R13, R14, 0x3B14F50F, FF1, GG1)
+	ROUND(18, R10, R11, R8, R9, R14, R15, R12, R13, 0x7629EA1E, FF1, GG1)
+	ROUND(19, R9, R10, R11, R8, R13, R14, R15, R12, 0xEC53D43C, FF1, GG1)
+	ROUND(20, R8, R9, R10, R11, R12, R13, R14, R15, 0xD8A7A879, FF1, GG1)
+	ROUND(21, R11, R8, R9, R10, R15, R12, R13, R14, 0xB14F50F3, FF1, GG1)
+	ROUND(22, R10, R11, R8, R9, R14, R15, R12, R13, 0x629EA1E7, FF1, GG1)
+	ROUND(23, R9, R10, R11, R8, R13, R14, R15, R12, 0xC53D43CE, FF1, GG1)
+	ROUND(24, R8, R9, R10, R11, R12, R13, R14, R15, 0x8A7A879D, FF1, GG1)
+	ROUND(25, R11, R8, R9, R10, R15, R12, R13, R14, 0x14F50F3B, FF1, GG1)
+	ROUND(26, R10, R11, R8, R9, R14, R15, R12, R13, 0x29EA1E76, FF1, GG1)
+	ROUND(27, R9, R10, R11, R8, R13, R14, R15, R12, 0x53D43CEC, FF1, GG1)
+	ROUND(28, R8, R9, R10, R11, R12, R13, R14, R15, 0xA7A879D8, FF1, GG1)
+	ROUND(29, R11, R8, R9, R10, R15, R12, R13, R14, 0x4F50F3B1, FF1, GG1)
+	ROUND(30, R10, R11, R8, R9, R14, R15, R12, R13, 0x9EA1E762, FF1, GG1)
+	ROUND(31, R9, R10, R11, R8, R13, R14, R15, R12, 0x3D43CEC5, FF1, GG1)
+	ROUND(32, R8, R9, R10, R11, R12, R13, R14, R15, 0x7A879D8A, FF1, GG1)
+	ROUND(33, R11, R8, R9, R10, R15, R12, R13, R14, 0xF50F3B14, FF1, GG1)
+	ROUND(34, R10, R11, R8, R9, R14, R15, R12, R13, 0xEA1E7629, FF1, GG1)
+	ROUND(35, R9, R10, R11, R8, R13, R14, R15, R12, 0xD43CEC53, FF1, GG1)
+	ROUND(36, R8, R9, R10, R11, R12, R13, R14, R15, 0xA879D8A7, FF1, GG1)
+	ROUND(37, R11, R8, R9, R10, R15, R12, R13, R14, 0x50F3B14F, FF1, GG1)
+	ROUND(38, R10, R11, R8, R9, R14, R15, R12, R13, 0xA1E7629E, FF1, GG1)
+	ROUND(39, R9, R10, R11, R8, R13, R14, R15, R12, 0x43CEC53D, FF1, GG1)
+	ROUND(40, R8, R9, R10, R11, R12, R13, R14, R15, 0x879D8A7A, FF1, GG1)
+	ROUND(41, R11, R8, R9, R10, R15, R12, R13, R14, 0x0F3B14F5, FF1, GG1)
+	ROUND(42, R10, R11, R8, R9, R14, R15, R12, R13, 0x1E7629EA, FF1, GG1)
+	ROUND(43, R9, R10, R11, R8, R13, R14, R15, R12, 0x3CEC53D4, FF1, GG1)
+	ROUND(44, R8, R9, R10, R11, R12, R13, R14, R15, 0x79D8A7A8, FF1, GG1)
+	ROUND(45, R11, R8, R9, R10, R15, R12, R13, R14, 0xF3B14F50, FF1, GG1)
+	ROUND(46, R10, R11, R8, R9, R14, R15, R12, R13, 0xE7629EA1, FF1, GG1)
+	ROUND(47, R9, R10, R11, R8, R13, R14, R15, R12, 0xCEC53D43, FF1, GG1)
+	ROUND(48, R8, R9, R10, R11, R12, R13, R14, R15, 0x9D8A7A87, FF1, GG1)
+	ROUND(49, R11, R8, R9, R10, R15, R12, R13, R14, 0x3B14F50F, FF1, GG1)
+	ROUND(50, R10, R11, R8, R9, R14, R15, R12, R13, 0x7629EA1E, FF1, GG1)
+	ROUND(51, R9, R10, R11, R8, R13, R14, R15, R12, 0xEC53D43C, FF1, GG1)
+	ROUND(52, R8, R9, R10, R11, R12, R13, R14, R15, 0xD8A7A879, FF1, GG1)
+	ROUND(53, R11, R8, R9, R10, R15, R12, R13, R14, 0xB14F50F3, FF1, GG1)
+	ROUND(54, R10, R11, R8, R9, R14, R15, R12, R13, 0x629EA1E7, FF1, GG1)
+	ROUND(55, R9, R10, R11, R8, R13, R14, R15, R12, 0xC53D43CE, FF1, GG1)
+	ROUND(56, R8, R9, R10, R11, R12, R13, R14, R15, 0x8A7A879D, FF1, GG1)
+	ROUND(57, R11, R8, R9, R10, R15, R12, R13, R14, 0x14F50F3B, FF1, GG1)
+	ROUND(58, R10, R11, R8, R9, R14, R15, R12, R13, 0x29EA1E76, FF1, GG1)
+	ROUND(59, R9, R10, R11, R8, R13, R14, R15, R12, 0x53D43CEC, FF1, GG1)
+	ROUND(60, R8, R9, R10, R11, R12, R13, R14, R15, 0xA7A879D8, FF1, GG1)
+	ROUND(61, R11, R8, R9, R10, R15, R12, R13, R14, 0x4F50F3B1, FF1, GG1)
+	ROUND(62, R10, R11, R8, R9, R14, R15, R12, R13, 0x9EA1E762, FF1, GG1)
+	ROUND(63, R9, R10, R11, R8, R13, R14, R15, R12, 0x3D43CEC5, FF1, GG1)
+
+	MOVQ h+0(FP), DI
+	XORL 0(DI), R8
+	XORL 4(DI), R9
+	XORL 8(DI), R10
+	XORL 12(DI), R11
+	XORL 16(DI), R12
+	XORL 20(DI), R13
+	XORL 24(DI), R14
+	XORL 28(DI), R15
+	MOVL R8, 0(DI)
+	MOVL R9, 4(DI)
+	MOVL R10, 8(DI)
+	MOVL R11, 12(DI)
+	MOVL R12, 16(DI)
+	MOVL R13, 20(DI)
+	MOVL R14, 24(DI)
+	MOVL R15, 28(DI)
+
+	ADDQ $64, SI
+	CMPQ SI, 288(SP)
+	JB   loop
+
+	VZEROUPPER
+
+done:
+	RET
@@ -0,0 +1,97 @@
+package sm3
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"io"
+)
+
+// NewHMAC returns HMAC-SM3 keyed with 'key'.
+func NewHMAC(key []byte) hash.Hash {
+	return hmac.New(New, key)
+}
+
+// HKDFExtract returns a pseudorandom key computed from the input keying
+// material 'secret' and optional 'salt' with HKDF-SM3 (RFC 5869, 2.2).
+func HKDFExtract(secret, salt []byte) []byte {
+	if salt == nil {
+		salt = make([]byte, Size)
+	}
+	h := NewHMAC(salt)
+	h.Write(secret)
+	return h.Sum(nil)
+}
+
+// hkdf implements HKDF-Expand as io.Reader.
+type hkdf struct {
+	expander hash.Hash
+	info     []byte
+	counter  byte
+	prev     []byte
+	buf      []byte
+}
+
+func (f *hkdf) Read(p []byte) (int, error) {
+	// HKDF output length is limited to 255*HashLen
+	need := len(p)
+	remains := len(f.buf) + int(255-f.counter+1)*Size
+	if remains < need {
+		return 0, errors.New("sm3: HKDF entropy limit reached")
+	}
+
+	n := copy(p, f.buf)
+	p = p[n:]
+	for len(p) > 0 {
+		f.expander.Reset()
+		f.expander.Write(f.prev)
+		f.expander.Write(f.info)
+		f.expander.Write([]byte{f.counter})
+		f.prev = f.expander.Sum(f.prev[:0])
+		f.counter++
+
+		f.buf = f.prev
+		n = copy(p, f.buf)
+		p = p[n:]
+	}
+	f.buf = f.buf[n:]
+	return need, nil
+}
+
+// HKDFExpand returns a reader, from which keys can be read, using the
+// pseudorandom key 'prk' and optional 'info' with HKDF-SM3 (RFC 5869,
+// 2.3). At most 255*Size bytes can be read.
+func HKDFExpand(prk, info []byte) io.Reader {
+	return &hkdf{expander: NewHMAC(prk), info: info, counter: 1}
+}
+
+// HKDF returns a reader, from which keys can be read, using the given
+// 'secret', 'salt' and 'info' with HKDF-SM3. Both salt and info are
+// optional. At most 255*Size bytes can be read.
+func HKDF(secret, salt, info []byte) io.Reader {
+	return HKDFExpand(HKDFExtract(secret, salt), info)
+}
+
+// KDF derives 'klen' bytes of key from shared secret 'z', with the key
+// derivation function defined in GM/T 0003.3-2012, 5.4.3. It is used by
+// SM2 key exchange and encryption. The output is
+// SM3(z || ct_1) || SM3(z || ct_2) || ... truncated to 'klen' bytes, where
+// ct_i is 32-bit big-endian counter starting from 1. KDF returns nil if
+// 'klen' is negative.
+func KDF(z []byte, klen int) []byte {
+	var ct [4]byte
+	if klen < 0 {
+		return nil
+	}
+	out := make([]byte, 0, klen+Size)
+	d := new(digest)
+	for i := uint32(1); len(out) < klen; i++ {
+		d.Reset()
+		d.Write(z)
+		store32Be(ct[:], i)
+		d.Write(ct[:])
+		sum := d.checkSum()
+		out = append(out, sum[:]...)
+	}
+	return out[:klen]
+}
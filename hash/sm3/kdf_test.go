@@ -0,0 +1,146 @@
+package sm3
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+func rangeBytes(from, to int) []byte {
+	b := make([]byte, 0, to-from)
+	for i := from; i < to; i++ {
+		b = append(b, byte(i))
+	}
+	return b
+}
+
+// Inputs from RFC 4231, section 4. Outputs were computed with OpenSSL.
+var hmacTests = []struct {
+	key  []byte
+	data []byte
+	out  string
+}{
+	{bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+		"51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70"},
+	{[]byte("Jefe"), []byte("what do ya want for nothing?"),
+		"2e87f1d16862e6d964b50a5200bf2b10b764faa9680a296a2405f24bec39f882"},
+	{bytes.Repeat([]byte{0xaa}, 20), bytes.Repeat([]byte{0xdd}, 50),
+		"dd9421e1c725bdf52ec1aa34edadb3c97f5951a83a2fa93f73a7902bc1dcc777"},
+	{rangeBytes(1, 26), bytes.Repeat([]byte{0xcd}, 50),
+		"b57c79be03472aeb8cada581dea332cb2ba83d19cb1b052dd07194def75fb8cd"},
+	{bytes.Repeat([]byte{0xaa}, 131), []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+		"b4fd844e13342002f0b2e0690ea7741f1497d993a70494cea601e657bedf67a0"},
+	{bytes.Repeat([]byte{0xaa}, 131), []byte("This is a test using a larger than block-size key and a larger " +
+		"than block-size data. The key needs to be hashed before being used by the HMAC algorithm."),
+		"5acbdeb0c8c1ef3a99088fe51c0a1d5f4e1c175935f016aee74eb8056db18acb"},
+}
+
+func TestHMAC(t *testing.T) {
+	for i, v := range hmacTests {
+		exp := test.FromHex(v.out)
+		h := NewHMAC(v.key)
+		if h.Size() != Size || h.BlockSize() != BlockSize {
+			t.Errorf("#%d: wrong sizes", i)
+		}
+		h.Write(v.data[:len(v.data)/2])
+		h.Sum(nil)
+		h.Write(v.data[len(v.data)/2:])
+		if out := h.Sum([]byte{0xAA}); out[0] != 0xAA || !bytes.Equal(out[1:], exp) {
+			t.Errorf("#%d: got %X, want %X", i, out[1:], exp)
+		}
+		h.Reset()
+		h.Write(v.data)
+		if out := h.Sum(nil); !bytes.Equal(out, exp) {
+			t.Errorf("#%d: after Reset: got %X, want %X", i, out, exp)
+		}
+	}
+}
+
+// Inputs from RFC 5869, appendix A.1-A.3. Outputs were computed with
+// OpenSSL.
+var hkdfTests = []struct {
+	ikm  []byte
+	salt []byte
+	info []byte
+	prk  string
+	okm  string
+}{
+	{bytes.Repeat([]byte{0x0b}, 22), rangeBytes(0x00, 0x0d), rangeBytes(0xf0, 0xfa),
+		"e0d6f7b0bd056327b7659f1f39ad850561fbcf4fb10fb58e88eafa55cf7cd01e",
+		"c69fe91b7aaee2dd5718d72dcaee0cce93f1b8e41f792da51261b6a517e68b36ed2c595572b01dfa359b"},
+	{rangeBytes(0x00, 0x50), rangeBytes(0x60, 0xb0), rangeBytes(0xb0, 0x100),
+		"1a43a7fedb2d111eb33babd0d256c272aa3262cdb12e6b43d4321ae8888485d5",
+		"c1226236bbdefa7921f9febe27b864f33e449201b436d8844ea53f58170dd6426defbd22ed1f3c5960f3" +
+			"5523e62e3b6c0d657f2c61893436f539013199bfaef25aafd1e7726ede927623a9f5cbb8885c7e5d"},
+	{bytes.Repeat([]byte{0x0b}, 22), nil, nil,
+		"004fc37143377d072d74e82ff480e8d7937ec607411bc1ec65dd34401871ff9c",
+		"c8c91a38ae2fb3b023a7c38ce9f0748f28230d59b6b950ba3ba949bf0d713a5774815778801741cb2034"},
+}
+
+func TestHKDF(t *testing.T) {
+	for i, v := range hkdfTests {
+		prk := HKDFExtract(v.ikm, v.salt)
+		if !bytes.Equal(prk, test.FromHex(v.prk)) {
+			t.Errorf("#%d: PRK: got %X, want %s", i, prk, v.prk)
+		}
+
+		exp := test.FromHex(v.okm)
+		out := make([]byte, len(exp))
+		if _, err := io.ReadFull(HKDF(v.ikm, v.salt, v.info), out); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: OKM: got %X, want %X", i, out, exp)
+		}
+
+		// Read in small pieces
+		r := HKDFExpand(prk, v.info)
+		for j := 0; j < len(out); j += 5 {
+			end := j + 5
+			if end > len(out) {
+				end = len(out)
+			}
+			r.Read(out[j:end])
+		}
+		if !bytes.Equal(out, exp) {
+			t.Errorf("#%d: OKM read in pieces: got %X, want %X", i, out, exp)
+		}
+	}
+}
+
+func TestHKDFLimit(t *testing.T) {
+	r := HKDF([]byte("secret"), nil, nil)
+	out := make([]byte, 255*Size)
+	if _, err := io.ReadFull(r, out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(out[:1]); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// Outputs were computed with an independent implementation.
+func TestKDF(t *testing.T) {
+	for i, v := range []struct {
+		z   []byte
+		out string
+	}{
+		{[]byte("abc"), "fe1ea80dac6f100c33537bd24619ec7c72a1e8"},
+		{rangeBytes(0, 64), "c3e5cfe48b9da30523c65df3b189227188a89ac9057b739bb779f028e4afe606" +
+			"e9df98cf02023b778579bdf48e7002306ba21850d002971e209d2e785d3518c9113608e38a6d"},
+		{nil, "88c0cffa4c713446a03f1fff1630aa6353bdb53e2a9272146be7a82fde06afa3"},
+	} {
+		exp := test.FromHex(v.out)
+		if out := KDF(v.z, len(exp)); !bytes.Equal(out, exp) {
+			t.Errorf("#%d: got %X, want %X", i, out, exp)
+		}
+	}
+	if KDF([]byte("abc"), -1) != nil {
+		t.Error("KDF with negative length should return nil")
+	}
+	if len(KDF([]byte("abc"), 0)) != 0 {
+		t.Error("expected empty output")
+	}
+}
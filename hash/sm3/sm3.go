@@ -4,6 +4,7 @@
 package sm3
 
 import (
+	"errors"
 	"hash"
 )
 
@@ -31,6 +32,15 @@ type digest struct {
 	b   [BlockSize]byte
 }
 
+const (
+	// Serialized state starts with a magic string followed by version
+	// of the format.
+	magic = "sm3\x01"
+	// Length of serialized state: magic, chaining value, buffer and
+	// length of the input.
+	marshaledSize = len(magic) + 8*4 + BlockSize + 8
+)
+
 func New() hash.Hash {
 	d := new(digest)
 	d.Reset()
@@ -55,7 +65,42 @@ func (d *digest) Reset() {
 	d.len = 0
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (d *digest) MarshalBinary() ([]byte, error) {
+	b := make([]byte, marshaledSize)
+	copy(b, magic)
+	off := len(magic)
+	for i := range d.h {
+		store32Be(b[off+4*i:], d.h[i])
+	}
+	off += 8 * 4
+	copy(b[off:], d.b[:])
+	off += BlockSize
+	store64Be(b[off:], d.len)
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (d *digest) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic) || string(b[:len(magic)]) != magic {
+		return errors.New("sm3: invalid hash state identifier")
+	}
+	if len(b) != marshaledSize {
+		return errors.New("sm3: invalid hash state size")
+	}
+	b = b[len(magic):]
+	for i := range d.h {
+		d.h[i] = loadBe32(b[4*i:])
+	}
+	b = b[8*4:]
+	copy(d.b[:], b[:BlockSize])
+	b = b[BlockSize:]
+	d.len = uint64(loadBe32(b))<<32 | uint64(loadBe32(b[4:]))
+	return nil
+}
+
 func (d *digest) Write(input []byte) (nn int, err error) {
+	nn = len(input)
 
 	// current possition in the buffer
 	idx := int(d.len & uint64((d.BlockSize() - 1)))
@@ -76,35 +121,40 @@ func (d *digest) Write(input []byte) (nn int, err error) {
 
 	// this eventually could be done in d.compress
 	copy(d.b[:], input[nblocks*d.BlockSize():])
-	return len(input), nil
+	return
 }
 
+// Sum appends the current digest to 'in' and returns the resulting
+// slice. It doesn't change the underlying state.
 func (d *digest) Sum(in []byte) []byte {
-	var output [32]byte
-
 	// Copy context so that caller can keep updating
 	dc := *d
+	output := dc.checkSum()
+	return append(in, output[:]...)
+}
 
-	dc.Write(in)
+// checkSum pads the input and returns the digest.
+func (d *digest) checkSum() [Size]byte {
+	var output [Size]byte
 
-	idx := int(dc.len & uint64(dc.BlockSize()-1))
-	for i := idx + 1; i < len(dc.b); i++ {
-		dc.b[i] = 0
+	idx := int(d.len & uint64(d.BlockSize()-1))
+	for i := idx + 1; i < len(d.b); i++ {
+		d.b[i] = 0
 	}
-	dc.b[idx] = 0x80
+	d.b[idx] = 0x80
 	if idx >= 56 {
-		dc.compress(dc.b[:], 1)
-		for i := range dc.b {
-			dc.b[i] = 0
+		d.compress(d.b[:], 1)
+		for i := range d.b {
+			d.b[i] = 0
 		}
 	}
 
 	// add total bits
-	store64Be(dc.b[56:], dc.len*8)
+	store64Be(d.b[56:], d.len*8)
 
-	dc.compress(dc.b[:], 1)
+	d.compress(d.b[:], 1)
 	for i := 0; i < Size/4; i++ {
-		store32Be(output[4*i:], dc.h[i])
+		store32Be(output[4*i:], d.h[i])
 	}
-	return output[:]
+	return output
 }
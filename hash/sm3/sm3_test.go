@@ -1,6 +1,8 @@
 package sm3
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/hex"
 	"testing"
 )
@@ -540,7 +542,8 @@ func TestSplit(t *testing.T) {
 	d.Init()
 	d.Write(in[:8])
 	d.Write(in[8:16])
-	copy(out[:], d.Sum(in[16:]))
+	d.Write(in[16:])
+	copy(out[:], d.Sum(nil))
 
 	if out != exp {
 		t.Error("Wrong result")
@@ -566,13 +569,113 @@ func TestCallSumMultipleTimes(t *testing.T) {
 	d.Write(in[:10])
 
 	d.Sum(nil) // That's done on purpose
-	copy(out[:], d.Sum(in[10:]))
+	d.Write(in[10:])
+	copy(out[:], d.Sum(nil))
 
 	if out != exp {
 		t.Error("Wrong result")
 	}
 }
 
+func TestMarshalResume(t *testing.T) {
+	msg := make([]byte, 300)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	h := New()
+	h.Write(msg)
+	exp := h.Sum(nil)
+
+	for _, n := range []int{0, 1, 55, 56, 63, 64, 65, 128, 299, 300} {
+		h := New()
+		h.Write(msg[:n])
+		b, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := New()
+		// Make sure state is overwritten
+		r.Write(msg[:77])
+		if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		r.Write(msg[n:])
+		if out := r.Sum(nil); !bytes.Equal(out, exp) {
+			t.Errorf("resumed after %d bytes: got %X, want %X", n, out, exp)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	b, _ := New().(encoding.BinaryMarshaler).MarshalBinary()
+	for _, v := range [][]byte{
+		nil,
+		[]byte("sm3"),
+		[]byte("sha\x03"),
+		b[:len(b)-1],
+		append(b, 0),
+	} {
+		if err := New().(encoding.BinaryUnmarshaler).UnmarshalBinary(v); err == nil {
+			t.Errorf("expected error for %X", v)
+		}
+	}
+}
+
+func checkSumX(t *testing.T, name string, in [][]byte, out [][Size]byte) {
+	for j := range in {
+		d := New()
+		d.Write(in[j])
+		if exp := d.Sum(nil); !bytes.Equal(out[j][:], exp) {
+			t.Fatalf("%s: lane %d, len %d: got %X, want %X", name, j, len(in[j]), out[j], exp)
+		}
+	}
+}
+
+func TestSumX(t *testing.T) {
+	msg := make([]byte, 300)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	// Lanes of various lengths, covering padding into one and two blocks
+	for n := 0; n < 200; n++ {
+		var in4 [4][]byte
+		var in8 [8][]byte
+		for j := range in8 {
+			in8[j] = msg[j : j+(n*(j+1))%(len(msg)-j)]
+		}
+		copy(in4[:], in8[:])
+
+		out4 := SumX4(in4)
+		checkSumX(t, "SumX4", in4[:], out4[:])
+		out8 := SumX8(in8)
+		checkSumX(t, "SumX8", in8[:], out8[:])
+
+		// Generic implementation
+		sumX(in4[:], out4[:], blockX4Generic)
+		checkSumX(t, "X4 generic", in4[:], out4[:])
+		sumX(in8[:], out8[:], blockX8Generic)
+		checkSumX(t, "X8 generic", in8[:], out8[:])
+	}
+}
+
+// Compression function selected at runtime must match the generic one
+func TestBlock(t *testing.T) {
+	msg := make([]byte, 16*BlockSize)
+	for i := range msg {
+		msg[i] = byte(i * 7)
+	}
+	for n := 0; n <= 16; n++ {
+		h1 := [8]uint32{init0, init1, init2, init3, init4, init5, init6, init7}
+		h2 := h1
+		block(&h1, msg[:n*BlockSize])
+		blockGeneric(&h2, msg[:n*BlockSize])
+		if h1 != h2 {
+			t.Errorf("%d blocks: got %08X, want %08X", n, h1, h2)
+		}
+	}
+}
+
 /* ------------------ Benchmarks ------------------- */
 var bench = New()
 var buf = make([]byte, 8192)
@@ -598,3 +701,49 @@ func BenchmarkHash1K(b *testing.B) {
 func BenchmarkHash8K(b *testing.B) {
 	benchmarkSize(b, 8192)
 }
+
+func BenchmarkBlockGeneric8K(b *testing.B) {
+	var h [8]uint32
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		blockGeneric(&h, buf)
+	}
+}
+
+func benchmarkSumX8(b *testing.B, size int) {
+	var in [8][]byte
+	for j := range in {
+		in[j] = buf[:size]
+	}
+	b.SetBytes(int64(8 * size))
+	for i := 0; i < b.N; i++ {
+		SumX8(in)
+	}
+}
+
+func benchmarkSumX4(b *testing.B, size int) {
+	var in [4][]byte
+	for j := range in {
+		in[j] = buf[:size]
+	}
+	b.SetBytes(int64(4 * size))
+	for i := 0; i < b.N; i++ {
+		SumX4(in)
+	}
+}
+
+func BenchmarkSumX4_1K(b *testing.B) {
+	benchmarkSumX4(b, 1024)
+}
+
+func BenchmarkSumX4_8K(b *testing.B) {
+	benchmarkSumX4(b, 8192)
+}
+
+func BenchmarkSumX8_1K(b *testing.B) {
+	benchmarkSumX8(b, 1024)
+}
+
+func BenchmarkSumX8_8K(b *testing.B) {
+	benchmarkSumX8(b, 8192)
+}
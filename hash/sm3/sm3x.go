@@ -0,0 +1,113 @@
+package sm3
+
+// Multi-buffer SM3: SumX4 and SumX8 compute digests of four or eight
+// independent messages. Compression function is applied to one block of
+// each message at once, on CPUs supporting AVX2 it is computed for all
+// messages in parallel.
+
+// Functions compressing one block of each of 4 and 8 lanes. Word i of
+// the chaining value and of the message block of lane j is stored at
+// index n*i+j of h and w respectively, where n is the number of lanes.
+// Implementation is selected at runtime.
+var (
+	blockX4 = blockX4Generic
+	blockX8 = blockX8Generic
+)
+
+func blockX4Generic(h *[64]uint32, w *[128]uint32) {
+	blockXGeneric(4, h, w)
+}
+
+func blockX8Generic(h *[64]uint32, w *[128]uint32) {
+	blockXGeneric(8, h, w)
+}
+
+// blockXGeneric compresses one block of each of n lanes, one by one.
+func blockXGeneric(n int, h *[64]uint32, w *[128]uint32) {
+	var d digest
+	var b [BlockSize]byte
+	for j := 0; j < n; j++ {
+		for i := range d.h {
+			d.h[i] = h[n*i+j]
+		}
+		for i := 0; i < BlockSize/4; i++ {
+			store32Be(b[4*i:], w[n*i+j])
+		}
+		d.compress(b[:], 1)
+		for i := range d.h {
+			h[n*i+j] = d.h[i]
+		}
+	}
+}
+
+// sumX computes digests of len(in) messages with function block, which
+// compresses one block of each message. Messages may differ in length,
+// lanes which are already finished process zero blocks and their results
+// are ignored.
+func sumX(in [][]byte, out [][Size]byte, block func(h *[64]uint32, w *[128]uint32)) {
+	var h [64]uint32
+	var w [128]uint32
+	// Padded last one or two blocks of each message
+	var tail [8][2 * BlockSize]byte
+	var full, total [8]int
+	var zero [BlockSize]byte
+
+	n := len(in)
+	iv := [8]uint32{init0, init1, init2, init3, init4, init5, init6, init7}
+	blocks := 0
+	for j := 0; j < n; j++ {
+		for i := range iv {
+			h[n*i+j] = iv[i]
+		}
+
+		full[j] = len(in[j]) / BlockSize
+		rem := copy(tail[j][:], in[j][full[j]*BlockSize:])
+		tail[j][rem] = 0x80
+		pad := BlockSize
+		if rem >= BlockSize-8 {
+			pad = 2 * BlockSize
+		}
+		store64Be(tail[j][pad-8:], uint64(len(in[j]))*8)
+		total[j] = full[j] + pad/BlockSize
+		if total[j] > blocks {
+			blocks = total[j]
+		}
+	}
+
+	for k := 0; k < blocks; k++ {
+		for j := 0; j < n; j++ {
+			var b []byte
+			switch {
+			case k < full[j]:
+				b = in[j][k*BlockSize:]
+			case k < total[j]:
+				b = tail[j][(k-full[j])*BlockSize:]
+			default:
+				b = zero[:]
+			}
+			for i := 0; i < BlockSize/4; i++ {
+				w[n*i+j] = loadBe32(b[4*i:])
+			}
+		}
+		block(&h, &w)
+		for j := 0; j < n; j++ {
+			if k == total[j]-1 {
+				for i := 0; i < Size/4; i++ {
+					store32Be(out[j][4*i:], h[n*i+j])
+				}
+			}
+		}
+	}
+}
+
+// SumX4 returns SM3 digests of four messages, which may differ in length.
+func SumX4(in [4][]byte) (out [4][Size]byte) {
+	sumX(in[:], out[:], blockX4)
+	return
+}
+
+// SumX8 returns SM3 digests of eight messages, which may differ in length.
+func SumX8(in [8][]byte) (out [8][Size]byte) {
+	sumX(in[:], out[:], blockX8)
+	return
+}
@@ -0,0 +1,20 @@
+// +build amd64,!noasm
+
+package sm3
+
+import "github.com/henrydcase/nobs/utils"
+
+// Those functions are implemented in sm3x_amd64.s.
+
+//go:noescape
+func blockX4AVX2(h *[64]uint32, w *[128]uint32)
+
+//go:noescape
+func blockX8AVX2(h *[64]uint32, w *[128]uint32)
+
+func init() {
+	if utils.X86.HasAVX2 {
+		blockX4 = blockX4AVX2
+		blockX8 = blockX8AVX2
+	}
+}
@@ -0,0 +1,547 @@
+// +build amd64,!noasm
+
+// SM3 compression function computed on 4 or 8 independent states at once,
+// with AVX2 instructions. Word i of the state (and of the message block)
+// of lane j is stored at index n*i+j, where n is the number of lanes, so
+// that each vector load gets the same word of all states. Both functions
+// share the code below, T0-T4 name temporary registers of the right size.
+
+#include "textflag.h"
+
+DATA ·constsX<>+0(SB)/4, $0x79CC4519
+DATA ·constsX<>+4(SB)/4, $0xF3988A32
+DATA ·constsX<>+8(SB)/4, $0xE7311465
+DATA ·constsX<>+12(SB)/4, $0xCE6228CB
+DATA ·constsX<>+16(SB)/4, $0x9CC45197
+DATA ·constsX<>+20(SB)/4, $0x3988A32F
+DATA ·constsX<>+24(SB)/4, $0x7311465E
+DATA ·constsX<>+28(SB)/4, $0xE6228CBC
+DATA ·constsX<>+32(SB)/4, $0xCC451979
+DATA ·constsX<>+36(SB)/4, $0x988A32F3
+DATA ·constsX<>+40(SB)/4, $0x311465E7
+DATA ·constsX<>+44(SB)/4, $0x6228CBCE
+DATA ·constsX<>+48(SB)/4, $0xC451979C
+DATA ·constsX<>+52(SB)/4, $0x88A32F39
+DATA ·constsX<>+56(SB)/4, $0x11465E73
+DATA ·constsX<>+60(SB)/4, $0x228CBCE6
+DATA ·constsX<>+64(SB)/4, $0x9D8A7A87
+DATA ·constsX<>+68(SB)/4, $0x3B14F50F
+DATA ·constsX<>+72(SB)/4, $0x7629EA1E
+DATA ·constsX<>+76(SB)/4, $0xEC53D43C
+DATA ·constsX<>+80(SB)/4, $0xD8A7A879
+DATA ·constsX<>+84(SB)/4, $0xB14F50F3
+DATA ·constsX<>+88(SB)/4, $0x629EA1E7
+DATA ·constsX<>+92(SB)/4, $0xC53D43CE
+DATA ·constsX<>+96(SB)/4, $0x8A7A879D
+DATA ·constsX<>+100(SB)/4, $0x14F50F3B
+DATA ·constsX<>+104(SB)/4, $0x29EA1E76
+DATA ·constsX<>+108(SB)/4, $0x53D43CEC
+DATA ·constsX<>+112(SB)/4, $0xA7A879D8
+DATA ·constsX<>+116(SB)/4, $0x4F50F3B1
+DATA ·constsX<>+120(SB)/4, $0x9EA1E762
+DATA ·constsX<>+124(SB)/4, $0x3D43CEC5
+DATA ·constsX<>+128(SB)/4, $0x7A879D8A
+DATA ·constsX<>+132(SB)/4, $0xF50F3B14
+DATA ·constsX<>+136(SB)/4, $0xEA1E7629
+DATA ·constsX<>+140(SB)/4, $0xD43CEC53
+DATA ·constsX<>+144(SB)/4, $0xA879D8A7
+DATA ·constsX<>+148(SB)/4, $0x50F3B14F
+DATA ·constsX<>+152(SB)/4, $0xA1E7629E
+DATA ·constsX<>+156(SB)/4, $0x43CEC53D
+DATA ·constsX<>+160(SB)/4, $0x879D8A7A
+DATA ·constsX<>+164(SB)/4, $0x0F3B14F5
+DATA ·constsX<>+168(SB)/4, $0x1E7629EA
+DATA ·constsX<>+172(SB)/4, $0x3CEC53D4
+DATA ·constsX<>+176(SB)/4, $0x79D8A7A8
+DATA ·constsX<>+180(SB)/4, $0xF3B14F50
+DATA ·constsX<>+184(SB)/4, $0xE7629EA1
+DATA ·constsX<>+188(SB)/4, $0xCEC53D43
+DATA ·constsX<>+192(SB)/4, $0x9D8A7A87
+DATA ·constsX<>+196(SB)/4, $0x3B14F50F
+DATA ·constsX<>+200(SB)/4, $0x7629EA1E
+DATA ·constsX<>+204(SB)/4, $0xEC53D43C
+DATA ·constsX<>+208(SB)/4, $0xD8A7A879
+DATA ·constsX<>+212(SB)/4, $0xB14F50F3
+DATA ·constsX<>+216(SB)/4, $0x629EA1E7
+DATA ·constsX<>+220(SB)/4, $0xC53D43CE
+DATA ·constsX<>+224(SB)/4, $0x8A7A879D
+DATA ·constsX<>+228(SB)/4, $0x14F50F3B
+DATA ·constsX<>+232(SB)/4, $0x29EA1E76
+DATA ·constsX<>+236(SB)/4, $0x53D43CEC
+DATA ·constsX<>+240(SB)/4, $0xA7A879D8
+DATA ·constsX<>+244(SB)/4, $0x4F50F3B1
+DATA ·constsX<>+248(SB)/4, $0x9EA1E762
+DATA ·constsX<>+252(SB)/4, $0x3D43CEC5
+GLOBL ·constsX<>(SB), RODATA|NOPTR, $256
+
+// Rotates each 32-bit word of 'src' left by 'n' bits into 'dst', 't' is
+// a temporary register
+#define ROL(n, src, dst, t) \
+	VPSLLD $(n), src, t; \
+	VPSRLD $(32-(n)), src, dst; \
+	VPOR t, dst, dst
+
+// Computes message word W[j] from W[j-16], W[j-9], W[j-3], W[j-13]
+// and W[j-6]:
+// W[j] = P1(W[j-16] ^ W[j-9] ^ (W[j-3] <<< 15)) ^ (W[j-13] <<< 7) ^ W[j-6]
+#define EXPAND(w16, w9, w3, w13, w6, wj) \
+	VMOVDQU w16, T0; \
+	VPXOR w9, T0, T0; \
+	VMOVDQU w3, T1; \
+	ROL(15, T1, T2, T3); \
+	VPXOR T2, T0, T0; \
+	ROL(15, T0, T1, T3); \
+	ROL(23, T0, T2, T3); \
+	VPXOR T1, T0, T0; \
+	VPXOR T2, T0, T0; \
+	VMOVDQU w13, T1; \
+	ROL(7, T1, T2, T3); \
+	VPXOR T2, T0, T0; \
+	VPXOR w6, T0, T0; \
+	VMOVDQU T0, wj
+
+// First part of the round: T2 = SS1, T0 = SS2
+#define SS(a, e, tj) \
+	ROL(12, a, T0, T1); \
+	VPBROADCASTD tj, T2; \
+	VPADDD e, T0, T1; \
+	VPADDD T2, T1, T1; \
+	ROL(7, T1, T2, T3); \
+	VPXOR T2, T0, T0
+
+// Last part of the round. Expects T1 = FF(a, b, c) + SS2, T0 = GG(e, f, g)
+// and T2 = SS1. Stores TT1 in d and P0(TT2) in h, rotates b and f.
+#define FINISH(b, d, f, h, wj, wj4) \
+	VPADDD d, T1, T1; \
+	VMOVDQU wj, T3; \
+	VPXOR wj4, T3, T4; \
+	VPADDD T4, T1, d; \
+	VPADDD h, T0, T0; \
+	VPADDD T2, T0, T0; \
+	VPADDD T3, T0, T0; \
+	ROL(9, T0, T1, T4); \
+	ROL(17, T0, T2, T4); \
+	VPXOR T1, T0, T0; \
+	VPXOR T2, T0, h; \
+	ROL(9, b, b, T4); \
+	ROL(19, f, f, T4)
+
+// Round j, 0 <= j < 16. wj and wj4 are W[j] and W[j+4], tj is the
+// round constant.
+#define ROUND0(a, b, c, d, e, f, g, h, wj, wj4, tj) \
+	SS(a, e, tj); \
+	VPXOR b, a, T1; \
+	VPXOR c, T1, T1; \
+	VPADDD T0, T1, T1; \
+	VPXOR f, e, T0; \
+	VPXOR g, T0, T0; \
+	FINISH(b, d, f, h, wj, wj4)
+
+// Round j, 16 <= j < 64
+#define ROUND1(a, b, c, d, e, f, g, h, wj, wj4, tj) \
+	SS(a, e, tj); \
+	VPAND b, a, T1; \
+	VPOR b, a, T3; \
+	VPAND c, T3, T3; \
+	VPOR T3, T1, T1; \
+	VPADDD T0, T1, T1; \
+	VPAND f, e, T0; \
+	VPANDN g, e, T4; \
+	VPOR T4, T0, T0; \
+	FINISH(b, d, f, h, wj, wj4)
+
+#define T0 Y8
+#define T1 Y9
+#define T2 Y10
+#define T3 Y11
+#define T4 Y12
+
+// func blockX8AVX2(h *[64]uint32, w *[128]uint32)
+TEXT ·blockX8AVX2(SB), 0, $2176-16
+	MOVQ h+0(FP), DI
+	MOVQ w+8(FP), SI
+	LEAQ ·constsX<>(SB), BX
+
+	// Message expansion, W[0..67] are kept on the stack
+	VMOVDQU 0(SI), T0
+	VMOVDQU T0, 0(SP)
+	VMOVDQU 32(SI), T0
+	VMOVDQU T0, 32(SP)
+	VMOVDQU 64(SI), T0
+	VMOVDQU T0, 64(SP)
+	VMOVDQU 96(SI), T0
+	VMOVDQU T0, 96(SP)
+	VMOVDQU 128(SI), T0
+	VMOVDQU T0, 128(SP)
+	VMOVDQU 160(SI), T0
+	VMOVDQU T0, 160(SP)
+	VMOVDQU 192(SI), T0
+	VMOVDQU T0, 192(SP)
+	VMOVDQU 224(SI), T0
+	VMOVDQU T0, 224(SP)
+	VMOVDQU 256(SI), T0
+	VMOVDQU T0, 256(SP)
+	VMOVDQU 288(SI), T0
+	VMOVDQU T0, 288(SP)
+	VMOVDQU 320(SI), T0
+	VMOVDQU T0, 320(SP)
+	VMOVDQU 352(SI), T0
+	VMOVDQU T0, 352(SP)
+	VMOVDQU 384(SI), T0
+	VMOVDQU T0, 384(SP)
+	VMOVDQU 416(SI), T0
+	VMOVDQU T0, 416(SP)
+	VMOVDQU 448(SI), T0
+	VMOVDQU T0, 448(SP)
+	VMOVDQU 480(SI), T0
+	VMOVDQU T0, 480(SP)
+	EXPAND(0(SP), 224(SP), 416(SP), 96(SP), 320(SP), 512(SP))
+	EXPAND(32(SP), 256(SP), 448(SP), 128(SP), 352(SP), 544(SP))
+	EXPAND(64(SP), 288(SP), 480(SP), 160(SP), 384(SP), 576(SP))
+	EXPAND(96(SP), 320(SP), 512(SP), 192(SP), 416(SP), 608(SP))
+	EXPAND(128(SP), 352(SP), 544(SP), 224(SP), 448(SP), 640(SP))
+	EXPAND(160(SP), 384(SP), 576(SP), 256(SP), 480(SP), 672(SP))
+	EXPAND(192(SP), 416(SP), 608(SP), 288(SP), 512(SP), 704(SP))
+	EXPAND(224(SP), 448(SP), 640(SP), 320(SP), 544(SP), 736(SP))
+	EXPAND(256(SP), 480(SP), 672(SP), 352(SP), 576(SP), 768(SP))
+	EXPAND(288(SP), 512(SP), 704(SP), 384(SP), 608(SP), 800(SP))
+	EXPAND(320(SP), 544(SP), 736(SP), 416(SP), 640(SP), 832(SP))
+	EXPAND(352(SP), 576(SP), 768(SP), 448(SP), 672(SP), 864(SP))
+	EXPAND(384(SP), 608(SP), 800(SP), 480(SP), 704(SP), 896(SP))
+	EXPAND(416(SP), 640(SP), 832(SP), 512(SP), 736(SP), 928(SP))
+	EXPAND(448(SP), 672(SP), 864(SP), 544(SP), 768(SP), 960(SP))
+	EXPAND(480(SP), 704(SP), 896(SP), 576(SP), 800(SP), 992(SP))
+	EXPAND(512(SP), 736(SP), 928(SP), 608(SP), 832(SP), 1024(SP))
+	EXPAND(544(SP), 768(SP), 960(SP), 640(SP), 864(SP), 1056(SP))
+	EXPAND(576(SP), 800(SP), 992(SP), 672(SP), 896(SP), 1088(SP))
+	EXPAND(608(SP), 832(SP), 1024(SP), 704(SP), 928(SP), 1120(SP))
+	EXPAND(640(SP), 864(SP), 1056(SP), 736(SP), 960(SP), 1152(SP))
+	EXPAND(672(SP), 896(SP), 1088(SP), 768(SP), 992(SP), 1184(SP))
+	EXPAND(704(SP), 928(SP), 1120(SP), 800(SP), 1024(SP), 1216(SP))
+	EXPAND(736(SP), 960(SP), 1152(SP), 832(SP), 1056(SP), 1248(SP))
+	EXPAND(768(SP), 992(SP), 1184(SP), 864(SP), 1088(SP), 1280(SP))
+	EXPAND(800(SP), 1024(SP), 1216(SP), 896(SP), 1120(SP), 1312(SP))
+	EXPAND(832(SP), 1056(SP), 1248(SP), 928(SP), 1152(SP), 1344(SP))
+	EXPAND(864(SP), 1088(SP), 1280(SP), 960(SP), 1184(SP), 1376(SP))
+	EXPAND(896(SP), 1120(SP), 1312(SP), 992(SP), 1216(SP), 1408(SP))
+	EXPAND(928(SP), 1152(SP), 1344(SP), 1024(SP), 1248(SP), 1440(SP))
+	EXPAND(960(SP), 1184(SP), 1376(SP), 1056(SP), 1280(SP), 1472(SP))
+	EXPAND(992(SP), 1216(SP), 1408(SP), 1088(SP), 1312(SP), 1504(SP))
+	EXPAND(1024(SP), 1248(SP), 1440(SP), 1120(SP), 1344(SP), 1536(SP))
+	EXPAND(1056(SP), 1280(SP), 1472(SP), 1152(SP), 1376(SP), 1568(SP))
+	EXPAND(1088(SP), 1312(SP), 1504(SP), 1184(SP), 1408(SP), 1600(SP))
+	EXPAND(1120(SP), 1344(SP), 1536(SP), 1216(SP), 1440(SP), 1632(SP))
+	EXPAND(1152(SP), 1376(SP), 1568(SP), 1248(SP), 1472(SP), 1664(SP))
+	EXPAND(1184(SP), 1408(SP), 1600(SP), 1280(SP), 1504(SP), 1696(SP))
+	EXPAND(1216(SP), 1440(SP), 1632(SP), 1312(SP), 1536(SP), 1728(SP))
+	EXPAND(1248(SP), 1472(SP), 1664(SP), 1344(SP), 1568(SP), 1760(SP))
+	EXPAND(1280(SP), 1504(SP), 1696(SP), 1376(SP), 1600(SP), 1792(SP))
+	EXPAND(1312(SP), 1536(SP), 1728(SP), 1408(SP), 1632(SP), 1824(SP))
+	EXPAND(1344(SP), 1568(SP), 1760(SP), 1440(SP), 1664(SP), 1856(SP))
+	EXPAND(1376(SP), 1600(SP), 1792(SP), 1472(SP), 1696(SP), 1888(SP))
+	EXPAND(1408(SP), 1632(SP), 1824(SP), 1504(SP), 1728(SP), 1920(SP))
+	EXPAND(1440(SP), 1664(SP), 1856(SP), 1536(SP), 1760(SP), 1952(SP))
+	EXPAND(1472(SP), 1696(SP), 1888(SP), 1568(SP), 1792(SP), 1984(SP))
+	EXPAND(1504(SP), 1728(SP), 1920(SP), 1600(SP), 1824(SP), 2016(SP))
+	EXPAND(1536(SP), 1760(SP), 1952(SP), 1632(SP), 1856(SP), 2048(SP))
+	EXPAND(1568(SP), 1792(SP), 1984(SP), 1664(SP), 1888(SP), 2080(SP))
+	EXPAND(1600(SP), 1824(SP), 2016(SP), 1696(SP), 1920(SP), 2112(SP))
+	EXPAND(1632(SP), 1856(SP), 2048(SP), 1728(SP), 1952(SP), 2144(SP))
+
+	// Load the state
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 128(DI), Y4
+	VMOVDQU 160(DI), Y5
+	VMOVDQU 192(DI), Y6
+	VMOVDQU 224(DI), Y7
+
+	ROUND0(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 0(SP), 128(SP), 0(BX))
+	ROUND0(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 32(SP), 160(SP), 4(BX))
+	ROUND0(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 64(SP), 192(SP), 8(BX))
+	ROUND0(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 96(SP), 224(SP), 12(BX))
+	ROUND0(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 128(SP), 256(SP), 16(BX))
+	ROUND0(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 160(SP), 288(SP), 20(BX))
+	ROUND0(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 192(SP), 320(SP), 24(BX))
+	ROUND0(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 224(SP), 352(SP), 28(BX))
+	ROUND0(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 256(SP), 384(SP), 32(BX))
+	ROUND0(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 288(SP), 416(SP), 36(BX))
+	ROUND0(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 320(SP), 448(SP), 40(BX))
+	ROUND0(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 352(SP), 480(SP), 44(BX))
+	ROUND0(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 384(SP), 512(SP), 48(BX))
+	ROUND0(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 416(SP), 544(SP), 52(BX))
+	ROUND0(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 448(SP), 576(SP), 56(BX))
+	ROUND0(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 480(SP), 608(SP), 60(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 512(SP), 640(SP), 64(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 544(SP), 672(SP), 68(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 576(SP), 704(SP), 72(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 608(SP), 736(SP), 76(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 640(SP), 768(SP), 80(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 672(SP), 800(SP), 84(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 704(SP), 832(SP), 88(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 736(SP), 864(SP), 92(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 768(SP), 896(SP), 96(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 800(SP), 928(SP), 100(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 832(SP), 960(SP), 104(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 864(SP), 992(SP), 108(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 896(SP), 1024(SP), 112(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 928(SP), 1056(SP), 116(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 960(SP), 1088(SP), 120(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 992(SP), 1120(SP), 124(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1024(SP), 1152(SP), 128(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1056(SP), 1184(SP), 132(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1088(SP), 1216(SP), 136(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1120(SP), 1248(SP), 140(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1152(SP), 1280(SP), 144(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1184(SP), 1312(SP), 148(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1216(SP), 1344(SP), 152(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1248(SP), 1376(SP), 156(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1280(SP), 1408(SP), 160(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1312(SP), 1440(SP), 164(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1344(SP), 1472(SP), 168(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1376(SP), 1504(SP), 172(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1408(SP), 1536(SP), 176(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1440(SP), 1568(SP), 180(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1472(SP), 1600(SP), 184(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1504(SP), 1632(SP), 188(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1536(SP), 1664(SP), 192(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1568(SP), 1696(SP), 196(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1600(SP), 1728(SP), 200(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1632(SP), 1760(SP), 204(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1664(SP), 1792(SP), 208(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1696(SP), 1824(SP), 212(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1728(SP), 1856(SP), 216(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1760(SP), 1888(SP), 220(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1792(SP), 1920(SP), 224(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1824(SP), 1952(SP), 228(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1856(SP), 1984(SP), 232(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 1888(SP), 2016(SP), 236(BX))
+	ROUND1(Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1920(SP), 2048(SP), 240(BX))
+	ROUND1(Y3, Y0, Y1, Y2, Y7, Y4, Y5, Y6, 1952(SP), 2080(SP), 244(BX))
+	ROUND1(Y2, Y3, Y0, Y1, Y6, Y7, Y4, Y5, 1984(SP), 2112(SP), 248(BX))
+	ROUND1(Y1, Y2, Y3, Y0, Y5, Y6, Y7, Y4, 2016(SP), 2144(SP), 252(BX))
+
+	// Feed-forward
+	VPXOR 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VPXOR 32(DI), Y1, Y1
+	VMOVDQU Y1, 32(DI)
+	VPXOR 64(DI), Y2, Y2
+	VMOVDQU Y2, 64(DI)
+	VPXOR 96(DI), Y3, Y3
+	VMOVDQU Y3, 96(DI)
+	VPXOR 128(DI), Y4, Y4
+	VMOVDQU Y4, 128(DI)
+	VPXOR 160(DI), Y5, Y5
+	VMOVDQU Y5, 160(DI)
+	VPXOR 192(DI), Y6, Y6
+	VMOVDQU Y6, 192(DI)
+	VPXOR 224(DI), Y7, Y7
+	VMOVDQU Y7, 224(DI)
+	VZEROUPPER
+	RET
+#undef T0
+#undef T1
+#undef T2
+#undef T3
+#undef T4
+
+#define T0 X8
+#define T1 X9
+#define T2 X10
+#define T3 X11
+#define T4 X12
+
+// func blockX4AVX2(h *[64]uint32, w *[128]uint32)
+TEXT ·blockX4AVX2(SB), 0, $1088-16
+	MOVQ h+0(FP), DI
+	MOVQ w+8(FP), SI
+	LEAQ ·constsX<>(SB), BX
+
+	// Message expansion, W[0..67] are kept on the stack
+	VMOVDQU 0(SI), T0
+	VMOVDQU T0, 0(SP)
+	VMOVDQU 16(SI), T0
+	VMOVDQU T0, 16(SP)
+	VMOVDQU 32(SI), T0
+	VMOVDQU T0, 32(SP)
+	VMOVDQU 48(SI), T0
+	VMOVDQU T0, 48(SP)
+	VMOVDQU 64(SI), T0
+	VMOVDQU T0, 64(SP)
+	VMOVDQU 80(SI), T0
+	VMOVDQU T0, 80(SP)
+	VMOVDQU 96(SI), T0
+	VMOVDQU T0, 96(SP)
+	VMOVDQU 112(SI), T0
+	VMOVDQU T0, 112(SP)
+	VMOVDQU 128(SI), T0
+	VMOVDQU T0, 128(SP)
+	VMOVDQU 144(SI), T0
+	VMOVDQU T0, 144(SP)
+	VMOVDQU 160(SI), T0
+	VMOVDQU T0, 160(SP)
+	VMOVDQU 176(SI), T0
+	VMOVDQU T0, 176(SP)
+	VMOVDQU 192(SI), T0
+	VMOVDQU T0, 192(SP)
+	VMOVDQU 208(SI), T0
+	VMOVDQU T0, 208(SP)
+	VMOVDQU 224(SI), T0
+	VMOVDQU T0, 224(SP)
+	VMOVDQU 240(SI), T0
+	VMOVDQU T0, 240(SP)
+	EXPAND(0(SP), 112(SP), 208(SP), 48(SP), 160(SP), 256(SP))
+	EXPAND(16(SP), 128(SP), 224(SP), 64(SP), 176(SP), 272(SP))
+	EXPAND(32(SP), 144(SP), 240(SP), 80(SP), 192(SP), 288(SP))
+	EXPAND(48(SP), 160(SP), 256(SP), 96(SP), 208(SP), 304(SP))
+	EXPAND(64(SP), 176(SP), 272(SP), 112(SP), 224(SP), 320(SP))
+	EXPAND(80(SP), 192(SP), 288(SP), 128(SP), 240(SP), 336(SP))
+	EXPAND(96(SP), 208(SP), 304(SP), 144(SP), 256(SP), 352(SP))
+	EXPAND(112(SP), 224(SP), 320(SP), 160(SP), 272(SP), 368(SP))
+	EXPAND(128(SP), 240(SP), 336(SP), 176(SP), 288(SP), 384(SP))
+	EXPAND(144(SP), 256(SP), 352(SP), 192(SP), 304(SP), 400(SP))
+	EXPAND(160(SP), 272(SP), 368(SP), 208(SP), 320(SP), 416(SP))
+	EXPAND(176(SP), 288(SP), 384(SP), 224(SP), 336(SP), 432(SP))
+	EXPAND(192(SP), 304(SP), 400(SP), 240(SP), 352(SP), 448(SP))
+	EXPAND(208(SP), 320(SP), 416(SP), 256(SP), 368(SP), 464(SP))
+	EXPAND(224(SP), 336(SP), 432(SP), 272(SP), 384(SP), 480(SP))
+	EXPAND(240(SP), 352(SP), 448(SP), 288(SP), 400(SP), 496(SP))
+	EXPAND(256(SP), 368(SP), 464(SP), 304(SP), 416(SP), 512(SP))
+	EXPAND(272(SP), 384(SP), 480(SP), 320(SP), 432(SP), 528(SP))
+	EXPAND(288(SP), 400(SP), 496(SP), 336(SP), 448(SP), 544(SP))
+	EXPAND(304(SP), 416(SP), 512(SP), 352(SP), 464(SP), 560(SP))
+	EXPAND(320(SP), 432(SP), 528(SP), 368(SP), 480(SP), 576(SP))
+	EXPAND(336(SP), 448(SP), 544(SP), 384(SP), 496(SP), 592(SP))
+	EXPAND(352(SP), 464(SP), 560(SP), 400(SP), 512(SP), 608(SP))
+	EXPAND(368(SP), 480(SP), 576(SP), 416(SP), 528(SP), 624(SP))
+	EXPAND(384(SP), 496(SP), 592(SP), 432(SP), 544(SP), 640(SP))
+	EXPAND(400(SP), 512(SP), 608(SP), 448(SP), 560(SP), 656(SP))
+	EXPAND(416(SP), 528(SP), 624(SP), 464(SP), 576(SP), 672(SP))
+	EXPAND(432(SP), 544(SP), 640(SP), 480(SP), 592(SP), 688(SP))
+	EXPAND(448(SP), 560(SP), 656(SP), 496(SP), 608(SP), 704(SP))
+	EXPAND(464(SP), 576(SP), 672(SP), 512(SP), 624(SP), 720(SP))
+	EXPAND(480(SP), 592(SP), 688(SP), 528(SP), 640(SP), 736(SP))
+	EXPAND(496(SP), 608(SP), 704(SP), 544(SP), 656(SP), 752(SP))
+	EXPAND(512(SP), 624(SP), 720(SP), 560(SP), 672(SP), 768(SP))
+	EXPAND(528(SP), 640(SP), 736(SP), 576(SP), 688(SP), 784(SP))
+	EXPAND(544(SP), 656(SP), 752(SP), 592(SP), 704(SP), 800(SP))
+	EXPAND(560(SP), 672(SP), 768(SP), 608(SP), 720(SP), 816(SP))
+	EXPAND(576(SP), 688(SP), 784(SP), 624(SP), 736(SP), 832(SP))
+	EXPAND(592(SP), 704(SP), 800(SP), 640(SP), 752(SP), 848(SP))
+	EXPAND(608(SP), 720(SP), 816(SP), 656(SP), 768(SP), 864(SP))
+	EXPAND(624(SP), 736(SP), 832(SP), 672(SP), 784(SP), 880(SP))
+	EXPAND(640(SP), 752(SP), 848(SP), 688(SP), 800(SP), 896(SP))
+	EXPAND(656(SP), 768(SP), 864(SP), 704(SP), 816(SP), 912(SP))
+	EXPAND(672(SP), 784(SP), 880(SP), 720(SP), 832(SP), 928(SP))
+	EXPAND(688(SP), 800(SP), 896(SP), 736(SP), 848(SP), 944(SP))
+	EXPAND(704(SP), 816(SP), 912(SP), 752(SP), 864(SP), 960(SP))
+	EXPAND(720(SP), 832(SP), 928(SP), 768(SP), 880(SP), 976(SP))
+	EXPAND(736(SP), 848(SP), 944(SP), 784(SP), 896(SP), 992(SP))
+	EXPAND(752(SP), 864(SP), 960(SP), 800(SP), 912(SP), 1008(SP))
+	EXPAND(768(SP), 880(SP), 976(SP), 816(SP), 928(SP), 1024(SP))
+	EXPAND(784(SP), 896(SP), 992(SP), 832(SP), 944(SP), 1040(SP))
+	EXPAND(800(SP), 912(SP), 1008(SP), 848(SP), 960(SP), 1056(SP))
+	EXPAND(816(SP), 928(SP), 1024(SP), 864(SP), 976(SP), 1072(SP))
+
+	// Load the state
+	VMOVDQU 0(DI), X0
+	VMOVDQU 16(DI), X1
+	VMOVDQU 32(DI), X2
+	VMOVDQU 48(DI), X3
+	VMOVDQU 64(DI), X4
+	VMOVDQU 80(DI), X5
+	VMOVDQU 96(DI), X6
+	VMOVDQU 112(DI), X7
+
+	ROUND0(X0, X1, X2, X3, X4, X5, X6, X7, 0(SP), 64(SP), 0(BX))
+	ROUND0(X3, X0, X1, X2, X7, X4, X5, X6, 16(SP), 80(SP), 4(BX))
+	ROUND0(X2, X3, X0, X1, X6, X7, X4, X5, 32(SP), 96(SP), 8(BX))
+	ROUND0(X1, X2, X3, X0, X5, X6, X7, X4, 48(SP), 112(SP), 12(BX))
+	ROUND0(X0, X1, X2, X3, X4, X5, X6, X7, 64(SP), 128(SP), 16(BX))
+	ROUND0(X3, X0, X1, X2, X7, X4, X5, X6, 80(SP), 144(SP), 20(BX))
+	ROUND0(X2, X3, X0, X1, X6, X7, X4, X5, 96(SP), 160(SP), 24(BX))
+	ROUND0(X1, X2, X3, X0, X5, X6, X7, X4, 112(SP), 176(SP), 28(BX))
+	ROUND0(X0, X1, X2, X3, X4, X5, X6, X7, 128(SP), 192(SP), 32(BX))
+	ROUND0(X3, X0, X1, X2, X7, X4, X5, X6, 144(SP), 208(SP), 36(BX))
+	ROUND0(X2, X3, X0, X1, X6, X7, X4, X5, 160(SP), 224(SP), 40(BX))
+	ROUND0(X1, X2, X3, X0, X5, X6, X7, X4, 176(SP), 240(SP), 44(BX))
+	ROUND0(X0, X1, X2, X3, X4, X5, X6, X7, 192(SP), 256(SP), 48(BX))
+	ROUND0(X3, X0, X1, X2, X7, X4, X5, X6, 208(SP), 272(SP), 52(BX))
+	ROUND0(X2, X3, X0, X1, X6, X7, X4, X5, 224(SP), 288(SP), 56(BX))
+	ROUND0(X1, X2, X3, X0, X5, X6, X7, X4, 240(SP), 304(SP), 60(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 256(SP), 320(SP), 64(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 272(SP), 336(SP), 68(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 288(SP), 352(SP), 72(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 304(SP), 368(SP), 76(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 320(SP), 384(SP), 80(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 336(SP), 400(SP), 84(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 352(SP), 416(SP), 88(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 368(SP), 432(SP), 92(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 384(SP), 448(SP), 96(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 400(SP), 464(SP), 100(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 416(SP), 480(SP), 104(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 432(SP), 496(SP), 108(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 448(SP), 512(SP), 112(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 464(SP), 528(SP), 116(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 480(SP), 544(SP), 120(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 496(SP), 560(SP), 124(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 512(SP), 576(SP), 128(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 528(SP), 592(SP), 132(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 544(SP), 608(SP), 136(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 560(SP), 624(SP), 140(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 576(SP), 640(SP), 144(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 592(SP), 656(SP), 148(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 608(SP), 672(SP), 152(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 624(SP), 688(SP), 156(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 640(SP), 704(SP), 160(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 656(SP), 720(SP), 164(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 672(SP), 736(SP), 168(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 688(SP), 752(SP), 172(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 704(SP), 768(SP), 176(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 720(SP), 784(SP), 180(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 736(SP), 800(SP), 184(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 752(SP), 816(SP), 188(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 768(SP), 832(SP), 192(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 784(SP), 848(SP), 196(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 800(SP), 864(SP), 200(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 816(SP), 880(SP), 204(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 832(SP), 896(SP), 208(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 848(SP), 912(SP), 212(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 864(SP), 928(SP), 216(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 880(SP), 944(SP), 220(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 896(SP), 960(SP), 224(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 912(SP), 976(SP), 228(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 928(SP), 992(SP), 232(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 944(SP), 1008(SP), 236(BX))
+	ROUND1(X0, X1, X2, X3, X4, X5, X6, X7, 960(SP), 1024(SP), 240(BX))
+	ROUND1(X3, X0, X1, X2, X7, X4, X5, X6, 976(SP), 1040(SP), 244(BX))
+	ROUND1(X2, X3, X0, X1, X6, X7, X4, X5, 992(SP), 1056(SP), 248(BX))
+	ROUND1(X1, X2, X3, X0, X5, X6, X7, X4, 1008(SP), 1072(SP), 252(BX))
+
+	// Feed-forward
+	VPXOR 0(DI), X0, X0
+	VMOVDQU X0, 0(DI)
+	VPXOR 16(DI), X1, X1
+	VMOVDQU X1, 16(DI)
+	VPXOR 32(DI), X2, X2
+	VMOVDQU X2, 32(DI)
+	VPXOR 48(DI), X3, X3
+	VMOVDQU X3, 48(DI)
+	VPXOR 64(DI), X4, X4
+	VMOVDQU X4, 64(DI)
+	VPXOR 80(DI), X5, X5
+	VMOVDQU X5, 80(DI)
+	VPXOR 96(DI), X6, X6
+	VMOVDQU X6, 96(DI)
+	VPXOR 112(DI), X7, X7
+	VMOVDQU X7, 112(DI)
+	VZEROUPPER
+	RET
+#undef T0
+#undef T1
+#undef T2
+#undef T3
+#undef T4
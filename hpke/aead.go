@@ -0,0 +1,37 @@
+package hpke
+
+import (
+	"crypto/cipher"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/cipher/modes"
+)
+
+// Identifiers of AEADs
+const (
+	AEAD_AES128GCM  uint16 = 0x0001
+	AEAD_AES256GCM  uint16 = 0x0002
+	AEAD_EXPORTONLY uint16 = 0xFFFF
+)
+
+// aead describes AEAD algorithm. Export-only AEAD has nk = 0.
+type aead struct {
+	id uint16
+	// Sizes of the key, nonce and tag in bytes
+	nk, nn, nt int
+}
+
+var aeads = map[uint16]*aead{
+	AEAD_AES128GCM:  {AEAD_AES128GCM, 16, 12, 16},
+	AEAD_AES256GCM:  {AEAD_AES256GCM, 32, 12, 16},
+	AEAD_EXPORTONLY: {AEAD_EXPORTONLY, 0, 0, 0},
+}
+
+// new returns AEAD keyed with key.
+func (a *aead) new(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return modes.NewGCM(b)
+}
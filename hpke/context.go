@@ -0,0 +1,89 @@
+package hpke
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+var (
+	errExportOnly    = errors.New("hpke: export-only context")
+	errWrongRole     = errors.New("hpke: operation not allowed by context role")
+	errMessageLimit  = errors.New("hpke: message limit reached")
+	errOpen          = errors.New("hpke: message authentication failed")
+	errExportTooLong = errors.New("hpke: exported secret too long")
+)
+
+// Context is encryption context established by Setup functions, RFC 9180,
+// Section 5.2. Sender context only seals and receiver context only opens
+// messages, both can export secrets. Context is not safe for concurrent use.
+type Context struct {
+	suite  *Suite
+	sender bool
+	// nil for export-only AEAD
+	aead      cipher.AEAD
+	key       []byte
+	baseNonce []byte
+	// Sequence number of the next message
+	seq            uint64
+	exporterSecret []byte
+}
+
+// nonce computes base_nonce XOR I2OSP(seq, Nn).
+func (c *Context) nonce() []byte {
+	n := make([]byte, len(c.baseNonce))
+	copy(n, c.baseNonce)
+	for i, s := 0, c.seq; i < 8; i, s = i+1, s>>8 {
+		n[len(n)-1-i] ^= byte(s)
+	}
+	return n
+}
+
+// check verifies that the context can process next message.
+func (c *Context) check(sender bool) error {
+	if c.aead == nil {
+		return errExportOnly
+	}
+	if c.sender != sender {
+		return errWrongRole
+	}
+	// Sequence number is limited by 64 bits, which is less than 2^(8*Nn)
+	if c.seq == ^uint64(0) {
+		return errMessageLimit
+	}
+	return nil
+}
+
+// Seal encrypts and authenticates pt together with associated data aad.
+func (c *Context) Seal(aad, pt []byte) ([]byte, error) {
+	if err := c.check(true); err != nil {
+		return nil, err
+	}
+	ct := c.aead.Seal(nil, c.nonce(), pt, aad)
+	c.seq++
+	return ct, nil
+}
+
+// Open decrypts ct and verifies its authenticity together with aad.
+// Messages must be opened in the order they were sealed.
+func (c *Context) Open(aad, ct []byte) ([]byte, error) {
+	if err := c.check(false); err != nil {
+		return nil, err
+	}
+	pt, err := c.aead.Open(nil, c.nonce(), ct, aad)
+	if err != nil {
+		return nil, errOpen
+	}
+	c.seq++
+	return pt, nil
+}
+
+// Export returns length bytes of secret bound to exporterContext,
+// RFC 9180, Section 5.3. Length must not exceed 255 times the KDF
+// output size.
+func (c *Context) Export(exporterContext []byte, length int) ([]byte, error) {
+	k := c.suite.kdf
+	if length < 0 || length > 255*k.nh {
+		return nil, errExportTooLong
+	}
+	return k.labeledExpand(c.suite.id, c.exporterSecret, "sec", exporterContext, length), nil
+}
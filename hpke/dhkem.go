@@ -0,0 +1,137 @@
+package hpke
+
+import (
+	"io"
+
+	"github.com/henrydcase/nobs/internal/ecdh"
+)
+
+// dhKEM implements DHKEM(Group, HKDF-SHA256), RFC 9180, Section 4.1.
+type dhKEM struct {
+	id      uint16
+	suiteID []byte
+	kdf     *kdf
+	g       ecdh.Group
+}
+
+func newDHKEM(id uint16, g ecdh.Group) *dhKEM {
+	return &dhKEM{id: id, suiteID: kemSuiteID(id), kdf: kdfs[KDF_HKDF_SHA256], g: g}
+}
+
+func (k *dhKEM) ID() uint16            { return k.id }
+func (k *dhKEM) PublicKeySize() int    { return k.g.PublicKeySize() }
+func (k *dhKEM) PrivateKeySize() int   { return k.g.PrivateKeySize() }
+func (k *dhKEM) CiphertextSize() int   { return k.g.PublicKeySize() }
+func (k *dhKEM) SharedSecretSize() int { return k.kdf.nh }
+
+// GenerateKeyPair derives key pair from PrivateKeySize() random bytes.
+func (k *dhKEM) GenerateKeyPair(rng io.Reader) (pk, sk []byte, err error) {
+	ikm, err := readSeed(rng, k.PrivateKeySize())
+	if err != nil {
+		return nil, nil, err
+	}
+	return k.DeriveKeyPair(ikm)
+}
+
+func (k *dhKEM) DeriveKeyPair(ikm []byte) (pk, sk []byte, err error) {
+	prk := k.kdf.labeledExtract(k.suiteID, nil, "dkp_prk", ikm)
+	if sk, err = k.deriveKey(prk); err != nil {
+		return nil, nil, err
+	}
+	pk, _ = k.g.PublicKey(sk)
+	return pk, sk, nil
+}
+
+// deriveKey derives private key from dkp_prk, RFC 9180, Section 7.1.3.
+// X25519 keys are expanded directly, P-256 ones use rejection sampling
+// with bitmask 0xff.
+func (k *dhKEM) deriveKey(prk []byte) ([]byte, error) {
+	n := k.g.PrivateKeySize()
+	if k.id == KEM_X25519_HKDF_SHA256 {
+		return k.kdf.labeledExpand(k.suiteID, prk, "sk", nil, n), nil
+	}
+	for counter := 0; counter < 256; counter++ {
+		sk := k.kdf.labeledExpand(k.suiteID, prk, "candidate", []byte{byte(counter)}, n)
+		if k.g.ValidPrivateKey(sk) {
+			return sk, nil
+		}
+	}
+	return nil, errInvalidPrivateKey
+}
+
+// extractAndExpand derives shared secret from DH output and kem_context.
+func (k *dhKEM) extractAndExpand(dh, kemContext []byte) []byte {
+	prk := k.kdf.labeledExtract(k.suiteID, nil, "eae_prk", dh)
+	return k.kdf.labeledExpand(k.suiteID, prk, "shared_secret", kemContext, k.kdf.nh)
+}
+
+func (k *dhKEM) Encap(rng io.Reader, pkR []byte) (ss, enc []byte, err error) {
+	return k.AuthEncap(rng, pkR, nil)
+}
+
+func (k *dhKEM) Decap(enc, skR []byte) (ss []byte, err error) {
+	return k.AuthDecap(enc, skR, nil)
+}
+
+// AuthEncap implements AuthEncap. In case skS is nil, it implements Encap.
+func (k *dhKEM) AuthEncap(rng io.Reader, pkR, skS []byte) (ss, enc []byte, err error) {
+	if len(pkR) != k.PublicKeySize() {
+		return nil, nil, errInvalidPublicKey
+	}
+	enc, skE, err := k.GenerateKeyPair(rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	dh, ok := k.g.DH(skE, pkR)
+	if !ok {
+		return nil, nil, errInvalidPublicKey
+	}
+	kemContext := append(append([]byte{}, enc...), pkR...)
+	if skS != nil {
+		if len(skS) != k.PrivateKeySize() {
+			return nil, nil, errInvalidPrivateKey
+		}
+		pkS, ok := k.g.PublicKey(skS)
+		if !ok {
+			return nil, nil, errInvalidPrivateKey
+		}
+		dhS, ok := k.g.DH(skS, pkR)
+		if !ok {
+			return nil, nil, errInvalidPublicKey
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS...)
+	}
+	return k.extractAndExpand(dh, kemContext), enc, nil
+}
+
+// AuthDecap implements AuthDecap. In case pkS is nil, it implements Decap.
+func (k *dhKEM) AuthDecap(enc, skR, pkS []byte) (ss []byte, err error) {
+	if len(skR) != k.PrivateKeySize() {
+		return nil, errInvalidPrivateKey
+	}
+	if len(enc) != k.CiphertextSize() {
+		return nil, errInvalidEnc
+	}
+	pkR, ok := k.g.PublicKey(skR)
+	if !ok {
+		return nil, errInvalidPrivateKey
+	}
+	dh, ok := k.g.DH(skR, enc)
+	if !ok {
+		return nil, errInvalidEnc
+	}
+	kemContext := append(append([]byte{}, enc...), pkR...)
+	if pkS != nil {
+		if len(pkS) != k.PublicKeySize() {
+			return nil, errInvalidPublicKey
+		}
+		dhS, ok := k.g.DH(skR, pkS)
+		if !ok {
+			return nil, errInvalidPublicKey
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS...)
+	}
+	return k.extractAndExpand(dh, kemContext), nil
+}
@@ -0,0 +1,27 @@
+// Package hpke implements Hybrid Public Key Encryption as specified in
+// RFC 9180, in base, PSK, auth and auth-PSK modes.
+//
+// A Suite combines a KEM, a KDF and an AEAD, each identified by its
+// 16-bit codepoint. Sender and receiver establish a Context with one of
+// the Setup functions, then use it to encrypt and decrypt a sequence of
+// messages or to export secrets. Single-shot functions (Seal, Open,
+// SendExport, ReceiveExport) combine both steps.
+//
+// Supported algorithms:
+//   - KEM: DHKEM(P-256, HKDF-SHA256) and DHKEM(X25519, HKDF-SHA256) from
+//     RFC 9180, ML-KEM-512/768/1024 and X-Wing from draft-ietf-hpke-pq,
+//     and X25519+SIKE (non-standard codepoints) combined by kem/hybrid.
+//     DHKEMs and kem/hybrid share ECDH from internal/ecdh. Only DHKEMs
+//     support auth modes.
+//   - KDF: HKDF-SHA256/384/512 and non-standard HKDF-SHA3-256,
+//     HKDF-SHA3-512 and HKDF-SM3, built with HMAC from hash/sha3 and
+//     hash/sm3.
+//   - AEAD: AES-128-GCM and AES-256-GCM, built with cipher/aes and
+//     cipher/modes, and the export-only mode.
+//
+// References:
+//   - [RFC9180] Hybrid Public Key Encryption,
+//     https://www.rfc-editor.org/rfc/rfc9180
+//   - [HPKEPQ] Post-Quantum and Post-Quantum/Traditional Hybrid
+//     Algorithms for HPKE, https://datatracker.ietf.org/doc/draft-ietf-hpke-pq/
+package hpke
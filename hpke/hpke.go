@@ -0,0 +1,314 @@
+package hpke
+
+import (
+	"errors"
+	"io"
+)
+
+// HPKE modes
+const (
+	modeBase    byte = 0x00
+	modePSK     byte = 0x01
+	modeAuth    byte = 0x02
+	modeAuthPSK byte = 0x03
+)
+
+var (
+	errUnsupportedKDF  = errors.New("hpke: unsupported KDF")
+	errUnsupportedAEAD = errors.New("hpke: unsupported AEAD")
+	errAuthUnsupported = errors.New("hpke: KEM doesn't support auth modes")
+	errInconsistentPSK = errors.New("hpke: inconsistent PSK inputs")
+	errMissingPSK      = errors.New("hpke: missing required PSK input")
+	errUnexpectedPSK   = errors.New("hpke: unexpected PSK input")
+)
+
+// Suite is a combination of KEM, KDF and AEAD. It is safe for concurrent
+// use.
+type Suite struct {
+	kem  KEM
+	kdf  *kdf
+	aead *aead
+	// suite_id = "HPKE" || I2OSP(kem_id, 2) || I2OSP(kdf_id, 2) || I2OSP(aead_id, 2)
+	id []byte
+}
+
+// NewSuite returns Suite for the given algorithm identifiers. Returns
+// error in case any of the algorithms is not supported.
+func NewSuite(kemID, kdfID, aeadID uint16) (*Suite, error) {
+	k, err := NewKEM(kemID)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := kdfs[kdfID]
+	if !ok {
+		return nil, errUnsupportedKDF
+	}
+	a, ok := aeads[aeadID]
+	if !ok {
+		return nil, errUnsupportedAEAD
+	}
+	id := []byte("HPKE")
+	id = append(id, i2osp2(int(kemID))...)
+	id = append(id, i2osp2(int(kdfID))...)
+	id = append(id, i2osp2(int(aeadID))...)
+	return &Suite{kem: k, kdf: f, aead: a, id: id}, nil
+}
+
+// KEM returns KEM used by the suite, which generates key pairs.
+func (s *Suite) KEM() KEM {
+	return s.kem
+}
+
+// verifyPSKInputs checks that PSK and its ID are either both present or
+// both absent, as required by the mode. RFC 9180, Section 5.1.
+func verifyPSKInputs(mode byte, psk, pskID []byte) error {
+	gotPSK := len(psk) != 0
+	if gotPSK != (len(pskID) != 0) {
+		return errInconsistentPSK
+	}
+	needPSK := mode == modePSK || mode == modeAuthPSK
+	if gotPSK && !needPSK {
+		return errUnexpectedPSK
+	}
+	if !gotPSK && needPSK {
+		return errMissingPSK
+	}
+	return nil
+}
+
+// keySchedule derives encryption context from the shared secret, RFC 9180,
+// Section 5.1.
+func (s *Suite) keySchedule(sender bool, mode byte, ss, info, psk, pskID []byte) (*Context, error) {
+	if err := verifyPSKInputs(mode, psk, pskID); err != nil {
+		return nil, err
+	}
+
+	pskIDHash := s.kdf.labeledExtract(s.id, nil, "psk_id_hash", pskID)
+	infoHash := s.kdf.labeledExtract(s.id, nil, "info_hash", info)
+	ksc := append([]byte{mode}, pskIDHash...)
+	ksc = append(ksc, infoHash...)
+
+	secret := s.kdf.labeledExtract(s.id, ss, "secret", psk)
+	c := &Context{
+		suite:          s,
+		sender:         sender,
+		exporterSecret: s.kdf.labeledExpand(s.id, secret, "exp", ksc, s.kdf.nh),
+	}
+	if s.aead.id != AEAD_EXPORTONLY {
+		c.key = s.kdf.labeledExpand(s.id, secret, "key", ksc, s.aead.nk)
+		c.baseNonce = s.kdf.labeledExpand(s.id, secret, "base_nonce", ksc, s.aead.nn)
+		aead, err := s.aead.new(c.key)
+		if err != nil {
+			return nil, err
+		}
+		c.aead = aead
+	}
+	return c, nil
+}
+
+// authKEM returns KEM of the suite if it supports auth modes.
+func (s *Suite) authKEM() (AuthKEM, error) {
+	k, ok := s.kem.(AuthKEM)
+	if !ok {
+		return nil, errAuthUnsupported
+	}
+	return k, nil
+}
+
+// setupS implements sender side of the setup in any mode. skS is nil in
+// modes without authentication.
+func (s *Suite) setupS(rng io.Reader, mode byte, pkR, info, psk, pskID, skS []byte) (enc []byte, c *Context, err error) {
+	var ss []byte
+	if skS != nil {
+		var k AuthKEM
+		if k, err = s.authKEM(); err != nil {
+			return nil, nil, err
+		}
+		ss, enc, err = k.AuthEncap(rng, pkR, skS)
+	} else {
+		ss, enc, err = s.kem.Encap(rng, pkR)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if c, err = s.keySchedule(true, mode, ss, info, psk, pskID); err != nil {
+		return nil, nil, err
+	}
+	return enc, c, nil
+}
+
+// setupR implements receiver side of the setup in any mode. pkS is nil in
+// modes without authentication.
+func (s *Suite) setupR(mode byte, enc, skR, info, psk, pskID, pkS []byte) (*Context, error) {
+	var ss []byte
+	var err error
+	if pkS != nil {
+		var k AuthKEM
+		if k, err = s.authKEM(); err != nil {
+			return nil, err
+		}
+		ss, err = k.AuthDecap(enc, skR, pkS)
+	} else {
+		ss, err = s.kem.Decap(enc, skR)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.keySchedule(false, mode, ss, info, psk, pskID)
+}
+
+// SetupBaseS establishes sender context for public key pkR in base mode.
+// Returns encapsulated key, which must be sent to the receiver.
+func (s *Suite) SetupBaseS(rng io.Reader, pkR, info []byte) (enc []byte, c *Context, err error) {
+	return s.setupS(rng, modeBase, pkR, info, nil, nil, nil)
+}
+
+// SetupBaseR establishes receiver context from encapsulated key in base mode.
+func (s *Suite) SetupBaseR(enc, skR, info []byte) (*Context, error) {
+	return s.setupR(modeBase, enc, skR, info, nil, nil, nil)
+}
+
+// SetupPSKS establishes sender context in PSK mode, which authenticates
+// the sender with pre-shared key psk identified by pskID.
+func (s *Suite) SetupPSKS(rng io.Reader, pkR, info, psk, pskID []byte) (enc []byte, c *Context, err error) {
+	return s.setupS(rng, modePSK, pkR, info, psk, pskID, nil)
+}
+
+// SetupPSKR establishes receiver context in PSK mode.
+func (s *Suite) SetupPSKR(enc, skR, info, psk, pskID []byte) (*Context, error) {
+	return s.setupR(modePSK, enc, skR, info, psk, pskID, nil)
+}
+
+// SetupAuthS establishes sender context in auth mode, which authenticates
+// the sender with its private key skS. Requires AuthKEM.
+func (s *Suite) SetupAuthS(rng io.Reader, pkR, info, skS []byte) (enc []byte, c *Context, err error) {
+	if skS == nil {
+		return nil, nil, errInvalidPrivateKey
+	}
+	return s.setupS(rng, modeAuth, pkR, info, nil, nil, skS)
+}
+
+// SetupAuthR establishes receiver context in auth mode for sender's public
+// key pkS. Requires AuthKEM.
+func (s *Suite) SetupAuthR(enc, skR, info, pkS []byte) (*Context, error) {
+	if pkS == nil {
+		return nil, errInvalidPublicKey
+	}
+	return s.setupR(modeAuth, enc, skR, info, nil, nil, pkS)
+}
+
+// SetupAuthPSKS establishes sender context in auth-PSK mode, which
+// combines auth and PSK modes. Requires AuthKEM.
+func (s *Suite) SetupAuthPSKS(rng io.Reader, pkR, info, psk, pskID, skS []byte) (enc []byte, c *Context, err error) {
+	if skS == nil {
+		return nil, nil, errInvalidPrivateKey
+	}
+	return s.setupS(rng, modeAuthPSK, pkR, info, psk, pskID, skS)
+}
+
+// SetupAuthPSKR establishes receiver context in auth-PSK mode. Requires
+// AuthKEM.
+func (s *Suite) SetupAuthPSKR(enc, skR, info, psk, pskID, pkS []byte) (*Context, error) {
+	if pkS == nil {
+		return nil, errInvalidPublicKey
+	}
+	return s.setupR(modeAuthPSK, enc, skR, info, psk, pskID, pkS)
+}
+
+// Seal encrypts single message pt to pkR in base mode, RFC 9180,
+// Section 6.1. Returns encapsulated key and ciphertext.
+func (s *Suite) Seal(rng io.Reader, pkR, info, aad, pt []byte) (enc, ct []byte, err error) {
+	enc, c, err := s.SetupBaseS(rng, pkR, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err = c.Seal(aad, pt)
+	return enc, ct, err
+}
+
+// Open decrypts single message encrypted with Seal.
+func (s *Suite) Open(enc, skR, info, aad, ct []byte) ([]byte, error) {
+	c, err := s.SetupBaseR(enc, skR, info)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(aad, ct)
+}
+
+// SealPSK is Seal in PSK mode.
+func (s *Suite) SealPSK(rng io.Reader, pkR, info, psk, pskID, aad, pt []byte) (enc, ct []byte, err error) {
+	enc, c, err := s.SetupPSKS(rng, pkR, info, psk, pskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err = c.Seal(aad, pt)
+	return enc, ct, err
+}
+
+// OpenPSK is Open in PSK mode.
+func (s *Suite) OpenPSK(enc, skR, info, psk, pskID, aad, ct []byte) ([]byte, error) {
+	c, err := s.SetupPSKR(enc, skR, info, psk, pskID)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(aad, ct)
+}
+
+// SealAuth is Seal in auth mode.
+func (s *Suite) SealAuth(rng io.Reader, pkR, info, skS, aad, pt []byte) (enc, ct []byte, err error) {
+	enc, c, err := s.SetupAuthS(rng, pkR, info, skS)
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err = c.Seal(aad, pt)
+	return enc, ct, err
+}
+
+// OpenAuth is Open in auth mode.
+func (s *Suite) OpenAuth(enc, skR, info, pkS, aad, ct []byte) ([]byte, error) {
+	c, err := s.SetupAuthR(enc, skR, info, pkS)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(aad, ct)
+}
+
+// SealAuthPSK is Seal in auth-PSK mode.
+func (s *Suite) SealAuthPSK(rng io.Reader, pkR, info, psk, pskID, skS, aad, pt []byte) (enc, ct []byte, err error) {
+	enc, c, err := s.SetupAuthPSKS(rng, pkR, info, psk, pskID, skS)
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err = c.Seal(aad, pt)
+	return enc, ct, err
+}
+
+// OpenAuthPSK is Open in auth-PSK mode.
+func (s *Suite) OpenAuthPSK(enc, skR, info, psk, pskID, pkS, aad, ct []byte) ([]byte, error) {
+	c, err := s.SetupAuthPSKR(enc, skR, info, psk, pskID, pkS)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(aad, ct)
+}
+
+// SendExport establishes sender context in base mode and exports length
+// bytes of secret bound to exporterContext, RFC 9180, Section 6.2.
+func (s *Suite) SendExport(rng io.Reader, pkR, info, exporterContext []byte, length int) (enc, secret []byte, err error) {
+	enc, c, err := s.SetupBaseS(rng, pkR, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, err = c.Export(exporterContext, length)
+	return enc, secret, err
+}
+
+// ReceiveExport establishes receiver context in base mode and exports the
+// same secret as SendExport.
+func (s *Suite) ReceiveExport(enc, skR, info, exporterContext []byte, length int) ([]byte, error) {
+	c, err := s.SetupBaseR(enc, skR, info)
+	if err != nil {
+		return nil, err
+	}
+	return c.Export(exporterContext, length)
+}
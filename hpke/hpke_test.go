@@ -0,0 +1,458 @@
+package hpke
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// hexBytes is []byte encoded in hex in JSON
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) (err error) {
+	var s string
+	if err = json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*b, err = hex.DecodeString(s)
+	return err
+}
+
+// vector is a test vector in the format of RFC 9180, Appendix A.
+type vector struct {
+	Mode           byte     `json:"mode"`
+	KEMID          uint16   `json:"kem_id"`
+	KDFID          uint16   `json:"kdf_id"`
+	AEADID         uint16   `json:"aead_id"`
+	Info           hexBytes `json:"info"`
+	IkmR           hexBytes `json:"ikmR"`
+	IkmS           hexBytes `json:"ikmS"`
+	IkmE           hexBytes `json:"ikmE"`
+	SkRm           hexBytes `json:"skRm"`
+	SkSm           hexBytes `json:"skSm"`
+	SkEm           hexBytes `json:"skEm"`
+	PSK            hexBytes `json:"psk"`
+	PSKID          hexBytes `json:"psk_id"`
+	PkRm           hexBytes `json:"pkRm"`
+	PkSm           hexBytes `json:"pkSm"`
+	PkEm           hexBytes `json:"pkEm"`
+	Enc            hexBytes `json:"enc"`
+	SharedSecret   hexBytes `json:"shared_secret"`
+	Key            hexBytes `json:"key"`
+	BaseNonce      hexBytes `json:"base_nonce"`
+	ExporterSecret hexBytes `json:"exporter_secret"`
+	Encryptions    []struct {
+		AAD   hexBytes `json:"aad"`
+		CT    hexBytes `json:"ct"`
+		Nonce hexBytes `json:"nonce"`
+		PT    hexBytes `json:"pt"`
+	} `json:"encryptions"`
+	Exports []struct {
+		Context hexBytes `json:"exporter_context"`
+		L       int      `json:"L"`
+		Value   hexBytes `json:"exported_value"`
+	} `json:"exports"`
+}
+
+// Reads gzipped JSON file from testdata.
+func readVectors(t *testing.T, path string) (v []vector) {
+	f, err := os.Open("testdata/" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.NewDecoder(r).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// setup establishes sender and receiver contexts in mode of the vector.
+func setup(s *Suite, v *vector, pkS []byte) (enc []byte, cs, cr *Context, err error) {
+	pkR, skR, err := s.kem.DeriveKeyPair(v.IkmR)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var skS []byte
+	if v.Mode == modeAuth || v.Mode == modeAuthPSK {
+		if pkS, skS, err = s.kem.DeriveKeyPair(v.IkmS); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	rng := bytes.NewReader(v.IkmE)
+	switch v.Mode {
+	case modeBase:
+		enc, cs, err = s.SetupBaseS(rng, pkR, v.Info)
+		if err == nil {
+			cr, err = s.SetupBaseR(enc, skR, v.Info)
+		}
+	case modePSK:
+		enc, cs, err = s.SetupPSKS(rng, pkR, v.Info, v.PSK, v.PSKID)
+		if err == nil {
+			cr, err = s.SetupPSKR(enc, skR, v.Info, v.PSK, v.PSKID)
+		}
+	case modeAuth:
+		enc, cs, err = s.SetupAuthS(rng, pkR, v.Info, skS)
+		if err == nil {
+			cr, err = s.SetupAuthR(enc, skR, v.Info, pkS)
+		}
+	case modeAuthPSK:
+		enc, cs, err = s.SetupAuthPSKS(rng, pkR, v.Info, v.PSK, v.PSKID, skS)
+		if err == nil {
+			cr, err = s.SetupAuthPSKR(enc, skR, v.Info, v.PSK, v.PSKID, pkS)
+		}
+	}
+	return enc, cs, cr, err
+}
+
+// testKEMVector checks key derivation and encapsulation of the vector.
+func testKEMVector(t *testing.T, k KEM, v *vector) {
+	pkR, skR, err := k.DeriveKeyPair(v.IkmR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(skR, v.SkRm) || !bytes.Equal(pkR, v.PkRm) {
+		t.Fatal("receiver key pair mismatch")
+	}
+	if v.Mode == modeAuth || v.Mode == modeAuthPSK {
+		pkS, skS, err := k.DeriveKeyPair(v.IkmS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(skS, v.SkSm) || !bytes.Equal(pkS, v.PkSm) {
+			t.Fatal("sender key pair mismatch")
+		}
+		return
+	}
+	ss, enc, err := k.Encap(bytes.NewReader(v.IkmE), pkR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, v.Enc) || !bytes.Equal(ss, v.SharedSecret) {
+		t.Fatal("encapsulation mismatch")
+	}
+	if ss, err = k.Decap(enc, skR); err != nil || !bytes.Equal(ss, v.SharedSecret) {
+		t.Fatal("decapsulation mismatch")
+	}
+}
+
+func testVector(t *testing.T, s *Suite, v *vector) {
+	testKEMVector(t, s.kem, v)
+	enc, cs, cr, err := setup(s, v, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, v.Enc) {
+		t.Fatal("enc mismatch")
+	}
+	for _, c := range []*Context{cs, cr} {
+		if !bytes.Equal(c.key, v.Key) ||
+			!bytes.Equal(c.baseNonce, v.BaseNonce) ||
+			!bytes.Equal(c.exporterSecret, v.ExporterSecret) {
+			t.Fatal("key schedule mismatch")
+		}
+	}
+
+	for i, e := range v.Encryptions {
+		if !bytes.Equal(cs.nonce(), e.Nonce) {
+			t.Fatalf("encryption %d: nonce mismatch", i)
+		}
+		ct, err := cs.Seal(e.AAD, e.PT)
+		if err != nil || !bytes.Equal(ct, e.CT) {
+			t.Fatalf("encryption %d: ciphertext mismatch", i)
+		}
+		pt, err := cr.Open(e.AAD, e.CT)
+		if err != nil || !bytes.Equal(pt, e.PT) {
+			t.Fatalf("encryption %d: plaintext mismatch", i)
+		}
+	}
+
+	for i, e := range v.Exports {
+		for _, c := range []*Context{cs, cr} {
+			out, err := c.Export(e.Context, e.L)
+			if err != nil || !bytes.Equal(out, e.Value) {
+				t.Fatalf("export %d: mismatch", i)
+			}
+		}
+	}
+}
+
+// Vectors from RFC 9180, Appendix A (CFRG repository), for supported suites.
+func TestRFC9180Vectors(t *testing.T) {
+	vectors := readVectors(t, "rfc9180.json.gz")
+	for i := range vectors {
+		v := &vectors[i]
+		s, err := NewSuite(v.KEMID, v.KDFID, v.AEADID)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		testVector(t, s, v)
+	}
+}
+
+// Vectors from draft-ietf-hpke-pq. KEM is checked for all vectors of
+// supported KEMs, remaining values only for supported suites.
+func TestPQVectors(t *testing.T) {
+	var kems, suites int
+	vectors := readVectors(t, "hpke-pq.json.gz")
+	for i := range vectors {
+		v := &vectors[i]
+		if v.KEMID != KEM_MLKEM512 && v.KEMID != KEM_MLKEM768 &&
+			v.KEMID != KEM_MLKEM1024 && v.KEMID != KEM_XWING {
+			continue
+		}
+		s, err := NewSuite(v.KEMID, v.KDFID, v.AEADID)
+		if err != nil {
+			k, _ := NewKEM(v.KEMID)
+			testKEMVector(t, k, v)
+			kems++
+			continue
+		}
+		testVector(t, s, v)
+		suites++
+	}
+	if kems == 0 || suites == 0 {
+		t.Fatal("no vectors tested")
+	}
+}
+
+var allKEMs = []uint16{
+	KEM_P256_HKDF_SHA256, KEM_X25519_HKDF_SHA256,
+	KEM_MLKEM512, KEM_MLKEM768, KEM_MLKEM1024, KEM_XWING,
+	KEM_X25519_SIKEp434, KEM_X25519_SIKEp503, KEM_X25519_SIKEp751,
+}
+
+var allKDFs = []uint16{
+	KDF_HKDF_SHA256, KDF_HKDF_SHA384, KDF_HKDF_SHA512,
+	KDF_HKDF_SHA3_256, KDF_HKDF_SHA3_512, KDF_HKDF_SM3,
+}
+
+func TestRoundTrip(t *testing.T) {
+	info, aad, pt := []byte("info"), []byte("aad"), []byte("plaintext")
+	psk, pskID := bytes.Repeat([]byte{0x42}, 32), []byte("psk id")
+	for _, kemID := range allKEMs {
+		if testing.Short() && (kemID == KEM_X25519_SIKEp503 || kemID == KEM_X25519_SIKEp751) {
+			continue
+		}
+		for _, kdfID := range allKDFs {
+			s, err := NewSuite(kemID, kdfID, AEAD_AES256GCM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkR, skR, err := s.KEM().GenerateKeyPair(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			enc, ct, err := s.Seal(rand.Reader, pkR, info, aad, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err := s.Open(enc, skR, info, aad, ct); err != nil || !bytes.Equal(out, pt) {
+				t.Fatalf("base mode failed for KEM %#x, KDF %#x", kemID, kdfID)
+			}
+
+			enc, ct, err = s.SealPSK(rand.Reader, pkR, info, psk, pskID, aad, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err := s.OpenPSK(enc, skR, info, psk, pskID, aad, ct); err != nil || !bytes.Equal(out, pt) {
+				t.Fatalf("PSK mode failed for KEM %#x, KDF %#x", kemID, kdfID)
+			}
+			if _, err = s.OpenPSK(enc, skR, info, psk, []byte("other"), aad, ct); err == nil {
+				t.Fatal("opened with wrong PSK ID")
+			}
+
+			enc, secret, err := s.SendExport(rand.Reader, pkR, info, []byte("ctx"), 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err := s.ReceiveExport(enc, skR, info, []byte("ctx"), 64); err != nil || !bytes.Equal(out, secret) {
+				t.Fatalf("export failed for KEM %#x, KDF %#x", kemID, kdfID)
+			}
+
+			pkS, skS, err := s.KEM().GenerateKeyPair(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := s.KEM().(AuthKEM); !ok {
+				if _, _, err = s.SealAuth(rand.Reader, pkR, info, skS, aad, pt); err != errAuthUnsupported {
+					t.Fatal("expected auth to be unsupported")
+				}
+				continue
+			}
+			enc, ct, err = s.SealAuth(rand.Reader, pkR, info, skS, aad, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err := s.OpenAuth(enc, skR, info, pkS, aad, ct); err != nil || !bytes.Equal(out, pt) {
+				t.Fatalf("auth mode failed for KEM %#x, KDF %#x", kemID, kdfID)
+			}
+			if _, err = s.OpenAuth(enc, skR, info, pkR, aad, ct); err == nil {
+				t.Fatal("opened with wrong sender key")
+			}
+			enc, ct, err = s.SealAuthPSK(rand.Reader, pkR, info, psk, pskID, skS, aad, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err := s.OpenAuthPSK(enc, skR, info, psk, pskID, pkS, aad, ct); err != nil || !bytes.Equal(out, pt) {
+				t.Fatalf("auth-PSK mode failed for KEM %#x, KDF %#x", kemID, kdfID)
+			}
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	s, err := NewSuite(KEM_X25519_HKDF_SHA256, KDF_HKDF_SHA256, AEAD_AES128GCM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkR, skR, _ := s.KEM().GenerateKeyPair(rand.Reader)
+	enc, cs, err := s.SetupBaseS(rand.Reader, pkR, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr, err := s.SetupBaseR(enc, skR, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct0, _ := cs.Seal(nil, []byte("m0"))
+	ct1, _ := cs.Seal(nil, []byte("m1"))
+	if _, err = cr.Open(nil, ct1); err != errOpen {
+		t.Fatal("opened message out of order")
+	}
+	if _, err = cr.Open([]byte("aad"), ct0); err != errOpen {
+		t.Fatal("opened message with wrong aad")
+	}
+	// Failed Open doesn't advance sequence number
+	if pt, err := cr.Open(nil, ct0); err != nil || string(pt) != "m0" {
+		t.Fatal("failed to open message")
+	}
+	if pt, err := cr.Open(nil, ct1); err != nil || string(pt) != "m1" {
+		t.Fatal("failed to open message")
+	}
+
+	if _, err = cs.Open(nil, ct0); err != errWrongRole {
+		t.Fatal("sender opened message")
+	}
+	if _, err = cr.Seal(nil, nil); err != errWrongRole {
+		t.Fatal("receiver sealed message")
+	}
+
+	cs.seq = ^uint64(0)
+	if _, err = cs.Seal(nil, nil); err != errMessageLimit {
+		t.Fatal("sequence number overflow")
+	}
+
+	if _, err = cs.Export(nil, 255*32+1); err != errExportTooLong {
+		t.Fatal("exported too long secret")
+	}
+	if _, err = cs.Export(nil, 255*32); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ = NewSuite(KEM_X25519_HKDF_SHA256, KDF_HKDF_SHA256, AEAD_EXPORTONLY)
+	_, cs, err = s.SetupBaseS(rand.Reader, pkR, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cs.Seal(nil, nil); err != errExportOnly {
+		t.Fatal("export-only context sealed message")
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	if _, err := NewSuite(0x0011, KDF_HKDF_SHA256, AEAD_AES128GCM); err != errUnsupportedKEM {
+		t.Fatal("expected unsupported KEM")
+	}
+	if _, err := NewSuite(KEM_X25519_HKDF_SHA256, 0x0010, AEAD_AES128GCM); err != errUnsupportedKDF {
+		t.Fatal("expected unsupported KDF")
+	}
+	if _, err := NewSuite(KEM_X25519_HKDF_SHA256, KDF_HKDF_SHA256, 0x0003); err != errUnsupportedAEAD {
+		t.Fatal("expected unsupported AEAD")
+	}
+
+	for _, tc := range []struct {
+		mode       byte
+		psk, pskID []byte
+		err        error
+	}{
+		{modeBase, []byte("psk"), nil, errInconsistentPSK},
+		{modePSK, nil, []byte("id"), errInconsistentPSK},
+		{modeBase, []byte("psk"), []byte("id"), errUnexpectedPSK},
+		{modeAuth, []byte("psk"), []byte("id"), errUnexpectedPSK},
+		{modePSK, nil, nil, errMissingPSK},
+		{modeAuthPSK, nil, nil, errMissingPSK},
+		{modeAuthPSK, []byte("psk"), []byte("id"), nil},
+	} {
+		if err := verifyPSKInputs(tc.mode, tc.psk, tc.pskID); err != tc.err {
+			t.Fatalf("mode %d: got %v, expected %v", tc.mode, err, tc.err)
+		}
+	}
+
+	for _, id := range allKEMs {
+		if testing.Short() && (id == KEM_X25519_SIKEp503 || id == KEM_X25519_SIKEp751) {
+			continue
+		}
+		k, _ := NewKEM(id)
+		pk, sk, _ := k.GenerateKeyPair(rand.Reader)
+		if _, _, err := k.Encap(rand.Reader, pk[1:]); err != errInvalidPublicKey {
+			t.Fatalf("KEM %#x: expected invalid public key", id)
+		}
+		if _, err := k.Decap(make([]byte, k.CiphertextSize()), sk[1:]); err != errInvalidPrivateKey {
+			t.Fatalf("KEM %#x: expected invalid private key", id)
+		}
+		if _, err := k.Decap(make([]byte, k.CiphertextSize()-1), sk); err != errInvalidEnc {
+			t.Fatalf("KEM %#x: expected invalid enc", id)
+		}
+	}
+
+	// X25519 point of small order results in all-zero shared secret
+	k, _ := NewKEM(KEM_X25519_HKDF_SHA256)
+	if _, _, err := k.Encap(rand.Reader, make([]byte, 32)); err != errInvalidPublicKey {
+		t.Fatal("accepted point of small order")
+	}
+	// Point not on P-256
+	k, _ = NewKEM(KEM_P256_HKDF_SHA256)
+	pk, _, _ := k.GenerateKeyPair(rand.Reader)
+	pk[64] ^= 1
+	if _, _, err := k.Encap(rand.Reader, pk); err != errInvalidPublicKey {
+		t.Fatal("accepted point not on the curve")
+	}
+}
+
+func benchmarkSeal(b *testing.B, kemID uint16) {
+	s, _ := NewSuite(kemID, KDF_HKDF_SHA256, AEAD_AES128GCM)
+	pkR, _, _ := s.KEM().GenerateKeyPair(rand.Reader)
+	pt := make([]byte, 1024)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, _, _ = s.Seal(rand.Reader, pkR, nil, nil, pt)
+	}
+}
+
+func benchmarkOpen(b *testing.B, kemID uint16) {
+	s, _ := NewSuite(kemID, KDF_HKDF_SHA256, AEAD_AES128GCM)
+	pkR, skR, _ := s.KEM().GenerateKeyPair(rand.Reader)
+	enc, ct, _ := s.Seal(rand.Reader, pkR, nil, nil, make([]byte, 1024))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, _ = s.Open(enc, skR, nil, nil, ct)
+	}
+}
+
+func BenchmarkSealX25519(b *testing.B)   { benchmarkSeal(b, KEM_X25519_HKDF_SHA256) }
+func BenchmarkOpenX25519(b *testing.B)   { benchmarkOpen(b, KEM_X25519_HKDF_SHA256) }
+func BenchmarkSealMLKEM768(b *testing.B) { benchmarkSeal(b, KEM_MLKEM768) }
+func BenchmarkOpenMLKEM768(b *testing.B) { benchmarkOpen(b, KEM_MLKEM768) }
+func BenchmarkSealXWing(b *testing.B)    { benchmarkSeal(b, KEM_XWING) }
+func BenchmarkOpenXWing(b *testing.B)    { benchmarkOpen(b, KEM_XWING) }
@@ -0,0 +1,111 @@
+package hpke
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/hash/sm3"
+)
+
+// Identifiers of KDFs
+const (
+	KDF_HKDF_SHA256 uint16 = 0x0001
+	KDF_HKDF_SHA384 uint16 = 0x0002
+	KDF_HKDF_SHA512 uint16 = 0x0003
+	// Non-standard codepoints
+	KDF_HKDF_SHA3_256 uint16 = 0xFF01
+	KDF_HKDF_SHA3_512 uint16 = 0xFF02
+	KDF_HKDF_SM3      uint16 = 0xFF03
+)
+
+// Version label prepended to all labeled KDF inputs
+var versionLabel = []byte("HPKE-v1")
+
+// kdf implements HKDF (RFC 5869) with the given HMAC.
+type kdf struct {
+	id uint16
+	// Size of the hash output in bytes
+	nh  int
+	mac func(key []byte) hash.Hash
+}
+
+func hmacOf(h func() hash.Hash) func(key []byte) hash.Hash {
+	return func(key []byte) hash.Hash { return hmac.New(h, key) }
+}
+
+var kdfs = map[uint16]*kdf{
+	KDF_HKDF_SHA256:   {KDF_HKDF_SHA256, sha256.Size, hmacOf(sha256.New)},
+	KDF_HKDF_SHA384:   {KDF_HKDF_SHA384, sha512.Size384, hmacOf(sha512.New384)},
+	KDF_HKDF_SHA512:   {KDF_HKDF_SHA512, sha512.Size, hmacOf(sha512.New)},
+	KDF_HKDF_SHA3_256: {KDF_HKDF_SHA3_256, 32, hmacOf(sha3.New256)},
+	KDF_HKDF_SHA3_512: {KDF_HKDF_SHA3_512, 64, hmacOf(sha3.New512)},
+	KDF_HKDF_SM3:      {KDF_HKDF_SM3, sm3.Size, hmacOf(sm3.New)},
+}
+
+// extract implements HKDF-Extract. Empty salt is equivalent to the string
+// of nh zeros, as HMAC pads the key with zeros.
+func (k *kdf) extract(salt, ikm []byte) []byte {
+	h := k.mac(salt)
+	_, _ = h.Write(ikm)
+	return h.Sum(nil)
+}
+
+// expand implements HKDF-Expand. Length must not exceed 255*nh.
+func (k *kdf) expand(prk, info []byte, length int) []byte {
+	out := make([]byte, 0, length+k.nh)
+	h := k.mac(prk)
+	var t []byte
+	for i := byte(1); len(out) < length; i++ {
+		h.Reset()
+		_, _ = h.Write(t)
+		_, _ = h.Write(info)
+		_, _ = h.Write([]byte{i})
+		t = h.Sum(t[:0])
+		out = append(out, t...)
+	}
+	return out[:length]
+}
+
+// labeledExtract implements LabeledExtract, RFC 9180, Section 4.
+func (k *kdf) labeledExtract(suiteID, salt []byte, label string, ikm []byte) []byte {
+	labeled := make([]byte, 0, len(versionLabel)+len(suiteID)+len(label)+len(ikm))
+	labeled = append(labeled, versionLabel...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return k.extract(salt, labeled)
+}
+
+// labeledExpand implements LabeledExpand, RFC 9180, Section 4.
+func (k *kdf) labeledExpand(suiteID, prk []byte, label string, info []byte, length int) []byte {
+	labeled := make([]byte, 2, 2+len(versionLabel)+len(suiteID)+len(label)+len(info))
+	binary.BigEndian.PutUint16(labeled, uint16(length))
+	labeled = append(labeled, versionLabel...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	return k.expand(prk, labeled, length)
+}
+
+// shakeDerive implements LabeledDerive of the SHAKE256 KDF, used by
+// the post-quantum KEMs to derive key pairs, [HPKEPQ], Section 4.
+func shakeDerive(suiteID, ikm []byte, label string, context []byte, length int) []byte {
+	var l [2]byte
+	out := make([]byte, length)
+	h := sha3.NewShake256()
+	_, _ = h.Write(ikm)
+	_, _ = h.Write(versionLabel)
+	_, _ = h.Write(suiteID)
+	binary.BigEndian.PutUint16(l[:], uint16(len(label)))
+	_, _ = h.Write(l[:])
+	_, _ = h.Write([]byte(label))
+	binary.BigEndian.PutUint16(l[:], uint16(length))
+	_, _ = h.Write(l[:])
+	_, _ = h.Write(context)
+	_, _ = h.Read(out)
+	return out
+}
@@ -0,0 +1,102 @@
+package hpke
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/internal/ecdh"
+)
+
+// Identifiers of KEMs
+const (
+	KEM_P256_HKDF_SHA256   uint16 = 0x0010
+	KEM_X25519_HKDF_SHA256 uint16 = 0x0020
+	KEM_MLKEM512           uint16 = 0x0040
+	KEM_MLKEM768           uint16 = 0x0041
+	KEM_MLKEM1024          uint16 = 0x0042
+	KEM_XWING              uint16 = 0x647A
+	// Non-standard codepoints
+	KEM_X25519_SIKEp434 uint16 = 0xFF10
+	KEM_X25519_SIKEp503 uint16 = 0xFF11
+	KEM_X25519_SIKEp751 uint16 = 0xFF12
+)
+
+var (
+	errInvalidPublicKey  = errors.New("hpke: invalid public key")
+	errInvalidPrivateKey = errors.New("hpke: invalid private key")
+	errInvalidEnc        = errors.New("hpke: invalid encapsulated key")
+	errUnsupportedKEM    = errors.New("hpke: unsupported KEM")
+)
+
+// KEM is a key encapsulation mechanism as defined in RFC 9180, Section 4.
+// Keys and encapsulated keys are passed in their serialized form.
+// Implementations are safe for concurrent use.
+type KEM interface {
+	// ID returns KEM identifier.
+	ID() uint16
+	// PublicKeySize returns size of serialized public key (Npk).
+	PublicKeySize() int
+	// PrivateKeySize returns size of serialized private key (Nsk).
+	PrivateKeySize() int
+	// CiphertextSize returns size of encapsulated key (Nenc).
+	CiphertextSize() int
+	// SharedSecretSize returns size of the shared secret (Nsecret).
+	SharedSecretSize() int
+	// GenerateKeyPair generates random key pair using rng.
+	GenerateKeyPair(rng io.Reader) (pk, sk []byte, err error)
+	// DeriveKeyPair deterministically derives key pair from ikm.
+	DeriveKeyPair(ikm []byte) (pk, sk []byte, err error)
+	// Encap generates shared secret and its encapsulation for pkR.
+	Encap(rng io.Reader, pkR []byte) (ss, enc []byte, err error)
+	// Decap recovers shared secret from enc using skR.
+	Decap(enc, skR []byte) (ss []byte, err error)
+}
+
+// AuthKEM is a KEM which additionally authenticates the sender, used by
+// auth and auth-PSK modes.
+type AuthKEM interface {
+	KEM
+	// AuthEncap is Encap authenticated with sender's private key skS.
+	AuthEncap(rng io.Reader, pkR, skS []byte) (ss, enc []byte, err error)
+	// AuthDecap is Decap authenticated with sender's public key pkS.
+	AuthDecap(enc, skR, pkS []byte) (ss []byte, err error)
+}
+
+// NewKEM returns KEM identified by id.
+func NewKEM(id uint16) (KEM, error) {
+	switch id {
+	case KEM_P256_HKDF_SHA256:
+		return newDHKEM(id, ecdh.P256), nil
+	case KEM_X25519_HKDF_SHA256:
+		return newDHKEM(id, ecdh.X25519), nil
+	case KEM_MLKEM512, KEM_MLKEM768, KEM_MLKEM1024:
+		return newMLKEM(id), nil
+	case KEM_XWING:
+		return xwingKEM{}, nil
+	case KEM_X25519_SIKEp434, KEM_X25519_SIKEp503, KEM_X25519_SIKEp751:
+		return newHybridSIKE(id), nil
+	}
+	return nil, errUnsupportedKEM
+}
+
+// kemSuiteID returns suite_id used by KEM: "KEM" || I2OSP(kem_id, 2).
+func kemSuiteID(id uint16) []byte {
+	return append([]byte("KEM"), byte(id>>8), byte(id))
+}
+
+// readSeed reads n bytes from rng.
+func readSeed(rng io.Reader, n int) ([]byte, error) {
+	seed := make([]byte, n)
+	if _, err := io.ReadFull(rng, seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// i2osp2 returns 2-byte big-endian encoding of n.
+func i2osp2(n int) []byte {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(n))
+	return b[:]
+}
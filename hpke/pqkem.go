@@ -0,0 +1,237 @@
+package hpke
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/henrydcase/nobs/dh/sidh"
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/kem/hybrid"
+	"github.com/henrydcase/nobs/kem/mlkem"
+	"github.com/henrydcase/nobs/kem/xwing"
+)
+
+// Post-quantum KEMs derive key pairs with SHAKE256 regardless of the KDF
+// used by the suite and output KEM shared secret directly, [HPKEPQ],
+// Section 4.
+
+// mlkemKEM implements ML-KEM. Private key is the 64-byte seed d || z.
+type mlkemKEM struct {
+	id      uint16
+	param   uint8
+	suiteID []byte
+}
+
+func newMLKEM(id uint16) *mlkemKEM {
+	k := &mlkemKEM{id: id, suiteID: kemSuiteID(id)}
+	switch id {
+	case KEM_MLKEM512:
+		k.param = mlkem.MLKEM512
+	case KEM_MLKEM768:
+		k.param = mlkem.MLKEM768
+	case KEM_MLKEM1024:
+		k.param = mlkem.MLKEM1024
+	}
+	return k
+}
+
+func (k *mlkemKEM) ID() uint16            { return k.id }
+func (k *mlkemKEM) PublicKeySize() int    { return mlkem.NewPublicKey(k.param).Size() }
+func (k *mlkemKEM) PrivateKeySize() int   { return mlkem.SeedSize }
+func (k *mlkemKEM) CiphertextSize() int   { return k.newKEM(nil).CiphertextSize() }
+func (k *mlkemKEM) SharedSecretSize() int { return mlkem.SharedSecretSize }
+
+func (k *mlkemKEM) newKEM(rng io.Reader) *mlkem.KEM {
+	var c mlkem.KEM
+	c.Allocate(k.param, rng)
+	return &c
+}
+
+// expand generates key pair from the seed.
+func (k *mlkemKEM) expand(sk []byte) (pk []byte, prv *mlkem.PrivateKey) {
+	prv = mlkem.NewPrivateKey(k.param)
+	// Reads exactly mlkem.SeedSize bytes and never fails
+	_ = prv.Generate(bytes.NewReader(sk))
+	pk = make([]byte, prv.PublicKey.Size())
+	prv.PublicKey.Export(pk)
+	return pk, prv
+}
+
+func (k *mlkemKEM) GenerateKeyPair(rng io.Reader) (pk, sk []byte, err error) {
+	if sk, err = readSeed(rng, mlkem.SeedSize); err != nil {
+		return nil, nil, err
+	}
+	pk, _ = k.expand(sk)
+	return pk, sk, nil
+}
+
+func (k *mlkemKEM) DeriveKeyPair(ikm []byte) (pk, sk []byte, err error) {
+	sk = shakeDerive(k.suiteID, ikm, "DeriveKeyPair", nil, mlkem.SeedSize)
+	pk, _ = k.expand(sk)
+	return pk, sk, nil
+}
+
+// Encap reads 32 bytes of encapsulation randomness from rng.
+func (k *mlkemKEM) Encap(rng io.Reader, pkR []byte) (ss, enc []byte, err error) {
+	pub := mlkem.NewPublicKey(k.param)
+	if pub.Import(pkR) != nil {
+		return nil, nil, errInvalidPublicKey
+	}
+	c := k.newKEM(rng)
+	ss = make([]byte, c.SharedSecretSize())
+	enc = make([]byte, c.CiphertextSize())
+	if err = c.Encapsulate(enc, ss, pub); err != nil {
+		return nil, nil, err
+	}
+	return ss, enc, nil
+}
+
+func (k *mlkemKEM) Decap(enc, skR []byte) (ss []byte, err error) {
+	if len(skR) != mlkem.SeedSize {
+		return nil, errInvalidPrivateKey
+	}
+	c := k.newKEM(nil)
+	if len(enc) != c.CiphertextSize() {
+		return nil, errInvalidEnc
+	}
+	_, prv := k.expand(skR)
+	ss = make([]byte, c.SharedSecretSize())
+	if err = c.Decapsulate(ss, prv, enc); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// xwingKEM implements X-Wing. Private key is the 32-byte seed.
+type xwingKEM struct{}
+
+func (xwingKEM) ID() uint16            { return KEM_XWING }
+func (xwingKEM) PublicKeySize() int    { return xwing.PublicKeySize }
+func (xwingKEM) PrivateKeySize() int   { return xwing.PrivateKeySize }
+func (xwingKEM) CiphertextSize() int   { return xwing.CiphertextSize }
+func (xwingKEM) SharedSecretSize() int { return xwing.SharedSecretSize }
+
+// expand generates key pair from the seed.
+func (xwingKEM) expand(sk []byte) (pk []byte, prv *xwing.PrivateKey, pub *xwing.PublicKey, err error) {
+	prv = xwing.NewPrivateKey()
+	pub = xwing.NewPublicKey()
+	if prv.Import(sk) != nil {
+		return nil, nil, nil, errInvalidPrivateKey
+	}
+	prv.GeneratePublicKey(pub)
+	pk = make([]byte, xwing.PublicKeySize)
+	pub.Export(pk)
+	return pk, prv, pub, nil
+}
+
+func (k xwingKEM) GenerateKeyPair(rng io.Reader) (pk, sk []byte, err error) {
+	if sk, err = readSeed(rng, xwing.PrivateKeySize); err != nil {
+		return nil, nil, err
+	}
+	pk, _, _, err = k.expand(sk)
+	return pk, sk, err
+}
+
+func (k xwingKEM) DeriveKeyPair(ikm []byte) (pk, sk []byte, err error) {
+	sk = shakeDerive(kemSuiteID(KEM_XWING), ikm, "DeriveKeyPair", nil, xwing.PrivateKeySize)
+	pk, _, _, err = k.expand(sk)
+	return pk, sk, err
+}
+
+// Encap reads 64 bytes of encapsulation randomness from rng.
+func (xwingKEM) Encap(rng io.Reader, pkR []byte) (ss, enc []byte, err error) {
+	pub := xwing.NewPublicKey()
+	if pub.Import(pkR) != nil {
+		return nil, nil, errInvalidPublicKey
+	}
+	ss = make([]byte, xwing.SharedSecretSize)
+	enc = make([]byte, xwing.CiphertextSize)
+	if err = xwing.NewXWing(rng).Encapsulate(enc, ss, pub); err != nil {
+		return nil, nil, err
+	}
+	return ss, enc, nil
+}
+
+func (k xwingKEM) Decap(enc, skR []byte) (ss []byte, err error) {
+	if len(enc) != xwing.CiphertextSize {
+		return nil, errInvalidEnc
+	}
+	_, prv, pub, err := k.expand(skR)
+	if err != nil {
+		return nil, err
+	}
+	ss = make([]byte, xwing.SharedSecretSize)
+	if err = xwing.NewXWing(nil).Decapsulate(ss, prv, pub, enc); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// hybridKEM implements a KEM built with kem/hybrid. It is used for SIKE,
+// which is paired with X25519 as it is no longer secure on its own. There
+// are no standard codepoints for these KEMs.
+type hybridKEM struct {
+	id      uint16
+	suiteID []byte
+	// newScheme returns the Scheme using rng
+	newScheme func(rng io.Reader) hybrid.Scheme
+	// Scheme used for sizes only
+	sizes hybrid.Scheme
+}
+
+func newHybridSIKE(id uint16) *hybridKEM {
+	var field uint8
+	switch id {
+	case KEM_X25519_SIKEp434:
+		field = sidh.Fp434
+	case KEM_X25519_SIKEp503:
+		field = sidh.Fp503
+	case KEM_X25519_SIKEp751:
+		field = sidh.Fp751
+	}
+	k := &hybridKEM{id: id, suiteID: kemSuiteID(id)}
+	k.newScheme = func(rng io.Reader) hybrid.Scheme {
+		return hybrid.New(hybrid.NewX25519(rng), hybrid.NewSIKE(field, rng))
+	}
+	k.sizes = k.newScheme(nil)
+	return k
+}
+
+func (k *hybridKEM) ID() uint16            { return k.id }
+func (k *hybridKEM) PublicKeySize() int    { return k.sizes.PublicKeySize() }
+func (k *hybridKEM) PrivateKeySize() int   { return k.sizes.PrivateKeySize() }
+func (k *hybridKEM) CiphertextSize() int   { return k.sizes.CiphertextSize() }
+func (k *hybridKEM) SharedSecretSize() int { return k.sizes.SharedSecretSize() }
+
+func (k *hybridKEM) GenerateKeyPair(rng io.Reader) (pk, sk []byte, err error) {
+	return k.newScheme(rng).GenerateKeyPair()
+}
+
+// DeriveKeyPair generates key pair with SHAKE256 seeded with 32 bytes
+// derived from ikm, as components may read any amount of randomness.
+func (k *hybridKEM) DeriveKeyPair(ikm []byte) (pk, sk []byte, err error) {
+	xof := sha3.NewShake256()
+	_, _ = xof.Write(shakeDerive(k.suiteID, ikm, "DeriveKeyPair", nil, 32))
+	return k.newScheme(xof).GenerateKeyPair()
+}
+
+func (k *hybridKEM) Encap(rng io.Reader, pkR []byte) (ss, enc []byte, err error) {
+	if len(pkR) != k.PublicKeySize() {
+		return nil, nil, errInvalidPublicKey
+	}
+	enc, ss, err = k.newScheme(rng).Encapsulate(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ss, enc, nil
+}
+
+func (k *hybridKEM) Decap(enc, skR []byte) (ss []byte, err error) {
+	if len(skR) != k.PrivateKeySize() {
+		return nil, errInvalidPrivateKey
+	}
+	if len(enc) != k.CiphertextSize() {
+		return nil, errInvalidEnc
+	}
+	return k.newScheme(nil).Decapsulate(skR, enc)
+}
@@ -0,0 +1,96 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package aestable implements table-based AES.
+//
+// Memory access pattern of this implementation depends on the key and
+// processed data, which makes it vulnerable to cache-timing attacks. It is
+// used only where such attacks aren't a concern, for example, when the key
+// is public. Use cipher/aes otherwise.
+package aestable
+
+import (
+	"errors"
+
+	"github.com/henrydcase/nobs/internal/alias"
+)
+
+// The AES block size in bytes.
+const BlockSize = 16
+
+var errKeySize = errors.New("aestable: invalid key size")
+
+// Cipher is an instance of AES encryption using a particular key.
+type Cipher struct {
+	enc    [32 + 28]uint32
+	dec    [32 + 28]uint32
+	keyLen int
+}
+
+// SetKey expands the key. The key argument should be the AES key,
+// either 16, 24, or 32 bytes to select AES-128, AES-192, or AES-256.
+func (c *Cipher) SetKey(key []byte) error {
+	k := len(key)
+
+	switch k {
+	default:
+		return errKeySize
+	case 16, 24, 32:
+		break
+	}
+	for i := range c.enc {
+		c.enc[i] = 0
+	}
+	for i := range c.dec {
+		c.dec[i] = 0
+	}
+	c.keyLen = k
+	expandKeyGo(key, c.enc[:c.keyLen+28], c.dec[:c.keyLen+28])
+	return nil
+}
+
+func (c *Cipher) BlockSize() int { return BlockSize }
+
+func (c *Cipher) Encrypt(dst, src []byte) {
+	if len(src) < BlockSize {
+		panic("crypto/aes: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("crypto/aes: output not full block")
+	}
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+	encryptBlockGo(c.enc[:c.keyLen+28], dst, src)
+}
+
+func (c *Cipher) Decrypt(dst, src []byte) {
+	if len(src) < BlockSize {
+		panic("crypto/aes: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("crypto/aes: output not full block")
+	}
+	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+	decryptBlockGo(c.dec[:c.keyLen+28], dst, src)
+}
+
+// EncryptBlocks encrypts multiple consecutive blocks (ECB mode). Length
+// of src must be a multiple of BlockSize.
+func (c *Cipher) EncryptBlocks(dst, src []byte) {
+	if len(src)%BlockSize != 0 {
+		panic("crypto/aes: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("crypto/aes: output smaller than input")
+	}
+	if alias.InexactOverlap(dst[:len(src)], src) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+	for i := 0; i < len(src); i += BlockSize {
+		encryptBlockGo(c.enc[:c.keyLen+28], dst[i:], src[i:])
+	}
+}
@@ -1,7 +1,6 @@
 // Copyright 2009 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
-// +build noasm amd64 arm64 ppc64le riscv64
 
 // This Go implementation is derived in part from the reference
 // ANSI C implementation, which carries the following notice:
@@ -35,7 +34,7 @@
 //	https://csrc.nist.gov/csrc/media/publications/fips/197/final/documents/fips-197.pdf
 //	https://csrc.nist.gov/archive/aes/rijndael/Rijndael-ammended.pdf
 
-package aes
+package aestable
 
 import (
 	"encoding/binary"
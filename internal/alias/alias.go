@@ -2,13 +2,11 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build !appengine
-
-// Package subtle implements functions that are often useful in cryptographic
-// code but require careful thought to use correctly.
+// Package alias implements memory aliasing tests, used by block ciphers
+// and modes of operation.
 //
-// This is a mirror of golang.org/x/crypto/internal/subtle.
-package aes
+// This is a mirror of golang.org/x/crypto/internal/alias.
+package alias
 
 import "unsafe"
 
@@ -0,0 +1,73 @@
+package bitslice
+
+import (
+	"testing"
+)
+
+// Multiply a and b in GF(2^8) modulo x⁸ + x⁴ + x³ + x + 1.
+func gmul(a, b byte) byte {
+	var p byte
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+	}
+	return p
+}
+
+// sboxRef computes AES S-box, inversion in GF(2^8) followed by the
+// affine transformation (FIPS 197, 5.1.1).
+func sboxRef(x byte) byte {
+	inv := byte(1)
+	for i := 0; i < 254; i++ {
+		inv = gmul(inv, x)
+	}
+	s := inv ^ 0x63
+	for i := uint(1); i < 5; i++ {
+		s ^= inv<<i | inv>>(8-i)
+	}
+	return s
+}
+
+// Test bitsliced S-box against the definition.
+func TestSbox(t *testing.T) {
+	var q [8]uint64
+	for i := 0; i < 256; i += 8 {
+		q[0] = 0
+		for j := 0; j < 8; j++ {
+			q[0] |= uint64(i+j) << (8 * uint(j))
+		}
+		for j := 1; j < 8; j++ {
+			q[j] = 0
+		}
+		Ortho(&q)
+		Sbox(&q)
+		Ortho(&q)
+		for j := 0; j < 8; j++ {
+			if got := byte(q[0] >> (8 * uint(j))); got != sboxRef(byte(i+j)) {
+				t.Errorf("Sbox(%#x) = %#x, want %#x", i+j, got, sboxRef(byte(i+j)))
+			}
+		}
+	}
+}
+
+func TestOrtho(t *testing.T) {
+	var q, exp [8]uint64
+	for i := range q {
+		q[i] = 0x0123456789ABCDEF * uint64(i+1)
+	}
+	exp = q
+	Ortho(&q)
+	if q == exp {
+		t.Error("Ortho didn't change the state")
+	}
+	Ortho(&q)
+	if q != exp {
+		t.Error("Ortho is not an involution")
+	}
+}
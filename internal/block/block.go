@@ -0,0 +1,88 @@
+// Package block implements helpers shared by block ciphers, their modes
+// of operation and other packages producing fixed size outputs: bulk
+// encryption, counter mode with a custom increment, XOR of byte slices
+// and growing of output slices.
+package block
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+const (
+	// Size of the block of ciphers supported by CTR
+	Size = 16
+	// Number of counter blocks encrypted at once
+	Batch = 8
+)
+
+// ErrOpen is returned by AEADs when the ciphertext or additional data
+// fails authentication.
+var ErrOpen = errors.New("cipher: message authentication failed")
+
+// bulkEncrypter is implemented by block ciphers which can encrypt
+// multiple blocks at once faster than one by one.
+type bulkEncrypter interface {
+	EncryptBlocks(dst, src []byte)
+}
+
+// Encrypt encrypts consecutive blocks from src with b.
+func Encrypt(b cipher.Block, dst, src []byte) {
+	if be, ok := b.(bulkEncrypter); ok {
+		be.EncryptBlocks(dst, src)
+		return
+	}
+	bs := b.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		b.Encrypt(dst[i:], src[i:])
+	}
+}
+
+// CTR encrypts src with keystream generated by encryption of counter
+// blocks with b, which must have 16-byte blocks. The counter is updated
+// with inc after each block.
+func CTR(b cipher.Block, counter *[Size]byte, inc func(*[Size]byte), dst, src []byte) {
+	var blks, ks [Batch * Size]byte
+
+	for len(src) > 0 {
+		n := len(src)
+		if n > len(ks) {
+			n = len(ks)
+		}
+		nb := (n + Size - 1) / Size
+		for i := 0; i < nb; i++ {
+			copy(blks[i*Size:], counter[:])
+			inc(counter)
+		}
+		Encrypt(b, ks[:nb*Size], blks[:nb*Size])
+		XOR(dst, src[:n], ks[:])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// XOR sets dst[i] = a[i] ^ b[i] for i < n = min(len(a), len(b)).
+// Returns n.
+func XOR(dst, a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+	return n
+}
+
+// SliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and
+// a second slice that aliases into it and contains only the extra bytes.
+func SliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
@@ -0,0 +1,138 @@
+// Package ecdh implements Diffie-Hellman on X25519 and NIST P-256 with
+// encoded keys. It is shared by KEMs built on top of ECDH, the DHKEM of
+// hpke and the ECDH components of kem/hybrid.
+package ecdh
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"github.com/henrydcase/nobs/dh/x25519"
+)
+
+// Group is a Diffie-Hellman group operating on encoded keys.
+// Implementations are stateless and safe for concurrent use.
+type Group interface {
+	// Name returns name of the group.
+	Name() string
+	// PublicKeySize returns size of encoded public key in bytes.
+	PublicKeySize() int
+	// PrivateKeySize returns size of encoded private key in bytes.
+	PrivateKeySize() int
+	// SharedSize returns size of the result of Diffie-Hellman in bytes.
+	SharedSize() int
+	// ValidPrivateKey reports whether sk is a valid private key.
+	ValidPrivateKey(sk []byte) bool
+	// PublicKey computes public key for sk. Returns false if sk is invalid.
+	PublicKey(sk []byte) ([]byte, bool)
+	// DH computes shared secret. Returns false if sk or pk is invalid
+	// or the result is the identity.
+	DH(sk, pk []byte) ([]byte, bool)
+}
+
+var (
+	// X25519 is the group of RFC 7748, implemented with dh/x25519.
+	// Public keys of small order are rejected by DH.
+	X25519 Group = x25519Group{}
+	// P256 is ECDH on NIST P-256, implemented with crypto/elliptic.
+	// Public keys are uncompressed points, private keys are big-endian
+	// scalars and shared secret is the x-coordinate of the shared point.
+	// Points not on the curve are rejected.
+	P256 Group = p256Group{}
+)
+
+// GenerateKey generates random key pair of g. Private keys are read from
+// rng until a valid one is found.
+func GenerateKey(g Group, rng io.Reader) (pk, sk []byte, err error) {
+	sk = make([]byte, g.PrivateKeySize())
+	for {
+		if _, err = io.ReadFull(rng, sk); err != nil {
+			return nil, nil, err
+		}
+		if pk, ok := g.PublicKey(sk); ok {
+			return pk, sk, nil
+		}
+	}
+}
+
+// x25519Group implements X25519 with dh/x25519.
+type x25519Group struct{}
+
+func (x25519Group) Name() string        { return "X25519" }
+func (x25519Group) PublicKeySize() int  { return x25519.KeySize }
+func (x25519Group) PrivateKeySize() int { return x25519.KeySize }
+func (x25519Group) SharedSize() int     { return x25519.KeySize }
+
+func (x25519Group) ValidPrivateKey(sk []byte) bool {
+	return len(sk) == x25519.KeySize
+}
+
+func (x25519Group) PublicKey(sk []byte) ([]byte, bool) {
+	var prv, pub x25519.Key
+	if len(sk) != x25519.KeySize {
+		return nil, false
+	}
+	copy(prv[:], sk)
+	x25519.KeyGen(&pub, &prv)
+	return pub[:], true
+}
+
+// DH rejects public keys of small order, which result in all-zero output.
+func (x25519Group) DH(sk, pk []byte) ([]byte, bool) {
+	var prv, pub, out x25519.Key
+	if len(sk) != x25519.KeySize || len(pk) != x25519.KeySize {
+		return nil, false
+	}
+	copy(prv[:], sk)
+	copy(pub[:], pk)
+	if !x25519.Shared(&out, &prv, &pub) {
+		return nil, false
+	}
+	return out[:], true
+}
+
+// p256Group implements ECDH on NIST P-256 with crypto/elliptic.
+type p256Group struct{}
+
+// Size of P-256 scalar and field element in bytes
+const p256Size = 32
+
+func (p256Group) Name() string        { return "P-256" }
+func (p256Group) PublicKeySize() int  { return 1 + 2*p256Size }
+func (p256Group) PrivateKeySize() int { return p256Size }
+func (p256Group) SharedSize() int     { return p256Size }
+
+// ValidPrivateKey checks if 0 < sk < n.
+func (p256Group) ValidPrivateKey(sk []byte) bool {
+	s := new(big.Int).SetBytes(sk)
+	return len(sk) == p256Size && s.Sign() > 0 && s.Cmp(elliptic.P256().Params().N) < 0
+}
+
+func (g p256Group) PublicKey(sk []byte) ([]byte, bool) {
+	if !g.ValidPrivateKey(sk) {
+		return nil, false
+	}
+	c := elliptic.P256()
+	x, y := c.ScalarBaseMult(sk)
+	return elliptic.Marshal(c, x, y), true
+}
+
+func (g p256Group) DH(sk, pk []byte) ([]byte, bool) {
+	c := elliptic.P256()
+	if !g.ValidPrivateKey(sk) {
+		return nil, false
+	}
+	x, y := elliptic.Unmarshal(c, pk)
+	if x == nil {
+		return nil, false
+	}
+	x, y = c.ScalarMult(x, y, sk)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, false
+	}
+	out := make([]byte, p256Size)
+	xb := x.Bytes()
+	copy(out[p256Size-len(xb):], xb)
+	return out, true
+}
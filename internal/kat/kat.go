@@ -0,0 +1,77 @@
+// Package kat reads known-answer test files in the .rsp format used by
+// NIST PQC submissions. File consists of records separated by empty
+// lines, each record is a list of "name = value" lines, where values are
+// usually hex encoded. Lines starting with '#' are comments.
+package kat
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+)
+
+var errFormat = errors.New("kat: wrong format of input file")
+
+// Maximal length of a line. Public keys of some schemes exceed default
+// buffer size of bufio.Scanner.
+const maxLine = 1 << 20
+
+// Record holds values of a single test case, keyed by their names.
+type Record map[string]string
+
+// Bytes returns hex decoded value of name. Panics if value is missing or
+// isn't a hex string.
+func (r Record) Bytes(name string) []byte {
+	v, ok := r[name]
+	if !ok {
+		panic("kat: missing value " + name)
+	}
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic("kat: wrong format of value " + name)
+	}
+	return b
+}
+
+// Reader reads records of .rsp file.
+type Reader struct {
+	s *bufio.Scanner
+}
+
+// NewReader returns Reader which reads records from r.
+func NewReader(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxLine)
+	return &Reader{s: s}
+}
+
+// Next reads the next record. Returns io.EOF if there are no more records.
+func (r *Reader) Next() (Record, error) {
+	rec := make(Record)
+	for r.s.Scan() {
+		line := strings.TrimSpace(r.s.Text())
+		if len(line) == 0 {
+			if len(rec) != 0 {
+				return rec, nil
+			}
+			continue
+		}
+		if line[0] == '#' {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, errFormat
+		}
+		rec[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+	if len(rec) != 0 {
+		return rec, nil
+	}
+	return nil, io.EOF
+}
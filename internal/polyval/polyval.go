@@ -0,0 +1,138 @@
+// Package polyval implements POLYVAL universal hash from RFC 8452, which is
+// also used to compute GHASH of GCM. Both GHASH and POLYVAL are computed
+// with POLYVAL field arithmetic, as described in RFC 8452, Appendix A.
+// Arithmetic in GF(2^128) is constant-time. Carry-less multiplication uses
+// integer multiplication with "holes" between bits, so that carries never
+// spill into the bits of the result, which avoids table lookups.
+package polyval
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// BlockSize is the size of the input block and of the output in bytes.
+const BlockSize = 16
+
+// fieldElement is an element of the POLYVAL field, stored as 128-bit
+// little-endian integer.
+type fieldElement struct {
+	lo, hi uint64
+}
+
+// bmul64 returns lower 64 bits of the carry-less product of x and y.
+func bmul64(x, y uint64) uint64 {
+	const m0, m1, m2, m3 = 0x1111111111111111, 0x2222222222222222,
+		0x4444444444444444, 0x8888888888888888
+
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := (x0 * y0) ^ (x1 * y3) ^ (x2 * y2) ^ (x3 * y1)
+	z1 := (x0 * y1) ^ (x1 * y0) ^ (x2 * y3) ^ (x3 * y2)
+	z2 := (x0 * y2) ^ (x1 * y1) ^ (x2 * y0) ^ (x3 * y3)
+	z3 := (x0 * y3) ^ (x1 * y2) ^ (x2 * y1) ^ (x3 * y0)
+	return (z0 & m0) | (z1 & m1) | (z2 & m2) | (z3 & m3)
+}
+
+// clmul returns 128-bit carry-less product of x and y.
+func clmul(x, y uint64) (hi, lo uint64) {
+	lo = bmul64(x, y)
+	hi = bits.Reverse64(bmul64(bits.Reverse64(x), bits.Reverse64(y))) >> 1
+	return hi, lo
+}
+
+// dot returns a*b*x^-128 mod x^128 + x^127 + x^126 + x^121 + 1.
+func dot(a, b fieldElement) fieldElement {
+	// Karatsuba multiplication
+	h0, l0 := clmul(a.lo, b.lo)
+	h1, l1 := clmul(a.hi, b.hi)
+	hm, lm := clmul(a.lo^a.hi, b.lo^b.hi)
+	lm ^= l0 ^ l1
+	hm ^= h0 ^ h1
+
+	d0, d1, d2, d3 := l0, h0^lm, l1^hm, h1
+
+	// Montgomery reduction, 64 bits at a time. The polynomial is 1 modulo
+	// x^64, hence adding d_i*P*x^(64*i) clears word d_i.
+	d1 ^= (d0 << 63) ^ (d0 << 62) ^ (d0 << 57)
+	d2 ^= d0 ^ (d0 >> 1) ^ (d0 >> 2) ^ (d0 >> 7)
+	d2 ^= (d1 << 63) ^ (d1 << 62) ^ (d1 << 57)
+	d3 ^= d1 ^ (d1 >> 1) ^ (d1 >> 2) ^ (d1 >> 7)
+	return fieldElement{lo: d2, hi: d3}
+}
+
+// mulX returns a*x.
+func mulX(a fieldElement) fieldElement {
+	// All ones if the top bit is set
+	msb := -(a.hi >> 63)
+	r := fieldElement{
+		lo: a.lo << 1,
+		hi: (a.hi << 1) | (a.lo >> 63),
+	}
+	r.lo ^= msb & 1
+	r.hi ^= msb & 0xC200000000000000
+	return r
+}
+
+// Polyval computes POLYVAL or GHASH of the data.
+type Polyval struct {
+	h, s fieldElement
+	// If set, blocks are in GHASH representation, which is byte-reversed
+	// POLYVAL representation.
+	ghash bool
+}
+
+func (p *Polyval) load(b []byte) fieldElement {
+	if p.ghash {
+		return fieldElement{
+			lo: binary.BigEndian.Uint64(b[8:]),
+			hi: binary.BigEndian.Uint64(b[:8]),
+		}
+	}
+	return fieldElement{
+		lo: binary.LittleEndian.Uint64(b[:8]),
+		hi: binary.LittleEndian.Uint64(b[8:]),
+	}
+}
+
+// Init sets the key and resets the accumulator. For GHASH the key is
+// converted as per RFC 8452, Appendix A.
+func (p *Polyval) Init(key []byte, ghash bool) {
+	p.ghash = ghash
+	p.h = p.load(key)
+	if ghash {
+		p.h = mulX(p.h)
+	}
+	p.s = fieldElement{}
+}
+
+// Update processes the data. Last incomplete block is padded with zeros.
+func (p *Polyval) Update(data []byte) {
+	var blk [BlockSize]byte
+	for len(data) > 0 {
+		b := data
+		if len(data) < BlockSize {
+			copy(blk[:], data)
+			b = blk[:]
+		}
+		x := p.load(b)
+		p.s.lo ^= x.lo
+		p.s.hi ^= x.hi
+		p.s = dot(p.s, p.h)
+		if len(data) < BlockSize {
+			break
+		}
+		data = data[BlockSize:]
+	}
+}
+
+// Sum writes current value of the accumulator to out.
+func (p *Polyval) Sum(out *[BlockSize]byte) {
+	if p.ghash {
+		binary.BigEndian.PutUint64(out[:8], p.s.hi)
+		binary.BigEndian.PutUint64(out[8:], p.s.lo)
+		return
+	}
+	binary.LittleEndian.PutUint64(out[:8], p.s.lo)
+	binary.LittleEndian.PutUint64(out[8:], p.s.hi)
+}
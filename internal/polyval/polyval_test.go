@@ -0,0 +1,20 @@
+package polyval
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// RFC 8452, Appendix A
+func TestPolyval(t *testing.T) {
+	var p Polyval
+	var out [BlockSize]byte
+	p.Init(test.FromHex("25629347589242761d31f826ba4b757b"), false)
+	p.Update(test.FromHex("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362"))
+	p.Sum(&out)
+	if exp := test.FromHex("f7a3b47b846119fae5b7866cf5e5b77e"); !bytes.Equal(out[:], exp) {
+		t.Errorf("got %X, want %X", out, exp)
+	}
+}
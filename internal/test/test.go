@@ -0,0 +1,94 @@
+// Package test provides helpers shared by tests of the packages: decoding
+// of hex encoded test vectors, reading of ACVP files and an in-memory
+// state manager for the stateful signature schemes.
+package test
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// FromHex decodes hex string s. Panics if s isn't a valid hex string.
+func FromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// SHA3Sum returns SHA3-256 of b. Used to keep digests of long outputs,
+// like signatures, in the test tables.
+func SHA3Sum(b []byte) []byte {
+	h := sha3.New256()
+	_, _ = h.Write(b)
+	return h.Sum(nil)
+}
+
+// Seed returns n consecutive bytes 00 01 02 ..., used as a deterministic
+// input of key generation.
+func Seed(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// HexBytes is []byte encoded in hex in JSON
+type HexBytes []byte
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *HexBytes) UnmarshalJSON(data []byte) (err error) {
+	var s string
+	if err = json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*b, err = hex.DecodeString(s)
+	return err
+}
+
+// ReadACVP decodes gzipped JSON file testdata/path into v. Files are
+// prompt.json and expectedResults.json of ACVP vectors from
+// https://github.com/usnistgov/ACVP-Server.
+func ReadACVP(t testing.TB, path string, v interface{}) {
+	t.Helper()
+	f, err := os.Open("testdata/" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.NewDecoder(r).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// MemState is a state.Manager keeping the last stored key in memory.
+type MemState struct {
+	// Last stored key
+	Key []byte
+	// Number of successful calls to Store
+	Stores int
+	// Makes Store fail if set
+	Fail bool
+}
+
+// Store implements state.Manager.
+func (m *MemState) Store(key []byte) error {
+	if m.Fail {
+		return errors.New("store failed")
+	}
+	m.Key = append(m.Key[:0], key...)
+	m.Stores++
+	return nil
+}
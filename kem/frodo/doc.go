@@ -0,0 +1,23 @@
+// Package frodo implements FrodoKEM, key encapsulation mechanism based on
+// the plain Learning With Errors problem, as submitted to the third round
+// of the NIST PQC standardization process. Parameter sets FrodoKEM-640,
+// FrodoKEM-976 and FrodoKEM-1344 are provided, each with matrix A generated
+// either with AES-128 or with SHAKE128.
+//
+// The API follows the one of SIKE in dh/sidh: KEM object is allocated for
+// a given parameter set and then used for Encapsulate and Decapsulate
+// operations. AES comes from cipher/aes, which uses AES-NI if available.
+// SHAKE128 and SHAKE256 come from hash/sha3, rows of A are generated four
+// at a time with sha3.ShakeX4.
+//
+// Matrix A is never stored, its rows are generated when needed. Since A is
+// derived from the public seed, it's generated with table-based AES on
+// platforms without AES-NI. Operations on secret data are done in constant
+// time. Decapsulation of an invalid ciphertext results in pseudorandom
+// shared secret derived from the private key and the ciphertext.
+//
+// References:
+//   - [FRODO] FrodoKEM: Learning With Errors Key Encapsulation, algorithm
+//     specification and supporting documentation, version of 2021-06-04,
+//     https://frodokem.org/
+package frodo
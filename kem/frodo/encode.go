@@ -0,0 +1,81 @@
+package frodo
+
+// pack encodes elements of in with d bits each, most significant bits
+// first, [FRODO], Algorithm 3.
+func pack(out []byte, in []uint16, d uint) {
+	var acc uint32
+	var bits uint
+	j := 0
+	for _, v := range in {
+		acc = acc<<d | uint32(v&(1<<d-1))
+		bits += d
+		for bits >= 8 {
+			bits -= 8
+			out[j] = byte(acc >> bits)
+			j++
+		}
+	}
+}
+
+// unpack decodes elements of d bits each, [FRODO], Algorithm 4.
+func unpack(out []uint16, in []byte, d uint) {
+	var acc uint32
+	var bits uint
+	j := 0
+	for _, b := range in {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		if bits >= d {
+			bits -= d
+			out[j] = uint16(acc>>bits) & (1<<d - 1)
+			j++
+		}
+	}
+}
+
+// encode encodes message m as nbar x nbar matrix. Every b bytes of m are
+// read as little-endian integer and split into 8 elements, b bits each,
+// which are multiplied by q/2^b. [FRODO], Algorithm 1.
+func (p *params) encode(out []uint16, m []byte) {
+	for i := 0; i < nbar*nbar/8; i++ {
+		var t uint64
+		for j := uint(0); j < p.b; j++ {
+			t |= uint64(m[i*int(p.b)+int(j)]) << (8 * j)
+		}
+		for j := 0; j < 8; j++ {
+			out[8*i+j] = uint16(t&(1<<p.b-1)) << (p.logq - p.b)
+			t >>= p.b
+		}
+	}
+}
+
+// decode decodes nbar x nbar matrix to message m, rounding its elements
+// to the nearest multiples of q/2^b. [FRODO], Algorithm 2.
+func (p *params) decode(m []byte, in []uint16) {
+	q := uint16(1<<p.logq - 1)
+	for i := 0; i < nbar*nbar/8; i++ {
+		var t uint64
+		for j := 0; j < 8; j++ {
+			v := ((in[8*i+j] & q) + 1<<(p.logq-p.b-1)) >> (p.logq - p.b)
+			t |= uint64(v&(1<<p.b-1)) << (p.b * uint(j))
+		}
+		for j := uint(0); j < p.b; j++ {
+			m[i*int(p.b)+int(j)] = byte(t >> (8 * j))
+		}
+	}
+}
+
+// sample converts uniformly random 16-bit elements of s to samples of the
+// error distribution using inversion of its cumulative distribution
+// function, in constant time. [FRODO], Algorithm 5.
+func (p *params) sample(s []uint16) {
+	for i := range s {
+		var e uint16
+		sign := s[i] & 1
+		r := s[i] >> 1
+		for _, c := range p.cdf[:len(p.cdf)-1] {
+			e += (c - r) >> 15
+		}
+		s[i] = (-sign ^ e) + sign
+	}
+}
@@ -0,0 +1,424 @@
+package frodo
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+var (
+	errInvalidPublicKey  = errors.New("frodo: invalid public key")
+	errInvalidPrivateKey = errors.New("frodo: invalid private key")
+)
+
+// Domain separators of the sampling of S, E and of Sp, Ep, Epp
+const (
+	dsKeygen = 0x5F
+	dsEncaps = 0x96
+)
+
+// PublicKey represents FrodoKEM public key.
+type PublicKey struct {
+	params *params
+	// Seed of the matrix A
+	seedA [seedASize]byte
+	// Matrix B, n x nbar
+	b []uint16
+	// pkh, hash of the encoded key
+	h [maxSec]byte
+}
+
+// PrivateKey represents FrodoKEM private key. It contains corresponding
+// public key.
+type PrivateKey struct {
+	PublicKey
+	// Value used for implicit rejection
+	s [maxSec]byte
+	// Transposed matrix S, nbar x n
+	st []uint16
+}
+
+// KEM implements FrodoKEM key encapsulation mechanism.
+type KEM struct {
+	allocated bool
+	rng       io.Reader
+	params    *params
+	xof       sha3.ShakeHash
+	msg       [maxSec]byte
+	// Output of G_2, seedSE and the key k
+	g [2 * maxSec]byte
+	// Matrices Sp, Ep and Epp
+	r []uint16
+	// Bp and C of the ciphertext and of the re-encrypted ciphertext
+	bp, bp2 []uint16
+	c, c2   [nbar * nbar]uint16
+}
+
+// NewPublicKey initializes public key for a given parameter set.
+func NewPublicKey(id uint8) *PublicKey {
+	p := getParams(id)
+	return &PublicKey{params: p, b: make([]uint16, p.n*nbar)}
+}
+
+// Import imports public key stored in the byte string. Returns error in
+// case size of the input is wrong.
+func (pub *PublicKey) Import(input []byte) error {
+	if len(input) != pub.Size() {
+		return errInvalidPublicKey
+	}
+	pub.importKey(input)
+	return nil
+}
+
+func (pub *PublicKey) importKey(input []byte) {
+	p := pub.params
+	copy(pub.seedA[:], input)
+	unpack(pub.b, input[seedASize:], p.logq)
+	pub.hash(input)
+}
+
+// hash computes pkh of the encoded key.
+func (pub *PublicKey) hash(encoded []byte) {
+	xof := pub.params.shake()
+	_, _ = xof.Write(encoded)
+	_, _ = xof.Read(pub.h[:pub.params.sec])
+}
+
+// Export writes public key to out, which must be at least Size() bytes
+// long. Encoding is seedA || pack(B).
+func (pub *PublicKey) Export(out []byte) {
+	copy(out, pub.seedA[:])
+	pack(out[seedASize:], pub.b, pub.params.logq)
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return pub.params.publicKeySize()
+}
+
+// NewPrivateKey initializes private key for a given parameter set.
+func NewPrivateKey(id uint8) *PrivateKey {
+	p := getParams(id)
+	return &PrivateKey{
+		PublicKey: *NewPublicKey(id),
+		st:        make([]uint16, p.n*nbar),
+	}
+}
+
+// Import imports private key stored in the byte string, which includes
+// the public key. Returns error in case size of the input is wrong or
+// stored pkh doesn't match the public key.
+func (prv *PrivateKey) Import(input []byte) error {
+	p := prv.params
+	if len(input) != prv.Size() {
+		return errInvalidPrivateKey
+	}
+	pk := input[p.sec : p.sec+p.publicKeySize()]
+	st := input[p.sec+len(pk) : len(input)-p.sec]
+	prv.importKey(pk)
+	if subtle.ConstantTimeCompare(prv.h[:p.sec], input[len(input)-p.sec:]) != 1 {
+		return errInvalidPrivateKey
+	}
+	copy(prv.s[:], input[:p.sec])
+	for i := range prv.st {
+		prv.st[i] = binary.LittleEndian.Uint16(st[2*i:])
+	}
+	return nil
+}
+
+// Export writes private key to out, which must be at least Size() bytes
+// long. Encoding is s || pk || S^T || pkh, elements of S^T are stored as
+// 16-bit little-endian integers.
+func (prv *PrivateKey) Export(out []byte) {
+	p := prv.params
+	copy(out, prv.s[:p.sec])
+	out = out[p.sec:]
+	prv.PublicKey.Export(out)
+	out = out[p.publicKeySize():]
+	for i, v := range prv.st {
+		binary.LittleEndian.PutUint16(out[2*i:], v)
+	}
+	copy(out[2*len(prv.st):], prv.h[:p.sec])
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return prv.params.privateKeySize()
+}
+
+// Generate generates a random key pair. It reads 2*len_sec+16 bytes from
+// rng, which are s, seedSE and z. Returns error in case rng fails.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	var seed [2*maxSec + seedASize]byte
+	p := prv.params
+	if _, err := io.ReadFull(rng, seed[:p.seedSize()]); err != nil {
+		return err
+	}
+	prv.generate(seed[:p.sec], seed[p.sec:2*p.sec], seed[2*p.sec:p.seedSize()])
+	return nil
+}
+
+// GeneratePublicKey copies public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	pub.params = prv.params
+	pub.seedA = prv.seedA
+	pub.h = prv.h
+	pub.b = append(pub.b[:0], prv.b...)
+}
+
+// generate derives key pair from s, seedSE and z. [FRODO], Algorithm 12.
+func (prv *PrivateKey) generate(s, seedSE, z []byte) {
+	p := prv.params
+	n := p.n
+	r := make([]uint16, 2*n*nbar)
+	pk := make([]byte, p.publicKeySize())
+
+	// seedA = SHAKE(z)
+	xof := p.shake()
+	_, _ = xof.Write(z)
+	_, _ = xof.Read(prv.seedA[:])
+
+	// S^T and E sampled from SHAKE(0x5F || seedSE)
+	xof.Reset()
+	_, _ = xof.Write([]byte{dsKeygen})
+	_, _ = xof.Write(seedSE)
+	readUint16(xof, r)
+	p.sample(r)
+	copy(prv.st, r[:n*nbar])
+
+	// B = A*S + E
+	newMatrixA(p, prv.seedA[:]).mulAddAS(prv.b, prv.st, r[n*nbar:])
+	for i := range prv.b {
+		prv.b[i] &= 1<<p.logq - 1
+	}
+	copy(prv.s[:], s)
+
+	prv.PublicKey.Export(pk)
+	prv.hash(pk)
+}
+
+// readUint16 reads 16-bit little-endian integers from xof to out.
+func readUint16(xof sha3.ShakeHash, out []uint16) {
+	var buf [256]byte
+	for len(out) > 0 {
+		l := len(out)
+		if l > len(buf)/2 {
+			l = len(buf) / 2
+		}
+		_, _ = xof.Read(buf[:2*l])
+		for i := 0; i < l; i++ {
+			out[i] = binary.LittleEndian.Uint16(buf[2*i:])
+		}
+		out = out[l:]
+	}
+}
+
+// NewFrodoKEM640AES instantiates FrodoKEM-640-AES KEM.
+func NewFrodoKEM640AES(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(FrodoKEM640AES, rng)
+	return &c
+}
+
+// NewFrodoKEM640SHAKE instantiates FrodoKEM-640-SHAKE KEM.
+func NewFrodoKEM640SHAKE(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(FrodoKEM640SHAKE, rng)
+	return &c
+}
+
+// NewFrodoKEM976AES instantiates FrodoKEM-976-AES KEM.
+func NewFrodoKEM976AES(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(FrodoKEM976AES, rng)
+	return &c
+}
+
+// NewFrodoKEM976SHAKE instantiates FrodoKEM-976-SHAKE KEM.
+func NewFrodoKEM976SHAKE(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(FrodoKEM976SHAKE, rng)
+	return &c
+}
+
+// NewFrodoKEM1344AES instantiates FrodoKEM-1344-AES KEM.
+func NewFrodoKEM1344AES(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(FrodoKEM1344AES, rng)
+	return &c
+}
+
+// NewFrodoKEM1344SHAKE instantiates FrodoKEM-1344-SHAKE KEM.
+func NewFrodoKEM1344SHAKE(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(FrodoKEM1344SHAKE, rng)
+	return &c
+}
+
+// Allocate allocates KEM object for multiple FrodoKEM operations. The rng
+// must be cryptographically secure PRNG.
+func (c *KEM) Allocate(id uint8, rng io.Reader) {
+	c.rng = rng
+	c.params = getParams(id)
+	n := c.params.n
+	c.xof = c.params.shake()
+	c.r = make([]uint16, 2*n*nbar+nbar*nbar)
+	c.bp = make([]uint16, n*nbar)
+	c.bp2 = make([]uint16, n*nbar)
+	c.allocated = true
+}
+
+// Encapsulate receives the public key and generates FrodoKEM ciphertext
+// and shared secret. Error is returned in case PRNG fails. Function panics
+// in case wrongly formated input was provided.
+func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *PublicKey) error {
+	if !c.allocated {
+		panic("KEM unallocated")
+	}
+
+	if pub.params != c.params {
+		panic("Wrong type of public key")
+	}
+
+	if len(secret) < c.SharedSecretSize() {
+		panic("shared secret buffer too small")
+	}
+
+	if len(ciphertext) < c.CiphertextSize() {
+		panic("ciphertext buffer too small")
+	}
+
+	p := c.params
+	if _, err := io.ReadFull(c.rng, c.msg[:p.sec]); err != nil {
+		return err
+	}
+
+	// seedSE || k = SHAKE(pkh || mu)
+	c.xof.Reset()
+	_, _ = c.xof.Write(pub.h[:p.sec])
+	_, _ = c.xof.Write(c.msg[:p.sec])
+	_, _ = c.xof.Read(c.g[:2*p.sec])
+	c.encrypt(c.bp, c.c[:], pub, c.msg[:p.sec], c.g[:p.sec])
+	pack(ciphertext, c.bp, p.logq)
+	pack(ciphertext[p.matrixSize():], c.c[:], p.logq)
+
+	// ss = SHAKE(c1 || c2 || k)
+	c.xof.Reset()
+	_, _ = c.xof.Write(ciphertext[:p.ciphertextSize()])
+	_, _ = c.xof.Write(c.g[p.sec : 2*p.sec])
+	_, _ = c.xof.Read(secret[:p.sec])
+	return nil
+}
+
+// Decapsulate given the private key and ciphertext as inputs, outputs a
+// shared secret. In case ciphertext is invalid, function outputs
+// pseudorandom value derived from the private key and the ciphertext.
+// Function panics in case input is wrongly formated, in particular, size
+// of the 'ciphertext' must be exactly equal to c.CiphertextSize(). Constant
+// time.
+func (c *KEM) Decapsulate(secret []byte, prv *PrivateKey, ciphertext []byte) error {
+	if !c.allocated {
+		panic("KEM unallocated")
+	}
+
+	if prv.params != c.params {
+		panic("Wrong type of private key")
+	}
+
+	if len(secret) < c.SharedSecretSize() {
+		panic("shared secret buffer too small")
+	}
+
+	if len(ciphertext) != c.CiphertextSize() {
+		panic("ciphertext has wrong size")
+	}
+
+	var w [nbar * nbar]uint16
+	p := c.params
+	q := uint16(1<<p.logq - 1)
+
+	// mu' = decode(C - Bp*S)
+	unpack(c.bp, ciphertext[:p.matrixSize()], p.logq)
+	unpack(c.c[:], ciphertext[p.matrixSize():], p.logq)
+	mulBS(w[:], c.bp, prv.st, p.n)
+	for i := range w {
+		w[i] = c.c[i] - w[i]
+	}
+	p.decode(c.msg[:p.sec], w[:])
+
+	// seedSE' || k' = SHAKE(pkh || mu')
+	c.xof.Reset()
+	_, _ = c.xof.Write(prv.h[:p.sec])
+	_, _ = c.xof.Write(c.msg[:p.sec])
+	_, _ = c.xof.Read(c.g[:2*p.sec])
+	c.encrypt(c.bp2, c.c2[:], &prv.PublicKey, c.msg[:p.sec], c.g[:p.sec])
+
+	// k' is replaced with s if Bp or C differ from the re-encrypted ones
+	var diff uint16
+	for i := range c.bp {
+		diff |= c.bp[i] ^ (c.bp2[i] & q)
+	}
+	for i := range c.c {
+		diff |= c.c[i] ^ (c.c2[i] & q)
+	}
+	eq := subtle.ConstantTimeEq(int32(diff), 0)
+	subtle.ConstantTimeCopy(1-eq, c.g[p.sec:2*p.sec], prv.s[:p.sec])
+
+	// ss = SHAKE(c1 || c2 || k')
+	c.xof.Reset()
+	_, _ = c.xof.Write(ciphertext)
+	_, _ = c.xof.Write(c.g[p.sec : 2*p.sec])
+	_, _ = c.xof.Read(secret[:p.sec])
+	return nil
+}
+
+// Resets internal state of KEM. Function should be used
+// after Allocate and between subsequent calls to Encapsulate
+// and/or Decapsulate.
+func (c *KEM) Reset() {
+	for i := range c.msg {
+		c.msg[i] = 0
+	}
+	for i := range c.g {
+		c.g[i] = 0
+	}
+	for i := range c.r {
+		c.r[i] = 0
+	}
+}
+
+// Returns size of resulting ciphertext.
+func (c *KEM) CiphertextSize() int {
+	return c.params.ciphertextSize()
+}
+
+// Returns size of resulting shared secret.
+func (c *KEM) SharedSecretSize() int {
+	return c.params.sec
+}
+
+// encrypt computes Bp = Sp*A + Ep and C = Sp*B + Epp + encode(m), where
+// Sp, Ep and Epp are sampled from seedSE. [FRODO], Algorithm 13, steps 3
+// to 10.
+func (c *KEM) encrypt(bp, cm []uint16, pub *PublicKey, m, seedSE []byte) {
+	p := c.params
+	n := p.n
+
+	c.xof.Reset()
+	_, _ = c.xof.Write([]byte{dsEncaps})
+	_, _ = c.xof.Write(seedSE)
+	readUint16(c.xof, c.r)
+	p.sample(c.r)
+	sp, ep, epp := c.r[:n*nbar], c.r[n*nbar:2*n*nbar], c.r[2*n*nbar:]
+
+	newMatrixA(p, pub.seedA[:]).mulAddSA(bp, sp, ep)
+	mulAddSB(cm, sp, pub.b, epp, n)
+	var enc [nbar * nbar]uint16
+	p.encode(enc[:], m)
+	for i := range cm {
+		cm[i] += enc[i]
+	}
+}
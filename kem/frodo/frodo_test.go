@@ -0,0 +1,356 @@
+package frodo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/henrydcase/nobs/drbg"
+	"github.com/henrydcase/nobs/internal/kat"
+)
+
+var allIDs = []uint8{
+	FrodoKEM640AES, FrodoKEM640SHAKE,
+	FrodoKEM976AES, FrodoKEM976SHAKE,
+	FrodoKEM1344AES, FrodoKEM1344SHAKE,
+}
+
+// First records of the NIST round-3 KAT files. Files were regenerated
+// with the NIST KAT procedure and truncated to the first test case.
+var katFiles = map[uint8]string{
+	FrodoKEM640AES:    "PQCkemKAT_19888.rsp.gz",
+	FrodoKEM640SHAKE:  "PQCkemKAT_19888_shake.rsp.gz",
+	FrodoKEM976AES:    "PQCkemKAT_31296.rsp.gz",
+	FrodoKEM976SHAKE:  "PQCkemKAT_31296_shake.rsp.gz",
+	FrodoKEM1344AES:   "PQCkemKAT_43088.rsp.gz",
+	FrodoKEM1344SHAKE: "PQCkemKAT_43088_shake.rsp.gz",
+}
+
+// testPanic returns error if call to function 'f' didn't cause panic.
+func testPanic(f func()) (err error) {
+	err = errors.New("no panic detected")
+	defer func() {
+		if r := recover(); r != nil {
+			err = nil
+		}
+	}()
+	f()
+	return err
+}
+
+// katEntry runs the NIST KAT procedure for a single seed and returns
+// pk, sk, ct and ss.
+func katEntry(id uint8, seed []byte) (pk, sk, ct, ss []byte) {
+	rng := drbg.NewCtrDrbg()
+	rng.Init(seed, nil)
+	prv := NewPrivateKey(id)
+	pub := NewPublicKey(id)
+	if err := prv.Generate(rng); err != nil {
+		panic(err)
+	}
+	prv.GeneratePublicKey(pub)
+	pk = make([]byte, pub.Size())
+	sk = make([]byte, prv.Size())
+	pub.Export(pk)
+	prv.Export(sk)
+
+	kem := new(KEM)
+	kem.Allocate(id, rng)
+	ct = make([]byte, kem.CiphertextSize())
+	ss = make([]byte, kem.SharedSecretSize())
+	if err := kem.Encapsulate(ct, ss, pub); err != nil {
+		panic(err)
+	}
+	return pk, sk, ct, ss
+}
+
+func TestKAT(t *testing.T) {
+	for _, id := range allIDs {
+		name := getParams(id).name
+		f, err := os.Open("testdata/" + katFiles[id])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := kat.NewReader(gz)
+		for {
+			rec, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pk, sk, ct, ss := katEntry(id, rec.Bytes("seed"))
+			if !bytes.Equal(pk, rec.Bytes("pk")) || !bytes.Equal(sk, rec.Bytes("sk")) {
+				t.Fatalf("%s count %s: wrong key pair", name, rec["count"])
+			}
+			if !bytes.Equal(ct, rec.Bytes("ct")) || !bytes.Equal(ss, rec.Bytes("ss")) {
+				t.Fatalf("%s count %s: wrong encapsulation", name, rec["count"])
+			}
+
+			prv := NewPrivateKey(id)
+			if err := prv.Import(rec.Bytes("sk")); err != nil {
+				t.Fatal(err)
+			}
+			kem := new(KEM)
+			kem.Allocate(id, nil)
+			if err := kem.Decapsulate(ss, prv, rec.Bytes("ct")); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(ss, rec.Bytes("ss")) {
+				t.Fatalf("%s count %s: wrong decapsulation", name, rec["count"])
+			}
+		}
+	}
+}
+
+// Regenerates complete PQCkemKAT_19888_shake.rsp and compares its SHA-256
+// with the digest of the file from the reference implementation
+// (github.com/microsoft/PQCrypto-LWEKE, 66fc7744c3aae6acfc5fcc587ec7f2cdec48d216).
+func TestKATDigest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipped in short mode")
+	}
+	const want = "604a10cfc871dfaed9cb5b057c644ab03b16852cea7f39bc7f9831513b5b1cfa"
+	var seed [48]byte
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	rng := drbg.NewCtrDrbg()
+	rng.Init(seed[:], nil)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "# %s\n\n", getParams(FrodoKEM640SHAKE).name)
+	for i := 0; i < 100; i++ {
+		_, _ = rng.Read(seed[:])
+		pk, sk, ct, ss := katEntry(FrodoKEM640SHAKE, seed[:])
+		fmt.Fprintf(h, "count = %d\nseed = %X\n", i, seed)
+		fmt.Fprintf(h, "pk = %X\nsk = %X\nct = %X\nss = %X\n\n", pk, sk, ct, ss)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("wrong digest of KAT file: %s", got)
+	}
+}
+
+func TestPack(t *testing.T) {
+	var in, out [nbar * nbar]uint16
+	var buf [2 * nbar * nbar]byte
+	for _, d := range []uint{15, 16} {
+		for i := range in {
+			in[i] = uint16(i*0x1235+0x57) & (1<<d - 1)
+		}
+		pack(buf[:d*nbar], in[:], d)
+		unpack(out[:], buf[:d*nbar], d)
+		if in != out {
+			t.Errorf("pack/unpack failed for d=%d", d)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	var m, m2 [maxSec]byte
+	var v [nbar * nbar]uint16
+	for _, id := range allIDs {
+		p := getParams(id)
+		_, _ = rand.Read(m[:p.sec])
+		p.encode(v[:], m[:p.sec])
+		// Small noise must be corrected by decoding
+		for i := range v {
+			v[i] += uint16(i%7) - 3
+		}
+		p.decode(m2[:p.sec], v[:])
+		if m != m2 {
+			t.Errorf("%s: decoding failed", p.name)
+		}
+	}
+}
+
+func TestKEMRoundTrip(t *testing.T) {
+	var ssE, ssD [maxSec]byte
+	for _, id := range allIDs {
+		kem := new(KEM)
+		kem.Allocate(id, rand.Reader)
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		ct := make([]byte, kem.CiphertextSize())
+		for i := 0; i < 3; i++ {
+			if err := sk.Generate(rand.Reader); err != nil {
+				t.Fatal(err)
+			}
+			sk.GeneratePublicKey(pk)
+			if err := kem.Encapsulate(ct, ssE[:], pk); err != nil {
+				t.Fatal(err)
+			}
+			if err := kem.Decapsulate(ssD[:], sk, ct); err != nil {
+				t.Fatal(err)
+			}
+			if ssE != ssD {
+				t.Fatalf("%s: shared secrets differ", getParams(id).name)
+			}
+		}
+		kem.Reset()
+	}
+}
+
+// Modified ciphertext must result in ss = SHAKE(c1 || c2 || s).
+func TestImplicitRejection(t *testing.T) {
+	var ssE, ssD [maxSec]byte
+	for _, id := range allIDs {
+		p := getParams(id)
+		kem := new(KEM)
+		kem.Allocate(id, rand.Reader)
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		ct := make([]byte, kem.CiphertextSize())
+		if err := sk.Generate(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		if err := kem.Encapsulate(ct, ssE[:], pk); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, i := range []int{0, len(ct) / 2, len(ct) - 1} {
+			ct[i] ^= 0x01
+			if err := kem.Decapsulate(ssD[:], sk, ct); err != nil {
+				t.Fatal(err)
+			}
+			var exp [maxSec]byte
+			xof := p.shake()
+			_, _ = xof.Write(ct)
+			_, _ = xof.Write(sk.s[:p.sec])
+			_, _ = xof.Read(exp[:p.sec])
+			if ssD == ssE || ssD != exp {
+				t.Errorf("%s: wrong implicit rejection", p.name)
+			}
+			ct[i] ^= 0x01
+		}
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, id := range allIDs {
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		if err := sk.Generate(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		ek := make([]byte, pk.Size())
+		dk := make([]byte, sk.Size())
+		pk.Export(ek)
+		sk.Export(dk)
+
+		if NewPublicKey(id).Import(ek[1:]) == nil {
+			t.Error("public key of wrong size accepted")
+		}
+		if NewPrivateKey(id).Import(dk[1:]) == nil {
+			t.Error("private key of wrong size accepted")
+		}
+
+		// Hash check
+		dk[len(dk)-1] ^= 1
+		if NewPrivateKey(id).Import(dk) == nil {
+			t.Error("private key with wrong hash accepted")
+		}
+		dk[len(dk)-1] ^= 1
+		if err := NewPrivateKey(id).Import(dk); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPanics(t *testing.T) {
+	var ss [maxSec]byte
+	kem := NewFrodoKEM640SHAKE(rand.Reader)
+	sk := NewPrivateKey(FrodoKEM640SHAKE)
+	pk := NewPublicKey(FrodoKEM640SHAKE)
+	ct := make([]byte, kem.CiphertextSize())
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+
+	if testPanic(func() { _ = new(KEM).Encapsulate(ct, ss[:], pk) }) != nil {
+		t.Error("unallocated KEM must panic")
+	}
+	if testPanic(func() { _ = kem.Encapsulate(ct[1:], ss[:], pk) }) != nil {
+		t.Error("short ciphertext buffer must panic")
+	}
+	if testPanic(func() { _ = kem.Encapsulate(ct, ss[:1], pk) }) != nil {
+		t.Error("short secret buffer must panic")
+	}
+	if testPanic(func() { _ = kem.Decapsulate(ss[:], sk, ct[1:]) }) != nil {
+		t.Error("wrong ciphertext size must panic")
+	}
+	if testPanic(func() { _ = NewFrodoKEM640AES(rand.Reader).Encapsulate(ct, ss[:], pk) }) != nil {
+		t.Error("public key of different parameter set must panic")
+	}
+	if testPanic(func() { NewPublicKey(FrodoKEM1344SHAKE + 1) }) != nil {
+		t.Error("unknown parameter set must panic")
+	}
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	for _, id := range allIDs {
+		b.Run(getParams(id).name, func(b *testing.B) {
+			sk := NewPrivateKey(id)
+			for n := 0; n < b.N; n++ {
+				_ = sk.Generate(rand.Reader)
+			}
+		})
+	}
+}
+
+func BenchmarkEncaps(b *testing.B) {
+	var ss [maxSec]byte
+	for _, id := range allIDs {
+		b.Run(getParams(id).name, func(b *testing.B) {
+			kem := new(KEM)
+			kem.Allocate(id, rand.Reader)
+			sk := NewPrivateKey(id)
+			pk := NewPublicKey(id)
+			ct := make([]byte, kem.CiphertextSize())
+			_ = sk.Generate(rand.Reader)
+			sk.GeneratePublicKey(pk)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_ = kem.Encapsulate(ct, ss[:], pk)
+			}
+		})
+	}
+}
+
+func BenchmarkDecaps(b *testing.B) {
+	var ss [maxSec]byte
+	for _, id := range allIDs {
+		b.Run(getParams(id).name, func(b *testing.B) {
+			kem := new(KEM)
+			kem.Allocate(id, rand.Reader)
+			sk := NewPrivateKey(id)
+			pk := NewPublicKey(id)
+			ct := make([]byte, kem.CiphertextSize())
+			_ = sk.Generate(rand.Reader)
+			sk.GeneratePublicKey(pk)
+			_ = kem.Encapsulate(ct, ss[:], pk)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_ = kem.Decapsulate(ss[:], sk, ct)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package frodo
+
+import (
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/cipher/aes"
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/internal/aestable"
+	"github.com/henrydcase/nobs/utils"
+)
+
+// matrixA generates rows of the matrix A from seedA, [FRODO], Section
+// 2.2.5. Rows are generated four at a time.
+type matrixA struct {
+	p *params
+	// AES-128 keyed with seedA, used by AES variants
+	block aes.IAES
+	// Four SHAKE128 instances and the seed, used by SHAKE variants
+	x4   *sha3.ShakeX4
+	seed [4][2 + seedASize]byte
+	// Input and output of the generation of four rows
+	in, out [4][2 * maxN]byte
+}
+
+func newMatrixA(p *params, seedA []byte) *matrixA {
+	a := &matrixA{p: p}
+	if p.aes {
+		// A is public, so the table-based implementation is used if
+		// AES-NI is not available.
+		a.block = &aestable.Cipher{}
+		if utils.X86.HasAES {
+			a.block = &aes.AESAsm{}
+		}
+		_ = a.block.SetKey(seedA)
+		// Blocks are (i, j, 0, ..., 0) for the row i and column j,
+		// columns are set once.
+		for r := range a.in {
+			for j := 0; j < p.n; j += 8 {
+				binary.LittleEndian.PutUint16(a.in[r][2*j+2:], uint16(j))
+			}
+		}
+	} else {
+		a.x4 = sha3.NewShake128X4()
+		for r := range a.seed {
+			copy(a.seed[r][2:], seedA)
+		}
+	}
+	return a
+}
+
+// rows generates rows i to i+3 of A and stores them in out.
+func (a *matrixA) rows(out *[4][maxN]uint16, i int) {
+	n := a.p.n
+	if a.p.aes {
+		for r := range a.in {
+			for j := 0; j < n; j += 8 {
+				binary.LittleEndian.PutUint16(a.in[r][2*j:], uint16(i+r))
+			}
+			a.block.EncryptBlocks(a.out[r][:2*n], a.in[r][:2*n])
+		}
+	} else {
+		for r := range a.seed {
+			binary.LittleEndian.PutUint16(a.seed[r][:], uint16(i+r))
+		}
+		a.x4.Reset()
+		_, _ = a.x4.Write(a.seed[0][:], a.seed[1][:], a.seed[2][:], a.seed[3][:])
+		_, _ = a.x4.Read(a.out[0][:2*n], a.out[1][:2*n], a.out[2][:2*n], a.out[3][:2*n])
+	}
+	for r := range out {
+		for j := 0; j < n; j++ {
+			out[r][j] = binary.LittleEndian.Uint16(a.out[r][2*j:])
+		}
+	}
+}
+
+// mulAddAS computes B = A*S + E, where st is S transposed. st is
+// nbar x n, e and out are n x nbar matrices. [FRODO], Algorithm 9.
+func (a *matrixA) mulAddAS(out, st, e []uint16) {
+	var rows [4][maxN]uint16
+	n := a.p.n
+	copy(out, e[:n*nbar])
+	for i := 0; i < n; i += 4 {
+		a.rows(&rows, i)
+		for r := range rows {
+			row := rows[r][:n]
+			for k := 0; k < nbar; k++ {
+				var sum uint16
+				s := st[k*n : (k+1)*n]
+				for j := range row {
+					sum += row[j] * s[j]
+				}
+				out[(i+r)*nbar+k] += sum
+			}
+		}
+	}
+}
+
+// mulAddSA computes Bp = Sp*A + Ep. s, e and out are nbar x n matrices.
+// [FRODO], Algorithm 10.
+func (a *matrixA) mulAddSA(out, s, e []uint16) {
+	var rows [4][maxN]uint16
+	n := a.p.n
+	copy(out, e[:n*nbar])
+	for i := 0; i < n; i += 4 {
+		a.rows(&rows, i)
+		for r := range rows {
+			row := rows[r][:n]
+			for k := 0; k < nbar; k++ {
+				sk := s[k*n+i+r]
+				o := out[k*n : (k+1)*n]
+				for j := range row {
+					o[j] += sk * row[j]
+				}
+			}
+		}
+	}
+}
+
+// mulAddSB computes V = Sp*B + Epp. s is nbar x n, b is n x nbar, e and
+// out are nbar x nbar matrices.
+func mulAddSB(out, s, b, e []uint16, n int) {
+	for k := 0; k < nbar; k++ {
+		for i := 0; i < nbar; i++ {
+			sum := e[k*nbar+i]
+			for j := 0; j < n; j++ {
+				sum += s[k*n+j] * b[j*nbar+i]
+			}
+			out[k*nbar+i] = sum
+		}
+	}
+}
+
+// mulBS computes W = Bp*S, where st is S transposed. b and st are nbar x n,
+// out is nbar x nbar matrix.
+func mulBS(out, b, st []uint16, n int) {
+	for i := 0; i < nbar; i++ {
+		for j := 0; j < nbar; j++ {
+			var sum uint16
+			for k := 0; k < n; k++ {
+				sum += b[i*n+k] * st[j*n+k]
+			}
+			out[i*nbar+j] = sum
+		}
+	}
+}
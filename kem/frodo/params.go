@@ -0,0 +1,90 @@
+package frodo
+
+import "github.com/henrydcase/nobs/hash/sha3"
+
+// Identifiers of FrodoKEM parameter sets
+const (
+	FrodoKEM640AES uint8 = iota
+	FrodoKEM640SHAKE
+	FrodoKEM976AES
+	FrodoKEM976SHAKE
+	FrodoKEM1344AES
+	FrodoKEM1344SHAKE
+)
+
+const (
+	// Size of the seed of matrix A in bytes
+	seedASize = 16
+	// Number of columns of S and E
+	nbar = 8
+	// Maximal dimension of the matrix A
+	maxN = 1344
+	// Maximal size of secrets, hashes and the message in bytes
+	maxSec = 32
+)
+
+// params describes a FrodoKEM parameter set, [FRODO], Section 2.4.
+type params struct {
+	id   uint8
+	name string
+	// Dimension of the matrix A
+	n int
+	// Modulus q = 2^logq
+	logq uint
+	// Number of bits encoded in each element of the message matrix
+	b uint
+	// Size of shared secret, private value s, seedSE, pkh and the message
+	// in bytes
+	sec int
+	// Whether A is generated with AES-128 or SHAKE128
+	aes bool
+	// Table of the cumulative distribution function of the error
+	// distribution
+	cdf []uint16
+	// SHAKE128 for FrodoKEM-640 and SHAKE256 otherwise
+	shake func() sha3.ShakeHash
+}
+
+var (
+	cdf640  = []uint16{4643, 13363, 20579, 25843, 29227, 31145, 32103, 32525, 32689, 32745, 32762, 32766, 32767}
+	cdf976  = []uint16{5638, 15915, 23689, 28571, 31116, 32217, 32613, 32731, 32760, 32766, 32767}
+	cdf1344 = []uint16{9142, 23462, 30338, 32361, 32725, 32765, 32767}
+)
+
+var frodoParams = [...]params{
+	FrodoKEM640AES:    {FrodoKEM640AES, "FrodoKEM-640-AES", 640, 15, 2, 16, true, cdf640, sha3.NewShake128},
+	FrodoKEM640SHAKE:  {FrodoKEM640SHAKE, "FrodoKEM-640-SHAKE", 640, 15, 2, 16, false, cdf640, sha3.NewShake128},
+	FrodoKEM976AES:    {FrodoKEM976AES, "FrodoKEM-976-AES", 976, 16, 3, 24, true, cdf976, sha3.NewShake256},
+	FrodoKEM976SHAKE:  {FrodoKEM976SHAKE, "FrodoKEM-976-SHAKE", 976, 16, 3, 24, false, cdf976, sha3.NewShake256},
+	FrodoKEM1344AES:   {FrodoKEM1344AES, "FrodoKEM-1344-AES", 1344, 16, 4, 32, true, cdf1344, sha3.NewShake256},
+	FrodoKEM1344SHAKE: {FrodoKEM1344SHAKE, "FrodoKEM-1344-SHAKE", 1344, 16, 4, 32, false, cdf1344, sha3.NewShake256},
+}
+
+func getParams(id uint8) *params {
+	if int(id) >= len(frodoParams) {
+		panic("frodo: parameter set ID unregistered")
+	}
+	return &frodoParams[id]
+}
+
+// Size of the packed n x nbar matrix
+func (p *params) matrixSize() int {
+	return int(p.logq) * p.n * nbar / 8
+}
+
+func (p *params) publicKeySize() int {
+	return seedASize + p.matrixSize()
+}
+
+func (p *params) privateKeySize() int {
+	return 2*p.sec + p.publicKeySize() + 2*p.n*nbar
+}
+
+func (p *params) ciphertextSize() int {
+	return p.matrixSize() + int(p.logq)*nbar*nbar/8
+}
+
+// Size of the seed s || seedSE || z used by key generation
+func (p *params) seedSize() int {
+	return 2*p.sec + seedASize
+}
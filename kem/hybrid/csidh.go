@@ -0,0 +1,101 @@
+package hybrid
+
+import (
+	"io"
+
+	"github.com/henrydcase/nobs/dh/csidh"
+)
+
+// csidhKEM adapts CSIDH key exchange to the Scheme interface.
+type csidhKEM struct {
+	rng io.Reader
+}
+
+// NewCSIDH returns Scheme based on CSIDH-512. Ciphertext is an ephemeral
+// public key, public keys are validated before use. The rng is also used
+// by the group action and the validation.
+func NewCSIDH(rng io.Reader) Scheme {
+	return &csidhKEM{rng: rng}
+}
+
+func (s *csidhKEM) Name() string {
+	return "CSIDH-512"
+}
+
+func (s *csidhKEM) PublicKeySize() int {
+	return csidh.PublicKeySize
+}
+
+func (s *csidhKEM) PrivateKeySize() int {
+	return csidh.PrivateKeySize
+}
+
+func (s *csidhKEM) CiphertextSize() int {
+	return csidh.PublicKeySize
+}
+
+func (s *csidhKEM) SharedSecretSize() int {
+	return csidh.SharedSecretSize
+}
+
+// generate returns fresh encoded key pair.
+func (s *csidhKEM) generate() (pk, sk []byte, err error) {
+	var prv csidh.PrivateKey
+	var pub csidh.PublicKey
+	if err = csidh.GeneratePrivateKey(&prv, s.rng); err != nil {
+		return nil, nil, err
+	}
+	csidh.GeneratePublicKey(&pub, &prv, s.rng)
+	pk = make([]byte, csidh.PublicKeySize)
+	sk = make([]byte, csidh.PrivateKeySize)
+	pub.Export(pk)
+	prv.Export(sk)
+	return pk, sk, nil
+}
+
+// derive computes shared secret from encoded keys.
+func (s *csidhKEM) derive(sk, pk []byte) ([]byte, bool) {
+	var prv csidh.PrivateKey
+	var pub csidh.PublicKey
+	var ss [csidh.SharedSecretSize]byte
+	if !prv.Import(sk) || !pub.Import(pk) {
+		return nil, false
+	}
+	if !csidh.DeriveSecret(&ss, &pub, &prv, s.rng) {
+		return nil, false
+	}
+	return ss[:], true
+}
+
+func (s *csidhKEM) GenerateKeyPair() (pk, sk []byte, err error) {
+	return s.generate()
+}
+
+func (s *csidhKEM) Encapsulate(pk []byte) (ct, ss []byte, err error) {
+	if len(pk) != csidh.PublicKeySize {
+		return nil, nil, errInvalidPublicKey
+	}
+	ct, esk, err := s.generate()
+	if err != nil {
+		return nil, nil, err
+	}
+	ss, ok := s.derive(esk, pk)
+	if !ok {
+		return nil, nil, errInvalidPublicKey
+	}
+	return ct, ss, nil
+}
+
+func (s *csidhKEM) Decapsulate(sk, ct []byte) (ss []byte, err error) {
+	if len(sk) != csidh.PrivateKeySize {
+		return nil, errInvalidPrivateKey
+	}
+	if len(ct) != csidh.PublicKeySize {
+		return nil, errInvalidCiphertext
+	}
+	ss, ok := s.derive(sk, ct)
+	if !ok {
+		return nil, errInvalidCiphertext
+	}
+	return ss, nil
+}
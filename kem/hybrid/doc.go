@@ -0,0 +1,23 @@
+// Package hybrid implements a generic combiner of two key encapsulation
+// mechanisms. It is meant to wrap SIKE and CSIDH, which are no longer
+// considered secure on their own, with a classical ECDH based KEM.
+//
+// Components are accessed through the Scheme interface, which operates on
+// encoded keys and ciphertexts. The package provides Scheme adapters for
+// SIKE (dh/sidh), CSIDH (dh/csidh), X25519 (dh/x25519) and P-256. ECDH is
+// turned into a KEM in the usual way: ciphertext is an ephemeral public
+// key and shared secret is the result of Diffie-Hellman.
+//
+// The combined ciphertext is a concatenation of ciphertexts of both
+// components and the shared secret is
+//
+//	cSHAKE256(ss_1 || ss_2 || ct_1 || ct_2, N="", S=name)
+//
+// where name identifies both components. Hashing the ciphertexts together
+// with the secrets makes the result IND-CCA secure as long as at least one
+// of the components is IND-CCA secure, [GHP18].
+//
+// References:
+//   - [GHP18] F. Giacon, F. Heuer, B. Poettering. KEM Combiners.
+//     https://eprint.iacr.org/2018/024
+package hybrid
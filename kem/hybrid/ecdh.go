@@ -0,0 +1,81 @@
+package hybrid
+
+import (
+	"io"
+
+	"github.com/henrydcase/nobs/internal/ecdh"
+)
+
+// dhKEM adapts a Diffie-Hellman group to the Scheme interface. Ciphertext
+// is an ephemeral public key.
+type dhKEM struct {
+	g   ecdh.Group
+	rng io.Reader
+}
+
+// NewX25519 returns Scheme based on X25519. Public keys of small order
+// are rejected.
+func NewX25519(rng io.Reader) Scheme {
+	return &dhKEM{g: ecdh.X25519, rng: rng}
+}
+
+// NewP256 returns Scheme based on ECDH on P-256, using crypto/elliptic.
+// Public keys and ciphertexts are uncompressed points, private keys are
+// big-endian scalars and shared secret is the x-coordinate of the shared
+// point. Points not on the curve are rejected.
+func NewP256(rng io.Reader) Scheme {
+	return &dhKEM{g: ecdh.P256, rng: rng}
+}
+
+func (s *dhKEM) Name() string {
+	return s.g.Name()
+}
+
+func (s *dhKEM) PublicKeySize() int {
+	return s.g.PublicKeySize()
+}
+
+func (s *dhKEM) PrivateKeySize() int {
+	return s.g.PrivateKeySize()
+}
+
+func (s *dhKEM) CiphertextSize() int {
+	return s.g.PublicKeySize()
+}
+
+func (s *dhKEM) SharedSecretSize() int {
+	return s.g.SharedSize()
+}
+
+func (s *dhKEM) GenerateKeyPair() (pk, sk []byte, err error) {
+	return ecdh.GenerateKey(s.g, s.rng)
+}
+
+func (s *dhKEM) Encapsulate(pk []byte) (ct, ss []byte, err error) {
+	if len(pk) != s.PublicKeySize() {
+		return nil, nil, errInvalidPublicKey
+	}
+	ct, esk, err := s.GenerateKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	ss, ok := s.g.DH(esk, pk)
+	if !ok {
+		return nil, nil, errInvalidPublicKey
+	}
+	return ct, ss, nil
+}
+
+func (s *dhKEM) Decapsulate(sk, ct []byte) (ss []byte, err error) {
+	if !s.g.ValidPrivateKey(sk) {
+		return nil, errInvalidPrivateKey
+	}
+	if len(ct) != s.CiphertextSize() {
+		return nil, errInvalidCiphertext
+	}
+	ss, ok := s.g.DH(sk, ct)
+	if !ok {
+		return nil, errInvalidCiphertext
+	}
+	return ss, nil
+}
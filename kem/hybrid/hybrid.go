@@ -0,0 +1,101 @@
+package hybrid
+
+import "github.com/henrydcase/nobs/hash/sha3"
+
+// SharedSecretSize is size of the combined shared secret in bytes.
+const SharedSecretSize = 32
+
+// combiner is a Scheme combining two other schemes.
+type combiner struct {
+	a, b Scheme
+	name string
+}
+
+// New returns Scheme combining a and b. Public keys, private keys and
+// ciphertexts are concatenations of the ones of a and b.
+func New(a, b Scheme) Scheme {
+	return &combiner{a: a, b: b, name: a.Name() + "+" + b.Name()}
+}
+
+func (c *combiner) Name() string {
+	return c.name
+}
+
+func (c *combiner) PublicKeySize() int {
+	return c.a.PublicKeySize() + c.b.PublicKeySize()
+}
+
+func (c *combiner) PrivateKeySize() int {
+	return c.a.PrivateKeySize() + c.b.PrivateKeySize()
+}
+
+func (c *combiner) CiphertextSize() int {
+	return c.a.CiphertextSize() + c.b.CiphertextSize()
+}
+
+func (c *combiner) SharedSecretSize() int {
+	return SharedSecretSize
+}
+
+func (c *combiner) GenerateKeyPair() (pk, sk []byte, err error) {
+	pka, ska, err := c.a.GenerateKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	pkb, skb, err := c.b.GenerateKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(pka, pkb...), append(ska, skb...), nil
+}
+
+func (c *combiner) Encapsulate(pk []byte) (ct, ss []byte, err error) {
+	if len(pk) != c.PublicKeySize() {
+		return nil, nil, errInvalidPublicKey
+	}
+	n := c.a.PublicKeySize()
+	cta, ssa, err := c.a.Encapsulate(pk[:n])
+	if err != nil {
+		return nil, nil, err
+	}
+	ctb, ssb, err := c.b.Encapsulate(pk[n:])
+	if err != nil {
+		return nil, nil, err
+	}
+	ct = append(cta, ctb...)
+	return ct, c.combine(ssa, ssb, ct), nil
+}
+
+func (c *combiner) Decapsulate(sk, ct []byte) (ss []byte, err error) {
+	if len(sk) != c.PrivateKeySize() {
+		return nil, errInvalidPrivateKey
+	}
+	if len(ct) != c.CiphertextSize() {
+		return nil, errInvalidCiphertext
+	}
+	// Both components are always run, so that timing doesn't reveal
+	// which one has failed.
+	n, m := c.a.PrivateKeySize(), c.a.CiphertextSize()
+	ssa, erra := c.a.Decapsulate(sk[:n], ct[:m])
+	ssb, errb := c.b.Decapsulate(sk[n:], ct[m:])
+	if erra != nil {
+		return nil, erra
+	}
+	if errb != nil {
+		return nil, errb
+	}
+	return c.combine(ssa, ssb, ct), nil
+}
+
+// combine computes cSHAKE256(ss_a || ss_b || ct_a || ct_b) customized
+// with the name of the scheme. Sizes of all inputs are fixed by the
+// components, so the encoding is unambiguous.
+func (c *combiner) combine(ssa, ssb, ct []byte) []byte {
+	ss := make([]byte, SharedSecretSize)
+	h := sha3.NewCShake256(nil, []byte(c.name))
+	_, _ = h.Write(ssa)
+	_, _ = h.Write(ssb)
+	_, _ = h.Write(ct)
+	_, _ = h.Read(ss)
+	return ss
+}
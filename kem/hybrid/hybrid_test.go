@@ -0,0 +1,230 @@
+package hybrid
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/henrydcase/nobs/dh/sidh"
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+func schemes() []Scheme {
+	return []Scheme{
+		New(NewSIKE(sidh.Fp434, rand.Reader), NewX25519(rand.Reader)),
+		New(NewSIKE(sidh.Fp503, rand.Reader), NewP256(rand.Reader)),
+		New(NewSIKE(sidh.Fp751, rand.Reader), NewX25519(rand.Reader)),
+		New(NewX25519(rand.Reader), NewP256(rand.Reader)),
+		New(New(NewSIKE(sidh.Fp434, rand.Reader), NewX25519(rand.Reader)), NewP256(rand.Reader)),
+	}
+}
+
+func checkSizes(t *testing.T, s Scheme, pk, sk, ct, ss []byte) {
+	t.Helper()
+	if len(pk) != s.PublicKeySize() || len(sk) != s.PrivateKeySize() ||
+		len(ct) != s.CiphertextSize() || len(ss) != s.SharedSecretSize() {
+		t.Fatalf("%s: wrong sizes of outputs", s.Name())
+	}
+}
+
+func roundTrip(t *testing.T, s Scheme) {
+	pk, sk, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, ssE, err := s.Encapsulate(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssD, err := s.Decapsulate(sk, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSizes(t, s, pk, sk, ct, ssE)
+	if !bytes.Equal(ssE, ssD) {
+		t.Fatalf("%s: shared secrets differ", s.Name())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, s := range schemes() {
+		for i := 0; i < 3; i++ {
+			roundTrip(t, s)
+		}
+	}
+}
+
+func TestCSIDH(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipped in short mode")
+	}
+	roundTrip(t, New(NewCSIDH(rand.Reader), NewX25519(rand.Reader)))
+
+	// Curve y^2 = x^3 + 2x^2 + x is singular
+	s := NewCSIDH(rand.Reader)
+	_, sk, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := make([]byte, s.CiphertextSize())
+	ct[0] = 2
+	if _, err = s.Decapsulate(sk, ct); err == nil {
+		t.Error("invalid CSIDH public key accepted")
+	}
+}
+
+// Combined secret must be cSHAKE256 over secrets and ciphertexts of the
+// components, customized with the name.
+func TestCombiner(t *testing.T) {
+	a, b := NewSIKE(sidh.Fp434, rand.Reader), NewX25519(rand.Reader)
+	s := New(a, b)
+	if s.Name() != "SIKEp434+X25519" {
+		t.Errorf("wrong name %s", s.Name())
+	}
+	pk, sk, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, ss, err := s.Encapsulate(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, m := a.PrivateKeySize(), a.CiphertextSize()
+	ssa, err := a.Decapsulate(sk[:n], ct[:m])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssb, err := b.Decapsulate(sk[n:], ct[m:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := make([]byte, SharedSecretSize)
+	h := sha3.NewCShake256(nil, []byte("SIKEp434+X25519"))
+	_, _ = h.Write(ssa)
+	_, _ = h.Write(ssb)
+	_, _ = h.Write(ct)
+	_, _ = h.Read(exp)
+	if !bytes.Equal(ss, exp) {
+		t.Error("wrong combined secret")
+	}
+
+	// Modification of SIKE ciphertext is implicitly rejected and must
+	// result in a different secret.
+	ct[0] ^= 1
+	ssD, err := s.Decapsulate(sk, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(ss, ssD) {
+		t.Error("modified ciphertext not detected")
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	for _, s := range schemes() {
+		pk, sk, err := s.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _, err := s.Encapsulate(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err = s.Encapsulate(pk[1:]); err == nil {
+			t.Errorf("%s: public key of wrong size accepted", s.Name())
+		}
+		if _, err = s.Decapsulate(sk[1:], ct); err == nil {
+			t.Errorf("%s: private key of wrong size accepted", s.Name())
+		}
+		if _, err = s.Decapsulate(sk, ct[1:]); err == nil {
+			t.Errorf("%s: ciphertext of wrong size accepted", s.Name())
+		}
+	}
+
+	// X25519 point of small order
+	x := NewX25519(rand.Reader)
+	_, sk, err := x.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = x.Decapsulate(sk, make([]byte, x.CiphertextSize())); err == nil {
+		t.Error("X25519 point of small order accepted")
+	}
+	if _, _, err = x.Encapsulate(make([]byte, x.PublicKeySize())); err == nil {
+		t.Error("X25519 point of small order accepted")
+	}
+
+	// P-256 point not on the curve
+	p := NewP256(rand.Reader)
+	pk, sk, err := p.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk[len(pk)-1] ^= 1
+	if _, err = p.Decapsulate(sk, pk); err == nil {
+		t.Error("P-256 point not on the curve accepted")
+	}
+	if _, _, err = p.Encapsulate(pk); err == nil {
+		t.Error("P-256 point not on the curve accepted")
+	}
+}
+
+// countingScheme counts calls to Decapsulate of the wrapped Scheme.
+type countingScheme struct {
+	Scheme
+	decaps int
+}
+
+func (s *countingScheme) Decapsulate(sk, ct []byte) ([]byte, error) {
+	s.decaps++
+	return s.Scheme.Decapsulate(sk, ct)
+}
+
+func TestDecapsulateRunsBoth(t *testing.T) {
+	b := &countingScheme{Scheme: NewP256(rand.Reader)}
+	s := New(NewX25519(rand.Reader), b)
+	pk, sk, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, _, err := s.Encapsulate(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// X25519 point of small order makes the first component fail
+	for i := 0; i < NewX25519(nil).CiphertextSize(); i++ {
+		ct[i] = 0
+	}
+	if _, err = s.Decapsulate(sk, ct); err == nil {
+		t.Error("X25519 point of small order accepted")
+	}
+	if b.decaps != 1 {
+		t.Error("second component not run after failure of the first one")
+	}
+}
+
+func BenchmarkEncaps(b *testing.B) {
+	for _, s := range schemes() {
+		b.Run(s.Name(), func(b *testing.B) {
+			pk, _, _ := s.GenerateKeyPair()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_, _, _ = s.Encapsulate(pk)
+			}
+		})
+	}
+}
+
+func BenchmarkDecaps(b *testing.B) {
+	for _, s := range schemes() {
+		b.Run(s.Name(), func(b *testing.B) {
+			pk, sk, _ := s.GenerateKeyPair()
+			ct, _, _ := s.Encapsulate(pk)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_, _ = s.Decapsulate(sk, ct)
+			}
+		})
+	}
+}
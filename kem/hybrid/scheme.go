@@ -0,0 +1,33 @@
+package hybrid
+
+import "errors"
+
+var (
+	errInvalidPublicKey  = errors.New("hybrid: invalid public key")
+	errInvalidPrivateKey = errors.New("hybrid: invalid private key")
+	errInvalidCiphertext = errors.New("hybrid: invalid ciphertext")
+)
+
+// Scheme is a key encapsulation mechanism operating on encoded keys and
+// ciphertexts. Implementations keep internal state (the rng and
+// preallocated buffers), hence are not safe for concurrent use.
+type Scheme interface {
+	// Name returns name of the scheme.
+	Name() string
+	// PublicKeySize returns size of encoded public key in bytes.
+	PublicKeySize() int
+	// PrivateKeySize returns size of encoded private key in bytes.
+	PrivateKeySize() int
+	// CiphertextSize returns size of the ciphertext in bytes.
+	CiphertextSize() int
+	// SharedSecretSize returns size of the shared secret in bytes.
+	SharedSecretSize() int
+	// GenerateKeyPair generates random key pair and returns it encoded.
+	GenerateKeyPair() (pk, sk []byte, err error)
+	// Encapsulate generates ciphertext and shared secret for the public
+	// key pk.
+	Encapsulate(pk []byte) (ct, ss []byte, err error)
+	// Decapsulate computes shared secret from the ciphertext ct with the
+	// private key sk.
+	Decapsulate(sk, ct []byte) (ss []byte, err error)
+}
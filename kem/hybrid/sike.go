@@ -0,0 +1,104 @@
+package hybrid
+
+import (
+	"io"
+
+	"github.com/henrydcase/nobs/dh/sidh"
+)
+
+// sike adapts sidh.KEM to the Scheme interface.
+type sike struct {
+	id   uint8
+	name string
+	rng  io.Reader
+	kem  sidh.KEM
+	pub  *sidh.PublicKey
+	prv  *sidh.PrivateKey
+}
+
+// NewSIKE returns Scheme implementing SIKE for a field identified by id
+// (sidh.Fp434, sidh.Fp503 or sidh.Fp751). Private key is encoded together
+// with the public key, as both are needed by decapsulation.
+func NewSIKE(id uint8, rng io.Reader) Scheme {
+	s := &sike{
+		id:  id,
+		rng: rng,
+		pub: sidh.NewPublicKey(id, sidh.KeyVariantSike),
+		prv: sidh.NewPrivateKey(id, sidh.KeyVariantSike),
+	}
+	s.kem.Allocate(id, rng)
+	switch id {
+	case sidh.Fp434:
+		s.name = "SIKEp434"
+	case sidh.Fp503:
+		s.name = "SIKEp503"
+	case sidh.Fp751:
+		s.name = "SIKEp751"
+	}
+	return s
+}
+
+func (s *sike) Name() string {
+	return s.name
+}
+
+func (s *sike) PublicKeySize() int {
+	return s.pub.Size()
+}
+
+func (s *sike) PrivateKeySize() int {
+	return s.prv.Size() + s.pub.Size()
+}
+
+func (s *sike) CiphertextSize() int {
+	return s.kem.CiphertextSize()
+}
+
+func (s *sike) SharedSecretSize() int {
+	return s.kem.SharedSecretSize()
+}
+
+func (s *sike) GenerateKeyPair() (pk, sk []byte, err error) {
+	if err = s.prv.Generate(s.rng); err != nil {
+		return nil, nil, err
+	}
+	s.prv.GeneratePublicKey(s.pub)
+	pk = make([]byte, s.PublicKeySize())
+	sk = make([]byte, s.PrivateKeySize())
+	s.pub.Export(pk)
+	s.prv.Export(sk)
+	copy(sk[s.prv.Size():], pk)
+	return pk, sk, nil
+}
+
+func (s *sike) Encapsulate(pk []byte) (ct, ss []byte, err error) {
+	if s.pub.Import(pk) != nil {
+		return nil, nil, errInvalidPublicKey
+	}
+	ct = make([]byte, s.CiphertextSize())
+	ss = make([]byte, s.SharedSecretSize())
+	s.kem.Reset()
+	if err = s.kem.Encapsulate(ct, ss, s.pub); err != nil {
+		return nil, nil, err
+	}
+	return ct, ss, nil
+}
+
+func (s *sike) Decapsulate(sk, ct []byte) (ss []byte, err error) {
+	if len(sk) != s.PrivateKeySize() {
+		return nil, errInvalidPrivateKey
+	}
+	if len(ct) != s.CiphertextSize() {
+		return nil, errInvalidCiphertext
+	}
+	n := s.prv.Size()
+	if s.prv.Import(sk[:n]) != nil || s.pub.Import(sk[n:]) != nil {
+		return nil, errInvalidPrivateKey
+	}
+	ss = make([]byte, s.SharedSecretSize())
+	s.kem.Reset()
+	if err = s.kem.Decapsulate(ss, s.prv, s.pub, ct); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
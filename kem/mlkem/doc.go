@@ -0,0 +1,17 @@
+// Package mlkem implements ML-KEM, the Module-Lattice-Based Key-Encapsulation
+// Mechanism standardized in FIPS 203, with parameter sets ML-KEM-512,
+// ML-KEM-768 and ML-KEM-1024.
+//
+// The API follows the one of SIKE in dh/sidh: KEM object is allocated for
+// a given parameter set and then used for Encapsulate and Decapsulate
+// operations. All hash functions (SHA3-256, SHA3-512, SHAKE128 and
+// SHAKE256) come from hash/sha3.
+//
+// Operations on secret data are done in constant time. Decapsulation uses
+// implicit rejection, i.e. an invalid ciphertext results in pseudorandom
+// shared secret derived from the private key and the ciphertext.
+//
+// References:
+//   - [FIPS203] Module-Lattice-Based Key-Encapsulation Mechanism Standard,
+//     https://doi.org/10.6028/NIST.FIPS.203
+package mlkem
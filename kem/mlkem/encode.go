@@ -0,0 +1,63 @@
+package mlkem
+
+// encode serializes p into 32*d bytes of out, using d bits per
+// coefficient. FIPS 203, Algorithm 5 (ByteEncode).
+func (p *poly) encode(out []byte, d uint) {
+	var acc uint32
+	var bits uint
+	var o int
+	for _, c := range p {
+		acc |= uint32(c) << bits
+		for bits += d; bits >= 8; bits -= 8 {
+			out[o] = byte(acc)
+			acc >>= 8
+			o++
+		}
+	}
+}
+
+// decode deserializes 32*d bytes of in into p, using d bits per
+// coefficient. FIPS 203, Algorithm 6 (ByteDecode). Returns false if any
+// of the coefficients is not reduced modulo q, which may happen only
+// for d = 12. Constant time.
+func (p *poly) decode(in []byte, d uint) bool {
+	var acc uint32
+	var bits uint
+	var o int
+	var bad uint16
+	mask := uint32(1)<<d - 1
+	for i := range p {
+		for ; bits < d; bits += 8 {
+			acc |= uint32(in[o]) << bits
+			o++
+		}
+		c := uint16(acc & mask)
+		acc >>= d
+		bits -= d
+		bad |= (q - 1 - c) >> 15
+		p[i] = fieldElement(c)
+	}
+	return bad == 0
+}
+
+// compress maps coefficients of p to d-bit values, x -> round(2^d/q * x).
+// FIPS 203, Section 4.2.1. Constant time.
+func (p *poly) compress(d uint) {
+	for i, x := range p {
+		// Division by q with Barrett reduction, dividend is < 2^24
+		a := uint32(x)<<d + q/2
+		t := uint32((uint64(a) * barrettMul) >> barrettShift)
+		r := a - t*q
+		// r < 2q, correct quotient if r >= q
+		t += ^(r - q) >> 31
+		p[i] = fieldElement(t & (1<<d - 1))
+	}
+}
+
+// decompress maps d-bit values of p to Z_q, y -> round(q/2^d * y).
+// FIPS 203, Section 4.2.1.
+func (p *poly) decompress(d uint) {
+	for i, y := range p {
+		p[i] = fieldElement((uint32(y)*q + 1<<(d-1)) >> d)
+	}
+}
@@ -0,0 +1,133 @@
+package mlkem
+
+const (
+	// Modulus
+	q = 3329
+	// Degree of the polynomial ring
+	n = 256
+	// 128^-1 mod q, used at the end of inverse NTT
+	nttScale = 3303
+
+	// Barrett reduction constants, barrettMul = floor(2^24/q)
+	barrettMul   = 5039
+	barrettShift = 24
+)
+
+// fieldElement is an integer modulo q. It is always kept in the
+// range [0, q).
+type fieldElement uint16
+
+// poly is an element of R_q = Z_q[X]/(X^256+1), either in normal
+// form or in NTT domain.
+type poly [n]fieldElement
+
+// zetas[i] = 17^BitRev7(i) mod q
+var zetas = [128]fieldElement{
+	1, 1729, 2580, 3289, 2642, 630, 1897, 848, 1062, 1919, 193, 797, 2786, 3260, 569, 1746,
+	296, 2447, 1339, 1476, 3046, 56, 2240, 1333, 1426, 2094, 535, 2882, 2393, 2879, 1974, 821,
+	289, 331, 3253, 1756, 1197, 2304, 2277, 2055, 650, 1977, 2513, 632, 2865, 33, 1320, 1915,
+	2319, 1435, 807, 452, 1438, 2868, 1534, 2402, 2647, 2617, 1481, 648, 2474, 3110, 1227, 910,
+	17, 2761, 583, 2649, 1637, 723, 2288, 1100, 1409, 2662, 3281, 233, 756, 2156, 3015, 3050,
+	1703, 1651, 2789, 1789, 1847, 952, 1461, 2687, 939, 2308, 2437, 2388, 733, 2337, 268, 641,
+	1584, 2298, 2037, 3220, 375, 2549, 2090, 1645, 1063, 319, 2773, 757, 2099, 561, 2466, 2594,
+	2804, 1092, 403, 1026, 1143, 2150, 2775, 886, 1722, 1212, 1874, 1029, 2110, 2935, 885, 2154,
+}
+
+// gammas[i] = 17^(2*BitRev7(i)+1) mod q
+var gammas = [128]fieldElement{
+	17, 3312, 2761, 568, 583, 2746, 2649, 680, 1637, 1692, 723, 2606, 2288, 1041, 1100, 2229,
+	1409, 1920, 2662, 667, 3281, 48, 233, 3096, 756, 2573, 2156, 1173, 3015, 314, 3050, 279,
+	1703, 1626, 1651, 1678, 2789, 540, 1789, 1540, 1847, 1482, 952, 2377, 1461, 1868, 2687, 642,
+	939, 2390, 2308, 1021, 2437, 892, 2388, 941, 733, 2596, 2337, 992, 268, 3061, 641, 2688,
+	1584, 1745, 2298, 1031, 2037, 1292, 3220, 109, 375, 2954, 2549, 780, 2090, 1239, 1645, 1684,
+	1063, 2266, 319, 3010, 2773, 556, 757, 2572, 2099, 1230, 561, 2768, 2466, 863, 2594, 735,
+	2804, 525, 1092, 2237, 403, 2926, 1026, 2303, 1143, 2186, 2150, 1179, 2775, 554, 886, 2443,
+	1722, 1607, 1212, 2117, 1874, 1455, 1029, 2300, 2110, 1219, 2935, 394, 885, 2444, 2154, 1175,
+}
+
+// reduceOnce maps a < 2q to [0, q). Constant time.
+func reduceOnce(a uint16) fieldElement {
+	x := a - q
+	x += (x >> 15) * q
+	return fieldElement(x)
+}
+
+// reduce maps a < 2^24 to [0, q) with Barrett reduction. Constant time.
+func reduce(a uint32) fieldElement {
+	t := uint32((uint64(a) * barrettMul) >> barrettShift)
+	return reduceOnce(uint16(a - t*q))
+}
+
+func fieldAdd(a, b fieldElement) fieldElement {
+	return reduceOnce(uint16(a + b))
+}
+
+func fieldSub(a, b fieldElement) fieldElement {
+	return reduceOnce(uint16(a - b + q))
+}
+
+func fieldMul(a, b fieldElement) fieldElement {
+	return reduce(uint32(a) * uint32(b))
+}
+
+// add sets p = a + b.
+func (p *poly) add(a, b *poly) {
+	for i := range p {
+		p[i] = fieldAdd(a[i], b[i])
+	}
+}
+
+// sub sets p = a - b.
+func (p *poly) sub(a, b *poly) {
+	for i := range p {
+		p[i] = fieldSub(a[i], b[i])
+	}
+}
+
+// mulAcc adds to p the product of a and b. All polynomials are in NTT
+// domain. FIPS 203, Algorithms 11 and 12.
+func (p *poly) mulAcc(a, b *poly) {
+	for i := 0; i < n/2; i++ {
+		a0, a1 := a[2*i], a[2*i+1]
+		b0, b1 := b[2*i], b[2*i+1]
+		c0 := fieldAdd(fieldMul(a0, b0), fieldMul(fieldMul(a1, b1), gammas[i]))
+		c1 := fieldAdd(fieldMul(a0, b1), fieldMul(a1, b0))
+		p[2*i] = fieldAdd(p[2*i], c0)
+		p[2*i+1] = fieldAdd(p[2*i+1], c1)
+	}
+}
+
+// ntt converts p to NTT domain. FIPS 203, Algorithm 9.
+func (p *poly) ntt() {
+	k := 1
+	for l := 128; l >= 2; l >>= 1 {
+		for s := 0; s < n; s += 2 * l {
+			z := zetas[k]
+			k++
+			for j := s; j < s+l; j++ {
+				t := fieldMul(z, p[j+l])
+				p[j+l] = fieldSub(p[j], t)
+				p[j] = fieldAdd(p[j], t)
+			}
+		}
+	}
+}
+
+// invNTT converts p from NTT domain to normal form. FIPS 203, Algorithm 10.
+func (p *poly) invNTT() {
+	k := 127
+	for l := 2; l <= 128; l <<= 1 {
+		for s := 0; s < n; s += 2 * l {
+			z := zetas[k]
+			k--
+			for j := s; j < s+l; j++ {
+				t := p[j]
+				p[j] = fieldAdd(t, p[j+l])
+				p[j+l] = fieldMul(z, fieldSub(p[j+l], t))
+			}
+		}
+	}
+	for i := range p {
+		p[i] = fieldMul(p[i], nttScale)
+	}
+}
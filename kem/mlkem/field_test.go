@@ -0,0 +1,91 @@
+package mlkem
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randPoly(r *rand.Rand) (p poly) {
+	for i := range p {
+		p[i] = fieldElement(r.Intn(q))
+	}
+	return p
+}
+
+// Multiplication in NTT domain must match schoolbook multiplication
+// in Z_q[X]/(X^256+1).
+func TestNTTMul(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for it := 0; it < 20; it++ {
+		a, b := randPoly(r), randPoly(r)
+		var exp [n]int64
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				v := int64(a[i]) * int64(b[j])
+				if i+j < n {
+					exp[i+j] += v
+				} else {
+					exp[i+j-n] -= v
+				}
+			}
+		}
+
+		var c poly
+		aa, bb := a, b
+		aa.ntt()
+		bb.ntt()
+		c.mulAcc(&aa, &bb)
+		c.invNTT()
+		for i := range c {
+			if int64(c[i]) != ((exp[i]%q)+q)%q {
+				t.Fatalf("coefficient %d: got %d, want %d", i, c[i], ((exp[i]%q)+q)%q)
+			}
+		}
+
+		aa.invNTT()
+		if aa != a {
+			t.Fatal("inverse NTT failed")
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	for _, d := range []uint{1, 4, 5, 10, 11} {
+		for x := 0; x < q; x++ {
+			var p poly
+			p[0] = fieldElement(x)
+			p.compress(d)
+			// round(2^d * x / q) mod 2^d
+			exp := ((x<<d + q/2) / q) & (1<<d - 1)
+			if int(p[0]) != exp {
+				t.Fatalf("Compress_%d(%d): got %d, want %d", d, x, p[0], exp)
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	var buf [polySize]byte
+	for _, d := range []uint{1, 4, 5, 10, 11, 12} {
+		a := randPoly(r)
+		for i := range a {
+			a[i] &= fieldElement(1<<d - 1)
+			if a[i] >= q {
+				a[i] -= q
+			}
+		}
+		var b poly
+		a.encode(buf[:], d)
+		if !b.decode(buf[:], d) || a != b {
+			t.Fatalf("ByteDecode_%d(ByteEncode_%d(x)) != x", d, d)
+		}
+	}
+	for i := range buf {
+		buf[i] = 0xFF
+	}
+	var b poly
+	if b.decode(buf[:], 12) {
+		t.Fatal("ByteDecode_12 accepted unreduced coefficients")
+	}
+}
@@ -0,0 +1,404 @@
+package mlkem
+
+import (
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+var (
+	errInvalidPublicKey  = errors.New("mlkem: invalid public key")
+	errInvalidPrivateKey = errors.New("mlkem: invalid private key")
+)
+
+// PublicKey represents ML-KEM encapsulation key.
+type PublicKey struct {
+	params *params
+	// Seed of the matrix A
+	rho [symSize]byte
+	// H(ek), hash of the encoded key
+	h [symSize]byte
+	// Vector t, in NTT domain
+	t [maxK]poly
+	// Matrix A expanded from rho, in NTT domain, row-major order
+	a [maxK * maxK]poly
+}
+
+// PrivateKey represents ML-KEM decapsulation key. It contains
+// corresponding public key.
+type PrivateKey struct {
+	PublicKey
+	// Secret vector s, in NTT domain
+	s [maxK]poly
+	// Value used for implicit rejection
+	z [symSize]byte
+}
+
+// KEM implements ML-KEM key encapsulation mechanism.
+type KEM struct {
+	allocated bool
+	rng       io.Reader
+	params    *params
+	msg       [symSize]byte
+	// Output of G, the shared secret and encryption randomness
+	kr [2 * symSize]byte
+	// Buffer for re-encrypted ciphertext
+	ct  []byte
+	g   hash.Hash
+	prf sha3.ShakeHash
+}
+
+// NewPublicKey initializes public key for a given parameter set.
+func NewPublicKey(id uint8) *PublicKey {
+	return &PublicKey{params: getParams(id)}
+}
+
+// Import imports encapsulation key ek stored in the byte string. Returns
+// error in case size of the input is wrong or it doesn't pass modulus
+// check (FIPS 203, Section 7.2).
+func (pub *PublicKey) Import(input []byte) error {
+	k := pub.params.k
+	if len(input) != pub.Size() {
+		return errInvalidPublicKey
+	}
+	for i := 0; i < k; i++ {
+		if !pub.t[i].decode(input[polySize*i:], 12) {
+			return errInvalidPublicKey
+		}
+	}
+	copy(pub.rho[:], input[polySize*k:])
+	pub.expandA()
+
+	g := sha3.New256()
+	_, _ = g.Write(input)
+	g.Sum(pub.h[:0])
+	return nil
+}
+
+// Export writes encapsulation key to out, which must be at least Size()
+// bytes long.
+func (pub *PublicKey) Export(out []byte) {
+	k := pub.params.k
+	for i := 0; i < k; i++ {
+		pub.t[i].encode(out[polySize*i:], 12)
+	}
+	copy(out[polySize*k:], pub.rho[:])
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return pub.params.publicKeySize()
+}
+
+// expandA samples matrix A from the seed rho.
+func (pub *PublicKey) expandA() {
+	k := pub.params.k
+	xof := sha3.NewShake128()
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			sampleNTT(&pub.a[i*k+j], xof, pub.rho[:], byte(j), byte(i))
+		}
+	}
+}
+
+// NewPrivateKey initializes private key for a given parameter set.
+func NewPrivateKey(id uint8) *PrivateKey {
+	return &PrivateKey{PublicKey: PublicKey{params: getParams(id)}}
+}
+
+// Import imports decapsulation key dk stored in the byte string, which
+// includes the encapsulation key. Returns error in case size of the input
+// is wrong or it doesn't pass hash check (FIPS 203, Section 7.3).
+func (prv *PrivateKey) Import(input []byte) error {
+	k := prv.params.k
+	if len(input) != prv.Size() {
+		return errInvalidPrivateKey
+	}
+	ek := input[polySize*k : 2*polySize*k+symSize]
+	if prv.PublicKey.Import(ek) != nil {
+		return errInvalidPrivateKey
+	}
+	h := input[2*polySize*k+symSize : 2*polySize*k+2*symSize]
+	if subtle.ConstantTimeCompare(prv.h[:], h) != 1 {
+		return errInvalidPrivateKey
+	}
+	for i := 0; i < k; i++ {
+		if !prv.s[i].decode(input[polySize*i:], 12) {
+			return errInvalidPrivateKey
+		}
+	}
+	copy(prv.z[:], input[2*polySize*k+2*symSize:])
+	return nil
+}
+
+// Export writes decapsulation key to out, which must be at least Size()
+// bytes long.
+func (prv *PrivateKey) Export(out []byte) {
+	k := prv.params.k
+	for i := 0; i < k; i++ {
+		prv.s[i].encode(out[polySize*i:], 12)
+	}
+	prv.PublicKey.Export(out[polySize*k:])
+	copy(out[2*polySize*k+symSize:], prv.h[:])
+	copy(out[2*polySize*k+2*symSize:], prv.z[:])
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return prv.params.privateKeySize()
+}
+
+// Generate generates a random key pair. It reads SeedSize bytes from rng,
+// the seed d followed by z. Returns error in case rng fails.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	var seed [SeedSize]byte
+	if _, err := io.ReadFull(rng, seed[:]); err != nil {
+		return err
+	}
+	prv.generate(seed[:symSize], seed[symSize:])
+	return nil
+}
+
+// GeneratePublicKey copies public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	*pub = prv.PublicKey
+}
+
+// generate derives key pair from seeds d and z. FIPS 203, Algorithms 13
+// and 16.
+func (prv *PrivateKey) generate(d, z []byte) {
+	var e poly
+	var buf [2 * symSize]byte
+	var ek = make([]byte, prv.PublicKey.Size())
+	p := prv.params
+
+	// (rho, sigma) = G(d || k)
+	g := sha3.New512()
+	_, _ = g.Write(d)
+	_, _ = g.Write([]byte{byte(p.k)})
+	g.Sum(buf[:0])
+	rho, sigma := buf[:symSize], buf[symSize:]
+
+	copy(prv.rho[:], rho)
+	prv.expandA()
+
+	prf := sha3.NewShake256()
+	for i := 0; i < p.k; i++ {
+		sampleCBD(&prv.s[i], prf, sigma, byte(i), p.eta1)
+		prv.s[i].ntt()
+	}
+	// t = A*s + e
+	for i := 0; i < p.k; i++ {
+		sampleCBD(&e, prf, sigma, byte(p.k+i), p.eta1)
+		e.ntt()
+		prv.t[i] = e
+		for j := 0; j < p.k; j++ {
+			prv.t[i].mulAcc(&prv.a[i*p.k+j], &prv.s[j])
+		}
+	}
+	copy(prv.z[:], z)
+
+	prv.PublicKey.Export(ek)
+	h := sha3.New256()
+	_, _ = h.Write(ek)
+	h.Sum(prv.h[:0])
+}
+
+// NewMLKEM512 instantiates ML-KEM-512 KEM.
+func NewMLKEM512(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(MLKEM512, rng)
+	return &c
+}
+
+// NewMLKEM768 instantiates ML-KEM-768 KEM.
+func NewMLKEM768(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(MLKEM768, rng)
+	return &c
+}
+
+// NewMLKEM1024 instantiates ML-KEM-1024 KEM.
+func NewMLKEM1024(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(MLKEM1024, rng)
+	return &c
+}
+
+// Allocate allocates KEM object for multiple ML-KEM operations. The rng
+// must be cryptographically secure PRNG.
+func (c *KEM) Allocate(id uint8, rng io.Reader) {
+	c.rng = rng
+	c.params = getParams(id)
+	c.ct = make([]byte, c.params.ciphertextSize())
+	c.g = sha3.New512()
+	c.prf = sha3.NewShake256()
+	c.allocated = true
+}
+
+// Encapsulate receives the public key and generates ML-KEM ciphertext and
+// shared secret. Error is returned in case PRNG fails. Function panics in
+// case wrongly formated input was provided.
+func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *PublicKey) error {
+	if !c.allocated {
+		panic("KEM unallocated")
+	}
+
+	if pub.params != c.params {
+		panic("Wrong type of public key")
+	}
+
+	if len(secret) < c.SharedSecretSize() {
+		panic("shared secret buffer too small")
+	}
+
+	if len(ciphertext) < c.CiphertextSize() {
+		panic("ciphertext buffer too small")
+	}
+
+	if _, err := io.ReadFull(c.rng, c.msg[:]); err != nil {
+		return err
+	}
+
+	// (K, r) = G(m || H(ek))
+	c.g.Reset()
+	_, _ = c.g.Write(c.msg[:])
+	_, _ = c.g.Write(pub.h[:])
+	c.g.Sum(c.kr[:0])
+	c.encrypt(ciphertext, pub, c.msg[:], c.kr[symSize:])
+	copy(secret, c.kr[:symSize])
+	return nil
+}
+
+// Decapsulate given the private key and ciphertext as inputs, outputs a
+// shared secret. In case ciphertext is invalid, function outputs pseudorandom
+// value derived from the private key and the ciphertext (implicit rejection).
+// Function panics in case input is wrongly formated, in particular, size of
+// the 'ciphertext' must be exactly equal to c.CiphertextSize(). Constant time.
+func (c *KEM) Decapsulate(secret []byte, prv *PrivateKey, ciphertext []byte) error {
+	if !c.allocated {
+		panic("KEM unallocated")
+	}
+
+	if prv.params != c.params {
+		panic("Wrong type of private key")
+	}
+
+	if len(secret) < c.SharedSecretSize() {
+		panic("shared secret buffer too small")
+	}
+
+	if len(ciphertext) != c.CiphertextSize() {
+		panic("ciphertext has wrong size")
+	}
+
+	var rejected [symSize]byte
+	c.decrypt(c.msg[:], prv, ciphertext)
+
+	// (K', r') = G(m' || h)
+	c.g.Reset()
+	_, _ = c.g.Write(c.msg[:])
+	_, _ = c.g.Write(prv.h[:])
+	c.g.Sum(c.kr[:0])
+	c.encrypt(c.ct, &prv.PublicKey, c.msg[:], c.kr[symSize:])
+
+	// K_bar = J(z || c)
+	c.prf.Reset()
+	_, _ = c.prf.Write(prv.z[:])
+	_, _ = c.prf.Write(ciphertext)
+	_, _ = c.prf.Read(rejected[:])
+
+	eq := subtle.ConstantTimeCompare(c.ct, ciphertext)
+	subtle.ConstantTimeCopy(1-eq, c.kr[:symSize], rejected[:])
+	copy(secret, c.kr[:symSize])
+	return nil
+}
+
+// Resets internal state of KEM. Function should be used
+// after Allocate and between subsequent calls to Encapsulate
+// and/or Decapsulate.
+func (c *KEM) Reset() {
+	for i := range c.msg {
+		c.msg[i] = 0
+	}
+	for i := range c.kr {
+		c.kr[i] = 0
+	}
+	for i := range c.ct {
+		c.ct[i] = 0
+	}
+}
+
+// Returns size of resulting ciphertext.
+func (c *KEM) CiphertextSize() int {
+	return c.params.ciphertextSize()
+}
+
+// Returns size of resulting shared secret.
+func (c *KEM) SharedSecretSize() int {
+	return SharedSecretSize
+}
+
+// encrypt implements K-PKE encryption of message m with randomness r.
+// FIPS 203, Algorithm 14.
+func (c *KEM) encrypt(ct []byte, pub *PublicKey, m, r []byte) {
+	var y [maxK]poly
+	var u, e poly
+	p := pub.params
+
+	for i := 0; i < p.k; i++ {
+		sampleCBD(&y[i], c.prf, r, byte(i), p.eta1)
+		y[i].ntt()
+	}
+
+	// u = A^T * y + e1
+	for i := 0; i < p.k; i++ {
+		u = poly{}
+		for j := 0; j < p.k; j++ {
+			u.mulAcc(&pub.a[j*p.k+i], &y[j])
+		}
+		u.invNTT()
+		sampleCBD(&e, c.prf, r, byte(p.k+i), p.eta2)
+		u.add(&u, &e)
+		u.compress(p.du)
+		u.encode(ct[32*int(p.du)*i:], p.du)
+	}
+
+	// v = t^T * y + e2 + Decompress_1(m)
+	u = poly{}
+	for j := 0; j < p.k; j++ {
+		u.mulAcc(&pub.t[j], &y[j])
+	}
+	u.invNTT()
+	sampleCBD(&e, c.prf, r, byte(2*p.k), p.eta2)
+	u.add(&u, &e)
+	e.decode(m, 1)
+	e.decompress(1)
+	u.add(&u, &e)
+	u.compress(p.dv)
+	u.encode(ct[32*int(p.du)*p.k:], p.dv)
+}
+
+// decrypt implements K-PKE decryption of ciphertext ct, the resulting
+// message is stored in m. FIPS 203, Algorithm 15.
+func (c *KEM) decrypt(m []byte, prv *PrivateKey, ct []byte) {
+	var u, v, w poly
+	p := prv.params
+
+	// w = v - NTT^-1(s^T * NTT(u))
+	for i := 0; i < p.k; i++ {
+		u.decode(ct[32*int(p.du)*i:], p.du)
+		u.decompress(p.du)
+		u.ntt()
+		w.mulAcc(&prv.s[i], &u)
+	}
+	w.invNTT()
+	v.decode(ct[32*int(p.du)*p.k:], p.dv)
+	v.decompress(p.dv)
+	w.sub(&v, &w)
+	w.compress(1)
+	w.encode(m, 1)
+}
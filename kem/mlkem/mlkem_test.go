@@ -0,0 +1,321 @@
+package mlkem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+var allIDs = []uint8{MLKEM512, MLKEM768, MLKEM1024}
+
+func idFromName(t *testing.T, name string) uint8 {
+	for _, id := range allIDs {
+		if getParams(id).name == name {
+			return id
+		}
+	}
+	t.Fatalf("unknown parameter set %s", name)
+	return 0
+}
+
+// testPanic returns error if call to function 'f' didn't cause panic.
+func testPanic(f func()) (err error) {
+	err = errors.New("no panic detected")
+	defer func() {
+		if r := recover(); r != nil {
+			err = nil
+		}
+	}()
+	f()
+	return err
+}
+
+// ACVP vectors from https://github.com/usnistgov/ACVP-Server, revision
+// f38183487eebff2952da0e5a3441371218acfe3f
+func TestACVPKeyGen(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			TgID         int    `json:"tgId"`
+			ParameterSet string `json:"parameterSet"`
+			Tests        []struct {
+				TcID int           `json:"tcId"`
+				D    test.HexBytes `json:"d"`
+				Z    test.HexBytes `json:"z"`
+				Ek   test.HexBytes `json:"ek"`
+				Dk   test.HexBytes `json:"dk"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "ML-KEM-keyGen-FIPS203/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "ML-KEM-keyGen-FIPS203/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			sk := NewPrivateKey(id)
+			pk := NewPublicKey(id)
+			seed := append(append([]byte{}, tc.D...), tc.Z...)
+			if err := sk.Generate(bytes.NewReader(seed)); err != nil {
+				t.Fatal(err)
+			}
+			sk.GeneratePublicKey(pk)
+
+			ek := make([]byte, pk.Size())
+			dk := make([]byte, sk.Size())
+			pk.Export(ek)
+			sk.Export(dk)
+			if !bytes.Equal(ek, exp.Ek) {
+				t.Errorf("%s tcId %d: wrong encapsulation key", g.ParameterSet, tc.TcID)
+			}
+			if !bytes.Equal(dk, exp.Dk) {
+				t.Errorf("%s tcId %d: wrong decapsulation key", g.ParameterSet, tc.TcID)
+			}
+		}
+	}
+}
+
+func TestACVPEncapDecap(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			TgID         int           `json:"tgId"`
+			ParameterSet string        `json:"parameterSet"`
+			Function     string        `json:"function"`
+			Dk           test.HexBytes `json:"dk"`
+			Tests        []struct {
+				TcID int           `json:"tcId"`
+				Ek   test.HexBytes `json:"ek"`
+				M    test.HexBytes `json:"m"`
+				C    test.HexBytes `json:"c"`
+				K    test.HexBytes `json:"k"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "ML-KEM-encapDecap-FIPS203/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "ML-KEM-encapDecap-FIPS203/expectedResults.json.gz", &results)
+
+	var ss [SharedSecretSize]byte
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			switch g.Function {
+			case "encapsulation":
+				pk := NewPublicKey(id)
+				if err := pk.Import(tc.Ek); err != nil {
+					t.Fatal(err)
+				}
+				kem := new(KEM)
+				kem.Allocate(id, bytes.NewReader(tc.M))
+				ct := make([]byte, kem.CiphertextSize())
+				if err := kem.Encapsulate(ct, ss[:], pk); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(ct, exp.C) || !bytes.Equal(ss[:], exp.K) {
+					t.Errorf("%s tcId %d: encapsulation failed", g.ParameterSet, tc.TcID)
+				}
+			case "decapsulation":
+				sk := NewPrivateKey(id)
+				if err := sk.Import(g.Dk); err != nil {
+					t.Fatal(err)
+				}
+				kem := new(KEM)
+				kem.Allocate(id, nil)
+				if err := kem.Decapsulate(ss[:], sk, tc.C); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(ss[:], exp.K) {
+					t.Errorf("%s tcId %d: decapsulation failed", g.ParameterSet, tc.TcID)
+				}
+			default:
+				t.Fatalf("unknown function %s", g.Function)
+			}
+		}
+	}
+}
+
+func TestKEMRoundTrip(t *testing.T) {
+	var ssE, ssD [SharedSecretSize]byte
+	for _, id := range allIDs {
+		kem := new(KEM)
+		kem.Allocate(id, rand.Reader)
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		ct := make([]byte, kem.CiphertextSize())
+		for i := 0; i < 10; i++ {
+			if err := sk.Generate(rand.Reader); err != nil {
+				t.Fatal(err)
+			}
+			sk.GeneratePublicKey(pk)
+			if err := kem.Encapsulate(ct, ssE[:], pk); err != nil {
+				t.Fatal(err)
+			}
+			if err := kem.Decapsulate(ssD[:], sk, ct); err != nil {
+				t.Fatal(err)
+			}
+			if ssE != ssD {
+				t.Fatalf("%s: shared secrets differ", getParams(id).name)
+			}
+		}
+		kem.Reset()
+	}
+}
+
+// Modified ciphertext must result in K_bar = SHAKE256(z || c).
+func TestImplicitRejection(t *testing.T) {
+	var ssE, ssD [SharedSecretSize]byte
+	for _, id := range allIDs {
+		kem := new(KEM)
+		kem.Allocate(id, rand.Reader)
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		ct := make([]byte, kem.CiphertextSize())
+		dk := make([]byte, sk.Size())
+		if err := sk.Generate(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		sk.Export(dk)
+		if err := kem.Encapsulate(ct, ssE[:], pk); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, i := range []int{0, len(ct) / 2, len(ct) - 1} {
+			ct[i] ^= 0x01
+			if err := kem.Decapsulate(ssD[:], sk, ct); err != nil {
+				t.Fatal(err)
+			}
+			var exp [SharedSecretSize]byte
+			kem.prf.Reset()
+			_, _ = kem.prf.Write(dk[len(dk)-symSize:])
+			_, _ = kem.prf.Write(ct)
+			_, _ = kem.prf.Read(exp[:])
+			if ssD == ssE || ssD != exp {
+				t.Errorf("%s: wrong implicit rejection", getParams(id).name)
+			}
+			ct[i] ^= 0x01
+		}
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, id := range allIDs {
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		if err := sk.Generate(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		ek := make([]byte, pk.Size())
+		dk := make([]byte, sk.Size())
+		pk.Export(ek)
+		sk.Export(dk)
+
+		if NewPublicKey(id).Import(ek[1:]) == nil {
+			t.Error("public key of wrong size accepted")
+		}
+		if NewPrivateKey(id).Import(dk[1:]) == nil {
+			t.Error("private key of wrong size accepted")
+		}
+
+		// Modulus check: set first coefficient to q
+		ek[0], ek[1] = q&0xFF, ek[1]&0xF0|q>>8
+		if NewPublicKey(id).Import(ek) == nil {
+			t.Error("public key with unreduced coefficient accepted")
+		}
+
+		// Hash check
+		dk[len(dk)-symSize-1] ^= 1
+		if NewPrivateKey(id).Import(dk) == nil {
+			t.Error("private key with wrong hash accepted")
+		}
+		dk[len(dk)-symSize-1] ^= 1
+		if err := NewPrivateKey(id).Import(dk); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPanics(t *testing.T) {
+	var ss [SharedSecretSize]byte
+	kem := NewMLKEM768(rand.Reader)
+	sk := NewPrivateKey(MLKEM768)
+	pk := NewPublicKey(MLKEM768)
+	ct := make([]byte, kem.CiphertextSize())
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+
+	if testPanic(func() { _ = new(KEM).Encapsulate(ct, ss[:], pk) }) != nil {
+		t.Error("unallocated KEM must panic")
+	}
+	if testPanic(func() { _ = kem.Encapsulate(ct[1:], ss[:], pk) }) != nil {
+		t.Error("short ciphertext buffer must panic")
+	}
+	if testPanic(func() { _ = kem.Encapsulate(ct, ss[1:], pk) }) != nil {
+		t.Error("short secret buffer must panic")
+	}
+	if testPanic(func() { _ = kem.Decapsulate(ss[:], sk, ct[1:]) }) != nil {
+		t.Error("wrong ciphertext size must panic")
+	}
+	if testPanic(func() { _ = NewMLKEM512(rand.Reader).Encapsulate(ct, ss[:], pk) }) != nil {
+		t.Error("public key of different parameter set must panic")
+	}
+	if testPanic(func() { NewPublicKey(3) }) != nil {
+		t.Error("unknown parameter set must panic")
+	}
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	for _, id := range allIDs {
+		b.Run(getParams(id).name, func(b *testing.B) {
+			sk := NewPrivateKey(id)
+			for n := 0; n < b.N; n++ {
+				_ = sk.Generate(rand.Reader)
+			}
+		})
+	}
+}
+
+func BenchmarkEncaps(b *testing.B) {
+	var ss [SharedSecretSize]byte
+	for _, id := range allIDs {
+		b.Run(getParams(id).name, func(b *testing.B) {
+			kem := new(KEM)
+			kem.Allocate(id, rand.Reader)
+			sk := NewPrivateKey(id)
+			pk := NewPublicKey(id)
+			ct := make([]byte, kem.CiphertextSize())
+			_ = sk.Generate(rand.Reader)
+			sk.GeneratePublicKey(pk)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_ = kem.Encapsulate(ct, ss[:], pk)
+			}
+		})
+	}
+}
+
+func BenchmarkDecaps(b *testing.B) {
+	var ss [SharedSecretSize]byte
+	for _, id := range allIDs {
+		b.Run(getParams(id).name, func(b *testing.B) {
+			kem := new(KEM)
+			kem.Allocate(id, rand.Reader)
+			sk := NewPrivateKey(id)
+			pk := NewPublicKey(id)
+			ct := make([]byte, kem.CiphertextSize())
+			_ = sk.Generate(rand.Reader)
+			sk.GeneratePublicKey(pk)
+			_ = kem.Encapsulate(ct, ss[:], pk)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_ = kem.Decapsulate(ss[:], sk, ct)
+			}
+		})
+	}
+}
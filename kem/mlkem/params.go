@@ -0,0 +1,59 @@
+package mlkem
+
+// Identifiers of ML-KEM parameter sets
+const (
+	MLKEM512 uint8 = iota
+	MLKEM768
+	MLKEM1024
+)
+
+const (
+	// Size of the shared secret in bytes
+	SharedSecretSize = 32
+	// Size of the seed d || z used by key generation, in bytes
+	SeedSize = 64
+
+	// Maximal rank of the module
+	maxK = 4
+	// Size of the message, randomness and seeds in bytes
+	symSize = 32
+	// Size of polynomial encoded with 12 bits per coefficient
+	polySize = 384
+)
+
+// params describes an ML-KEM parameter set, FIPS 203, Section 8.
+type params struct {
+	id   uint8
+	name string
+	// Rank of the module
+	k int
+	// Parameters of the centered binomial distributions
+	eta1, eta2 int
+	// Compression of ciphertext components u and v
+	du, dv uint
+}
+
+var mlkemParams = [...]params{
+	MLKEM512:  {id: MLKEM512, name: "ML-KEM-512", k: 2, eta1: 3, eta2: 2, du: 10, dv: 4},
+	MLKEM768:  {id: MLKEM768, name: "ML-KEM-768", k: 3, eta1: 2, eta2: 2, du: 10, dv: 4},
+	MLKEM1024: {id: MLKEM1024, name: "ML-KEM-1024", k: 4, eta1: 2, eta2: 2, du: 11, dv: 5},
+}
+
+func getParams(id uint8) *params {
+	if int(id) >= len(mlkemParams) {
+		panic("mlkem: parameter set ID unregistered")
+	}
+	return &mlkemParams[id]
+}
+
+func (p *params) publicKeySize() int {
+	return polySize*p.k + symSize
+}
+
+func (p *params) privateKeySize() int {
+	return 2*polySize*p.k + 3*symSize
+}
+
+func (p *params) ciphertextSize() int {
+	return 32 * (int(p.du)*p.k + int(p.dv))
+}
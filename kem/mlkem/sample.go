@@ -0,0 +1,59 @@
+package mlkem
+
+import "github.com/henrydcase/nobs/hash/sha3"
+
+// Rate of SHAKE128 in bytes
+const shake128Rate = 168
+
+// sampleNTT samples uniformly a polynomial in NTT domain with SHAKE128
+// seeded with rho || x || y. FIPS 203, Algorithm 7. Operates on public
+// data only, hence it is not constant time.
+func sampleNTT(p *poly, xof sha3.ShakeHash, rho []byte, x, y byte) {
+	var buf [shake128Rate]byte
+	xof.Reset()
+	_, _ = xof.Write(rho)
+	_, _ = xof.Write([]byte{x, y})
+	for i := 0; i < n; {
+		_, _ = xof.Read(buf[:])
+		for j := 0; j < len(buf) && i < n; j += 3 {
+			d1 := uint16(buf[j]) | uint16(buf[j+1]&0x0F)<<8
+			d2 := uint16(buf[j+1]>>4) | uint16(buf[j+2])<<4
+			if d1 < q {
+				p[i] = fieldElement(d1)
+				i++
+			}
+			if d2 < q && i < n {
+				p[i] = fieldElement(d2)
+				i++
+			}
+		}
+	}
+}
+
+// sampleCBD samples a polynomial from the centered binomial distribution
+// with parameter eta, using as an input PRF_eta(s, b) = SHAKE256(s || b).
+// FIPS 203, Algorithm 8. Constant time.
+func sampleCBD(p *poly, prf sha3.ShakeHash, s []byte, b byte, eta int) {
+	var buf [64 * 3]byte
+	prf.Reset()
+	_, _ = prf.Write(s)
+	_, _ = prf.Write([]byte{b})
+	_, _ = prf.Read(buf[:64*eta])
+
+	var acc uint32
+	var bits, o int
+	for i := range p {
+		for ; bits < 2*eta; bits += 8 {
+			acc |= uint32(buf[o]) << uint(bits)
+			o++
+		}
+		var x, y uint16
+		for j := 0; j < eta; j++ {
+			x += uint16(acc>>uint(j)) & 1
+			y += uint16(acc>>uint(eta+j)) & 1
+		}
+		acc >>= uint(2 * eta)
+		bits -= 2 * eta
+		p[i] = fieldSub(fieldElement(x), fieldElement(y))
+	}
+}
@@ -0,0 +1,16 @@
+// Package xwing implements X-Wing, the hybrid post-quantum/traditional
+// KEM combining ML-KEM-768 with X25519, as specified in
+// draft-connolly-cfrg-xwing-kem.
+//
+// The API follows the one of SIKE in dh/sidh: KEM object is allocated once
+// and then used for Encapsulate and Decapsulate operations. ML-KEM-768
+// comes from kem/mlkem, X25519 from dh/x25519 and the combiner uses
+// SHA3-256 from hash/sha3.
+//
+// Private key is a 32-byte seed from which ML-KEM-768 and X25519 key pairs
+// are expanded with SHAKE256.
+//
+// References:
+//   - [XWING] X-Wing: general-purpose hybrid post-quantum KEM,
+//     https://datatracker.ietf.org/doc/draft-connolly-cfrg-xwing-kem/
+package xwing
@@ -0,0 +1,265 @@
+package xwing
+
+import (
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/henrydcase/nobs/dh/x25519"
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/kem/mlkem"
+)
+
+const (
+	// Size of the public key in bytes
+	PublicKeySize = mlkemPublicKeySize + x25519.KeySize
+	// Size of the private key (seed) in bytes
+	PrivateKeySize = 32
+	// Size of the ciphertext in bytes
+	CiphertextSize = mlkemCiphertextSize + x25519.KeySize
+	// Size of the shared secret in bytes
+	SharedSecretSize = 32
+
+	// Sizes of ML-KEM-768 encapsulation key and ciphertext
+	mlkemPublicKeySize  = 1184
+	mlkemCiphertextSize = 1088
+)
+
+// XWingLabel used by the combiner
+//
+//	\./
+//	/^\
+var label = []byte(`\.//^\`)
+
+var (
+	errInvalidPublicKey  = errors.New("xwing: invalid public key")
+	errInvalidPrivateKey = errors.New("xwing: invalid private key")
+)
+
+// PublicKey represents X-Wing public key.
+type PublicKey struct {
+	pm *mlkem.PublicKey
+	px x25519.Key
+}
+
+// PrivateKey represents X-Wing private key. It contains corresponding
+// public key.
+type PrivateKey struct {
+	PublicKey
+	seed [PrivateKeySize]byte
+	sm   *mlkem.PrivateKey
+	sx   x25519.Key
+}
+
+// KEM implements X-Wing key encapsulation mechanism.
+type KEM struct {
+	allocated bool
+	rng       io.Reader
+	// ML-KEM-768 sharing rng with X-Wing
+	m   *mlkem.KEM
+	h   hash.Hash
+	ssm [mlkem.SharedSecretSize]byte
+	// Ephemeral X25519 key, X25519 ciphertext and shared secret
+	ekx, ctx, ssx x25519.Key
+}
+
+// NewPublicKey initializes public key.
+func NewPublicKey() *PublicKey {
+	return &PublicKey{pm: mlkem.NewPublicKey(mlkem.MLKEM768)}
+}
+
+// Import imports public key stored in the byte string. Returns error in
+// case size of the input is wrong or ML-KEM-768 encapsulation key doesn't
+// pass modulus check.
+func (pub *PublicKey) Import(input []byte) error {
+	if len(input) != PublicKeySize {
+		return errInvalidPublicKey
+	}
+	if pub.pm.Import(input[:mlkemPublicKeySize]) != nil {
+		return errInvalidPublicKey
+	}
+	copy(pub.px[:], input[mlkemPublicKeySize:])
+	return nil
+}
+
+// Export writes public key pk_M || pk_X to out, which must be at least
+// Size() bytes long.
+func (pub *PublicKey) Export(out []byte) {
+	pub.pm.Export(out)
+	copy(out[mlkemPublicKeySize:], pub.px[:])
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return PublicKeySize
+}
+
+// NewPrivateKey initializes private key.
+func NewPrivateKey() *PrivateKey {
+	return &PrivateKey{
+		PublicKey: *NewPublicKey(),
+		sm:        mlkem.NewPrivateKey(mlkem.MLKEM768),
+	}
+}
+
+// Import imports private key stored in the byte string and expands it.
+// Returns error in case size of the input is wrong.
+func (prv *PrivateKey) Import(input []byte) error {
+	if len(input) != PrivateKeySize {
+		return errInvalidPrivateKey
+	}
+	copy(prv.seed[:], input)
+	prv.expand()
+	return nil
+}
+
+// Export writes private key seed to out, which must be at least Size()
+// bytes long.
+func (prv *PrivateKey) Export(out []byte) {
+	copy(out, prv.seed[:])
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return PrivateKeySize
+}
+
+// Generate generates a random key pair. It reads PrivateKeySize bytes
+// from rng. Returns error in case rng fails.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	if _, err := io.ReadFull(rng, prv.seed[:]); err != nil {
+		return err
+	}
+	prv.expand()
+	return nil
+}
+
+// GeneratePublicKey copies public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	prv.sm.GeneratePublicKey(pub.pm)
+	pub.px = prv.px
+}
+
+// expand derives ML-KEM-768 and X25519 key pairs from the seed,
+// [XWING], Section 5.2.
+func (prv *PrivateKey) expand() {
+	// SHAKE256(sk, 96) = d || z || sk_X
+	xof := sha3.NewShake256()
+	_, _ = xof.Write(prv.seed[:])
+	// Reads exactly mlkem.SeedSize bytes and never fails
+	_ = prv.sm.Generate(xof)
+	prv.sm.GeneratePublicKey(prv.pm)
+	_, _ = xof.Read(prv.sx[:])
+	x25519.KeyGen(&prv.px, &prv.sx)
+}
+
+// NewXWing instantiates X-Wing KEM.
+func NewXWing(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(rng)
+	return &c
+}
+
+// Allocate allocates KEM object for multiple X-Wing operations. The rng
+// must be cryptographically secure PRNG.
+func (c *KEM) Allocate(rng io.Reader) {
+	c.rng = rng
+	c.m = mlkem.NewMLKEM768(rng)
+	c.h = sha3.New256()
+	c.allocated = true
+}
+
+// Encapsulate receives the public key and generates X-Wing ciphertext and
+// shared secret. It reads 64 bytes from rng: the ML-KEM-768 encapsulation
+// seed followed by the ephemeral X25519 key. Error is returned in case
+// PRNG fails. Function panics in case wrongly formated input was provided.
+func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *PublicKey) error {
+	if !c.allocated {
+		panic("KEM unallocated")
+	}
+
+	if len(secret) < c.SharedSecretSize() {
+		panic("shared secret buffer too small")
+	}
+
+	if len(ciphertext) < c.CiphertextSize() {
+		panic("ciphertext buffer too small")
+	}
+
+	if err := c.m.Encapsulate(ciphertext, c.ssm[:], pub.pm); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(c.rng, c.ekx[:]); err != nil {
+		return err
+	}
+	x25519.KeyGen(&c.ctx, &c.ekx)
+	// The draft doesn't reject public keys of small order, resulting
+	// all-zero X25519 shared secret is used as is.
+	x25519.Shared(&c.ssx, &c.ekx, &pub.px)
+	copy(ciphertext[mlkemCiphertextSize:], c.ctx[:])
+	c.combine(secret, &pub.px)
+	return nil
+}
+
+// Decapsulate given the keypair and ciphertext as inputs, outputs a shared
+// secret. Public key must correspond to the private key. In case ML-KEM
+// ciphertext is invalid, shared secret is pseudorandom (implicit
+// rejection). Function panics in case input is wrongly formated, in
+// particular, size of the 'ciphertext' must be exactly equal to
+// c.CiphertextSize(). Constant time.
+func (c *KEM) Decapsulate(secret []byte, prv *PrivateKey, pub *PublicKey, ciphertext []byte) error {
+	if !c.allocated {
+		panic("KEM unallocated")
+	}
+
+	if len(secret) < c.SharedSecretSize() {
+		panic("shared secret buffer too small")
+	}
+
+	if len(ciphertext) != c.CiphertextSize() {
+		panic("ciphertext has wrong size")
+	}
+
+	if err := c.m.Decapsulate(c.ssm[:], prv.sm, ciphertext[:mlkemCiphertextSize]); err != nil {
+		return err
+	}
+	copy(c.ctx[:], ciphertext[mlkemCiphertextSize:])
+	x25519.Shared(&c.ssx, &prv.sx, &c.ctx)
+	c.combine(secret, &pub.px)
+	return nil
+}
+
+// combine computes shared secret
+// SHA3-256(ss_M || ss_X || ct_X || pk_X || XWingLabel), [XWING], Section 5.3.
+func (c *KEM) combine(secret []byte, pkx *x25519.Key) {
+	var ss [SharedSecretSize]byte
+	c.h.Reset()
+	_, _ = c.h.Write(c.ssm[:])
+	_, _ = c.h.Write(c.ssx[:])
+	_, _ = c.h.Write(c.ctx[:])
+	_, _ = c.h.Write(pkx[:])
+	_, _ = c.h.Write(label)
+	copy(secret, c.h.Sum(ss[:0]))
+}
+
+// Resets internal state of KEM. Function should be used
+// after Allocate and between subsequent calls to Encapsulate
+// and/or Decapsulate.
+func (c *KEM) Reset() {
+	c.m.Reset()
+	for i := range c.ssm {
+		c.ssm[i] = 0
+	}
+	c.ekx = x25519.Key{}
+	c.ssx = x25519.Key{}
+}
+
+// Returns size of resulting ciphertext.
+func (c *KEM) CiphertextSize() int {
+	return CiphertextSize
+}
+
+// Returns size of resulting shared secret.
+func (c *KEM) SharedSecretSize() int {
+	return SharedSecretSize
+}
@@ -0,0 +1,262 @@
+package xwing
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// testPanic returns error if call to function 'f' didn't cause panic.
+func testPanic(f func()) (err error) {
+	err = errors.New("no panic detected")
+	defer func() {
+		if r := recover(); r != nil {
+			err = nil
+		}
+	}()
+	f()
+	return err
+}
+
+// readVectors parses spec/test-vectors.txt from the draft repository
+// (https://github.com/dconnolly/draft-connolly-cfrg-xwing-kem). Each value
+// is either on the same line as its name or on the following indented
+// lines, vectors are separated by empty lines.
+func readVectors(t *testing.T) []map[string][]byte {
+	data, err := ioutil.ReadFile("testdata/test-vectors.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SHAKE128 of the file, as published in the draft repository
+	var digest [32]byte
+	h := sha3.NewShake128()
+	_, _ = h.Write(data)
+	_, _ = h.Read(digest[:])
+	if hex.EncodeToString(digest[:]) != "1bcd0057d861d6b866239936cadcaeee1ec0164dedc181c386e9e54fe46156fe" {
+		t.Fatal("wrong digest of test vectors")
+	}
+
+	var vecs []map[string][]byte
+	var name string
+	vals := make(map[string]string)
+	flush := func() {
+		if len(vals) == 0 {
+			return
+		}
+		vec := make(map[string][]byte)
+		for k, v := range vals {
+			if vec[k], err = hex.DecodeString(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		vecs = append(vecs, vec)
+		vals = make(map[string]string)
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case line[0] == ' ':
+			vals[name] += strings.TrimSpace(line)
+		default:
+			f := strings.Fields(line)
+			name = f[0]
+			if len(f) > 1 {
+				vals[name] = f[1]
+			}
+		}
+	}
+	flush()
+	return vecs
+}
+
+func TestVectors(t *testing.T) {
+	var ss [SharedSecretSize]byte
+	vecs := readVectors(t)
+	if len(vecs) != 3 {
+		t.Fatalf("expected 3 test vectors, got %d", len(vecs))
+	}
+	for i, v := range vecs {
+		sk := NewPrivateKey()
+		pk := NewPublicKey()
+		if err := sk.Import(v["seed"]); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		dk := make([]byte, sk.Size())
+		ek := make([]byte, pk.Size())
+		sk.Export(dk)
+		pk.Export(ek)
+		if !bytes.Equal(dk, v["sk"]) || !bytes.Equal(ek, v["pk"]) {
+			t.Fatalf("vector %d: wrong key pair", i)
+		}
+
+		pk = NewPublicKey()
+		if err := pk.Import(v["pk"]); err != nil {
+			t.Fatal(err)
+		}
+		kem := NewXWing(bytes.NewReader(v["eseed"]))
+		ct := make([]byte, kem.CiphertextSize())
+		if err := kem.Encapsulate(ct, ss[:], pk); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ct, v["ct"]) || !bytes.Equal(ss[:], v["ss"]) {
+			t.Fatalf("vector %d: wrong encapsulation", i)
+		}
+
+		ss = [SharedSecretSize]byte{}
+		if err := kem.Decapsulate(ss[:], sk, pk, ct); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ss[:], v["ss"]) {
+			t.Fatalf("vector %d: wrong decapsulation", i)
+		}
+	}
+}
+
+func TestKEMRoundTrip(t *testing.T) {
+	var ssE, ssD [SharedSecretSize]byte
+	kem := NewXWing(rand.Reader)
+	sk := NewPrivateKey()
+	pk := NewPublicKey()
+	ct := make([]byte, kem.CiphertextSize())
+	for i := 0; i < 10; i++ {
+		if err := sk.Generate(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		if err := kem.Encapsulate(ct, ssE[:], pk); err != nil {
+			t.Fatal(err)
+		}
+		if err := kem.Decapsulate(ssD[:], sk, pk, ct); err != nil {
+			t.Fatal(err)
+		}
+		if ssE != ssD {
+			t.Fatal("shared secrets differ")
+		}
+		kem.Reset()
+	}
+}
+
+// Modification of either part of the ciphertext must change shared secret.
+func TestModifiedCiphertext(t *testing.T) {
+	var ssE, ssD [SharedSecretSize]byte
+	kem := NewXWing(rand.Reader)
+	sk := NewPrivateKey()
+	pk := NewPublicKey()
+	ct := make([]byte, kem.CiphertextSize())
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+	if err := kem.Encapsulate(ct, ssE[:], pk); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{0, mlkemCiphertextSize - 1, mlkemCiphertextSize, len(ct) - 1} {
+		ct[i] ^= 0x02
+		if err := kem.Decapsulate(ssD[:], sk, pk, ct); err != nil {
+			t.Fatal(err)
+		}
+		if ssD == ssE {
+			t.Errorf("modified byte %d of ciphertext not detected", i)
+		}
+		ct[i] ^= 0x02
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	sk := NewPrivateKey()
+	pk := NewPublicKey()
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+	ek := make([]byte, pk.Size())
+	dk := make([]byte, sk.Size())
+	pk.Export(ek)
+	sk.Export(dk)
+
+	if NewPublicKey().Import(ek[1:]) == nil {
+		t.Error("public key of wrong size accepted")
+	}
+	if NewPrivateKey().Import(dk[1:]) == nil {
+		t.Error("private key of wrong size accepted")
+	}
+	// Modulus check of ML-KEM: set first coefficient to q = 3329
+	ek[0], ek[1] = 0x01, ek[1]&0xF0|0x0D
+	if NewPublicKey().Import(ek) == nil {
+		t.Error("public key with unreduced coefficient accepted")
+	}
+}
+
+func TestPanics(t *testing.T) {
+	var ss [SharedSecretSize]byte
+	kem := NewXWing(rand.Reader)
+	sk := NewPrivateKey()
+	pk := NewPublicKey()
+	ct := make([]byte, kem.CiphertextSize())
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+
+	if testPanic(func() { _ = new(KEM).Encapsulate(ct, ss[:], pk) }) != nil {
+		t.Error("unallocated KEM must panic")
+	}
+	if testPanic(func() { _ = kem.Encapsulate(ct[1:], ss[:], pk) }) != nil {
+		t.Error("short ciphertext buffer must panic")
+	}
+	if testPanic(func() { _ = kem.Encapsulate(ct, ss[1:], pk) }) != nil {
+		t.Error("short secret buffer must panic")
+	}
+	if testPanic(func() { _ = kem.Decapsulate(ss[:], sk, pk, ct[1:]) }) != nil {
+		t.Error("wrong ciphertext size must panic")
+	}
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	sk := NewPrivateKey()
+	for n := 0; n < b.N; n++ {
+		_ = sk.Generate(rand.Reader)
+	}
+}
+
+func BenchmarkEncaps(b *testing.B) {
+	var ss [SharedSecretSize]byte
+	kem := NewXWing(rand.Reader)
+	sk := NewPrivateKey()
+	pk := NewPublicKey()
+	ct := make([]byte, kem.CiphertextSize())
+	_ = sk.Generate(rand.Reader)
+	sk.GeneratePublicKey(pk)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = kem.Encapsulate(ct, ss[:], pk)
+	}
+}
+
+func BenchmarkDecaps(b *testing.B) {
+	var ss [SharedSecretSize]byte
+	kem := NewXWing(rand.Reader)
+	sk := NewPrivateKey()
+	pk := NewPublicKey()
+	ct := make([]byte, kem.CiphertextSize())
+	_ = sk.Generate(rand.Reader)
+	sk.GeneratePublicKey(pk)
+	_ = kem.Encapsulate(ct, ss[:], pk)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = kem.Decapsulate(ss[:], sk, pk, ct)
+	}
+}
@@ -0,0 +1,75 @@
+// Package prehash implements hashing of messages for pre-hash variants of
+// the signature schemes, HashML-DSA (FIPS 204) and HashSLH-DSA (FIPS 205).
+package prehash
+
+import (
+	"errors"
+	"hash"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// Hash identifies the hash function applied to the message.
+type Hash uint8
+
+// Supported hash functions. SHAKE128 and SHAKE256 produce 256 and 512 bits
+// of output respectively.
+const (
+	SHA3_224 Hash = iota + 1
+	SHA3_256
+	SHA3_384
+	SHA3_512
+	SHAKE128
+	SHAKE256
+)
+
+// ErrUnknown is returned for unsupported hash functions.
+var ErrUnknown = errors.New("unknown pre-hash function")
+
+// DER encoded OID of the hash function, without the last byte
+var oidPrefix = []byte{0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02}
+
+// Last byte of the DER encoded OID of the hash function
+var oidSuffix = [...]byte{
+	SHA3_224: 0x07,
+	SHA3_256: 0x08,
+	SHA3_384: 0x09,
+	SHA3_512: 0x0A,
+	SHAKE128: 0x0B,
+	SHAKE256: 0x0C,
+}
+
+// Digest returns DER encoded OID of the hash function followed by the
+// hash of msg.
+func (ph Hash) Digest(msg []byte) ([]byte, error) {
+	var h hash.Hash
+	var xof sha3.ShakeHash
+	var size int
+
+	switch ph {
+	case SHA3_224:
+		h = sha3.New224()
+	case SHA3_256:
+		h = sha3.New256()
+	case SHA3_384:
+		h = sha3.New384()
+	case SHA3_512:
+		h = sha3.New512()
+	case SHAKE128:
+		xof, size = sha3.NewShake128(), 32
+	case SHAKE256:
+		xof, size = sha3.NewShake256(), 64
+	default:
+		return nil, ErrUnknown
+	}
+
+	out := append(append([]byte{}, oidPrefix...), oidSuffix[ph])
+	if h != nil {
+		_, _ = h.Write(msg)
+		return h.Sum(out), nil
+	}
+	_, _ = xof.Write(msg)
+	out = append(out, make([]byte, size)...)
+	_, _ = xof.Read(out[len(out)-size:])
+	return out, nil
+}
@@ -0,0 +1,30 @@
+// Package lms implements Leighton-Micali Signatures (LMS) and the
+// Hierarchical Signature System (HSS) specified in RFC 8554, with SHAKE256
+// parameter sets from NIST SP 800-208: LMS_SHAKE_M32_H* and
+// LMS_SHAKE_M24_H* trees with LMOTS_SHAKE_N32_W* and LMOTS_SHAKE_N24_W*
+// one-time signatures. SHAKE256 comes from hash/sha3.
+//
+// LMS is a stateful scheme: each signature uses one-time key with an index,
+// which must never be used again. Private key keeps the index of the next
+// one-time key and stores itself with state.Manager before a signature
+// is released, so the index isn't reused even if the program crashes.
+// Single LMS tree is an HSS with one level.
+//
+// Format of the private key isn't specified by RFC 8554. This package
+// derives all trees from a single seed: private elements of one-time keys
+// are derived as in RFC 8554, Appendix A, SEED and I of the child tree
+// signed with the leaf q are H(I || u32str(q) || u16str(D) || u8str(0xff) ||
+// SEED) with D equal to 0xfffe and 0xffff respectively, and randomizer C
+// of the signature with D equal to 0xfffd. Signatures are thus
+// deterministic.
+//
+// Private key keeps all nodes of one tree for each level in memory, which
+// takes 2^(h+1)*m bytes per level. When the tree at a lower level is
+// exhausted, the next one is generated during signing.
+//
+// References:
+//   - [RFC8554] Leighton-Micali Hash-Based Signatures,
+//     https://www.rfc-editor.org/rfc/rfc8554
+//   - [SP800-208] Recommendation for Stateful Hash-Based Signature Schemes,
+//     https://doi.org/10.6028/NIST.SP.800-208
+package lms
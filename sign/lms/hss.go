@@ -0,0 +1,387 @@
+package lms
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/sign/state"
+)
+
+var (
+	errInvalidPublicKey  = errors.New("lms: invalid public key")
+	errInvalidPrivateKey = errors.New("lms: invalid private key")
+	errKeyNotGenerated   = errors.New("lms: private key not generated")
+	errKeyExhausted      = errors.New("lms: private key exhausted")
+)
+
+// Level describes parameters of one level of HSS.
+type Level struct {
+	LMS LMSType
+	OTS OTSType
+}
+
+// PublicKey represents HSS public key.
+type PublicKey struct {
+	// Number of levels
+	levels int
+	// LMS public key of the top level tree
+	key [8 + idSize + maxN]byte
+	lms *lmsParams
+}
+
+// NewPublicKey initializes public key. Parameters are determined when
+// the key is imported.
+func NewPublicKey() *PublicKey {
+	return &PublicKey{}
+}
+
+// Import imports public key stored in the byte string. Returns error in
+// case the key is malformed or uses unsupported parameters.
+// RFC 8554, Section 6.
+func (pub *PublicKey) Import(input []byte) error {
+	if len(input) < 4 {
+		return errInvalidPublicKey
+	}
+	levels := binary.BigEndian.Uint32(input)
+	lms, _ := parsePublicKey(input[4:])
+	if levels < 1 || levels > MaxLevels || lms == nil {
+		return errInvalidPublicKey
+	}
+	pub.levels = int(levels)
+	pub.lms = lms
+	copy(pub.key[:], input[4:])
+	return nil
+}
+
+// Export writes public key to out, which must be at least Size() bytes long.
+func (pub *PublicKey) Export(out []byte) {
+	binary.BigEndian.PutUint32(out, uint32(pub.levels))
+	copy(out[4:], pub.key[:pub.lms.publicKeySize()])
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return 4 + pub.lms.publicKeySize()
+}
+
+// PrivateKey represents HSS private key. It is safe for concurrent use.
+type PrivateKey struct {
+	mu     sync.Mutex
+	lms    []*lmsParams
+	ots    []*otsParams
+	id     [idSize]byte
+	seed   [maxN]byte
+	states state.Manager
+
+	// Index of the next signature
+	q uint64
+	// Indices below reserved may have been used, according to the state
+	// stored with the manager
+	reserved uint64
+
+	// Current trees of the levels and signatures of their public keys
+	// made with the trees at the level above. Trees below the top level
+	// are nil until needed.
+	trees []*tree
+	sigs  [][]byte
+}
+
+// NewPrivateKey initializes private key with given levels, starting from
+// the top one, which stores its state with the manager m. Panics if
+// parameters are unsupported or the total height of the trees exceeds 63.
+func NewPrivateKey(m state.Manager, levels ...Level) *PrivateKey {
+	var height int
+	if len(levels) < 1 || len(levels) > MaxLevels {
+		panic("lms: wrong number of levels")
+	}
+	prv := &PrivateKey{
+		lms:    make([]*lmsParams, len(levels)),
+		ots:    make([]*otsParams, len(levels)),
+		states: m,
+		trees:  make([]*tree, len(levels)),
+		sigs:   make([][]byte, len(levels)-1),
+	}
+	for i, l := range levels {
+		prv.lms[i] = getLMSParams(l.LMS)
+		prv.ots[i] = getOTSParams(l.OTS)
+		if prv.lms[i] == nil || prv.ots[i] == nil || prv.lms[i].n != prv.ots[i].n {
+			panic("lms: unsupported parameters")
+		}
+		height += prv.lms[i].h
+	}
+	if height > 63 {
+		panic("lms: total height of the trees too big")
+	}
+	return prv
+}
+
+// Import imports private key stored in the byte string. Returns error in
+// case the key is malformed or its levels differ from the ones prv was
+// initialized with. It computes the top level tree.
+func (prv *PrivateKey) Import(input []byte) error {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	if len(input) != prv.size() || binary.BigEndian.Uint32(input) != uint32(len(prv.lms)) {
+		return errInvalidPrivateKey
+	}
+	off := 4
+	for i := range prv.lms {
+		if LMSType(binary.BigEndian.Uint32(input[off:])) != prv.lms[i].typ ||
+			OTSType(binary.BigEndian.Uint32(input[off+4:])) != prv.ots[i].typ {
+			return errInvalidPrivateKey
+		}
+		off += 8
+	}
+	q := binary.BigEndian.Uint64(input[off:])
+	if q > prv.total() {
+		return errInvalidPrivateKey
+	}
+	prv.q, prv.reserved = q, q
+	copy(prv.id[:], input[off+8:])
+	copy(prv.seed[:], input[off+8+idSize:])
+	prv.init()
+	return nil
+}
+
+// Export writes private key to out, which must be at least Size() bytes
+// long. Encoding is u32str(L) || (u32str(lmstype) || u32str(otstype)) for
+// each level || u64str(index) || I || SEED, where the index is the first
+// one not reserved.
+func (prv *PrivateKey) Export(out []byte) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	prv.export(out)
+}
+
+func (prv *PrivateKey) export(out []byte) {
+	binary.BigEndian.PutUint32(out, uint32(len(prv.lms)))
+	off := 4
+	for i := range prv.lms {
+		binary.BigEndian.PutUint32(out[off:], uint32(prv.lms[i].typ))
+		binary.BigEndian.PutUint32(out[off+4:], uint32(prv.ots[i].typ))
+		off += 8
+	}
+	binary.BigEndian.PutUint64(out[off:], prv.reserved)
+	copy(out[off+8:], prv.id[:])
+	copy(out[off+8+idSize:], prv.seed[:prv.lms[0].n])
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return prv.size()
+}
+
+func (prv *PrivateKey) size() int {
+	return 4 + 8*len(prv.lms) + 8 + idSize + prv.lms[0].n
+}
+
+// Generate generates a random key pair and stores it with the state
+// manager. It reads 16+n bytes from rng, which are I and SEED of the top
+// level tree. Returns error in case rng or the manager fails.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	var buf [idSize + maxN]byte
+	n := prv.lms[0].n
+	if _, err := io.ReadFull(rng, buf[:idSize+n]); err != nil {
+		return err
+	}
+	copy(prv.id[:], buf[:idSize])
+	copy(prv.seed[:], buf[idSize:idSize+n])
+	prv.q, prv.reserved = 0, 0
+
+	out := make([]byte, prv.size())
+	prv.export(out)
+	if err := prv.states.Store(out); err != nil {
+		return err
+	}
+	prv.init()
+	return nil
+}
+
+// GeneratePublicKey writes public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	pub.levels = len(prv.lms)
+	pub.lms = prv.lms[0]
+	prv.trees[0].publicKey(pub.key[:])
+}
+
+// SignatureSize returns size of the signature in bytes.
+func (prv *PrivateKey) SignatureSize() int {
+	size := 4
+	for i := range prv.lms {
+		size += lmsSignatureSize(prv.lms[i], prv.ots[i])
+		if i > 0 {
+			size += prv.lms[i].publicKeySize()
+		}
+	}
+	return size
+}
+
+// Remaining returns number of signatures which can still be made.
+func (prv *PrivateKey) Remaining() uint64 {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	return prv.total() - prv.q
+}
+
+// Reserve stores the state in which next count indices are used. The
+// following count signatures are made without calling the manager. If the
+// program crashes, the reserved indices which weren't used are lost.
+func (prv *PrivateKey) Reserve(count uint64) error {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	return prv.reserve(count)
+}
+
+func (prv *PrivateKey) reserve(count uint64) error {
+	res := prv.total()
+	if count < res-prv.q {
+		res = prv.q + count
+	}
+	if res <= prv.reserved {
+		return nil
+	}
+
+	old := prv.reserved
+	prv.reserved = res
+	out := make([]byte, prv.size())
+	prv.export(out)
+	if err := prv.states.Store(out); err != nil {
+		prv.reserved = old
+		return err
+	}
+	return nil
+}
+
+// Total number of signatures
+func (prv *PrivateKey) total() uint64 {
+	var h uint
+	for _, l := range prv.lms {
+		h += uint(l.h)
+	}
+	return uint64(1) << h
+}
+
+// Number of bits of the index below level i
+func (prv *PrivateKey) below(i int) uint {
+	var h uint
+	for _, l := range prv.lms[i+1:] {
+		h += uint(l.h)
+	}
+	return h
+}
+
+// leaf returns index of the leaf of the current tree at level i.
+func (prv *PrivateKey) leaf(i int) uint32 {
+	return uint32(prv.q>>prv.below(i)) & (1<<uint(prv.lms[i].h) - 1)
+}
+
+// init computes the top level tree, lower trees are computed by update.
+func (prv *PrivateKey) init() {
+	prv.trees[0] = newTree(prv.lms[0], prv.ots[0], prv.id[:], prv.seed[:prv.lms[0].n])
+	for i := 1; i < len(prv.trees); i++ {
+		prv.trees[i] = nil
+	}
+}
+
+// update replaces trees below the top level, which aren't the ones for the
+// current index, and signs their public keys with the level above. Public
+// keys of the same tree are always signed with the same leaf, which gives
+// the same signature, so they can be signed again after Import.
+func (prv *PrivateKey) update() {
+	for i := 1; i < len(prv.trees); i++ {
+		parent := prv.trees[i-1]
+		q := prv.leaf(i - 1)
+		if t := prv.trees[i]; t != nil && q == prv.sigLeaf(i-1) {
+			continue
+		}
+
+		t := parent.child(q, prv.lms[i], prv.ots[i])
+		pub := make([]byte, t.lms.publicKeySize())
+		t.publicKey(pub)
+		sig := make([]byte, lmsSignatureSize(parent.lms, parent.ots), len(pub)+lmsSignatureSize(parent.lms, parent.ots))
+		parent.sign(sig, q, pub)
+		prv.trees[i] = t
+		prv.sigs[i-1] = append(sig, pub...)
+		// Trees below are derived from this one
+		for j := i + 1; j < len(prv.trees); j++ {
+			prv.trees[j] = nil
+		}
+	}
+}
+
+// sigLeaf returns leaf of the level i which signed the current tree at
+// level i+1.
+func (prv *PrivateKey) sigLeaf(i int) uint32 {
+	return binary.BigEndian.Uint32(prv.sigs[i])
+}
+
+// Sign signs message msg with the private key. Before the signature is
+// made, updated private key is stored with the state manager, unless the
+// index was reserved by Reserve. Returns error if the key is exhausted or
+// the manager fails. RFC 8554, Algorithm 8.
+func Sign(prv *PrivateKey, msg []byte) ([]byte, error) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	if prv.trees[0] == nil {
+		return nil, errKeyNotGenerated
+	}
+	if prv.q >= prv.total() {
+		return nil, errKeyExhausted
+	}
+	if prv.q >= prv.reserved {
+		if err := prv.reserve(1); err != nil {
+			return nil, err
+		}
+	}
+	prv.update()
+
+	sig := make([]byte, 4, prv.SignatureSize())
+	last := len(prv.trees) - 1
+	binary.BigEndian.PutUint32(sig, uint32(last))
+	for _, s := range prv.sigs {
+		sig = append(sig, s...)
+	}
+	off := len(sig)
+	sig = sig[:cap(sig)]
+	prv.trees[last].sign(sig[off:], prv.leaf(last), msg)
+	prv.q++
+	return sig, nil
+}
+
+// Verify verifies HSS signature sig of message msg with the public key.
+// RFC 8554, Algorithm 7.
+func Verify(pub *PublicKey, msg, sig []byte) bool {
+	xof := sha3.NewShake256()
+	if pub.lms == nil || len(sig) < 4 || binary.BigEndian.Uint32(sig) != uint32(pub.levels-1) {
+		return false
+	}
+	sig = sig[4:]
+	key := pub.key[:pub.lms.publicKeySize()]
+	for i := 0; i < pub.levels-1; i++ {
+		size := signatureSize(sig)
+		if size == 0 || len(sig) < size+8 {
+			return false
+		}
+		lms := getLMSParams(LMSType(binary.BigEndian.Uint32(sig[size:])))
+		if lms == nil || len(sig) < size+lms.publicKeySize() {
+			return false
+		}
+		next := sig[size : size+lms.publicKeySize()]
+		if !verify(xof, key, next, sig[:size]) {
+			return false
+		}
+		key = next
+		sig = sig[size+len(next):]
+	}
+	return verify(xof, key, msg, sig)
+}
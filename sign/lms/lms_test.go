@@ -0,0 +1,325 @@
+package lms
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// Key generated from I = 00 01 ... 0F and SEED = 10 11 ..., with the index
+// of the next signature set to q.
+func testKey(t testing.TB, m *test.MemState, q uint64, levels ...Level) *PrivateKey {
+	sk := NewPrivateKey(m, levels...)
+	seed := test.Seed(idSize + sk.lms[0].n)
+	if err := sk.Generate(bytes.NewReader(seed)); err != nil {
+		t.Fatal(err)
+	}
+	if q != 0 {
+		key := make([]byte, sk.Size())
+		sk.Export(key)
+		binary.BigEndian.PutUint64(key[4+8*len(levels):], q)
+		if err := sk.Import(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sk
+}
+
+// Public keys and SHA3-256 of signatures of the message "message" made
+// with keys from testKey. RFC 8554 test vectors use SHA-256 only, so the
+// values were computed with an independent implementation of RFC 8554
+// with SP 800-208 SHAKE256 instances.
+var signTests = []struct {
+	levels []Level
+	q      uint64
+	pk     string
+	sig    string
+}{
+	{
+		[]Level{{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8}}, 0,
+		"000000010000000f0000000c000102030405060708090a0b0c0d0e0f" +
+			"bee7419c29ef822ee76c4511d2b921738a5dff15af45f8d4ddb4c586397a2cfd",
+		"298c08702b5da6f02062de58c932d06d129c4fb4251e9d17db90682fddebadd9",
+	},
+	{
+		[]Level{{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8}}, 31,
+		"000000010000000f0000000c000102030405060708090a0b0c0d0e0f" +
+			"bee7419c29ef822ee76c4511d2b921738a5dff15af45f8d4ddb4c586397a2cfd",
+		"c247263af034b8073954be0145850cea173ebfbb083e59ecee8cf9a638a49998",
+	},
+	{
+		[]Level{{LMS_SHAKE_M24_H5, LMOTS_SHAKE_N24_W8}}, 3,
+		"000000010000001400000010000102030405060708090a0b0c0d0e0f" +
+			"cf15a96558461cd790fa95ccdfad32f9358f8cd8d1cfe2c1",
+		"9d5939d141785a0ed293ca5fb966fc27ff36c2b51482d7e9d57d8ff953cb606b",
+	},
+	{
+		[]Level{{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W4}, {LMS_SHAKE_M24_H5, LMOTS_SHAKE_N24_W8}}, 0,
+		"000000020000000f0000000b000102030405060708090a0b0c0d0e0f" +
+			"f63b83cccb053ac4c980e633ee6355cf98489662334a2e8e9948907d004afdd0",
+		"c81ea00638a7b5111a98d80dc9a97722543186e3ef922959aaa78c9a02cb3163",
+	},
+	{
+		[]Level{{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W4}, {LMS_SHAKE_M24_H5, LMOTS_SHAKE_N24_W8}}, 37,
+		"000000020000000f0000000b000102030405060708090a0b0c0d0e0f" +
+			"f63b83cccb053ac4c980e633ee6355cf98489662334a2e8e9948907d004afdd0",
+		"4a298dcc3d658cf93b6b670613b85341126079fba192f739c4c385caf85214d2",
+	},
+	{
+		[]Level{
+			{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W1},
+			{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8},
+			{LMS_SHAKE_M24_H5, LMOTS_SHAKE_N24_W2},
+		}, 1234,
+		"000000030000000f00000009000102030405060708090a0b0c0d0e0f" +
+			"5c783209b5ae250c4720405480df34441a945d3c2240f1aa209586bdb64a3f48",
+		"8b1c4ec42d75e0e37cf5127724c821c1770a581332a973fedc40feea38b52770",
+	},
+}
+
+func TestSign(t *testing.T) {
+	msg := []byte("message")
+	for i, tt := range signTests {
+		sk := testKey(t, new(test.MemState), tt.q, tt.levels...)
+		pk := NewPublicKey()
+		sk.GeneratePublicKey(pk)
+		pkb := make([]byte, pk.Size())
+		pk.Export(pkb)
+		if !bytes.Equal(pkb, test.FromHex(tt.pk)) {
+			t.Errorf("%d: wrong public key %X", i, pkb)
+		}
+
+		sig, err := Sign(sk, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != sk.SignatureSize() {
+			t.Errorf("%d: wrong signature size %d", i, len(sig))
+		}
+		if !bytes.Equal(test.SHA3Sum(sig), test.FromHex(tt.sig)) {
+			t.Errorf("%d: wrong signature", i)
+		}
+		pk2 := NewPublicKey()
+		if err = pk2.Import(pkb); err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(pk2, msg, sig) {
+			t.Errorf("%d: signature rejected", i)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	msg := []byte("message")
+	levels := []Level{{LMS_SHAKE_M24_H5, LMOTS_SHAKE_N24_W4}, {LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W2}}
+	sk := NewPrivateKey(new(test.MemState), levels...)
+	pk := NewPublicKey()
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+
+	// Signatures made across the boundary of the lower trees
+	sigs := make([][]byte, 40)
+	for i := range sigs {
+		var err error
+		if sigs[i], err = Sign(sk, msg); err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(pk, msg, sigs[i]) {
+			t.Fatalf("signature %d rejected", i)
+		}
+	}
+	if sk.Remaining() != 1<<10-40 {
+		t.Errorf("wrong number of remaining signatures %d", sk.Remaining())
+	}
+
+	sig := sigs[33]
+	if Verify(pk, []byte("massage"), sig) {
+		t.Error("signature of other message accepted")
+	}
+	// Index, both LMS signatures, public key of the lower tree and the
+	// path of the lowest tree
+	for _, i := range []int{3, 4, 100, 1000, len(sig) - 1} {
+		sig[i] ^= 1
+		if Verify(pk, msg, sig) {
+			t.Errorf("modified signature accepted (byte %d)", i)
+		}
+		sig[i] ^= 1
+	}
+	if Verify(pk, msg, sig[:len(sig)-1]) || Verify(pk, msg, append(sig, 0)) {
+		t.Error("signature of wrong size accepted")
+	}
+	// Signature made with the lower tree only
+	if Verify(pk, msg, append([]byte{0, 0, 0, 0}, sig[4+signatureSize(sig[4:])+56:]...)) {
+		t.Error("signature of the lower tree accepted")
+	}
+}
+
+func TestState(t *testing.T) {
+	msg := []byte("message")
+	m := new(test.MemState)
+	sk := testKey(t, m, 0, Level{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8})
+	index := func(sig []byte) uint32 { return binary.BigEndian.Uint32(sig[4:]) }
+	restore := func() *PrivateKey {
+		sk := NewPrivateKey(m, Level{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8})
+		if err := sk.Import(m.Key); err != nil {
+			t.Fatal(err)
+		}
+		return sk
+	}
+
+	// Key is stored before each signature
+	for i := 0; i < 3; i++ {
+		stores := m.Stores
+		sig, err := Sign(sk, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index(sig) != uint32(i) || m.Stores != stores+1 {
+			t.Errorf("signature %d: wrong index %d or no store", i, index(sig))
+		}
+	}
+
+	// Key restored after crash doesn't reuse indices
+	sk = restore()
+	sig, _ := Sign(sk, msg)
+	if index(sig) != 3 {
+		t.Errorf("restored key used index %d", index(sig))
+	}
+
+	// Failing manager prevents signing
+	m.Fail = true
+	if _, err := Sign(sk, msg); err == nil {
+		t.Error("signature made without storing the state")
+	}
+	m.Fail = false
+	sig, _ = Sign(sk, msg)
+	if index(sig) != 4 {
+		t.Errorf("wrong index %d after failed store", index(sig))
+	}
+
+	// Reserved indices are used without storing, the unused ones are lost
+	// after crash
+	stores := m.Stores
+	if err := sk.Reserve(10); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = Sign(sk, msg)
+	}
+	if m.Stores != stores+1 {
+		t.Errorf("%d stores for reserved indices", m.Stores-stores)
+	}
+	sk = restore()
+	if sig, _ = Sign(sk, msg); index(sig) != 15 {
+		t.Errorf("wrong index %d after reservation", index(sig))
+	}
+
+	// Exhausted key
+	if err := sk.Reserve(100); err != nil {
+		t.Fatal(err)
+	}
+	for sk.Remaining() > 0 {
+		if _, err := Sign(sk, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Sign(sk, msg); err != errKeyExhausted {
+		t.Errorf("exhausted key: got error %v", err)
+	}
+	if _, err := Sign(NewPrivateKey(m, Level{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8}), msg); err == nil {
+		t.Error("key which wasn't generated signed a message")
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	levels := []Level{{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8}}
+	sk := testKey(t, new(test.MemState), 0, levels...)
+	pk := NewPublicKey()
+	sk.GeneratePublicKey(pk)
+	pkb := make([]byte, pk.Size())
+	pk.Export(pkb)
+	skb := make([]byte, sk.Size())
+	sk.Export(skb)
+
+	for i, b := range [][]byte{
+		nil,
+		pkb[:len(pkb)-1],
+		append(pkb, 0),
+		append([]byte{0, 0, 0, 0}, pkb[4:]...),
+		append([]byte{0, 0, 0, 9}, pkb[4:]...),
+		append(append(append([]byte{}, pkb[:7]...), 0x14), pkb[8:]...),
+		append(append(append([]byte{}, pkb[:11]...), 0x10), pkb[12:]...),
+	} {
+		if err := NewPublicKey().Import(b); err == nil {
+			t.Errorf("%d: invalid public key accepted", i)
+		}
+	}
+
+	for i, b := range [][]byte{
+		skb[:len(skb)-1],
+		append(append(append([]byte{}, skb[:11]...), 0x0B), skb[12:]...),
+		append(append(append([]byte{}, skb[:12]...), 0xFF), skb[13:]...),
+	} {
+		if err := NewPrivateKey(new(test.MemState), levels...).Import(b); err == nil {
+			t.Errorf("%d: invalid private key accepted", i)
+		}
+	}
+	// Levels differ from the ones of the encoded key
+	if err := NewPrivateKey(new(test.MemState), levels[0], levels[0]).Import(skb); err == nil {
+		t.Error("private key with wrong levels accepted")
+	}
+}
+
+func benchLevels(b *testing.B, f func(b *testing.B, levels []Level)) {
+	for _, c := range []struct {
+		name   string
+		levels []Level
+	}{
+		{"H5_W8", []Level{{LMS_SHAKE_M32_H5, LMOTS_SHAKE_N32_W8}}},
+		{"H10_W4", []Level{{LMS_SHAKE_M32_H10, LMOTS_SHAKE_N32_W4}}},
+	} {
+		levels := c.levels
+		b.Run(c.name, func(b *testing.B) { f(b, levels) })
+	}
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	benchLevels(b, func(b *testing.B, levels []Level) {
+		sk := NewPrivateKey(new(test.MemState), levels...)
+		for i := 0; i < b.N; i++ {
+			_ = sk.Generate(rand.Reader)
+		}
+	})
+}
+
+func BenchmarkSign(b *testing.B) {
+	benchLevels(b, func(b *testing.B, levels []Level) {
+		sk := testKey(b, new(test.MemState), 0, levels...)
+		msg := []byte("message")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// Reset the index, key generation isn't benchmarked
+			sk.q, sk.reserved = 0, 1
+			_, _ = Sign(sk, msg)
+		}
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchLevels(b, func(b *testing.B, levels []Level) {
+		sk := testKey(b, new(test.MemState), 0, levels...)
+		pk := NewPublicKey()
+		sk.GeneratePublicKey(pk)
+		msg := []byte("message")
+		sig, _ := Sign(sk, msg)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			Verify(pk, msg, sig)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package lms
+
+import (
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// Domain separation values, RFC 8554, Section 3.2. The last three are
+// used for pseudorandom derivation of the randomizer C and of child trees.
+const (
+	dPblc      = 0x8080
+	dMesg      = 0x8181
+	dLeaf      = 0x8282
+	dIntr      = 0x8383
+	dC         = 0xfffd
+	dChildSeed = 0xfffe
+	dChildI    = 0xffff
+)
+
+// Size of the prefix I || u32str(q) || u16str(i) || u8str(j) of the hashes
+// in LM-OTS chains
+const chainPrefixSize = idSize + 4 + 2 + 1
+
+// hash computes SHAKE256 of concatenation of in, len(out) bytes long.
+func hash(xof sha3.ShakeHash, out []byte, in ...[]byte) {
+	xof.Reset()
+	for _, m := range in {
+		_, _ = xof.Write(m)
+	}
+	_, _ = xof.Read(out)
+}
+
+// prefix returns I || u32str(q) || u16str(d).
+func prefix(id []byte, q uint32, d uint16) []byte {
+	var buf [idSize + 6]byte
+	copy(buf[:], id)
+	binary.BigEndian.PutUint32(buf[idSize:], q)
+	binary.BigEndian.PutUint16(buf[idSize+4:], d)
+	return buf[:]
+}
+
+// coef returns i-th w-bit coefficient of s, RFC 8554, Section 3.1.3.
+func coef(s []byte, i int, w uint) byte {
+	shift := 8 - (w*uint(i%(8/int(w))) + w)
+	return (s[i*int(w)/8] >> shift) & (1<<w - 1)
+}
+
+// digits computes coefficients a[i] of message hash q followed by its
+// checksum, RFC 8554, Algorithm 2, step 2 and Algorithm 3, step 4.
+func (p *otsParams) digits(a []byte, q []byte) {
+	var sum uint16
+	var qc [maxN + 2]byte
+	max := uint16(1)<<p.w - 1
+
+	copy(qc[:], q[:p.n])
+	for i := 0; i < 8*p.n/int(p.w); i++ {
+		sum += max - uint16(coef(q, i, p.w))
+	}
+	binary.BigEndian.PutUint16(qc[p.n:], sum<<p.ls)
+	for i := 0; i < p.p; i++ {
+		a[i] = coef(qc[:], i, p.w)
+	}
+}
+
+// otsPublicKey computes LM-OTS public key K of the leaf q. Private values
+// x[i] = H(I || u32str(q) || u16str(i) || u8str(0xff) || SEED) have the
+// same form as the hashes in the chains, so all 2^w hashes of each chain
+// are computed in the same buffer, four chains at a time. RFC 8554,
+// Algorithm 1 and Appendix A.
+func (t *tree) otsPublicKey(out []byte, q uint32) {
+	var y [maxP * maxN]byte
+	var buf [4][chainPrefixSize + maxN]byte
+	p, n := t.ots.p, t.ots.n
+	l := chainPrefixSize + n
+
+	for j := range buf {
+		copy(buf[j][:], prefix(t.id[:], q, 0))
+	}
+	for i := 0; i < p; i += 4 {
+		for j := range buf {
+			// Last group of chains is padded with copies of the last chain
+			c := i + j
+			if c >= p {
+				c = p - 1
+			}
+			binary.BigEndian.PutUint16(buf[j][idSize+4:], uint16(c))
+			buf[j][idSize+6] = 0xff
+			copy(buf[j][chainPrefixSize:], t.seed[:n])
+		}
+		for k := 0; k < 1<<t.ots.w; k++ {
+			t.x4.Reset()
+			_, _ = t.x4.Write(buf[0][:l], buf[1][:l], buf[2][:l], buf[3][:l])
+			_, _ = t.x4.Read(buf[0][chainPrefixSize:l], buf[1][chainPrefixSize:l],
+				buf[2][chainPrefixSize:l], buf[3][chainPrefixSize:l])
+			for j := range buf {
+				buf[j][idSize+6] = byte(k)
+			}
+		}
+		for j := range buf {
+			c := i + j
+			if c < p {
+				copy(y[c*n:], buf[j][chainPrefixSize:l])
+			}
+		}
+	}
+	hash(t.xof, out[:n], prefix(t.id[:], q, dPblc), y[:p*n])
+}
+
+// otsSign computes LM-OTS signature of msg with the leaf q. RFC 8554,
+// Algorithm 3.
+func (t *tree) otsSign(sig []byte, q uint32, msg []byte) {
+	var a [maxP]byte
+	var qh [maxN]byte
+	var buf [chainPrefixSize + maxN]byte
+	ots := t.ots
+	n := ots.n
+	l := chainPrefixSize + n
+
+	binary.BigEndian.PutUint32(sig, uint32(ots.typ))
+	c := sig[4 : 4+n]
+	hash(t.xof, c, prefix(t.id[:], q, dC), []byte{0xff}, t.seed[:n])
+	hash(t.xof, qh[:n], prefix(t.id[:], q, dMesg), c, msg)
+	ots.digits(a[:], qh[:n])
+
+	copy(buf[:], prefix(t.id[:], q, 0))
+	y := sig[4+n:]
+	for i := 0; i < ots.p; i++ {
+		binary.BigEndian.PutUint16(buf[idSize+4:], uint16(i))
+		buf[idSize+6] = 0xff
+		copy(buf[chainPrefixSize:], t.seed[:n])
+		for j := 0; j <= int(a[i]); j++ {
+			hash(t.xof, buf[chainPrefixSize:l], buf[:l])
+			buf[idSize+6] = byte(j)
+		}
+		copy(y[i*n:], buf[chainPrefixSize:l])
+	}
+}
+
+// otsCandidate computes candidate public key Kc from LM-OTS signature sig
+// of msg, which must have correct size. RFC 8554, Algorithm 4b.
+func otsCandidate(xof sha3.ShakeHash, out []byte, ots *otsParams, id []byte, q uint32, sig, msg []byte) {
+	var a [maxP]byte
+	var qh [maxN]byte
+	var z [maxP * maxN]byte
+	var buf [chainPrefixSize + maxN]byte
+	n := ots.n
+	l := chainPrefixSize + n
+	max := 1<<ots.w - 1
+
+	hash(xof, qh[:n], prefix(id, q, dMesg), sig[4:4+n], msg)
+	ots.digits(a[:], qh[:n])
+
+	copy(buf[:], prefix(id, q, 0))
+	y := sig[4+n:]
+	for i := 0; i < ots.p; i++ {
+		binary.BigEndian.PutUint16(buf[idSize+4:], uint16(i))
+		copy(buf[chainPrefixSize:], y[i*n:(i+1)*n])
+		for j := int(a[i]); j < max; j++ {
+			buf[idSize+6] = byte(j)
+			hash(xof, buf[chainPrefixSize:l], buf[:l])
+		}
+		copy(z[i*n:], buf[chainPrefixSize:l])
+	}
+	hash(xof, out[:n], prefix(id, q, dPblc), z[:ots.p*n])
+}
@@ -0,0 +1,132 @@
+package lms
+
+// LMSType is a typecode of LMS parameter set, RFC 8554, Section 5.1.
+type LMSType uint32
+
+// OTSType is a typecode of LM-OTS parameter set, RFC 8554, Section 4.1.
+type OTSType uint32
+
+// LMS typecodes with SHAKE256 output of 32 and 24 bytes and tree heights
+// 5 to 25, SP 800-208, Section 4.
+const (
+	LMS_SHAKE_M32_H5  LMSType = 0x0F
+	LMS_SHAKE_M32_H10 LMSType = 0x10
+	LMS_SHAKE_M32_H15 LMSType = 0x11
+	LMS_SHAKE_M32_H20 LMSType = 0x12
+	LMS_SHAKE_M32_H25 LMSType = 0x13
+	LMS_SHAKE_M24_H5  LMSType = 0x14
+	LMS_SHAKE_M24_H10 LMSType = 0x15
+	LMS_SHAKE_M24_H15 LMSType = 0x16
+	LMS_SHAKE_M24_H20 LMSType = 0x17
+	LMS_SHAKE_M24_H25 LMSType = 0x18
+)
+
+// LM-OTS typecodes with SHAKE256 output of 32 and 24 bytes and Winternitz
+// parameters 1, 2, 4 and 8, SP 800-208, Section 4.
+const (
+	LMOTS_SHAKE_N32_W1 OTSType = 0x09
+	LMOTS_SHAKE_N32_W2 OTSType = 0x0A
+	LMOTS_SHAKE_N32_W4 OTSType = 0x0B
+	LMOTS_SHAKE_N32_W8 OTSType = 0x0C
+	LMOTS_SHAKE_N24_W1 OTSType = 0x0D
+	LMOTS_SHAKE_N24_W2 OTSType = 0x0E
+	LMOTS_SHAKE_N24_W4 OTSType = 0x0F
+	LMOTS_SHAKE_N24_W8 OTSType = 0x10
+)
+
+const (
+	// Maximal number of levels of HSS
+	MaxLevels = 8
+
+	// Maximal size of hash values
+	maxN = 32
+	// Maximal number of n-byte elements of LM-OTS signature
+	maxP = 265
+	// Size of the key pair identifier I
+	idSize = 16
+)
+
+// lmsParams describes LMS parameter set.
+type lmsParams struct {
+	typ LMSType
+	// Size of hash values, m
+	n int
+	// Height of the tree
+	h int
+}
+
+// otsParams describes LM-OTS parameter set.
+type otsParams struct {
+	typ OTSType
+	// Size of hash values
+	n int
+	// Winternitz parameter, number of bits encoded by one chain
+	w uint
+	// Number of chains
+	p int
+	// Left shift of the checksum
+	ls uint
+}
+
+var lmsParamSets = [...]lmsParams{
+	{LMS_SHAKE_M32_H5, 32, 5},
+	{LMS_SHAKE_M32_H10, 32, 10},
+	{LMS_SHAKE_M32_H15, 32, 15},
+	{LMS_SHAKE_M32_H20, 32, 20},
+	{LMS_SHAKE_M32_H25, 32, 25},
+	{LMS_SHAKE_M24_H5, 24, 5},
+	{LMS_SHAKE_M24_H10, 24, 10},
+	{LMS_SHAKE_M24_H15, 24, 15},
+	{LMS_SHAKE_M24_H20, 24, 20},
+	{LMS_SHAKE_M24_H25, 24, 25},
+}
+
+// Values of p and ls are computed as in RFC 8554, Appendix B
+var otsParamSets = [...]otsParams{
+	{LMOTS_SHAKE_N32_W1, 32, 1, 265, 7},
+	{LMOTS_SHAKE_N32_W2, 32, 2, 133, 6},
+	{LMOTS_SHAKE_N32_W4, 32, 4, 67, 4},
+	{LMOTS_SHAKE_N32_W8, 32, 8, 34, 0},
+	{LMOTS_SHAKE_N24_W1, 24, 1, 200, 8},
+	{LMOTS_SHAKE_N24_W2, 24, 2, 101, 6},
+	{LMOTS_SHAKE_N24_W4, 24, 4, 51, 4},
+	{LMOTS_SHAKE_N24_W8, 24, 8, 26, 0},
+}
+
+// getLMSParams returns parameters of LMS typecode t, or nil if it's
+// unsupported.
+func getLMSParams(t LMSType) *lmsParams {
+	for i := range lmsParamSets {
+		if lmsParamSets[i].typ == t {
+			return &lmsParamSets[i]
+		}
+	}
+	return nil
+}
+
+// getOTSParams returns parameters of LM-OTS typecode t, or nil if it's
+// unsupported.
+func getOTSParams(t OTSType) *otsParams {
+	for i := range otsParamSets {
+		if otsParamSets[i].typ == t {
+			return &otsParamSets[i]
+		}
+	}
+	return nil
+}
+
+// Size of LMS public key: u32str(type) || u32str(otstype) || I || T[1]
+func (p *lmsParams) publicKeySize() int {
+	return 8 + idSize + p.n
+}
+
+// Size of LM-OTS signature: u32str(type) || C || y[0] || ... || y[p-1]
+func (p *otsParams) signatureSize() int {
+	return 4 + p.n + p.p*p.n
+}
+
+// Size of LMS signature: u32str(q) || lmots_signature || u32str(type) ||
+// path[0] || ... || path[h-1]
+func lmsSignatureSize(lms *lmsParams, ots *otsParams) int {
+	return 4 + ots.signatureSize() + 4 + lms.h*lms.n
+}
@@ -0,0 +1,145 @@
+package lms
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// tree is a private key of a single LMS tree, with all nodes of the tree.
+type tree struct {
+	lms *lmsParams
+	ots *otsParams
+	// Key pair identifier I
+	id   [idSize]byte
+	seed [maxN]byte
+	// Nodes T[r] for r in [1, 2^(h+1)), stored at offset r*n
+	nodes []byte
+	xof   sha3.ShakeHash
+	x4    *sha3.ShakeX4
+}
+
+// newTree creates LMS private key and computes all nodes of the tree.
+// RFC 8554, Section 5.3.
+func newTree(lms *lmsParams, ots *otsParams, id, seed []byte) *tree {
+	var k [maxN]byte
+	n := lms.n
+	t := &tree{
+		lms:   lms,
+		ots:   ots,
+		nodes: make([]byte, n<<uint(lms.h+1)),
+		xof:   sha3.NewShake256(),
+		x4:    sha3.NewShake256X4(),
+	}
+	copy(t.id[:], id)
+	copy(t.seed[:], seed)
+
+	leaves := uint32(1) << uint(lms.h)
+	for q := uint32(0); q < leaves; q++ {
+		r := leaves + q
+		t.otsPublicKey(k[:n], q)
+		hash(t.xof, t.nodes[int(r)*n:int(r+1)*n], prefix(t.id[:], r, dLeaf), k[:n])
+	}
+	for r := leaves - 1; r > 0; r-- {
+		hash(t.xof, t.nodes[int(r)*n:int(r+1)*n], prefix(t.id[:], r, dIntr),
+			t.nodes[int(2*r)*n:int(2*r+2)*n])
+	}
+	return t
+}
+
+// publicKey writes LMS public key to out. RFC 8554, Algorithm 1.
+func (t *tree) publicKey(out []byte) {
+	binary.BigEndian.PutUint32(out, uint32(t.lms.typ))
+	binary.BigEndian.PutUint32(out[4:], uint32(t.ots.typ))
+	copy(out[8:], t.id[:])
+	copy(out[8+idSize:], t.nodes[t.lms.n:2*t.lms.n])
+}
+
+// sign computes LMS signature of msg with the leaf q. RFC 8554, Section 5.4.1.
+func (t *tree) sign(sig []byte, q uint32, msg []byte) {
+	n := t.lms.n
+	binary.BigEndian.PutUint32(sig, q)
+	t.otsSign(sig[4:], q, msg)
+	off := 4 + t.ots.signatureSize()
+	binary.BigEndian.PutUint32(sig[off:], uint32(t.lms.typ))
+	off += 4
+	r := uint32(1)<<uint(t.lms.h) + q
+	for i := 0; i < t.lms.h; i++ {
+		copy(sig[off+i*n:], t.nodes[int(r^1)*n:int(r^1+1)*n])
+		r >>= 1
+	}
+}
+
+// child derives LMS tree signed with the leaf q of t.
+func (t *tree) child(q uint32, lms *lmsParams, ots *otsParams) *tree {
+	var id [idSize]byte
+	var seed [maxN]byte
+	hash(t.xof, seed[:lms.n], prefix(t.id[:], q, dChildSeed), []byte{0xff}, t.seed[:t.lms.n])
+	hash(t.xof, id[:], prefix(t.id[:], q, dChildI), []byte{0xff}, t.seed[:t.lms.n])
+	return newTree(lms, ots, id[:], seed[:lms.n])
+}
+
+// parsePublicKey returns parameters of LMS public key pub, or nil if
+// they are unsupported or the size of pub is wrong.
+func parsePublicKey(pub []byte) (*lmsParams, *otsParams) {
+	if len(pub) < 8 {
+		return nil, nil
+	}
+	lms := getLMSParams(LMSType(binary.BigEndian.Uint32(pub)))
+	ots := getOTSParams(OTSType(binary.BigEndian.Uint32(pub[4:])))
+	if lms == nil || ots == nil || lms.n != ots.n || len(pub) != lms.publicKeySize() {
+		return nil, nil
+	}
+	return lms, ots
+}
+
+// signatureSize returns size of LMS signature at the beginning of sig, or
+// 0 if it is malformed.
+func signatureSize(sig []byte) int {
+	if len(sig) < 8 {
+		return 0
+	}
+	ots := getOTSParams(OTSType(binary.BigEndian.Uint32(sig[4:])))
+	if ots == nil || len(sig) < 4+ots.signatureSize()+4 {
+		return 0
+	}
+	lms := getLMSParams(LMSType(binary.BigEndian.Uint32(sig[4+ots.signatureSize():])))
+	if lms == nil || len(sig) < lmsSignatureSize(lms, ots) {
+		return 0
+	}
+	return lmsSignatureSize(lms, ots)
+}
+
+// verify verifies LMS signature sig of msg with LMS public key pub.
+// RFC 8554, Algorithm 6a.
+func verify(xof sha3.ShakeHash, pub, msg, sig []byte) bool {
+	var node [maxN]byte
+	lms, ots := parsePublicKey(pub)
+	if lms == nil || len(sig) != lmsSignatureSize(lms, ots) {
+		return false
+	}
+	n := lms.n
+	off := 4 + ots.signatureSize()
+	q := binary.BigEndian.Uint32(sig)
+	if OTSType(binary.BigEndian.Uint32(sig[4:])) != ots.typ ||
+		LMSType(binary.BigEndian.Uint32(sig[off:])) != lms.typ ||
+		q >= uint32(1)<<uint(lms.h) {
+		return false
+	}
+	id := pub[8 : 8+idSize]
+
+	otsCandidate(xof, node[:n], ots, id, q, sig[4:off], msg)
+	r := uint32(1)<<uint(lms.h) + q
+	hash(xof, node[:n], prefix(id, r, dLeaf), node[:n])
+	path := sig[off+4:]
+	for ; r > 1; r >>= 1 {
+		if r&1 == 1 {
+			hash(xof, node[:n], prefix(id, r>>1, dIntr), path[:n], node[:n])
+		} else {
+			hash(xof, node[:n], prefix(id, r>>1, dIntr), node[:n], path[:n])
+		}
+		path = path[n:]
+	}
+	return bytes.Equal(node[:n], pub[8+idSize:])
+}
@@ -0,0 +1,16 @@
+// Package mldsa implements ML-DSA, the Module-Lattice-Based Digital Signature
+// Algorithm standardized in FIPS 204, with parameter sets ML-DSA-44,
+// ML-DSA-65 and ML-DSA-87.
+//
+// Package provides key generation, hedged and deterministic signing and
+// verification of pure ML-DSA signatures with optional context string, as
+// well as pre-hash variant HashML-DSA with SHA-3 and SHAKE hash functions.
+// All hash functions come from hash/sha3.
+//
+// Operations on secret data are done in constant time, except of rejection
+// sampling, which leaks only number of iterations.
+//
+// References:
+//   - [FIPS204] Module-Lattice-Based Digital Signature Standard,
+//     https://doi.org/10.6028/NIST.FIPS.204
+package mldsa
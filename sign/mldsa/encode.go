@@ -0,0 +1,90 @@
+package mldsa
+
+// pack serializes p into 32*bits bytes of out. Each coefficient c is
+// stored as b - c, using bits bits. With b = 0 it implements SimpleBitPack,
+// otherwise BitPack, where coefficients are in range [b - 2^bits + 1, b].
+// FIPS 204, Algorithms 16 and 17.
+func (p *poly) pack(out []byte, bits uint, b int32) {
+	var acc uint64
+	var n uint
+	var o int
+	for _, c := range p {
+		v := c
+		if b != 0 {
+			v = fieldSub(fromInt(b), c)
+		}
+		acc |= uint64(v) << n
+		for n += bits; n >= 8; n -= 8 {
+			out[o] = byte(acc)
+			acc >>= 8
+			o++
+		}
+	}
+}
+
+// unpack deserializes 32*bits bytes of in into p. It is the inverse of
+// pack. FIPS 204, Algorithms 18 and 19.
+func (p *poly) unpack(in []byte, bits uint, b int32) {
+	var acc uint64
+	var n uint
+	var o int
+	mask := uint64(1)<<bits - 1
+	for i := range p {
+		for ; n < bits; n += 8 {
+			acc |= uint64(in[o]) << n
+			o++
+		}
+		v := int32(acc & mask)
+		acc >>= bits
+		n -= bits
+		if b != 0 {
+			p[i] = fromInt(b - v)
+		} else {
+			p[i] = fieldElement(v)
+		}
+	}
+}
+
+// packHint serializes hint vector h into omega + k bytes of out.
+// FIPS 204, Algorithm 20.
+func packHint(out []byte, h []poly, omega int) {
+	var idx int
+	for i := range out {
+		out[i] = 0
+	}
+	for i := range h {
+		for j, c := range h[i] {
+			if c != 0 {
+				out[idx] = byte(j)
+				idx++
+			}
+		}
+		out[omega+i] = byte(idx)
+	}
+}
+
+// unpackHint deserializes hint vector h from omega + k bytes of in.
+// Returns false if encoding is malformed. FIPS 204, Algorithm 21.
+func unpackHint(h []poly, in []byte, omega int) bool {
+	var idx int
+	for i := range h {
+		h[i] = poly{}
+		limit := int(in[omega+i])
+		if limit < idx || limit > omega {
+			return false
+		}
+		first := idx
+		for ; idx < limit; idx++ {
+			if idx > first && in[idx-1] >= in[idx] {
+				return false
+			}
+			h[i][in[idx]] = 1
+		}
+	}
+	for ; idx < omega; idx++ {
+		if in[idx] != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package mldsa
+
+import "math/bits"
+
+const (
+	// Modulus
+	q = 8380417
+	// Degree of the polynomial ring
+	n = 256
+	// 256^-1 mod q, used at the end of inverse NTT
+	nttScale = 8347681
+
+	// Barrett reduction constant, floor(2^64/q)
+	barrettMul = 2201172575745
+)
+
+// fieldElement is an integer modulo q. It is always kept in the
+// range [0, q).
+type fieldElement uint32
+
+// poly is an element of R_q = Z_q[X]/(X^256+1), either in normal
+// form or in NTT domain.
+type poly [n]fieldElement
+
+// zetas[i] = 1753^BitRev8(i) mod q
+var zetas = [256]fieldElement{
+	1, 4808194, 3765607, 3761513, 5178923, 5496691, 5234739, 5178987,
+	7778734, 3542485, 2682288, 2129892, 3764867, 7375178, 557458, 7159240,
+	5010068, 4317364, 2663378, 6705802, 4855975, 7946292, 676590, 7044481,
+	5152541, 1714295, 2453983, 1460718, 7737789, 4795319, 2815639, 2283733,
+	3602218, 3182878, 2740543, 4793971, 5269599, 2101410, 3704823, 1159875,
+	394148, 928749, 1095468, 4874037, 2071829, 4361428, 3241972, 2156050,
+	3415069, 1759347, 7562881, 4805951, 3756790, 6444618, 6663429, 4430364,
+	5483103, 3192354, 556856, 3870317, 2917338, 1853806, 3345963, 1858416,
+	3073009, 1277625, 5744944, 3852015, 4183372, 5157610, 5258977, 8106357,
+	2508980, 2028118, 1937570, 4564692, 2811291, 5396636, 7270901, 4158088,
+	1528066, 482649, 1148858, 5418153, 7814814, 169688, 2462444, 5046034,
+	4213992, 4892034, 1987814, 5183169, 1736313, 235407, 5130263, 3258457,
+	5801164, 1787943, 5989328, 6125690, 3482206, 4197502, 7080401, 6018354,
+	7062739, 2461387, 3035980, 621164, 3901472, 7153756, 2925816, 3374250,
+	1356448, 5604662, 2683270, 5601629, 4912752, 2312838, 7727142, 7921254,
+	348812, 8052569, 1011223, 6026202, 4561790, 6458164, 6143691, 1744507,
+	1753, 6444997, 5720892, 6924527, 2660408, 6600190, 8321269, 2772600,
+	1182243, 87208, 636927, 4415111, 4423672, 6084020, 5095502, 4663471,
+	8352605, 822541, 1009365, 5926272, 6400920, 1596822, 4423473, 4620952,
+	6695264, 4969849, 2678278, 4611469, 4829411, 635956, 8129971, 5925040,
+	4234153, 6607829, 2192938, 6653329, 2387513, 4768667, 8111961, 5199961,
+	3747250, 2296099, 1239911, 4541938, 3195676, 2642980, 1254190, 8368000,
+	2998219, 141835, 8291116, 2513018, 7025525, 613238, 7070156, 6161950,
+	7921677, 6458423, 4040196, 4908348, 2039144, 6500539, 7561656, 6201452,
+	6757063, 2105286, 6006015, 6346610, 586241, 7200804, 527981, 5637006,
+	6903432, 1994046, 2491325, 6987258, 507927, 7192532, 7655613, 6545891,
+	5346675, 8041997, 2647994, 3009748, 5767564, 4148469, 749577, 4357667,
+	3980599, 2569011, 6764887, 1723229, 1665318, 2028038, 1163598, 5011144,
+	3994671, 8368538, 7009900, 3020393, 3363542, 214880, 545376, 7609976,
+	3105558, 7277073, 508145, 7826699, 860144, 3430436, 140244, 6866265,
+	6195333, 3123762, 2358373, 6187330, 5365997, 6663603, 2926054, 7987710,
+	8077412, 3531229, 4405932, 4606686, 1900052, 7598542, 1054478, 7648983,
+}
+
+// reduceOnce maps a < 2q to [0, q). Constant time.
+func reduceOnce(a uint32) fieldElement {
+	x := a - q
+	x += (x >> 31) * q
+	return fieldElement(x)
+}
+
+// reduce maps a < 2^64 to [0, q) with Barrett reduction. Constant time.
+func reduce(a uint64) fieldElement {
+	t, _ := bits.Mul64(a, barrettMul)
+	return reduceOnce(uint32(a - t*q))
+}
+
+func fieldAdd(a, b fieldElement) fieldElement {
+	return reduceOnce(uint32(a + b))
+}
+
+func fieldSub(a, b fieldElement) fieldElement {
+	return reduceOnce(uint32(a - b + q))
+}
+
+func fieldMul(a, b fieldElement) fieldElement {
+	return reduce(uint64(a) * uint64(b))
+}
+
+// fromInt maps -q < a < q to Z_q.
+func fromInt(a int32) fieldElement {
+	return reduceOnce(uint32(a + q))
+}
+
+// centered returns representative of a in range [-(q-1)/2, (q-1)/2].
+// Constant time.
+func centered(a fieldElement) int32 {
+	x := int32(a)
+	return x - int32(q)&(((q-1)/2-x)>>31)
+}
+
+// abs returns absolute value of centered representative of a.
+// Constant time.
+func abs(a fieldElement) int32 {
+	x := centered(a)
+	m := x >> 31
+	return (x ^ m) - m
+}
+
+// add sets p = a + b.
+func (p *poly) add(a, b *poly) {
+	for i := range p {
+		p[i] = fieldAdd(a[i], b[i])
+	}
+}
+
+// sub sets p = a - b.
+func (p *poly) sub(a, b *poly) {
+	for i := range p {
+		p[i] = fieldSub(a[i], b[i])
+	}
+}
+
+// mul sets p = a * b. Polynomials a and b must be in NTT domain.
+func (p *poly) mul(a, b *poly) {
+	for i := range p {
+		p[i] = fieldMul(a[i], b[i])
+	}
+}
+
+// mulAcc adds to p the product of a and b. Polynomials must be in NTT
+// domain.
+func (p *poly) mulAcc(a, b *poly) {
+	for i := range p {
+		p[i] = fieldAdd(p[i], fieldMul(a[i], b[i]))
+	}
+}
+
+// norm returns true if infinity norm of p is smaller than bound.
+// Constant time, as long as bound is public.
+func (p *poly) norm(bound int32) bool {
+	var r int32
+	for _, c := range p {
+		r |= bound - 1 - abs(c)
+	}
+	return r >= 0
+}
+
+// ntt converts p to NTT domain. FIPS 204, Algorithm 41.
+func (p *poly) ntt() {
+	k := 0
+	for l := 128; l >= 1; l >>= 1 {
+		for s := 0; s < n; s += 2 * l {
+			k++
+			z := zetas[k]
+			for j := s; j < s+l; j++ {
+				t := fieldMul(z, p[j+l])
+				p[j+l] = fieldSub(p[j], t)
+				p[j] = fieldAdd(p[j], t)
+			}
+		}
+	}
+}
+
+// invNTT converts p from NTT domain to normal form. FIPS 204, Algorithm 42.
+func (p *poly) invNTT() {
+	k := 256
+	for l := 1; l < n; l <<= 1 {
+		for s := 0; s < n; s += 2 * l {
+			k--
+			z := q - zetas[k]
+			for j := s; j < s+l; j++ {
+				t := p[j]
+				p[j] = fieldAdd(t, p[j+l])
+				p[j+l] = fieldMul(z, fieldSub(t, p[j+l]))
+			}
+		}
+	}
+	for i := range p {
+		p[i] = fieldMul(p[i], nttScale)
+	}
+}
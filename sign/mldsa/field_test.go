@@ -0,0 +1,87 @@
+package mldsa
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randPoly(r *rand.Rand) (p poly) {
+	for i := range p {
+		p[i] = fieldElement(r.Intn(q))
+	}
+	return p
+}
+
+// Multiplication in NTT domain must match schoolbook multiplication
+// in Z_q[X]/(X^256+1).
+func TestNTTMul(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for it := 0; it < 10; it++ {
+		a, b := randPoly(r), randPoly(r)
+		var exp [n]int64
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				v := int64(a[i]) * int64(b[j]) % q
+				if i+j < n {
+					exp[i+j] += v
+				} else {
+					exp[i+j-n] -= v
+				}
+			}
+		}
+
+		var c poly
+		aa, bb := a, b
+		aa.ntt()
+		bb.ntt()
+		c.mul(&aa, &bb)
+		c.invNTT()
+		for i := range c {
+			if e := (exp[i]%q + q) % q; int64(c[i]) != e {
+				t.Fatalf("coefficient %d: got %d, want %d", i, c[i], e)
+			}
+		}
+
+		aa.invNTT()
+		if aa != a {
+			t.Fatal("inverse NTT failed")
+		}
+	}
+}
+
+func TestReduce(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, x := range []uint64{0, q - 1, q, 2*q - 1, (q - 1) * (q - 1), 1<<64 - 1} {
+		if got := reduce(x); uint64(got) != x%q {
+			t.Fatalf("reduce(%d): got %d, want %d", x, got, x%q)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		x := r.Uint64()
+		if got := reduce(x); uint64(got) != x%q {
+			t.Fatalf("reduce(%d): got %d, want %d", x, got, x%q)
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	var buf [32 * 20]byte
+	for _, v := range []struct {
+		bits uint
+		b    int32
+	}{{3, 2}, {4, 4}, {4, 0}, {6, 0}, {10, 0}, {13, 1 << 12}, {18, 1 << 17}, {20, 1 << 19}} {
+		var a, b poly
+		for i := range a {
+			a[i] = fieldElement(r.Intn(1 << v.bits))
+			if v.b != 0 {
+				a[i] = fromInt(v.b - int32(a[i]))
+			}
+		}
+		a.pack(buf[:], v.bits, v.b)
+		b.unpack(buf[:], v.bits, v.b)
+		if a != b {
+			t.Fatalf("unpack(pack(x)) != x for %d bits", v.bits)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package mldsa
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+var (
+	errInvalidPublicKey  = errors.New("mldsa: invalid public key")
+	errInvalidPrivateKey = errors.New("mldsa: invalid private key")
+	errContextTooLong    = errors.New("mldsa: context too long")
+)
+
+// PublicKey represents ML-DSA public key.
+type PublicKey struct {
+	params *params
+	// Seed of the matrix A
+	rho [seedSize]byte
+	// Vector t1, in normal form
+	t1 [maxK]poly
+	// Vector t1*2^d, in NTT domain
+	t1Hat [maxK]poly
+	// H(pk), hash of the encoded key
+	tr [muSize]byte
+	// Matrix A expanded from rho, in NTT domain, row-major order
+	a [maxK * maxL]poly
+}
+
+// PrivateKey represents ML-DSA private key. It contains corresponding
+// public key.
+type PrivateKey struct {
+	PublicKey
+	// Seed used for derivation of the mask
+	key [seedSize]byte
+	// Secret vectors s1, s2 and vector t0, in NTT domain
+	s1Hat [maxL]poly
+	s2Hat [maxK]poly
+	t0Hat [maxK]poly
+}
+
+// NewPublicKey initializes public key for a given parameter set.
+func NewPublicKey(id uint8) *PublicKey {
+	return &PublicKey{params: getParams(id)}
+}
+
+// Import imports public key stored in the byte string. Returns error in
+// case size of the input is wrong.
+func (pub *PublicKey) Import(input []byte) error {
+	if len(input) != pub.Size() {
+		return errInvalidPublicKey
+	}
+	copy(pub.rho[:], input)
+	for i := 0; i < pub.params.k; i++ {
+		pub.t1[i].unpack(input[seedSize+polyT1Size*i:], 10, 0)
+	}
+	pub.expandA()
+	pub.expand(input)
+	return nil
+}
+
+// Export writes public key to out, which must be at least Size() bytes long.
+func (pub *PublicKey) Export(out []byte) {
+	copy(out, pub.rho[:])
+	for i := 0; i < pub.params.k; i++ {
+		pub.t1[i].pack(out[seedSize+polyT1Size*i:], 10, 0)
+	}
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return pub.params.publicKeySize()
+}
+
+// expandA samples matrix A from the seed rho. FIPS 204, Algorithm 32.
+func (pub *PublicKey) expandA() {
+	p := pub.params
+	xof := sha3.NewShake128()
+	for i := 0; i < p.k; i++ {
+		for j := 0; j < p.l; j++ {
+			rejNTTPoly(&pub.a[i*p.l+j], xof, pub.rho[:], byte(j), byte(i))
+		}
+	}
+}
+
+// expand computes t1*2^d and tr from t1 and the encoded public key.
+func (pub *PublicKey) expand(encoded []byte) {
+	for i := 0; i < pub.params.k; i++ {
+		for j := range pub.t1[i] {
+			pub.t1Hat[i][j] = pub.t1[i][j] << d
+		}
+		pub.t1Hat[i].ntt()
+	}
+	h := sha3.NewShake256()
+	_, _ = h.Write(encoded)
+	_, _ = h.Read(pub.tr[:])
+}
+
+// NewPrivateKey initializes private key for a given parameter set.
+func NewPrivateKey(id uint8) *PrivateKey {
+	return &PrivateKey{PublicKey: PublicKey{params: getParams(id)}}
+}
+
+// Import imports private key stored in the byte string. Public key is
+// recomputed from the private key. Returns error in case size of the input
+// is wrong or the key is inconsistent.
+func (prv *PrivateKey) Import(input []byte) error {
+	var s1 [maxL]poly
+	var s2, t0 [maxK]poly
+	p := prv.params
+	if len(input) != prv.Size() {
+		return errInvalidPrivateKey
+	}
+
+	copy(prv.rho[:], input)
+	copy(prv.key[:], input[seedSize:])
+	tr := input[2*seedSize : 2*seedSize+muSize]
+	off := 2*seedSize + muSize
+	sSize := 32 * int(p.etaBits())
+	for i := 0; i < p.l; i++ {
+		s1[i].unpack(input[off:], p.etaBits(), int32(p.eta))
+		off += sSize
+	}
+	for i := 0; i < p.k; i++ {
+		s2[i].unpack(input[off:], p.etaBits(), int32(p.eta))
+		off += sSize
+	}
+	for i := 0; i < p.k; i++ {
+		t0[i].unpack(input[off:], d, 1<<(d-1))
+		off += polyT0Size
+	}
+	for i := 0; i < p.l; i++ {
+		if !s1[i].norm(int32(p.eta) + 1) {
+			return errInvalidPrivateKey
+		}
+	}
+	for i := 0; i < p.k; i++ {
+		if !s2[i].norm(int32(p.eta) + 1) {
+			return errInvalidPrivateKey
+		}
+	}
+
+	// Recomputed t0 and tr must match the encoded ones
+	var t poly
+	var diff fieldElement
+	prv.derive(&s1, &s2, &t)
+	for i := 0; i < p.k; i++ {
+		for j := range t0[i] {
+			diff |= prv.t0Hat[i][j] ^ t0[i][j]
+		}
+	}
+	if subtle.ConstantTimeEq(int32(diff), 0)&subtle.ConstantTimeCompare(prv.tr[:], tr) != 1 {
+		return errInvalidPrivateKey
+	}
+	for i := 0; i < p.k; i++ {
+		prv.t0Hat[i].ntt()
+	}
+	return nil
+}
+
+// Export writes private key to out, which must be at least Size() bytes long.
+func (prv *PrivateKey) Export(out []byte) {
+	var t poly
+	p := prv.params
+	copy(out, prv.rho[:])
+	copy(out[seedSize:], prv.key[:])
+	copy(out[2*seedSize:], prv.tr[:])
+	off := 2*seedSize + muSize
+	sSize := 32 * int(p.etaBits())
+	for i := 0; i < p.l; i++ {
+		t = prv.s1Hat[i]
+		t.invNTT()
+		t.pack(out[off:], p.etaBits(), int32(p.eta))
+		off += sSize
+	}
+	for i := 0; i < p.k; i++ {
+		t = prv.s2Hat[i]
+		t.invNTT()
+		t.pack(out[off:], p.etaBits(), int32(p.eta))
+		off += sSize
+	}
+	for i := 0; i < p.k; i++ {
+		t = prv.t0Hat[i]
+		t.invNTT()
+		t.pack(out[off:], d, 1<<(d-1))
+		off += polyT0Size
+	}
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return prv.params.privateKeySize()
+}
+
+// Generate generates a random key pair. It reads SeedSize bytes from rng.
+// Returns error in case rng fails.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	var seed [SeedSize]byte
+	if _, err := io.ReadFull(rng, seed[:]); err != nil {
+		return err
+	}
+	prv.generate(seed[:])
+	return nil
+}
+
+// GeneratePublicKey copies public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	*pub = prv.PublicKey
+}
+
+// generate derives key pair from the seed. FIPS 204, Algorithm 6.
+func (prv *PrivateKey) generate(seed []byte) {
+	var buf [2*seedSize + 2*seedSize]byte
+	var s1 [maxL]poly
+	var s2 [maxK]poly
+	var t0 poly
+	p := prv.params
+
+	// (rho, rho', K) = H(seed || k || l)
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed)
+	_, _ = h.Write([]byte{byte(p.k), byte(p.l)})
+	_, _ = h.Read(buf[:])
+	copy(prv.rho[:], buf[:seedSize])
+	rhoPrime := buf[seedSize : 3*seedSize]
+	copy(prv.key[:], buf[3*seedSize:])
+
+	for i := 0; i < p.l; i++ {
+		rejBoundedPoly(&s1[i], h, rhoPrime, uint16(i), p.eta)
+	}
+	for i := 0; i < p.k; i++ {
+		rejBoundedPoly(&s2[i], h, rhoPrime, uint16(p.l+i), p.eta)
+	}
+	prv.derive(&s1, &s2, &t0)
+	for i := 0; i < p.k; i++ {
+		prv.t0Hat[i].ntt()
+	}
+}
+
+// derive computes public key from rho and secret vectors s1 and s2 given
+// in normal form. Vector t0 is stored in normal form in prv.t0Hat. The t
+// is used as temporary storage.
+func (prv *PrivateKey) derive(s1 *[maxL]poly, s2 *[maxK]poly, t *poly) {
+	var encoded [seedSize + polyT1Size*maxK]byte
+	p := prv.params
+
+	prv.expandA()
+	for i := 0; i < p.l; i++ {
+		prv.s1Hat[i] = s1[i]
+		prv.s1Hat[i].ntt()
+	}
+	for i := 0; i < p.k; i++ {
+		prv.s2Hat[i] = s2[i]
+		prv.s2Hat[i].ntt()
+	}
+
+	// t = NTT^-1(A*NTT(s1)) + s2, (t1, t0) = Power2Round(t)
+	for i := 0; i < p.k; i++ {
+		*t = poly{}
+		for j := 0; j < p.l; j++ {
+			t.mulAcc(&prv.a[i*p.l+j], &prv.s1Hat[j])
+		}
+		t.invNTT()
+		t.add(t, &s2[i])
+		for j := range t {
+			prv.t1[i][j], prv.t0Hat[i][j] = power2Round(t[j])
+		}
+	}
+	prv.PublicKey.Export(encoded[:])
+	prv.PublicKey.expand(encoded[:prv.PublicKey.Size()])
+}
@@ -0,0 +1,379 @@
+package mldsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+var allIDs = []uint8{MLDSA44, MLDSA65, MLDSA87}
+
+func idFromName(t *testing.T, name string) uint8 {
+	for _, id := range allIDs {
+		if getParams(id).name == name {
+			return id
+		}
+	}
+	t.Fatalf("unknown parameter set %s", name)
+	return 0
+}
+
+// Key pair generated from the seed 00 01 02 ... 1F
+func testKey(t testing.TB, id uint8) *PrivateKey {
+	sk := NewPrivateKey(id)
+	if err := sk.Generate(bytes.NewReader(test.Seed(SeedSize))); err != nil {
+		t.Fatal(err)
+	}
+	return sk
+}
+
+// ACVP vectors from https://github.com/usnistgov/ACVP-Server. Signature
+// generation and verification test ML-DSA.Sign_internal and
+// ML-DSA.Verify_internal.
+func TestACVPKeyGen(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			ParameterSet string `json:"parameterSet"`
+			Tests        []struct {
+				TcID int           `json:"tcId"`
+				Seed test.HexBytes `json:"seed"`
+				Pk   test.HexBytes `json:"pk"`
+				Sk   test.HexBytes `json:"sk"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "ML-DSA-keyGen-FIPS204/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "ML-DSA-keyGen-FIPS204/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			sk := NewPrivateKey(id)
+			pk := NewPublicKey(id)
+			if err := sk.Generate(bytes.NewReader(tc.Seed)); err != nil {
+				t.Fatal(err)
+			}
+			sk.GeneratePublicKey(pk)
+
+			pkb := make([]byte, pk.Size())
+			skb := make([]byte, sk.Size())
+			pk.Export(pkb)
+			sk.Export(skb)
+			if !bytes.Equal(pkb, exp.Pk) {
+				t.Errorf("%s tcId %d: wrong public key", g.ParameterSet, tc.TcID)
+			}
+			if !bytes.Equal(skb, exp.Sk) {
+				t.Errorf("%s tcId %d: wrong private key", g.ParameterSet, tc.TcID)
+			}
+
+			// Import must recompute the same keys
+			sk2 := NewPrivateKey(id)
+			pk2 := NewPublicKey(id)
+			if err := sk2.Import(exp.Sk); err != nil {
+				t.Fatal(err)
+			}
+			if err := pk2.Import(exp.Pk); err != nil {
+				t.Fatal(err)
+			}
+			if *sk2 != *sk || *pk2 != *pk {
+				t.Errorf("%s tcId %d: import failed", g.ParameterSet, tc.TcID)
+			}
+		}
+	}
+}
+
+func TestACVPSigGen(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			ParameterSet  string `json:"parameterSet"`
+			Deterministic bool   `json:"deterministic"`
+			Tests         []struct {
+				TcID      int           `json:"tcId"`
+				Sk        test.HexBytes `json:"sk"`
+				Message   test.HexBytes `json:"message"`
+				Rnd       test.HexBytes `json:"rnd"`
+				Signature test.HexBytes `json:"signature"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "ML-DSA-sigGen-FIPS204/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "ML-DSA-sigGen-FIPS204/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			sk := NewPrivateKey(id)
+			if err := sk.Import(tc.Sk); err != nil {
+				t.Fatal(err)
+			}
+			rnd := make([]byte, seedSize)
+			if !g.Deterministic {
+				copy(rnd, tc.Rnd)
+			}
+			sig := make([]byte, sk.SignatureSize())
+			sk.sign(sig, rnd, tc.Message)
+			if !bytes.Equal(sig, exp.Signature) {
+				t.Errorf("%s tcId %d: wrong signature", g.ParameterSet, tc.TcID)
+			}
+		}
+	}
+}
+
+func TestACVPSigVer(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			ParameterSet string        `json:"parameterSet"`
+			Pk           test.HexBytes `json:"pk"`
+			Tests        []struct {
+				TcID       int           `json:"tcId"`
+				Message    test.HexBytes `json:"message"`
+				Signature  test.HexBytes `json:"signature"`
+				TestPassed bool          `json:"testPassed"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "ML-DSA-sigVer-FIPS204/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "ML-DSA-sigVer-FIPS204/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		pk := NewPublicKey(id)
+		if err := pk.Import(g.Pk); err != nil {
+			t.Fatal(err)
+		}
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			if pk.verify(tc.Signature, tc.Message) != exp.TestPassed {
+				t.Errorf("%s tcId %d: expected %v", g.ParameterSet, tc.TcID, exp.TestPassed)
+			}
+		}
+	}
+}
+
+// Deterministic signatures of "message" with the key from testKey,
+// SHA3-256 of public key and signatures with empty context and with
+// context "context". Generated with Go crypto/mldsa.
+var signTests = []struct {
+	id              uint8
+	pk, sig, sigCtx string
+}{
+	{
+		MLDSA44,
+		"373c7bf2cac5bd2a6c35933bab0fa1c951f22247e1333383fcb618822080373f",
+		"c0c8c7b976682de6bc54432b5cc5c4fe846512efbc760a98f75e36977381649e",
+		"a7c229461eef9235d30979c12b31a7793771955bc1d9599140bc06bc07a9c85f",
+	},
+	{
+		MLDSA65,
+		"1800725067e388d837d911fe4f66101cc1961b1bb755030dc574272cfb00013f",
+		"2eece2b319b2de469a9b95e06b3ba3872af5c23feadc382225765530199dc808",
+		"6d110387073718ebe3abfa4830bcd277d130a38e9a331aea1fe6964799e66cff",
+	},
+	{
+		MLDSA87,
+		"e6cf50a9c2fa5234f59949ff61f8161db4d629532127f4aefa8bb10811ecfb1e",
+		"17a0ecbba4b141564f4aff65d39859864f8d0aa236001c83965593fe1c5feecd",
+		"8bc8906da470106d6a61eca92b359cab2e805975ffbb85e4f468ef0dce891453",
+	},
+}
+
+func TestSign(t *testing.T) {
+	msg := []byte("message")
+	for _, tt := range signTests {
+		sk := testKey(t, tt.id)
+		pk := NewPublicKey(tt.id)
+		sk.GeneratePublicKey(pk)
+		pkb := make([]byte, pk.Size())
+		pk.Export(pkb)
+		if !bytes.Equal(test.SHA3Sum(pkb), test.FromHex(tt.pk)) {
+			t.Errorf("%s: wrong public key", getParams(tt.id).name)
+		}
+
+		for _, v := range []struct{ ctx, exp string }{{"", tt.sig}, {"context", tt.sigCtx}} {
+			sig, err := Sign(nil, sk, []byte(v.ctx), msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.SHA3Sum(sig), test.FromHex(v.exp)) {
+				t.Errorf("%s: wrong signature with context %q", getParams(tt.id).name, v.ctx)
+			}
+			if !Verify(pk, []byte(v.ctx), msg, sig) {
+				t.Errorf("%s: valid signature rejected", getParams(tt.id).name)
+			}
+			if Verify(pk, []byte("other"), msg, sig) {
+				t.Errorf("%s: signature accepted with wrong context", getParams(tt.id).name)
+			}
+		}
+	}
+}
+
+// Deterministic HashML-DSA signatures of "message" with context "context"
+// and the key from testKey, SHA3-256 of signatures. Generated with Go
+// crypto/mldsa, by signing the external message representative mu.
+var signPreHashTests = []struct {
+	id  uint8
+	ph  PreHash
+	sig string
+}{
+	{MLDSA44, SHA3_256, "f5b1d79299e0b08497909306fb03d638a2b9919e138136766cd37b85747c95d5"},
+	{MLDSA44, SHAKE256, "37f1400d0f9ffa75bf76a243aac0d1b573ae18c8c913defd5798fd59d2cc224b"},
+	{MLDSA65, SHA3_256, "c06c3584f395f8a7997f27115d495ed424865ead253a643a2a44bc2e0b950be7"},
+	{MLDSA65, SHAKE256, "0ec974e13988b7cc63952bc20c7a51505b6aa383fbf4bc4c4b4e6625e0ef33bd"},
+	{MLDSA87, SHA3_256, "2f55ff894c3f8616f5ac22b02f4cd1d194f6a4b7dd8615c5a95ebdef9ce57cad"},
+	{MLDSA87, SHAKE256, "9db177bdc206b52c4c35aeed23587c23566fc5202be2309b4d220f48b5708738"},
+}
+
+func TestSignPreHash(t *testing.T) {
+	msg, ctx := []byte("message"), []byte("context")
+	for _, tt := range signPreHashTests {
+		sk := testKey(t, tt.id)
+		sig, err := SignPreHash(nil, sk, tt.ph, ctx, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(test.SHA3Sum(sig), test.FromHex(tt.sig)) {
+			t.Errorf("%s: wrong signature with pre-hash %d", getParams(tt.id).name, tt.ph)
+		}
+		if !VerifyPreHash(&sk.PublicKey, tt.ph, ctx, msg, sig) {
+			t.Errorf("%s: valid signature rejected", getParams(tt.id).name)
+		}
+		if Verify(&sk.PublicKey, ctx, msg, sig) {
+			t.Errorf("%s: pre-hash signature accepted as pure one", getParams(tt.id).name)
+		}
+	}
+
+	sk := testKey(t, MLDSA44)
+	for ph := SHA3_224; ph <= SHAKE256; ph++ {
+		sig, err := SignPreHash(rand.Reader, sk, ph, nil, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyPreHash(&sk.PublicKey, ph, nil, msg, sig) {
+			t.Errorf("pre-hash %d: valid signature rejected", ph)
+		}
+	}
+	if _, err := SignPreHash(rand.Reader, sk, 0, nil, msg); err == nil {
+		t.Error("unknown pre-hash function accepted")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	msg := []byte("message")
+	for _, id := range allIDs {
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		for i := 0; i < 5; i++ {
+			if err := sk.Generate(rand.Reader); err != nil {
+				t.Fatal(err)
+			}
+			sk.GeneratePublicKey(pk)
+			sig, err := Sign(rand.Reader, sk, nil, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Verify(pk, nil, msg, sig) || !Verify(pk, []byte{}, msg, sig) {
+				t.Fatalf("%s: valid signature rejected", getParams(id).name)
+			}
+			for _, j := range []int{0, len(sig) / 2, len(sig) - 1} {
+				sig[j] ^= 1
+				if Verify(pk, nil, msg, sig) {
+					t.Fatalf("%s: modified signature accepted", getParams(id).name)
+				}
+				sig[j] ^= 1
+			}
+			if Verify(pk, nil, msg[1:], sig) || Verify(pk, nil, msg, sig[1:]) {
+				t.Fatalf("%s: wrong signature accepted", getParams(id).name)
+			}
+		}
+	}
+
+	sk := testKey(t, MLDSA44)
+	if _, err := Sign(rand.Reader, sk, make([]byte, MaxContextSize+1), msg); err == nil {
+		t.Error("too long context accepted")
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, id := range allIDs {
+		sk := testKey(t, id)
+		skb := make([]byte, sk.Size())
+		sk.Export(skb)
+		if NewPrivateKey(id).Import(skb[1:]) == nil {
+			t.Error("private key of wrong size accepted")
+		}
+		if NewPublicKey(id).Import(skb[:sk.PublicKey.Size()-1]) == nil {
+			t.Error("public key of wrong size accepted")
+		}
+		// Coefficient of s1 out of range, tr and t0 modified
+		for _, off := range []int{2*seedSize + muSize, 2 * seedSize, len(skb) - 1} {
+			skb[off] ^= 0xFF
+			if NewPrivateKey(id).Import(skb) == nil {
+				t.Errorf("%s: modified private key accepted", getParams(id).name)
+			}
+			skb[off] ^= 0xFF
+		}
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	for _, gamma2 := range []int32{(q - 1) / 88, (q - 1) / 32} {
+		for x := int32(0); x < q; x += 7 {
+			a1, a0 := decompose(fieldElement(x), gamma2)
+			// FIPS 204, Algorithm 36
+			r0 := x % (2 * gamma2)
+			if r0 > gamma2 {
+				r0 -= 2 * gamma2
+			}
+			r1 := (x - r0) / (2 * gamma2)
+			if x-r0 == q-1 {
+				r1, r0 = 0, r0-1
+			}
+			if a1 != r1 || a0 != r0 {
+				t.Fatalf("Decompose(%d): got (%d, %d), want (%d, %d)", x, a1, a0, r1, r0)
+			}
+		}
+	}
+}
+
+func benchParams(b *testing.B, f func(b *testing.B, id uint8)) {
+	for _, id := range allIDs {
+		id := id
+		b.Run(getParams(id).name, func(b *testing.B) { f(b, id) })
+	}
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	benchParams(b, func(b *testing.B, id uint8) {
+		sk := NewPrivateKey(id)
+		for n := 0; n < b.N; n++ {
+			_ = sk.Generate(rand.Reader)
+		}
+	})
+}
+
+func BenchmarkSign(b *testing.B) {
+	msg := []byte("message")
+	benchParams(b, func(b *testing.B, id uint8) {
+		sk := testKey(b, id)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			_, _ = Sign(rand.Reader, sk, nil, msg)
+		}
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	msg := []byte("message")
+	benchParams(b, func(b *testing.B, id uint8) {
+		sk := testKey(b, id)
+		sig, _ := Sign(rand.Reader, sk, nil, msg)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			_ = Verify(&sk.PublicKey, nil, msg, sig)
+		}
+	})
+}
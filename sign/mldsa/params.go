@@ -0,0 +1,109 @@
+package mldsa
+
+// Identifiers of ML-DSA parameter sets
+const (
+	MLDSA44 uint8 = iota
+	MLDSA65
+	MLDSA87
+)
+
+const (
+	// Size of the seed used by key generation, in bytes
+	SeedSize = 32
+	// Maximal length of the context string, in bytes
+	MaxContextSize = 255
+
+	// Maximal dimensions of the matrix A
+	maxK = 8
+	maxL = 7
+	// Number of dropped bits of t
+	d = 13
+	// Size of the seeds rho, K and of the randomness rnd
+	seedSize = 32
+	// Size of the message representative mu and of tr
+	muSize = 64
+	// Size of polynomial t1 encoded with 10 bits per coefficient
+	polyT1Size = 320
+	// Size of polynomial t0 encoded with 13 bits per coefficient
+	polyT0Size = 416
+)
+
+// params describes an ML-DSA parameter set, FIPS 204, Section 4.
+type params struct {
+	id   uint8
+	name string
+	// Dimensions of the matrix A
+	k, l int
+	// Range of coefficients of secret vectors
+	eta int
+	// Number of +/-1 coefficients in the challenge
+	tau int
+	// beta = tau * eta
+	beta int32
+	// Range of coefficients of the mask y, a power of 2
+	gamma1 int32
+	// Low-order rounding range
+	gamma2 int32
+	// Maximal number of 1s in the hint
+	omega int
+	// Size of the commitment hash c~ in bytes
+	cTildeSize int
+}
+
+var mldsaParams = [...]params{
+	MLDSA44: {
+		id: MLDSA44, name: "ML-DSA-44", k: 4, l: 4, eta: 2, tau: 39, beta: 78,
+		gamma1: 1 << 17, gamma2: (q - 1) / 88, omega: 80, cTildeSize: 32,
+	},
+	MLDSA65: {
+		id: MLDSA65, name: "ML-DSA-65", k: 6, l: 5, eta: 4, tau: 49, beta: 196,
+		gamma1: 1 << 19, gamma2: (q - 1) / 32, omega: 55, cTildeSize: 48,
+	},
+	MLDSA87: {
+		id: MLDSA87, name: "ML-DSA-87", k: 8, l: 7, eta: 2, tau: 60, beta: 120,
+		gamma1: 1 << 19, gamma2: (q - 1) / 32, omega: 75, cTildeSize: 64,
+	},
+}
+
+func getParams(id uint8) *params {
+	if int(id) >= len(mldsaParams) {
+		panic("mldsa: parameter set ID unregistered")
+	}
+	return &mldsaParams[id]
+}
+
+// Number of bits used to encode coefficients of s1 and s2
+func (p *params) etaBits() uint {
+	if p.eta == 2 {
+		return 3
+	}
+	return 4
+}
+
+// Number of bits used to encode coefficients of z
+func (p *params) gamma1Bits() uint {
+	if p.gamma1 == 1<<17 {
+		return 18
+	}
+	return 20
+}
+
+// Number of bits used to encode coefficients of w1
+func (p *params) w1Bits() uint {
+	if p.gamma2 == (q-1)/88 {
+		return 6
+	}
+	return 4
+}
+
+func (p *params) publicKeySize() int {
+	return seedSize + polyT1Size*p.k
+}
+
+func (p *params) privateKeySize() int {
+	return 2*seedSize + muSize + 32*int(p.etaBits())*(p.k+p.l) + polyT0Size*p.k
+}
+
+func (p *params) signatureSize() int {
+	return p.cTildeSize + 32*int(p.gamma1Bits())*p.l + p.omega + p.k
+}
@@ -0,0 +1,55 @@
+package mldsa
+
+// power2Round splits a into a1*2^d + a0, with a0 in (-2^(d-1), 2^(d-1)].
+// FIPS 204, Algorithm 35. Constant time.
+func power2Round(a fieldElement) (a1, a0 fieldElement) {
+	x := int32(a)
+	h := (x + 1<<(d-1) - 1) >> d
+	return fieldElement(h), fromInt(x - h<<d)
+}
+
+// decompose splits a into a1*2*gamma2 + a0, with a0 in (-gamma2, gamma2],
+// except of the corner case a - a0 = q - 1, where a1 = 0 and a0 is
+// decremented. FIPS 204, Algorithm 36. Constant time.
+func decompose(a fieldElement, gamma2 int32) (a1, a0 int32) {
+	x := int32(a)
+	a1 = (x + 127) >> 7
+	if gamma2 == (q-1)/32 {
+		a1 = (a1*1025 + 1<<21) >> 22
+		a1 &= 15
+	} else {
+		a1 = (a1*11275 + 1<<23) >> 24
+		a1 ^= ((43 - a1) >> 31) & a1
+	}
+	a0 = x - a1*2*gamma2
+	a0 -= (((q-1)/2 - a0) >> 31) & q
+	return a1, a0
+}
+
+// highBits returns a1 from decompose(a). FIPS 204, Algorithm 37.
+func highBits(a fieldElement, gamma2 int32) fieldElement {
+	a1, _ := decompose(a, gamma2)
+	return fieldElement(a1)
+}
+
+// makeHint returns 1 if adding z to r changes its high bits.
+// FIPS 204, Algorithm 39. Constant time.
+func makeHint(z, r fieldElement, gamma2 int32) fieldElement {
+	r1 := highBits(r, gamma2)
+	v1 := highBits(fieldAdd(r, z), gamma2)
+	return fieldElement(uint32(r1^v1|-(r1^v1)) >> 31)
+}
+
+// useHint returns high bits of r adjusted according to hint h.
+// FIPS 204, Algorithm 40.
+func useHint(h, r fieldElement, gamma2 int32) fieldElement {
+	m := (q - 1) / (2 * gamma2)
+	r1, r0 := decompose(r, gamma2)
+	if h == 0 {
+		return fieldElement(r1)
+	}
+	if r0 > 0 {
+		return fieldElement((r1 + 1) % m)
+	}
+	return fieldElement((r1 - 1 + m) % m)
+}
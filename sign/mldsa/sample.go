@@ -0,0 +1,103 @@
+package mldsa
+
+import "github.com/henrydcase/nobs/hash/sha3"
+
+const (
+	// Rates of SHAKE128 and SHAKE256 in bytes
+	shake128Rate = 168
+	shake256Rate = 136
+)
+
+// rejNTTPoly samples uniformly a polynomial in NTT domain with SHAKE128
+// seeded with rho || x || y. FIPS 204, Algorithm 30. Operates on public
+// data only, hence it is not constant time.
+func rejNTTPoly(p *poly, xof sha3.ShakeHash, rho []byte, x, y byte) {
+	var buf [shake128Rate]byte
+	xof.Reset()
+	_, _ = xof.Write(rho)
+	_, _ = xof.Write([]byte{x, y})
+	for i := 0; i < n; {
+		_, _ = xof.Read(buf[:])
+		for j := 0; j < len(buf) && i < n; j += 3 {
+			c := uint32(buf[j]) | uint32(buf[j+1])<<8 | uint32(buf[j+2]&0x7F)<<16
+			if c < q {
+				p[i] = fieldElement(c)
+				i++
+			}
+		}
+	}
+}
+
+// rejBoundedPoly samples a polynomial with coefficients in [-eta, eta]
+// with SHAKE256 seeded with rho || nonce. FIPS 204, Algorithm 31.
+func rejBoundedPoly(p *poly, xof sha3.ShakeHash, rho []byte, nonce uint16, eta int) {
+	var buf [shake256Rate]byte
+	xof.Reset()
+	_, _ = xof.Write(rho)
+	_, _ = xof.Write([]byte{byte(nonce), byte(nonce >> 8)})
+	for i := 0; i < n; {
+		_, _ = xof.Read(buf[:])
+		for j := 0; j < len(buf) && i < n; j++ {
+			for _, z := range [2]int32{int32(buf[j] & 0x0F), int32(buf[j] >> 4)} {
+				if i == n {
+					break
+				}
+				if eta == 2 && z < 15 {
+					p[i] = fromInt(2 - z%5)
+					i++
+				} else if eta == 4 && z < 9 {
+					p[i] = fromInt(4 - z)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// expandMask samples polynomial with coefficients in [-gamma1+1, gamma1]
+// from SHAKE256(rho || nonce). FIPS 204, Algorithm 34.
+func expandMask(p *poly, xof sha3.ShakeHash, rho []byte, nonce uint16, gamma1 int32, bits uint) {
+	var buf [32 * 20]byte
+	xof.Reset()
+	_, _ = xof.Write(rho)
+	_, _ = xof.Write([]byte{byte(nonce), byte(nonce >> 8)})
+	_, _ = xof.Read(buf[:32*bits])
+	p.unpack(buf[:], bits, gamma1)
+}
+
+// sampleInBall samples polynomial with tau coefficients equal to +/-1
+// and the remaining ones equal to 0, using SHAKE256(seed). FIPS 204,
+// Algorithm 29. The seed is public, hence it is not constant time.
+func sampleInBall(p *poly, xof sha3.ShakeHash, seed []byte, tau int) {
+	var buf [shake256Rate]byte
+	xof.Reset()
+	_, _ = xof.Write(seed)
+	_, _ = xof.Read(buf[:])
+
+	var signs uint64
+	for i := 0; i < 8; i++ {
+		signs |= uint64(buf[i]) << uint(8*i)
+	}
+	*p = poly{}
+	off := 8
+	for i := n - tau; i < n; i++ {
+		var j int
+		for {
+			if off == len(buf) {
+				_, _ = xof.Read(buf[:])
+				off = 0
+			}
+			j = int(buf[off])
+			off++
+			if j <= i {
+				break
+			}
+		}
+		p[i] = p[j]
+		p[j] = 1
+		if signs&1 == 1 {
+			p[j] = q - 1
+		}
+		signs >>= 1
+	}
+}
@@ -0,0 +1,272 @@
+package mldsa
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/sign/internal/prehash"
+)
+
+// Maximal size of encoded vector w1 in bytes
+const maxW1Size = maxK * 32 * 6
+
+// PreHash identifies hash function used by HashML-DSA.
+type PreHash uint8
+
+// Hash functions supported by HashML-DSA. SHAKE128 and SHAKE256 produce
+// 256 and 512 bits of output respectively.
+const (
+	SHA3_224 = PreHash(prehash.SHA3_224)
+	SHA3_256 = PreHash(prehash.SHA3_256)
+	SHA3_384 = PreHash(prehash.SHA3_384)
+	SHA3_512 = PreHash(prehash.SHA3_512)
+	SHAKE128 = PreHash(prehash.SHAKE128)
+	SHAKE256 = PreHash(prehash.SHAKE256)
+)
+
+var errUnknownPreHash = errors.New("mldsa: unknown pre-hash function")
+
+// digest returns DER encoded OID of the hash function followed by the
+// hash of msg.
+func (ph PreHash) digest(msg []byte) ([]byte, error) {
+	out, err := prehash.Hash(ph).Digest(msg)
+	if err != nil {
+		return nil, errUnknownPreHash
+	}
+	return out, nil
+}
+
+// SignatureSize returns size of the signature in bytes.
+func (pub *PublicKey) SignatureSize() int {
+	return pub.params.signatureSize()
+}
+
+// Sign signs message msg with the private key and context string ctx,
+// which must be at most MaxContextSize bytes long. If rand is nil,
+// deterministic variant of the algorithm is used, otherwise 32 bytes of
+// randomness are read from rand (hedged variant). FIPS 204, Algorithm 2.
+func Sign(rand io.Reader, prv *PrivateKey, ctx, msg []byte) ([]byte, error) {
+	if len(ctx) > MaxContextSize {
+		return nil, errContextTooLong
+	}
+	var rnd [seedSize]byte
+	if rand != nil {
+		if _, err := io.ReadFull(rand, rnd[:]); err != nil {
+			return nil, err
+		}
+	}
+	sig := make([]byte, prv.SignatureSize())
+	prv.sign(sig, rnd[:], []byte{0, byte(len(ctx))}, ctx, msg)
+	return sig, nil
+}
+
+// Verify verifies signature sig of message msg with the public key and
+// context string ctx. FIPS 204, Algorithm 3.
+func Verify(pub *PublicKey, ctx, msg, sig []byte) bool {
+	if len(ctx) > MaxContextSize {
+		return false
+	}
+	return pub.verify(sig, []byte{0, byte(len(ctx))}, ctx, msg)
+}
+
+// SignPreHash signs hash of message msg, computed with function ph (the
+// HashML-DSA variant). Other parameters have the same meaning as in Sign.
+// FIPS 204, Algorithm 4.
+func SignPreHash(rand io.Reader, prv *PrivateKey, ph PreHash, ctx, msg []byte) ([]byte, error) {
+	if len(ctx) > MaxContextSize {
+		return nil, errContextTooLong
+	}
+	digest, err := ph.digest(msg)
+	if err != nil {
+		return nil, err
+	}
+	var rnd [seedSize]byte
+	if rand != nil {
+		if _, err := io.ReadFull(rand, rnd[:]); err != nil {
+			return nil, err
+		}
+	}
+	sig := make([]byte, prv.SignatureSize())
+	prv.sign(sig, rnd[:], []byte{1, byte(len(ctx))}, ctx, digest)
+	return sig, nil
+}
+
+// VerifyPreHash verifies HashML-DSA signature sig of message msg, which
+// is hashed with function ph. FIPS 204, Algorithm 5.
+func VerifyPreHash(pub *PublicKey, ph PreHash, ctx, msg, sig []byte) bool {
+	if len(ctx) > MaxContextSize {
+		return false
+	}
+	digest, err := ph.digest(msg)
+	if err != nil {
+		return false
+	}
+	return pub.verify(sig, []byte{1, byte(len(ctx))}, ctx, digest)
+}
+
+// sign implements ML-DSA.Sign_internal, FIPS 204, Algorithm 7. Signature
+// is written to sig. Message M' is a concatenation of msg.
+func (prv *PrivateKey) sign(sig, rnd []byte, msg ...[]byte) {
+	var mu, rhoPrime [muSize]byte
+	var y, yHat, z [maxL]poly
+	var w, h [maxK]poly
+	var c, t poly
+	var w1 [maxW1Size]byte
+	p := prv.params
+	w1Size := 32 * int(p.w1Bits())
+
+	// mu = H(tr || M'), rho'' = H(K || rnd || mu)
+	xof := sha3.NewShake256()
+	_, _ = xof.Write(prv.tr[:])
+	for _, m := range msg {
+		_, _ = xof.Write(m)
+	}
+	_, _ = xof.Read(mu[:])
+	xof.Reset()
+	_, _ = xof.Write(prv.key[:])
+	_, _ = xof.Write(rnd)
+	_, _ = xof.Write(mu[:])
+	_, _ = xof.Read(rhoPrime[:])
+
+	for kappa := 0; ; kappa += p.l {
+		for i := 0; i < p.l; i++ {
+			expandMask(&y[i], xof, rhoPrime[:], uint16(kappa+i), p.gamma1, p.gamma1Bits())
+			yHat[i] = y[i]
+			yHat[i].ntt()
+		}
+
+		// w = NTT^-1(A*NTT(y)), w1 = HighBits(w)
+		for i := 0; i < p.k; i++ {
+			w[i] = poly{}
+			for j := 0; j < p.l; j++ {
+				w[i].mulAcc(&prv.a[i*p.l+j], &yHat[j])
+			}
+			w[i].invNTT()
+			for j := range t {
+				t[j] = highBits(w[i][j], p.gamma2)
+			}
+			t.pack(w1[w1Size*i:], p.w1Bits(), 0)
+		}
+
+		// c~ = H(mu || w1Encode(w1))
+		cTilde := sig[:p.cTildeSize]
+		xof.Reset()
+		_, _ = xof.Write(mu[:])
+		_, _ = xof.Write(w1[:w1Size*p.k])
+		_, _ = xof.Read(cTilde)
+		sampleInBall(&c, xof, cTilde, p.tau)
+		c.ntt()
+
+		// z = y + c*s1
+		ok := true
+		for i := 0; i < p.l; i++ {
+			t.mul(&c, &prv.s1Hat[i])
+			t.invNTT()
+			z[i].add(&y[i], &t)
+			ok = z[i].norm(p.gamma1-p.beta) && ok
+		}
+		if !ok {
+			continue
+		}
+
+		// r0 = LowBits(w - c*s2)
+		for i := 0; i < p.k; i++ {
+			t.mul(&c, &prv.s2Hat[i])
+			t.invNTT()
+			w[i].sub(&w[i], &t)
+			var r int32
+			for _, a := range w[i] {
+				_, r0 := decompose(a, p.gamma2)
+				m := r0 >> 31
+				r |= p.gamma2 - p.beta - 1 - ((r0 ^ m) - m)
+			}
+			ok = r >= 0 && ok
+		}
+		if !ok {
+			continue
+		}
+
+		// h = MakeHint(-c*t0, w - c*s2 + c*t0)
+		var ones fieldElement
+		for i := 0; i < p.k; i++ {
+			t.mul(&c, &prv.t0Hat[i])
+			t.invNTT()
+			ok = t.norm(p.gamma2) && ok
+			for j := range t {
+				h[i][j] = makeHint(fieldSub(0, t[j]), fieldAdd(w[i][j], t[j]), p.gamma2)
+				ones += h[i][j]
+			}
+		}
+		if !ok || int(ones) > p.omega {
+			continue
+		}
+
+		off := p.cTildeSize
+		for i := 0; i < p.l; i++ {
+			z[i].pack(sig[off:], p.gamma1Bits(), p.gamma1)
+			off += 32 * int(p.gamma1Bits())
+		}
+		packHint(sig[off:], h[:p.k], p.omega)
+		return
+	}
+}
+
+// verify implements ML-DSA.Verify_internal, FIPS 204, Algorithm 8.
+// Message M' is a concatenation of msg.
+func (pub *PublicKey) verify(sig []byte, msg ...[]byte) bool {
+	var mu [muSize]byte
+	var cTilde [64]byte
+	var z [maxL]poly
+	var h [maxK]poly
+	var c, w poly
+	var w1 [maxW1Size]byte
+	p := pub.params
+	w1Size := 32 * int(p.w1Bits())
+
+	if len(sig) != p.signatureSize() {
+		return false
+	}
+	off := p.cTildeSize
+	for i := 0; i < p.l; i++ {
+		z[i].unpack(sig[off:], p.gamma1Bits(), p.gamma1)
+		if !z[i].norm(p.gamma1 - p.beta) {
+			return false
+		}
+		z[i].ntt()
+		off += 32 * int(p.gamma1Bits())
+	}
+	if !unpackHint(h[:p.k], sig[off:], p.omega) {
+		return false
+	}
+
+	xof := sha3.NewShake256()
+	_, _ = xof.Write(pub.tr[:])
+	for _, m := range msg {
+		_, _ = xof.Write(m)
+	}
+	_, _ = xof.Read(mu[:])
+	sampleInBall(&c, xof, sig[:p.cTildeSize], p.tau)
+	c.ntt()
+
+	// w1 = UseHint(h, NTT^-1(A*NTT(z) - NTT(c)*NTT(t1*2^d)))
+	for i := 0; i < p.k; i++ {
+		w.mul(&c, &pub.t1Hat[i])
+		w.sub(&poly{}, &w)
+		for j := 0; j < p.l; j++ {
+			w.mulAcc(&pub.a[i*p.l+j], &z[j])
+		}
+		w.invNTT()
+		for j := range w {
+			w[j] = useHint(h[i][j], w[j], p.gamma2)
+		}
+		w.pack(w1[w1Size*i:], p.w1Bits(), 0)
+	}
+
+	xof.Reset()
+	_, _ = xof.Write(mu[:])
+	_, _ = xof.Write(w1[:w1Size*p.k])
+	_, _ = xof.Read(cTilde[:p.cTildeSize])
+	return subtle.ConstantTimeCompare(cTilde[:p.cTildeSize], sig[:p.cTildeSize]) == 1
+}
@@ -0,0 +1,61 @@
+package slhdsa
+
+import "encoding/binary"
+
+// Types of addresses, FIPS 205, Section 4.2
+const (
+	addrWotsHash uint32 = iota
+	addrWotsPK
+	addrTree
+	addrForsTree
+	addrForsRoots
+	addrWotsPRF
+	addrForsPRF
+)
+
+// address is the 32-byte hash function address ADRS, FIPS 205, Section 4.2.
+// Words are stored in big-endian order: layer (4 bytes), tree (12 bytes),
+// type (4 bytes) and three type-specific words.
+type address [32]byte
+
+func (a *address) setLayer(l uint32) {
+	binary.BigEndian.PutUint32(a[0:], l)
+}
+
+// Tree addresses are at most 64 bits long, so upper 4 bytes are always 0.
+func (a *address) setTree(t uint64) {
+	binary.BigEndian.PutUint32(a[4:], 0)
+	binary.BigEndian.PutUint64(a[8:], t)
+}
+
+// setTypeAndClear sets type of the address and zeroes the remaining words.
+func (a *address) setTypeAndClear(t uint32) {
+	binary.BigEndian.PutUint32(a[16:], t)
+	for i := 20; i < len(a); i++ {
+		a[i] = 0
+	}
+}
+
+func (a *address) setKeyPair(i uint32) {
+	binary.BigEndian.PutUint32(a[20:], i)
+}
+
+func (a *address) keyPair() uint32 {
+	return binary.BigEndian.Uint32(a[20:])
+}
+
+func (a *address) setChain(i uint32) {
+	binary.BigEndian.PutUint32(a[24:], i)
+}
+
+func (a *address) setHash(i uint32) {
+	binary.BigEndian.PutUint32(a[28:], i)
+}
+
+func (a *address) setTreeHeight(z uint32) {
+	binary.BigEndian.PutUint32(a[24:], z)
+}
+
+func (a *address) setTreeIndex(i uint32) {
+	binary.BigEndian.PutUint32(a[28:], i)
+}
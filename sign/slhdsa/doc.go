@@ -0,0 +1,20 @@
+// Package slhdsa implements SLH-DSA, the Stateless Hash-Based Digital
+// Signature Algorithm standardized in FIPS 205, with parameter sets
+// instantiated with SHAKE256: SLH-DSA-SHAKE-128s/f, SLH-DSA-SHAKE-192s/f
+// and SLH-DSA-SHAKE-256s/f. Parameter sets ending with "s" produce small
+// signatures, ones ending with "f" are fast to sign.
+//
+// Package provides key generation, hedged and deterministic signing and
+// verification of pure SLH-DSA signatures with optional context string,
+// as well as pre-hash variant HashSLH-DSA with SHA-3 and SHAKE hash
+// functions.
+//
+// SHAKE256 comes from hash/sha3. Independent hashes done during key
+// generation and signing (WOTS+ chains, leaves and nodes of Merkle trees)
+// are computed four at a time with sha3.ShakeX4, which uses AVX2 if
+// available.
+//
+// References:
+//   - [FIPS205] Stateless Hash-Based Digital Signature Standard,
+//     https://doi.org/10.6028/NIST.FIPS.205
+package slhdsa
@@ -0,0 +1,73 @@
+package slhdsa
+
+// forsSign computes FORS signature of the message digest md and the FORS
+// public key, which is stored in pk. adrs must have FORS_TREE type and
+// key pair address set. Leaves are computed four at a time. FIPS 205,
+// Algorithms 14-17.
+func (h *hasher) forsSign(sig, pk, md []byte, adrs *address) {
+	var indices [maxK]uint32
+	var roots [maxK * maxN]byte
+	var skAdrs, leafAdrs [4]address
+	var x [4][]byte
+	p := h.p
+	n := p.n
+	nodes := h.nodes[:n<<uint(p.a)]
+	base2b(indices[:p.k], md, uint(p.a))
+
+	for i, idx := range indices[:p.k] {
+		off := uint32(i) << uint(p.a)
+		// Number of leaves is at least 2^6, so it is a multiple of 4
+		for j := 0; j < 1<<uint(p.a); j += 4 {
+			for l := range x {
+				skAdrs[l] = *adrs
+				skAdrs[l].setTypeAndClear(addrForsPRF)
+				skAdrs[l].setKeyPair(adrs.keyPair())
+				skAdrs[l].setTreeIndex(off + uint32(j+l))
+				leafAdrs[l] = *adrs
+				leafAdrs[l].setTreeHeight(0)
+				leafAdrs[l].setTreeIndex(off + uint32(j+l))
+				x[l] = nodes[(j+l)*n : (j+l+1)*n]
+			}
+			h.prfX4(&x, &skAdrs)
+			if l := int(idx) - j; l >= 0 && l < 4 {
+				copy(sig[:n], x[l])
+			}
+			h.thashX4(&x, &leafAdrs, &x)
+		}
+
+		a := *adrs
+		h.merkle(roots[i*n:], sig[n:(p.a+1)*n], nodes, p.a, off, idx, &a)
+		sig = sig[(p.a+1)*n:]
+	}
+
+	rootsAdrs := *adrs
+	rootsAdrs.setTypeAndClear(addrForsRoots)
+	rootsAdrs.setKeyPair(adrs.keyPair())
+	h.thash(pk, &rootsAdrs, roots[:p.k*n])
+}
+
+// forsPKFromSig computes FORS public key from the signature sig of the
+// message digest md. FIPS 205, Algorithm 17.
+func (h *hasher) forsPKFromSig(pk, sig, md []byte, adrs *address) {
+	var indices [maxK]uint32
+	var roots [maxK * maxN]byte
+	p := h.p
+	n := p.n
+	base2b(indices[:p.k], md, uint(p.a))
+
+	a := *adrs
+	for i, idx := range indices[:p.k] {
+		leaf := uint32(i)<<uint(p.a) + idx
+		node := roots[i*n : (i+1)*n]
+		a.setTreeHeight(0)
+		a.setTreeIndex(leaf)
+		h.thash(node, &a, sig[:n])
+		h.climb(node, sig[n:(p.a+1)*n], p.a, leaf, &a)
+		sig = sig[(p.a+1)*n:]
+	}
+
+	rootsAdrs := *adrs
+	rootsAdrs.setTypeAndClear(addrForsRoots)
+	rootsAdrs.setKeyPair(adrs.keyPair())
+	h.thash(pk, &rootsAdrs, roots[:p.k*n])
+}
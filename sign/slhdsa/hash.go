@@ -0,0 +1,82 @@
+package slhdsa
+
+import "github.com/henrydcase/nobs/hash/sha3"
+
+// hasher computes hash functions of SLH-DSA instantiated with SHAKE256,
+// FIPS 205, Section 11.1. Functions PRF, F, H and T_l have the same form
+// SHAKE256(PK.seed || ADRS || M), for PRF the message M is SK.seed.
+type hasher struct {
+	p      *params
+	pkSeed []byte
+	skSeed []byte
+	xof    sha3.ShakeHash
+	x4     *sha3.ShakeX4
+	// Inputs of the four instances of thashX4
+	buf [4][maxN + len(address{}) + 2*maxN]byte
+	// Nodes of the Merkle tree, used only by signing
+	nodes []byte
+}
+
+func newHasher(p *params, pkSeed, skSeed []byte) *hasher {
+	return &hasher{
+		p:      p,
+		pkSeed: pkSeed,
+		skSeed: skSeed,
+		xof:    sha3.NewShake256(),
+		x4:     sha3.NewShake256X4(),
+	}
+}
+
+// thash computes n bytes of SHAKE256(PK.seed || ADRS || M), where M is
+// a concatenation of in, and stores them in out. out may overlap in.
+func (h *hasher) thash(out []byte, adrs *address, in ...[]byte) {
+	h.xof.Reset()
+	_, _ = h.xof.Write(h.pkSeed)
+	_, _ = h.xof.Write(adrs[:])
+	for _, m := range in {
+		_, _ = h.xof.Write(m)
+	}
+	_, _ = h.xof.Read(out[:h.p.n])
+}
+
+// thashX4 computes four hashes like thash in parallel. Inputs must have the
+// same length, at most 2n bytes. Outputs may overlap inputs.
+func (h *hasher) thashX4(out *[4][]byte, adrs *[4]address, in *[4][]byte) {
+	n := h.p.n
+	l := n + len(adrs[0]) + len(in[0])
+	for j := range h.buf {
+		copy(h.buf[j][:], h.pkSeed)
+		copy(h.buf[j][n:], adrs[j][:])
+		copy(h.buf[j][n+len(adrs[j]):], in[j])
+	}
+	h.x4.Reset()
+	_, _ = h.x4.Write(h.buf[0][:l], h.buf[1][:l], h.buf[2][:l], h.buf[3][:l])
+	_, _ = h.x4.Read(out[0][:n], out[1][:n], out[2][:n], out[3][:n])
+}
+
+// prf computes PRF(PK.seed, SK.seed, ADRS).
+func (h *hasher) prf(out []byte, adrs *address) {
+	h.thash(out, adrs, h.skSeed)
+}
+
+// prfX4 computes four PRFs in parallel.
+func (h *hasher) prfX4(out *[4][]byte, adrs *[4]address) {
+	in := [4][]byte{h.skSeed, h.skSeed, h.skSeed, h.skSeed}
+	h.thashX4(out, adrs, &in)
+}
+
+// base2b splits in into len(out) b-bit integers, most significant bits
+// first. FIPS 205, Algorithm 4.
+func base2b(out []uint32, in []byte, b uint) {
+	var total uint32
+	var bits uint
+	for i := range out {
+		for bits < b {
+			total = total<<8 | uint32(in[0])
+			in = in[1:]
+			bits += 8
+		}
+		bits -= b
+		out[i] = (total >> bits) & (1<<b - 1)
+	}
+}
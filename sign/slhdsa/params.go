@@ -0,0 +1,91 @@
+package slhdsa
+
+// Identifiers of SLH-DSA parameter sets
+const (
+	SHAKE_128s uint8 = iota
+	SHAKE_128f
+	SHAKE_192s
+	SHAKE_192f
+	SHAKE_256s
+	SHAKE_256f
+)
+
+const (
+	// Maximal length of the context string, in bytes
+	MaxContextSize = 255
+
+	// Maximal values of the parameters
+	maxN   = 32
+	maxLen = 2*maxN + wotsLen2
+	maxK   = 35
+	maxM   = 49
+	// Base-2 logarithm of the Winternitz parameter w
+	lgW = 4
+	// Winternitz parameter
+	wotsW = 1 << lgW
+	// Number of base-w digits of the WOTS+ checksum
+	wotsLen2 = 3
+)
+
+// params describes an SLH-DSA parameter set, FIPS 205, Section 11.
+type params struct {
+	id   uint8
+	name string
+	// Security parameter, size of hash values in bytes
+	n int
+	// Height of the hypertree
+	h int
+	// Number of layers of the hypertree
+	d int
+	// Height of XMSS trees, h/d
+	hp int
+	// Height of FORS trees
+	a int
+	// Number of FORS trees
+	k int
+	// Size of the message digest in bytes
+	m int
+}
+
+var slhdsaParams = [...]params{
+	SHAKE_128s: {id: SHAKE_128s, name: "SLH-DSA-SHAKE-128s", n: 16, h: 63, d: 7, hp: 9, a: 12, k: 14, m: 30},
+	SHAKE_128f: {id: SHAKE_128f, name: "SLH-DSA-SHAKE-128f", n: 16, h: 66, d: 22, hp: 3, a: 6, k: 33, m: 34},
+	SHAKE_192s: {id: SHAKE_192s, name: "SLH-DSA-SHAKE-192s", n: 24, h: 63, d: 7, hp: 9, a: 14, k: 17, m: 39},
+	SHAKE_192f: {id: SHAKE_192f, name: "SLH-DSA-SHAKE-192f", n: 24, h: 66, d: 22, hp: 3, a: 8, k: 33, m: 42},
+	SHAKE_256s: {id: SHAKE_256s, name: "SLH-DSA-SHAKE-256s", n: 32, h: 64, d: 8, hp: 8, a: 14, k: 22, m: 47},
+	SHAKE_256f: {id: SHAKE_256f, name: "SLH-DSA-SHAKE-256f", n: 32, h: 68, d: 17, hp: 4, a: 9, k: 35, m: 49},
+}
+
+func getParams(id uint8) *params {
+	if int(id) >= len(slhdsaParams) {
+		panic("slhdsa: parameter set ID unregistered")
+	}
+	return &slhdsaParams[id]
+}
+
+// Number of n-byte elements of WOTS+ signature
+func (p *params) wotsLen() int {
+	return 2*p.n + wotsLen2
+}
+
+func (p *params) publicKeySize() int {
+	return 2 * p.n
+}
+
+func (p *params) privateKeySize() int {
+	return 4 * p.n
+}
+
+// Size of the FORS signature
+func (p *params) forsSize() int {
+	return p.k * (p.a + 1) * p.n
+}
+
+// Size of the XMSS signature
+func (p *params) xmssSize() int {
+	return (p.wotsLen() + p.hp) * p.n
+}
+
+func (p *params) signatureSize() int {
+	return p.n + p.forsSize() + p.d*p.xmssSize()
+}
@@ -0,0 +1,187 @@
+package slhdsa
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/sign/internal/prehash"
+)
+
+// PreHash identifies hash function used by HashSLH-DSA.
+type PreHash uint8
+
+// Hash functions supported by HashSLH-DSA. SHAKE128 and SHAKE256 produce
+// 256 and 512 bits of output respectively.
+const (
+	SHA3_224 = PreHash(prehash.SHA3_224)
+	SHA3_256 = PreHash(prehash.SHA3_256)
+	SHA3_384 = PreHash(prehash.SHA3_384)
+	SHA3_512 = PreHash(prehash.SHA3_512)
+	SHAKE128 = PreHash(prehash.SHAKE128)
+	SHAKE256 = PreHash(prehash.SHAKE256)
+)
+
+var errUnknownPreHash = errors.New("slhdsa: unknown pre-hash function")
+
+// digest returns DER encoded OID of the hash function followed by the
+// hash of msg.
+func (ph PreHash) digest(msg []byte) ([]byte, error) {
+	out, err := prehash.Hash(ph).Digest(msg)
+	if err != nil {
+		return nil, errUnknownPreHash
+	}
+	return out, nil
+}
+
+// Sign signs message msg with the private key and context string ctx,
+// which must be at most MaxContextSize bytes long. If rand is nil,
+// deterministic variant of the algorithm is used, otherwise n bytes of
+// randomness are read from rand (hedged variant). FIPS 205, Algorithm 22.
+func Sign(rand io.Reader, prv *PrivateKey, ctx, msg []byte) ([]byte, error) {
+	if len(ctx) > MaxContextSize {
+		return nil, errContextTooLong
+	}
+	optRand, err := prv.optRand(rand)
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, prv.SignatureSize())
+	prv.sign(sig, optRand, []byte{0, byte(len(ctx))}, ctx, msg)
+	return sig, nil
+}
+
+// Verify verifies signature sig of message msg with the public key and
+// context string ctx. FIPS 205, Algorithm 24.
+func Verify(pub *PublicKey, ctx, msg, sig []byte) bool {
+	if len(ctx) > MaxContextSize {
+		return false
+	}
+	return pub.verify(sig, []byte{0, byte(len(ctx))}, ctx, msg)
+}
+
+// SignPreHash signs hash of message msg, computed with function ph (the
+// HashSLH-DSA variant). Other parameters have the same meaning as in Sign.
+// FIPS 205, Algorithm 23.
+func SignPreHash(rand io.Reader, prv *PrivateKey, ph PreHash, ctx, msg []byte) ([]byte, error) {
+	if len(ctx) > MaxContextSize {
+		return nil, errContextTooLong
+	}
+	digest, err := ph.digest(msg)
+	if err != nil {
+		return nil, err
+	}
+	optRand, err := prv.optRand(rand)
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, prv.SignatureSize())
+	prv.sign(sig, optRand, []byte{1, byte(len(ctx))}, ctx, digest)
+	return sig, nil
+}
+
+// VerifyPreHash verifies HashSLH-DSA signature sig of message msg, which
+// is hashed with function ph. FIPS 205, Algorithm 25.
+func VerifyPreHash(pub *PublicKey, ph PreHash, ctx, msg, sig []byte) bool {
+	if len(ctx) > MaxContextSize {
+		return false
+	}
+	digest, err := ph.digest(msg)
+	if err != nil {
+		return false
+	}
+	return pub.verify(sig, []byte{1, byte(len(ctx))}, ctx, digest)
+}
+
+// optRand returns n bytes read from rand, or PK.seed if rand is nil.
+func (prv *PrivateKey) optRand(rand io.Reader) ([]byte, error) {
+	n := prv.params.n
+	if rand == nil {
+		return prv.seed[:n], nil
+	}
+	out := make([]byte, n)
+	if _, err := io.ReadFull(rand, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// hashMsg computes H_msg(R, PK.seed, PK.root, M) and splits it into the
+// FORS message digest and indices of the tree and the leaf of the
+// hypertree. Message M is a concatenation of msg. FIPS 205, Algorithm 19,
+// lines 6-10.
+func (pub *PublicKey) hashMsg(h *hasher, r []byte, msg ...[]byte) (md []byte, idxTree uint64, idxLeaf uint32) {
+	var digest [maxM]byte
+	var buf [8]byte
+	p := pub.params
+
+	h.xof.Reset()
+	_, _ = h.xof.Write(r)
+	_, _ = h.xof.Write(pub.seed[:p.n])
+	_, _ = h.xof.Write(pub.root[:p.n])
+	for _, m := range msg {
+		_, _ = h.xof.Write(m)
+	}
+	_, _ = h.xof.Read(digest[:p.m])
+
+	mdSize := (p.k*p.a + 7) / 8
+	treeBits := uint(p.h - p.hp)
+	treeSize := int(treeBits+7) / 8
+	leafSize := (p.hp + 7) / 8
+
+	md = digest[:mdSize]
+	copy(buf[8-treeSize:], digest[mdSize:mdSize+treeSize])
+	idxTree = binary.BigEndian.Uint64(buf[:]) & (^uint64(0) >> (64 - treeBits))
+	buf = [8]byte{}
+	copy(buf[8-leafSize:], digest[mdSize+treeSize:mdSize+treeSize+leafSize])
+	idxLeaf = uint32(binary.BigEndian.Uint64(buf[:])) & (1<<uint(p.hp) - 1)
+	return md, idxTree, idxLeaf
+}
+
+// sign implements slh_sign_internal, FIPS 205, Algorithm 19. Signature is
+// written to sig. Message M is a concatenation of msg.
+func (prv *PrivateKey) sign(sig, optRand []byte, msg ...[]byte) {
+	var adrs address
+	var pkFors [maxN]byte
+	p := prv.params
+	n := p.n
+	h := newHasher(p, prv.seed[:n], prv.skSeed[:n])
+	// FORS trees are higher than XMSS trees in all parameter sets
+	h.nodes = make([]byte, n<<uint(p.a))
+
+	// R = PRF_msg(SK.prf, opt_rand, M)
+	h.xof.Reset()
+	_, _ = h.xof.Write(prv.prf[:n])
+	_, _ = h.xof.Write(optRand)
+	for _, m := range msg {
+		_, _ = h.xof.Write(m)
+	}
+	_, _ = h.xof.Read(sig[:n])
+
+	md, idxTree, idxLeaf := prv.hashMsg(h, sig[:n], msg...)
+	adrs.setTree(idxTree)
+	adrs.setTypeAndClear(addrForsTree)
+	adrs.setKeyPair(idxLeaf)
+	h.forsSign(sig[n:n+p.forsSize()], pkFors[:n], md, &adrs)
+	h.htSign(sig[n+p.forsSize():], pkFors[:n], idxTree, idxLeaf)
+}
+
+// verify implements slh_verify_internal, FIPS 205, Algorithm 20. Message M
+// is a concatenation of msg.
+func (pub *PublicKey) verify(sig []byte, msg ...[]byte) bool {
+	var adrs address
+	var pkFors [maxN]byte
+	p := pub.params
+	n := p.n
+
+	if len(sig) != p.signatureSize() {
+		return false
+	}
+	h := newHasher(p, pub.seed[:n], nil)
+	md, idxTree, idxLeaf := pub.hashMsg(h, sig[:n], msg...)
+	adrs.setTree(idxTree)
+	adrs.setTypeAndClear(addrForsTree)
+	adrs.setKeyPair(idxLeaf)
+	h.forsPKFromSig(pkFors[:n], sig[n:n+p.forsSize()], md, &adrs)
+	return h.htVerify(sig[n+p.forsSize():], pkFors[:n], pub.root[:n], idxTree, idxLeaf)
+}
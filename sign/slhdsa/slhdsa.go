@@ -0,0 +1,131 @@
+package slhdsa
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	errInvalidPublicKey  = errors.New("slhdsa: invalid public key")
+	errInvalidPrivateKey = errors.New("slhdsa: invalid private key")
+	errContextTooLong    = errors.New("slhdsa: context too long")
+)
+
+// PublicKey represents SLH-DSA public key.
+type PublicKey struct {
+	params *params
+	// PK.seed, used in all tweakable hashes
+	seed [maxN]byte
+	// PK.root, root of the top layer XMSS tree
+	root [maxN]byte
+}
+
+// PrivateKey represents SLH-DSA private key. It contains corresponding
+// public key.
+type PrivateKey struct {
+	PublicKey
+	// SK.seed, used to derive WOTS+ and FORS secret values
+	skSeed [maxN]byte
+	// SK.prf, used to derive randomizer of the message
+	prf [maxN]byte
+}
+
+// NewPublicKey initializes public key for a given parameter set.
+func NewPublicKey(id uint8) *PublicKey {
+	return &PublicKey{params: getParams(id)}
+}
+
+// Import imports public key stored in the byte string. Returns error in
+// case size of the input is wrong.
+func (pub *PublicKey) Import(input []byte) error {
+	n := pub.params.n
+	if len(input) != pub.Size() {
+		return errInvalidPublicKey
+	}
+	copy(pub.seed[:], input[:n])
+	copy(pub.root[:], input[n:])
+	return nil
+}
+
+// Export writes public key to out, which must be at least Size() bytes long.
+func (pub *PublicKey) Export(out []byte) {
+	n := pub.params.n
+	copy(out, pub.seed[:n])
+	copy(out[n:], pub.root[:n])
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return pub.params.publicKeySize()
+}
+
+// SignatureSize returns size of the signature in bytes.
+func (pub *PublicKey) SignatureSize() int {
+	return pub.params.signatureSize()
+}
+
+// NewPrivateKey initializes private key for a given parameter set.
+func NewPrivateKey(id uint8) *PrivateKey {
+	return &PrivateKey{PublicKey: PublicKey{params: getParams(id)}}
+}
+
+// Import imports private key stored in the byte string. Returns error in
+// case size of the input is wrong. Function doesn't check if PK.root
+// corresponds to the rest of the key, as it requires computing a whole
+// XMSS tree.
+func (prv *PrivateKey) Import(input []byte) error {
+	n := prv.params.n
+	if len(input) != prv.Size() {
+		return errInvalidPrivateKey
+	}
+	copy(prv.skSeed[:], input)
+	copy(prv.prf[:], input[n:])
+	copy(prv.seed[:], input[2*n:])
+	copy(prv.root[:], input[3*n:])
+	return nil
+}
+
+// Export writes private key to out, which must be at least Size() bytes
+// long. Encoding is SK.seed || SK.prf || PK.seed || PK.root.
+func (prv *PrivateKey) Export(out []byte) {
+	n := prv.params.n
+	copy(out, prv.skSeed[:n])
+	copy(out[n:], prv.prf[:n])
+	copy(out[2*n:], prv.seed[:n])
+	copy(out[3*n:], prv.root[:n])
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return prv.params.privateKeySize()
+}
+
+// Generate generates a random key pair. It reads 3n bytes from rng,
+// which are SK.seed, SK.prf and PK.seed. Returns error in case rng fails.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	var seeds [3 * maxN]byte
+	n := prv.params.n
+	if _, err := io.ReadFull(rng, seeds[:3*n]); err != nil {
+		return err
+	}
+	copy(prv.skSeed[:], seeds[:n])
+	copy(prv.prf[:], seeds[n:2*n])
+	copy(prv.seed[:], seeds[2*n:3*n])
+	prv.generate()
+	return nil
+}
+
+// GeneratePublicKey copies public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	*pub = prv.PublicKey
+}
+
+// generate computes PK.root from the seeds. FIPS 205, Algorithm 18.
+func (prv *PrivateKey) generate() {
+	var adrs address
+	p := prv.params
+	h := newHasher(p, prv.seed[:p.n], prv.skSeed[:p.n])
+	h.nodes = make([]byte, p.n<<uint(p.hp))
+	adrs.setLayer(uint32(p.d - 1))
+	h.xmssTree(prv.root[:p.n], nil, 0, &adrs)
+}
@@ -0,0 +1,451 @@
+package slhdsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+var allIDs = []uint8{SHAKE_128s, SHAKE_128f, SHAKE_192s, SHAKE_192f, SHAKE_256s, SHAKE_256f}
+
+// Parameter sets which are fast enough to be used in all tests
+var fastIDs = []uint8{SHAKE_128f, SHAKE_192f, SHAKE_256f}
+
+// Key pair generated from the seeds 00 01 02 ... (3n-1)
+func testKey(t testing.TB, id uint8) *PrivateKey {
+	seed := test.Seed(3 * getParams(id).n)
+	sk := NewPrivateKey(id)
+	if err := sk.Generate(bytes.NewReader(seed)); err != nil {
+		t.Fatal(err)
+	}
+	return sk
+}
+
+func idFromName(t *testing.T, name string) uint8 {
+	for _, id := range allIDs {
+		if getParams(id).name == name {
+			return id
+		}
+	}
+	t.Fatalf("unknown parameter set %s", name)
+	return 0
+}
+
+// Hash functions of HashSLH-DSA by their ACVP names
+var acvpPreHash = map[string]PreHash{
+	"SHA3-224":  SHA3_224,
+	"SHA3-256":  SHA3_256,
+	"SHA3-384":  SHA3_384,
+	"SHA3-512":  SHA3_512,
+	"SHAKE-128": SHAKE128,
+	"SHAKE-256": SHAKE256,
+}
+
+// ACVP vectors from https://github.com/usnistgov/ACVP-Server (sample
+// vector set 53), limited to the SHAKE parameter sets and, for HashSLH-DSA,
+// to the SHA-3 and SHAKE pre-hash functions.
+func TestACVPKeyGen(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			ParameterSet string `json:"parameterSet"`
+			Tests        []struct {
+				TcID   int           `json:"tcId"`
+				SkSeed test.HexBytes `json:"skSeed"`
+				SkPrf  test.HexBytes `json:"skPrf"`
+				PkSeed test.HexBytes `json:"pkSeed"`
+				Sk     test.HexBytes `json:"sk"`
+				Pk     test.HexBytes `json:"pk"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "SLH-DSA-keyGen-FIPS205/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "SLH-DSA-keyGen-FIPS205/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		if testing.Short() && getParams(id).hp > 4 {
+			continue
+		}
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			seeds := append(append(append([]byte(nil), tc.SkSeed...), tc.SkPrf...), tc.PkSeed...)
+			sk := NewPrivateKey(id)
+			if err := sk.Generate(bytes.NewReader(seeds)); err != nil {
+				t.Fatal(err)
+			}
+			skb := make([]byte, sk.Size())
+			sk.Export(skb)
+			if !bytes.Equal(skb, exp.Sk) {
+				t.Errorf("%s tcId %d: wrong private key", g.ParameterSet, tc.TcID)
+			}
+			pkb := make([]byte, sk.PublicKey.Size())
+			sk.PublicKey.Export(pkb)
+			if !bytes.Equal(pkb, exp.Pk) {
+				t.Errorf("%s tcId %d: wrong public key", g.ParameterSet, tc.TcID)
+			}
+		}
+	}
+}
+
+func TestACVPSigGen(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			ParameterSet  string `json:"parameterSet"`
+			Deterministic bool   `json:"deterministic"`
+			Interface     string `json:"signatureInterface"`
+			PreHash       string `json:"preHash"`
+			Tests         []struct {
+				TcID                 int           `json:"tcId"`
+				Sk                   test.HexBytes `json:"sk"`
+				Message              test.HexBytes `json:"message"`
+				Context              test.HexBytes `json:"context"`
+				HashAlg              string        `json:"hashAlg"`
+				AdditionalRandomness test.HexBytes `json:"additionalRandomness"`
+				Signature            test.HexBytes `json:"signature"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "SLH-DSA-sigGen-FIPS205/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "SLH-DSA-sigGen-FIPS205/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		if testing.Short() && getParams(id).hp > 4 {
+			continue
+		}
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			sk := NewPrivateKey(id)
+			if err := sk.Import(tc.Sk); err != nil {
+				t.Fatal(err)
+			}
+			var rnd io.Reader
+			if !g.Deterministic {
+				rnd = bytes.NewReader(tc.AdditionalRandomness)
+			}
+			var sig []byte
+			var err error
+			switch {
+			case g.Interface == "internal":
+				optRand := sk.seed[:sk.params.n]
+				if rnd != nil {
+					optRand = tc.AdditionalRandomness
+				}
+				sig = make([]byte, sk.SignatureSize())
+				sk.sign(sig, optRand, tc.Message)
+			case g.PreHash == "preHash":
+				ph, ok := acvpPreHash[tc.HashAlg]
+				if !ok {
+					t.Fatalf("tcId %d: unknown hash %s", tc.TcID, tc.HashAlg)
+				}
+				sig, err = SignPreHash(rnd, sk, ph, tc.Context, tc.Message)
+			default:
+				sig, err = Sign(rnd, sk, tc.Context, tc.Message)
+			}
+			if err != nil {
+				t.Fatalf("%s tcId %d: %v", g.ParameterSet, tc.TcID, err)
+			}
+			if !bytes.Equal(sig, exp.Signature) {
+				t.Errorf("%s tcId %d: wrong signature", g.ParameterSet, tc.TcID)
+			}
+		}
+	}
+}
+
+func TestACVPSigVer(t *testing.T) {
+	var prompt, results struct {
+		TestGroups []struct {
+			ParameterSet string `json:"parameterSet"`
+			Interface    string `json:"signatureInterface"`
+			PreHash      string `json:"preHash"`
+			Tests        []struct {
+				TcID       int           `json:"tcId"`
+				Pk         test.HexBytes `json:"pk"`
+				Message    test.HexBytes `json:"message"`
+				Context    test.HexBytes `json:"context"`
+				HashAlg    string        `json:"hashAlg"`
+				Signature  test.HexBytes `json:"signature"`
+				TestPassed bool          `json:"testPassed"`
+			} `json:"tests"`
+		} `json:"testGroups"`
+	}
+	test.ReadACVP(t, "SLH-DSA-sigVer-FIPS205/prompt.json.gz", &prompt)
+	test.ReadACVP(t, "SLH-DSA-sigVer-FIPS205/expectedResults.json.gz", &results)
+
+	for i, g := range prompt.TestGroups {
+		id := idFromName(t, g.ParameterSet)
+		for j, tc := range g.Tests {
+			exp := results.TestGroups[i].Tests[j]
+			pk := NewPublicKey(id)
+			if err := pk.Import(tc.Pk); err != nil {
+				t.Fatal(err)
+			}
+			var ok bool
+			switch {
+			case g.Interface == "internal":
+				ok = pk.verify(tc.Signature, tc.Message)
+			case g.PreHash == "preHash":
+				ph, known := acvpPreHash[tc.HashAlg]
+				if !known {
+					t.Fatalf("tcId %d: unknown hash %s", tc.TcID, tc.HashAlg)
+				}
+				ok = VerifyPreHash(pk, ph, tc.Context, tc.Message, tc.Signature)
+			default:
+				ok = Verify(pk, tc.Context, tc.Message, tc.Signature)
+			}
+			if ok != exp.TestPassed {
+				t.Errorf("%s tcId %d: expected %v", g.ParameterSet, tc.TcID, exp.TestPassed)
+			}
+		}
+	}
+}
+
+// Public keys and SHA3-256 of deterministic signatures of the message
+// "message" made with keys from testKey. Values were computed with an
+// independent, literal implementation of the FIPS 205 pseudocode. They
+// complement ACVP vectors with the keys derived by testKey.
+var signTests = []struct {
+	id uint8
+	// Public key
+	pk string
+	// Signature with context ""
+	sig string
+	// Signature with context "context"
+	sigCtx string
+	// HashSLH-DSA signature with SHA3-256 and context "context"
+	sigPreHash string
+}{
+	{
+		SHAKE_128s,
+		"202122232425262728292a2b2c2d2e2f89fd81fdbb5b94129b14761bdc6bf682",
+		"922a86a61160155bf0510f9a0fa5221f5046f83a5d4153745cfa8d07aed6bd9c",
+		"192fb995f45b480e543b921dbf8ace98080f7953d375a7d34cc86fc63f3196f8",
+		"3fceb0764e85aa8056d7046a40dbd2c43a79bc2751e6165983419c701f86153f",
+	},
+	{
+		SHAKE_128f,
+		"202122232425262728292a2b2c2d2e2fa90e4715b9a925c332801767fd786371",
+		"a27094bb16d17334e0e936164f7627d7cf405ce5e32583362392591032972f26",
+		"6fe2eac74adeb761d033af47e50b1c7a0ebe5e80cb5743417a4e62ba9d523b06",
+		"789c6359b67fdac5ea8fbf86b151fee5ff2e85ca1514803db603f3a327431c3c",
+	},
+	{
+		SHAKE_192s,
+		"303132333435363738393a3b3c3d3e3f4041424344454647" +
+			"eb247f955d8eca24a5860536c56b2c4d1e8d8e835eb27d2d",
+		"cd045bc5efc97751d00f8196b4c4cf61059ce3ff2bde14974e16be49d37b5c27",
+		"d2bdaffccd172c2aa1464a129173023cf8136d7be879f76833776614944afbf7",
+		"",
+	},
+	{
+		SHAKE_192f,
+		"303132333435363738393a3b3c3d3e3f4041424344454647" +
+			"3f01b06bebed020a459696868d115fe8507ded8dc08e825d",
+		"59b319ca24ba956f5e5e2f5abf61aed8d70e430c148fc0d2305108ee4f50ad71",
+		"975f4760e966fcc51a95c2d6e5eb85661608c8ee05a8625a8ac9d6dbbf1adebb",
+		"72f875783738dc5413860b4c1a1e9199112480af8b0ef658a756de4780f8ab61",
+	},
+	{
+		SHAKE_256s,
+		"404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f" +
+			"27ea444dbc8ca9c169fd484b9e977eb77a4f233550757e025cf180ede7e8839f",
+		"1b3541d8be5e37888e9ef8f1d29b13c1da8a23299cba0fe13d75907328d3dc06",
+		"28ec15a23a03e946fe92e735b155eb008f7270eaaf6295f3a3e6175f2aaf2da6",
+		"",
+	},
+	{
+		SHAKE_256f,
+		"404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f" +
+			"818d7e76beef979b5bbf9161fdefa21bd0fe0bfe19157a5711a8de8a8f6878e6",
+		"0206191523651d3dd96258087e99938b2bbf38906a8c5b749db69393758d5d47",
+		"c297723d0143dddefbfbf11cdd02f1baba79715166dffbdf0038e56ea5d66f76",
+		"51928c9acf6bbce057d1e4c91d0c2243428daa525a58b08f7bfac7fe19a126b6",
+	},
+}
+
+func TestSign(t *testing.T) {
+	msg := []byte("message")
+	for _, tt := range signTests {
+		p := getParams(tt.id)
+		if testing.Short() && p.hp > 4 {
+			continue
+		}
+		sk := testKey(t, tt.id)
+		pk := NewPublicKey(tt.id)
+		sk.GeneratePublicKey(pk)
+		pkb := make([]byte, pk.Size())
+		pk.Export(pkb)
+		if !bytes.Equal(pkb, test.FromHex(tt.pk)) {
+			t.Errorf("%s: wrong public key %X", p.name, pkb)
+		}
+
+		for _, c := range []struct {
+			ctx, exp string
+		}{{"", tt.sig}, {"context", tt.sigCtx}} {
+			sig, err := Sign(nil, sk, []byte(c.ctx), msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sig) != pk.SignatureSize() {
+				t.Errorf("%s: wrong signature size %d", p.name, len(sig))
+			}
+			if !bytes.Equal(test.SHA3Sum(sig), test.FromHex(c.exp)) {
+				t.Errorf("%s: wrong signature with context %q", p.name, c.ctx)
+			}
+			if !Verify(pk, []byte(c.ctx), msg, sig) {
+				t.Errorf("%s: signature with context %q rejected", p.name, c.ctx)
+			}
+		}
+
+		if tt.sigPreHash == "" {
+			continue
+		}
+		sig, err := SignPreHash(nil, sk, SHA3_256, []byte("context"), msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(test.SHA3Sum(sig), test.FromHex(tt.sigPreHash)) {
+			t.Errorf("%s: wrong pre-hash signature", p.name)
+		}
+		if !VerifyPreHash(pk, SHA3_256, []byte("context"), msg, sig) {
+			t.Errorf("%s: pre-hash signature rejected", p.name)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	msg := []byte("message")
+	ctx := []byte("context")
+	for _, id := range fastIDs {
+		sk := NewPrivateKey(id)
+		pk := NewPublicKey(id)
+		if err := sk.Generate(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		sk.GeneratePublicKey(pk)
+		name := sk.params.name
+
+		// Hedged signatures differ
+		sig, err := Sign(rand.Reader, sk, ctx, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig2, _ := Sign(rand.Reader, sk, ctx, msg)
+		if bytes.Equal(sig, sig2) {
+			t.Errorf("%s: hedged signatures are equal", name)
+		}
+		if !Verify(pk, ctx, msg, sig) || !Verify(pk, ctx, msg, sig2) {
+			t.Errorf("%s: signature rejected", name)
+		}
+		if Verify(pk, nil, msg, sig) || Verify(pk, ctx, []byte("massage"), sig) {
+			t.Errorf("%s: signature of other message accepted", name)
+		}
+		if VerifyPreHash(pk, SHA3_256, ctx, msg, sig) {
+			t.Errorf("%s: pure signature accepted as pre-hash one", name)
+		}
+		// Randomizer, FORS and each layer of the hypertree
+		for _, i := range []int{0, sk.params.n, len(sig) - 1} {
+			sig[i] ^= 1
+			if Verify(pk, ctx, msg, sig) {
+				t.Errorf("%s: modified signature accepted (byte %d)", name, i)
+			}
+			sig[i] ^= 1
+		}
+		if Verify(pk, ctx, msg, sig[:len(sig)-1]) {
+			t.Errorf("%s: truncated signature accepted", name)
+		}
+
+		for _, ph := range []PreHash{SHA3_224, SHA3_384, SHA3_512, SHAKE128, SHAKE256} {
+			sig, err = SignPreHash(rand.Reader, sk, ph, ctx, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyPreHash(pk, ph, ctx, msg, sig) {
+				t.Errorf("%s: pre-hash %d signature rejected", name, ph)
+			}
+			if Verify(pk, ctx, msg, sig) {
+				t.Errorf("%s: pre-hash %d signature accepted as pure one", name, ph)
+			}
+		}
+		if _, err = SignPreHash(nil, sk, PreHash(0), ctx, msg); err == nil {
+			t.Errorf("%s: unknown pre-hash accepted", name)
+		}
+		if _, err = Sign(nil, sk, make([]byte, MaxContextSize+1), msg); err == nil {
+			t.Errorf("%s: long context accepted", name)
+		}
+
+		// Exported and imported private key produces the same signatures
+		skb := make([]byte, sk.Size())
+		sk.Export(skb)
+		sk2 := NewPrivateKey(id)
+		if err = sk2.Import(skb); err != nil {
+			t.Fatal(err)
+		}
+		sig, _ = Sign(nil, sk, ctx, msg)
+		sig2, _ = Sign(nil, sk2, ctx, msg)
+		if !bytes.Equal(sig, sig2) {
+			t.Errorf("%s: imported key produces different signature", name)
+		}
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, id := range allIDs {
+		n := getParams(id).n
+		if err := NewPublicKey(id).Import(make([]byte, 2*n+1)); err == nil {
+			t.Errorf("%s: public key of wrong size accepted", getParams(id).name)
+		}
+		if err := NewPrivateKey(id).Import(make([]byte, 4*n-1)); err == nil {
+			t.Errorf("%s: private key of wrong size accepted", getParams(id).name)
+		}
+	}
+}
+
+func TestBase2b(t *testing.T) {
+	var out [5]uint32
+	base2b(out[:], []byte{0xAB, 0xCD, 0xEF, 0x12}, 6)
+	exp := [5]uint32{0x2A, 0x3C, 0x37, 0x2F, 0x04}
+	if out != exp {
+		t.Errorf("got %X, want %X", out, exp)
+	}
+}
+
+func benchParams(b *testing.B, f func(b *testing.B, id uint8)) {
+	for _, id := range allIDs {
+		id := id
+		b.Run(getParams(id).name, func(b *testing.B) { f(b, id) })
+	}
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	benchParams(b, func(b *testing.B, id uint8) {
+		sk := NewPrivateKey(id)
+		for i := 0; i < b.N; i++ {
+			_ = sk.Generate(rand.Reader)
+		}
+	})
+}
+
+func BenchmarkSign(b *testing.B) {
+	benchParams(b, func(b *testing.B, id uint8) {
+		sk := testKey(b, id)
+		msg := []byte("message")
+		for i := 0; i < b.N; i++ {
+			_, _ = Sign(nil, sk, nil, msg)
+		}
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchParams(b, func(b *testing.B, id uint8) {
+		sk := testKey(b, id)
+		msg := []byte("message")
+		sig, _ := Sign(nil, sk, nil, msg)
+		for i := 0; i < b.N; i++ {
+			Verify(&sk.PublicKey, nil, msg, sig)
+		}
+	})
+}
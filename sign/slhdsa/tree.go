@@ -0,0 +1,138 @@
+package slhdsa
+
+import "bytes"
+
+// merkle computes root of the Merkle tree of given height, whose leaves
+// are stored in nodes. nodes is overwritten. Index of the first leaf at
+// level 0 of the tree is off. If auth is not nil, authentication path of
+// the leaf idx (relative to off) is stored in it. adrs must have the type
+// set. Nodes of each level are computed four at a time. This is the
+// iterative equivalent of FIPS 205, Algorithms 9 and 15.
+func (h *hasher) merkle(root, auth, nodes []byte, height int, off, idx uint32, adrs *address) {
+	var a [4]address
+	var in, out [4][]byte
+	n := h.p.n
+
+	for z := 1; z <= height; z++ {
+		if auth != nil {
+			sib := int((idx >> uint(z-1)) ^ 1)
+			copy(auth[(z-1)*n:z*n], nodes[sib*n:])
+		}
+		adrs.setTreeHeight(uint32(z))
+		base := off >> uint(z)
+		cnt := 1 << uint(height-z)
+
+		// Parent i is stored in place of the node i, which is already consumed
+		i := 0
+		for ; i+4 <= cnt; i += 4 {
+			for j := range a {
+				a[j] = *adrs
+				a[j].setTreeIndex(base + uint32(i+j))
+				in[j] = nodes[2*(i+j)*n : 2*(i+j+1)*n]
+				out[j] = nodes[(i+j)*n : (i+j+1)*n]
+			}
+			h.thashX4(&out, &a, &in)
+		}
+		for ; i < cnt; i++ {
+			adrs.setTreeIndex(base + uint32(i))
+			h.thash(nodes[i*n:(i+1)*n], adrs, nodes[2*i*n:2*(i+1)*n])
+		}
+	}
+	copy(root, nodes[:n])
+}
+
+// climb computes root of the Merkle tree from the node at level 0 with
+// index idx and its authentication path. adrs must have the type set.
+// FIPS 205, Algorithm 11, lines 6-17 and Algorithm 17, lines 11-22.
+func (h *hasher) climb(node, auth []byte, height int, idx uint32, adrs *address) {
+	n := h.p.n
+	for k := 0; k < height; k++ {
+		adrs.setTreeHeight(uint32(k + 1))
+		adrs.setTreeIndex(idx >> uint(k+1))
+		sib := auth[k*n : (k+1)*n]
+		if (idx>>uint(k))&1 == 0 {
+			h.thash(node, adrs, node[:n], sib)
+		} else {
+			h.thash(node, adrs, sib, node[:n])
+		}
+	}
+}
+
+// xmssTree computes root of the XMSS tree at address adrs, which must
+// have the layer and tree address set. If auth is not nil, authentication
+// path of the leaf idx is stored in it. FIPS 205, Algorithms 9 and 10.
+func (h *hasher) xmssTree(root, auth []byte, idx uint32, adrs *address) {
+	n := h.p.n
+	nodes := h.nodes[:n<<uint(h.p.hp)]
+
+	leaf := *adrs
+	leaf.setTypeAndClear(addrWotsHash)
+	for i := 0; i < 1<<uint(h.p.hp); i++ {
+		leaf.setKeyPair(uint32(i))
+		h.wotsPKGen(nodes[i*n:], &leaf)
+	}
+	node := *adrs
+	node.setTypeAndClear(addrTree)
+	h.merkle(root, auth, nodes, h.p.hp, 0, idx, &node)
+}
+
+// xmssPKFromSig computes root of the XMSS tree at address adrs from the
+// signature sig of n-byte message msg with the leaf idx. FIPS 205,
+// Algorithm 11.
+func (h *hasher) xmssPKFromSig(root []byte, idx uint32, sig, msg []byte, adrs *address) {
+	wotsSize := h.p.wotsLen() * h.p.n
+
+	a := *adrs
+	a.setTypeAndClear(addrWotsHash)
+	a.setKeyPair(idx)
+	h.wotsPKFromSig(root, sig[:wotsSize], msg, &a)
+
+	a.setTypeAndClear(addrTree)
+	h.climb(root, sig[wotsSize:], h.p.hp, idx, &a)
+}
+
+// htSign computes hypertree signature of n-byte message msg with the
+// leaf idxLeaf of the tree idxTree. FIPS 205, Algorithm 12.
+func (h *hasher) htSign(sig, msg []byte, idxTree uint64, idxLeaf uint32) {
+	var root [maxN]byte
+	var adrs address
+	p := h.p
+	wotsSize := p.wotsLen() * p.n
+
+	copy(root[:], msg[:p.n])
+	for j := 0; j < p.d; j++ {
+		adrs.setLayer(uint32(j))
+		adrs.setTree(idxTree)
+
+		a := adrs
+		a.setTypeAndClear(addrWotsHash)
+		a.setKeyPair(idxLeaf)
+		h.wotsSign(sig[:wotsSize], root[:p.n], &a)
+		// Root of the tree is the message signed at the next layer
+		h.xmssTree(root[:p.n], sig[wotsSize:p.xmssSize()], idxLeaf, &adrs)
+
+		idxLeaf = uint32(idxTree & (1<<uint(p.hp) - 1))
+		idxTree >>= uint(p.hp)
+		sig = sig[p.xmssSize():]
+	}
+}
+
+// htVerify verifies hypertree signature sig of n-byte message msg against
+// the root. FIPS 205, Algorithm 13.
+func (h *hasher) htVerify(sig, msg, root []byte, idxTree uint64, idxLeaf uint32) bool {
+	var node [maxN]byte
+	var adrs address
+	p := h.p
+
+	copy(node[:], msg[:p.n])
+	for j := 0; j < p.d; j++ {
+		adrs.setLayer(uint32(j))
+		adrs.setTree(idxTree)
+		h.xmssPKFromSig(node[:p.n], idxLeaf, sig, node[:p.n], &adrs)
+
+		idxLeaf = uint32(idxTree & (1<<uint(p.hp) - 1))
+		idxTree >>= uint(p.hp)
+		sig = sig[p.xmssSize():]
+	}
+	return bytes.Equal(node[:p.n], root)
+}
@@ -0,0 +1,101 @@
+package slhdsa
+
+// wotsDigits converts n-byte message msg into base-w digits followed by
+// digits of the checksum. FIPS 205, Algorithm 7, lines 1-9.
+func (p *params) wotsDigits(out []uint32, msg []byte) {
+	var csum uint32
+	len1 := 2 * p.n
+	base2b(out[:len1], msg[:p.n], lgW)
+	for _, d := range out[:len1] {
+		csum += wotsW - 1 - d
+	}
+	// Checksum has at most 12 bits, it is left-aligned to 16 bits
+	csum <<= 4
+	base2b(out[len1:len1+wotsLen2], []byte{byte(csum >> 8), byte(csum)}, lgW)
+}
+
+// chain applies F to x, steps times, starting at position start of the
+// chain. adrs must have the chain address set. FIPS 205, Algorithm 5.
+func (h *hasher) chain(x []byte, start, steps uint32, adrs *address) {
+	for j := start; j < start+steps; j++ {
+		adrs.setHash(j)
+		h.thash(x, adrs, x)
+	}
+}
+
+// wotsPKGen computes WOTS+ public key of the key pair at address adrs.
+// Chains are computed four at a time. FIPS 205, Algorithm 6.
+func (h *hasher) wotsPKGen(out []byte, adrs *address) {
+	var tmp [maxLen * maxN]byte
+	var skAdrs, chAdrs [4]address
+	var x [4][]byte
+	n, l := h.p.n, h.p.wotsLen()
+
+	for i := 0; i < l; i += 4 {
+		for j := range x {
+			// Last group of chains is padded with copies of the last chain
+			c := i + j
+			if c >= l {
+				c = l - 1
+			}
+			skAdrs[j] = *adrs
+			skAdrs[j].setTypeAndClear(addrWotsPRF)
+			skAdrs[j].setKeyPair(adrs.keyPair())
+			skAdrs[j].setChain(uint32(c))
+			chAdrs[j] = *adrs
+			chAdrs[j].setChain(uint32(c))
+			x[j] = tmp[c*n : (c+1)*n]
+		}
+		h.prfX4(&x, &skAdrs)
+		for k := uint32(0); k < wotsW-1; k++ {
+			for j := range chAdrs {
+				chAdrs[j].setHash(k)
+			}
+			h.thashX4(&x, &chAdrs, &x)
+		}
+	}
+
+	pkAdrs := *adrs
+	pkAdrs.setTypeAndClear(addrWotsPK)
+	pkAdrs.setKeyPair(adrs.keyPair())
+	h.thash(out, &pkAdrs, tmp[:l*n])
+}
+
+// wotsSign computes WOTS+ signature of n-byte message msg with the key
+// pair at address adrs. FIPS 205, Algorithm 7.
+func (h *hasher) wotsSign(sig, msg []byte, adrs *address) {
+	var digits [maxLen]uint32
+	n := h.p.n
+	h.p.wotsDigits(digits[:], msg)
+
+	skAdrs := *adrs
+	skAdrs.setTypeAndClear(addrWotsPRF)
+	skAdrs.setKeyPair(adrs.keyPair())
+	for i, d := range digits[:h.p.wotsLen()] {
+		x := sig[i*n : (i+1)*n]
+		skAdrs.setChain(uint32(i))
+		h.prf(x, &skAdrs)
+		adrs.setChain(uint32(i))
+		h.chain(x, 0, d, adrs)
+	}
+}
+
+// wotsPKFromSig computes WOTS+ public key from the signature sig of
+// message msg. FIPS 205, Algorithm 8.
+func (h *hasher) wotsPKFromSig(out, sig, msg []byte, adrs *address) {
+	var digits [maxLen]uint32
+	var tmp [maxLen * maxN]byte
+	n, l := h.p.n, h.p.wotsLen()
+	h.p.wotsDigits(digits[:], msg)
+
+	copy(tmp[:], sig[:l*n])
+	for i, d := range digits[:l] {
+		adrs.setChain(uint32(i))
+		h.chain(tmp[i*n:(i+1)*n], d, wotsW-1-d, adrs)
+	}
+
+	pkAdrs := *adrs
+	pkAdrs.setTypeAndClear(addrWotsPK)
+	pkAdrs.setKeyPair(adrs.keyPair())
+	h.thash(out, &pkAdrs, tmp[:l*n])
+}
@@ -0,0 +1,220 @@
+package sm2
+
+// curve describes short Weierstrass curve y^2 = x^3 + ax + b over prime
+// field, with a base point G of prime order n.
+type curve struct {
+	name string
+	// Base field and scalar field
+	p, n *modulus
+	// Curve coefficients and 3*b, in Montgomery form
+	a, b, b3 elem
+	// Base point
+	g point
+	// Encoding of a, b, Gx and Gy used by Z_A computation
+	encParams []byte
+}
+
+// newCurve returns curve initialized from hex encoded parameters.
+func newCurve(name, p, a, b, gx, gy, n string) *curve {
+	c := &curve{name: name, p: newModulus(p), n: newModulus(n)}
+	for _, v := range []struct {
+		e   *elem
+		hex string
+	}{{&c.a, a}, {&c.b, b}, {&c.g.x, gx}, {&c.g.y, gy}} {
+		buf := decodeHex(v.hex)
+		if c.p.fromBytes(v.e, buf) != 1 {
+			panic("sm2: invalid curve parameter")
+		}
+		c.encParams = append(c.encParams, buf...)
+	}
+	c.p.add(&c.b3, &c.b, &c.b)
+	c.p.add(&c.b3, &c.b3, &c.b)
+	c.g.z = c.p.one
+	if !c.isOnCurve(&c.g.x, &c.g.y) {
+		panic("sm2: base point not on the curve")
+	}
+	return c
+}
+
+func decodeHex(s string) []byte {
+	var b [32]byte
+	if len(s) != 64 {
+		panic("sm2: invalid curve parameter")
+	}
+	for i := 0; i < 64; i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		default:
+			panic("sm2: invalid curve parameter")
+		}
+		b[i/2] |= c << uint(4*(1-i%2))
+	}
+	return b[:]
+}
+
+// sm2p256v1 is the curve recommended by GB/T 32918.5-2017.
+var sm2p256v1 = newCurve("sm2p256v1",
+	"FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF",
+	"FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFC",
+	"28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93",
+	"32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7",
+	"BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0",
+	"FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123")
+
+// point is a point in projective coordinates (X:Y:Z), which represents
+// affine point (X/Z, Y/Z). Point at infinity is (0:1:0).
+type point struct {
+	x, y, z elem
+}
+
+// setInfinity sets q to the point at infinity.
+func (c *curve) setInfinity(q *point) {
+	q.x = elem{}
+	q.y = c.p.one
+	q.z = elem{}
+}
+
+// isOnCurve returns true if affine point (x, y) satisfies the curve
+// equation.
+func (c *curve) isOnCurve(x, y *elem) bool {
+	var l, r elem
+	fp := c.p
+	fp.sqr(&l, y)
+	fp.sqr(&r, x)
+	fp.add(&r, &r, &c.a)
+	fp.mul(&r, &r, x)
+	fp.add(&r, &r, &c.b)
+	return l.equal(&r) == 1
+}
+
+// add computes r = p + q with complete addition formulas for
+// projective coordinates and arbitrary a ([RCB15], algorithm 1). The
+// formulas are valid for all inputs, including doubling and points
+// at infinity.
+func (c *curve) add(r, p, q *point) {
+	var t0, t1, t2, t3, t4, t5, x3, y3, z3 elem
+	fp := c.p
+
+	fp.mul(&t0, &p.x, &q.x)
+	fp.mul(&t1, &p.y, &q.y)
+	fp.mul(&t2, &p.z, &q.z)
+	fp.add(&t3, &p.x, &p.y)
+	fp.add(&t4, &q.x, &q.y)
+	fp.mul(&t3, &t3, &t4)
+	fp.add(&t4, &t0, &t1)
+	fp.sub(&t3, &t3, &t4)
+	fp.add(&t4, &p.x, &p.z)
+	fp.add(&t5, &q.x, &q.z)
+	fp.mul(&t4, &t4, &t5)
+	fp.add(&t5, &t0, &t2)
+	fp.sub(&t4, &t4, &t5)
+	fp.add(&t5, &p.y, &p.z)
+	fp.add(&x3, &q.y, &q.z)
+	fp.mul(&t5, &t5, &x3)
+	fp.add(&x3, &t1, &t2)
+	fp.sub(&t5, &t5, &x3)
+	fp.mul(&z3, &c.a, &t4)
+	fp.mul(&x3, &c.b3, &t2)
+	fp.add(&z3, &x3, &z3)
+	fp.sub(&x3, &t1, &z3)
+	fp.add(&z3, &t1, &z3)
+	fp.mul(&y3, &x3, &z3)
+	fp.add(&t1, &t0, &t0)
+	fp.add(&t1, &t1, &t0)
+	fp.mul(&t2, &c.a, &t2)
+	fp.mul(&t4, &c.b3, &t4)
+	fp.add(&t1, &t1, &t2)
+	fp.sub(&t2, &t0, &t2)
+	fp.mul(&t2, &c.a, &t2)
+	fp.add(&t4, &t4, &t2)
+	fp.mul(&t0, &t1, &t4)
+	fp.add(&y3, &y3, &t0)
+	fp.mul(&t0, &t5, &t4)
+	fp.mul(&x3, &t3, &x3)
+	fp.sub(&x3, &x3, &t0)
+	fp.mul(&t0, &t3, &t1)
+	fp.mul(&z3, &t5, &z3)
+	fp.add(&z3, &z3, &t0)
+
+	r.x, r.y, r.z = x3, y3, z3
+}
+
+// cmov sets p to q if flag is 1, leaves p unchanged if flag is 0.
+func (p *point) cmov(q *point, flag int) {
+	p.x.cmov(&q.x, flag)
+	p.y.cmov(&q.y, flag)
+	p.z.cmov(&q.z, flag)
+}
+
+// scalarMult computes r = [k]p, where k is 32-byte big-endian scalar.
+// It uses fixed 4-bit window with constant-time table lookup.
+func (c *curve) scalarMult(r, p *point, k []byte) {
+	var table [16]point
+	var q, t point
+
+	c.setInfinity(&table[0])
+	table[1] = *p
+	for i := 2; i < 16; i++ {
+		c.add(&table[i], &table[i-1], p)
+	}
+
+	c.setInfinity(&q)
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 4; j++ {
+			c.add(&q, &q, &q)
+		}
+		w := int(k[i/2]>>uint(4*(1-i%2))) & 0xF
+		for j := range table {
+			t.cmov(&table[j], ctEq(j, w))
+		}
+		c.add(&q, &q, &t)
+	}
+	*r = q
+}
+
+// ctEq returns 1 if a == b, otherwise 0. Constant time.
+func ctEq(a, b int) int {
+	d := uint64(a ^ b)
+	return int(1 ^ ((d | -d) >> 63))
+}
+
+// scalarBaseMult computes r = [k]G.
+func (c *curve) scalarBaseMult(r *point, k []byte) {
+	c.scalarMult(r, &c.g, k)
+}
+
+// toAffine converts p to affine coordinates (x, y). It returns false if
+// p is the point at infinity.
+func (c *curve) toAffine(x, y *elem, p *point) bool {
+	var zInv elem
+	c.p.inv(&zInv, &p.z)
+	c.p.mul(x, &p.x, &zInv)
+	c.p.mul(y, &p.y, &zInv)
+	return p.z.isZero() == 0
+}
+
+// encodePoint returns uncompressed encoding 04 || x || y of affine point.
+func (c *curve) encodePoint(x, y *elem) []byte {
+	b := make([]byte, 65)
+	b[0] = 4
+	c.p.toBytes(b[1:33], x)
+	c.p.toBytes(b[33:], y)
+	return b
+}
+
+// decodePoint decodes uncompressed point and checks it is on the curve.
+func (c *curve) decodePoint(x, y *elem, b []byte) bool {
+	if len(b) != 65 || b[0] != 4 {
+		return false
+	}
+	if c.p.fromBytes(x, b[1:33]) != 1 || c.p.fromBytes(y, b[33:]) != 1 {
+		return false
+	}
+	return c.isOnCurve(x, y)
+}
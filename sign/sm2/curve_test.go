@@ -0,0 +1,182 @@
+package sm2
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Reference affine arithmetic with math/big.
+type refCurve struct {
+	c       *curve
+	p, a, n *big.Int
+}
+
+func newRefCurve(c *curve) *refCurve {
+	return &refCurve{c: c, p: modulusToBig(c.p), a: elemToBig(c.p, &c.a), n: modulusToBig(c.n)}
+}
+
+// add returns P+Q, nil represents point at infinity.
+func (r *refCurve) add(P, Q []*big.Int) []*big.Int {
+	if P == nil {
+		return Q
+	}
+	if Q == nil {
+		return P
+	}
+	p := r.p
+	l := new(big.Int)
+	if P[0].Cmp(Q[0]) == 0 {
+		if new(big.Int).Add(P[1], Q[1]).Mod(new(big.Int).Add(P[1], Q[1]), p).Sign() == 0 {
+			return nil
+		}
+		l.Mul(P[0], P[0]).Mul(l, big.NewInt(3)).Add(l, r.a)
+		l.Mul(l, new(big.Int).ModInverse(new(big.Int).Lsh(P[1], 1), p))
+	} else {
+		l.Sub(Q[1], P[1])
+		l.Mul(l, new(big.Int).ModInverse(new(big.Int).Sub(Q[0], P[0]).Mod(new(big.Int).Sub(Q[0], P[0]), p), p))
+	}
+	l.Mod(l, p)
+	x := new(big.Int).Mul(l, l)
+	x.Sub(x, P[0]).Sub(x, Q[0]).Mod(x, p)
+	y := new(big.Int).Sub(P[0], x)
+	y.Mul(y, l).Sub(y, P[1]).Mod(y, p)
+	return []*big.Int{x, y}
+}
+
+func (r *refCurve) mul(k *big.Int, P []*big.Int) []*big.Int {
+	var R []*big.Int
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		R = r.add(R, R)
+		if k.Bit(i) == 1 {
+			R = r.add(R, P)
+		}
+	}
+	return R
+}
+
+func (r *refCurve) affine(p *point) []*big.Int {
+	var x, y elem
+	if !r.c.toAffine(&x, &y, p) {
+		return nil
+	}
+	return []*big.Int{elemToBig(r.c.p, &x), elemToBig(r.c.p, &y)}
+}
+
+func equalPoints(P, Q []*big.Int) bool {
+	if P == nil || Q == nil {
+		return P == nil && Q == nil
+	}
+	return P[0].Cmp(Q[0]) == 0 && P[1].Cmp(Q[1]) == 0
+}
+
+func TestScalarMult(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for _, c := range []*curve{sm2p256v1, exampleCurve} {
+		ref := newRefCurve(c)
+		G := ref.affine(&c.g)
+		var k [32]byte
+		for i := 0; i < 20; i++ {
+			var p, q point
+			rnd.Read(k[:])
+			bk := new(big.Int).SetBytes(k[:])
+			c.scalarBaseMult(&p, k[:])
+			P := ref.mul(bk, G)
+			if !equalPoints(ref.affine(&p), P) {
+				t.Fatalf("%s: [k]G differs", c.name)
+			}
+
+			// Non-base point
+			rnd.Read(k[:])
+			bk.SetBytes(k[:])
+			c.scalarMult(&q, &p, k[:])
+			if !equalPoints(ref.affine(&q), ref.mul(bk, P)) {
+				t.Fatalf("%s: [k]P differs", c.name)
+			}
+		}
+
+		// Special scalars: 0, 1, n-1, n
+		var p point
+		nb := ref.n.Bytes()
+		for _, v := range []struct {
+			k   *big.Int
+			exp []*big.Int
+		}{
+			{big.NewInt(0), nil},
+			{big.NewInt(1), G},
+			{new(big.Int).Sub(ref.n, big.NewInt(1)), []*big.Int{G[0], new(big.Int).Sub(ref.p, G[1])}},
+			{new(big.Int).SetBytes(nb), nil},
+		} {
+			var kb [32]byte
+			b := v.k.Bytes()
+			copy(kb[32-len(b):], b)
+			c.scalarBaseMult(&p, kb[:])
+			if !equalPoints(ref.affine(&p), v.exp) {
+				t.Errorf("%s: wrong [%X]G", c.name, v.k)
+			}
+		}
+	}
+}
+
+func TestCompleteAddition(t *testing.T) {
+	for _, c := range []*curve{sm2p256v1, exampleCurve} {
+		ref := newRefCurve(c)
+		G := ref.affine(&c.g)
+		var inf, negG, r point
+		c.setInfinity(&inf)
+		negG = c.g
+		c.p.neg(&negG.y, &negG.y)
+
+		c.add(&r, &c.g, &c.g)
+		if !equalPoints(ref.affine(&r), ref.add(G, G)) {
+			t.Errorf("%s: G+G", c.name)
+		}
+		c.add(&r, &c.g, &negG)
+		if ref.affine(&r) != nil {
+			t.Errorf("%s: G-G", c.name)
+		}
+		c.add(&r, &c.g, &inf)
+		if !equalPoints(ref.affine(&r), G) {
+			t.Errorf("%s: G+O", c.name)
+		}
+		c.add(&r, &inf, &inf)
+		if ref.affine(&r) != nil {
+			t.Errorf("%s: O+O", c.name)
+		}
+	}
+}
+
+func TestDecodePoint(t *testing.T) {
+	c := sm2p256v1
+	var x, y elem
+	b := c.encodePoint(&c.g.x, &c.g.y)
+	if !c.decodePoint(&x, &y, b) || x.equal(&c.g.x) != 1 || y.equal(&c.g.y) != 1 {
+		t.Fatal("can't decode base point")
+	}
+	for _, f := range []func([]byte){
+		func(b []byte) { b[0] = 2 },
+		func(b []byte) { b[64] ^= 1 },
+		// x = p
+		func(b []byte) { copy(b[1:33], modulusToBig(c.p).Bytes()) },
+	} {
+		m := append([]byte(nil), b...)
+		f(m)
+		if c.decodePoint(&x, &y, m) {
+			t.Errorf("invalid point decoded: %X", m)
+		}
+	}
+	if c.decodePoint(&x, &y, b[:64]) {
+		t.Error("truncated point decoded")
+	}
+}
+
+func BenchmarkScalarBaseMult(b *testing.B) {
+	var p point
+	k := make([]byte, 32)
+	for i := range k {
+		k[i] = byte(i * 7)
+	}
+	for i := 0; i < b.N; i++ {
+		sm2p256v1.scalarBaseMult(&p, k)
+	}
+}
@@ -0,0 +1,16 @@
+// Package sm2 implements SM2 elliptic curve public key cryptography as
+// defined in GB/T 32918-2016 (also GM/T 0003-2012): digital signature,
+// public key encryption and key exchange protocol. All algorithms use
+// SM3 hash function and the recommended 256-bit curve sm2p256v1 defined
+// in GB/T 32918.5-2017.
+//
+// Field and scalar arithmetic are implemented in constant time, the point
+// arithmetic uses complete addition formulas, so that there are no
+// exceptional cases depending on secret data.
+//
+// References:
+//   - [GBT32918] GB/T 32918.1-5, Information security technology - Public key
+//     cryptographic algorithm SM2 based on elliptic curves.
+//   - [RCB15] J. Renes, C. Costello, L. Batina. Complete addition formulas
+//     for prime order elliptic curves. https://eprint.iacr.org/2015/1060
+package sm2
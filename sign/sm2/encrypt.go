@@ -0,0 +1,115 @@
+package sm2
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sm3"
+)
+
+var errDecryption = errors.New("sm2: decryption error")
+
+// kdfXY computes SM3 KDF of x || y to 'out' and returns 1 if the output
+// is all zero.
+func kdfXY(c *curve, x, y *elem, n int) ([]byte, int) {
+	var z [2 * KeySize]byte
+	c.p.toBytes(z[:KeySize], x)
+	c.p.toBytes(z[KeySize:], y)
+	t := sm3.KDF(z[:], n)
+	var acc byte
+	for _, v := range t {
+		acc |= v
+	}
+	return t, subtle.ConstantTimeByteEq(acc, 0)
+}
+
+// hashC3 computes C3 = SM3(x2 || msg || y2).
+func hashC3(c *curve, x, y *elem, msg []byte) []byte {
+	var b [KeySize]byte
+	h := sm3.New()
+	c.p.toBytes(b[:], x)
+	h.Write(b[:])
+	h.Write(msg)
+	c.p.toBytes(b[:], y)
+	h.Write(b[:])
+	return h.Sum(nil)
+}
+
+// Encrypt encrypts 'msg' with public key 'pub' as described in
+// GB/T 32918.4, 6.1. Random nonce is read from rand. It returns ciphertext
+// in C1 || C3 || C2 format, where C1 is uncompressed point, C3 is SM3 hash
+// and C2 is the masked message, of the same length as msg.
+func Encrypt(rand io.Reader, pub *PublicKey, msg []byte) ([]byte, error) {
+	var kb [KeySize]byte
+	var p, q point
+	var x1, y1, x2, y2 elem
+
+	c := pub.c
+	for {
+		if err := randScalar(c, kb[:], rand); err != nil {
+			return nil, err
+		}
+		// C1 = [k]G
+		c.scalarBaseMult(&p, kb[:])
+		c.toAffine(&x1, &y1, &p)
+
+		// (x2, y2) = [k]P_B
+		q = point{x: pub.x, y: pub.y, z: c.p.one}
+		c.scalarMult(&q, &q, kb[:])
+		c.toAffine(&x2, &y2, &q)
+
+		t, zero := kdfXY(c, &x2, &y2, len(msg))
+		if zero == 1 && len(msg) > 0 {
+			continue
+		}
+
+		ct := make([]byte, 0, PublicKeySize+sm3.Size+len(msg))
+		ct = append(ct, c.encodePoint(&x1, &y1)...)
+		ct = append(ct, hashC3(c, &x2, &y2, msg)...)
+		for i := range t {
+			ct = append(ct, msg[i]^t[i])
+		}
+		return ct, nil
+	}
+}
+
+// Decrypt decrypts ciphertext 'ct' in C1 || C3 || C2 format with private
+// key 'priv' as described in GB/T 32918.4, 7.1.
+func Decrypt(priv *PrivateKey, ct []byte) ([]byte, error) {
+	var q point
+	var x2, y2 elem
+
+	c := priv.c
+	if len(ct) < PublicKeySize+sm3.Size {
+		return nil, errDecryption
+	}
+	// C1 must be a point on the curve
+	if !c.decodePoint(&q.x, &q.y, ct[:PublicKeySize]) {
+		return nil, errDecryption
+	}
+	q.z = c.p.one
+	c3 := ct[PublicKeySize : PublicKeySize+sm3.Size]
+	c2 := ct[PublicKeySize+sm3.Size:]
+
+	// (x2, y2) = [d_B]C1
+	c.scalarMult(&q, &q, priv.dBytes[:])
+	c.toAffine(&x2, &y2, &q)
+
+	t, zero := kdfXY(c, &x2, &y2, len(c2))
+	msg := make([]byte, len(c2))
+	for i := range msg {
+		msg[i] = c2[i] ^ t[i]
+	}
+	ok := subtle.ConstantTimeCompare(hashC3(c, &x2, &y2, msg), c3)
+	if len(c2) > 0 {
+		ok &= 1 ^ zero
+	}
+	if ok != 1 {
+		for i := range msg {
+			msg[i] = 0
+		}
+		return nil, errDecryption
+	}
+	return msg, nil
+}
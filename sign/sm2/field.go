@@ -0,0 +1,399 @@
+package sm2
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// elem is an element of the field modulo m, where m < 2^256, in Montgomery
+// form x*R mod m, with R=2^256. Limbs are stored in little-endian order.
+type elem [4]uint64
+
+// modulus stores a modulus m together with constants used by Montgomery
+// arithmetic. It is used both for the base field and for the scalars.
+type modulus struct {
+	m [4]uint64
+	// -m^-1 mod 2^64
+	mInv uint64
+	// R^2 mod m
+	rr elem
+	// R mod m, 1 in Montgomery form
+	one elem
+	// m-2, used for inversion
+	mMinus2 [4]uint64
+	// Bit length of m
+	bitLen int
+}
+
+// newModulus returns modulus initialized from hex encoded value.
+func newModulus(hex string) *modulus {
+	var m modulus
+	v, ok := new(big.Int).SetString(hex, 16)
+	if !ok || v.BitLen() > 256 || v.Bit(0) == 0 {
+		panic("sm2: invalid modulus")
+	}
+	toLimbs := func(out *[4]uint64, x *big.Int) {
+		var buf [32]byte
+		xb := x.Bytes()
+		copy(buf[32-len(xb):], xb)
+		for i := 0; i < 4; i++ {
+			out[i] = load64(buf[32-8*(i+1):])
+		}
+	}
+	toLimbs(&m.m, v)
+	m.bitLen = v.BitLen()
+
+	// -m^-1 mod 2^64 with Newton iteration
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - m.m[0]*inv
+	}
+	m.mInv = -inv
+
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	toLimbs((*[4]uint64)(&m.one), new(big.Int).Mod(r, v))
+	toLimbs((*[4]uint64)(&m.rr), new(big.Int).Mod(new(big.Int).Mul(r, r), v))
+	toLimbs(&m.mMinus2, new(big.Int).Sub(v, big.NewInt(2)))
+	return &m
+}
+
+func load64(b []byte) uint64 {
+	return uint64(b[7]) | uint64(b[6])<<8 | uint64(b[5])<<16 | uint64(b[4])<<24 |
+		uint64(b[3])<<32 | uint64(b[2])<<40 | uint64(b[1])<<48 | uint64(b[0])<<56
+}
+
+func store64(b []byte, v uint64) {
+	for i := 0; i < 8; i++ {
+		b[7-i] = byte(v >> uint(8*i))
+	}
+}
+
+// reduce computes z = x - m if x >= m, where x = hi*2^256 + x, hi <= 1 and
+// x < 2m. Constant time.
+func (md *modulus) reduce(z *elem, x *[4]uint64, hi uint64) {
+	var d [4]uint64
+	var b uint64
+	d[0], b = bits.Sub64(x[0], md.m[0], 0)
+	d[1], b = bits.Sub64(x[1], md.m[1], b)
+	d[2], b = bits.Sub64(x[2], md.m[2], b)
+	d[3], b = bits.Sub64(x[3], md.m[3], b)
+	// Keep x only if subtraction borrowed and there was no carry
+	_, b = bits.Sub64(hi, 0, b)
+	mask := -b
+	for i := range z {
+		z[i] = (x[i] & mask) | (d[i] &^ mask)
+	}
+}
+
+// mul computes z = x*y*R^-1 mod m with CIOS Montgomery multiplication.
+func (md *modulus) mul(z, x, y *elem) {
+	var t0, t1, t2, t3, t4, t5 uint64
+	var c, hi, lo, carry, u uint64
+	m := &md.m
+
+	// t = t + x*y[0]
+	hi, lo = bits.Mul64(x[0], y[0])
+	lo, carry = bits.Add64(lo, t0, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(x[1], y[0])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(x[2], y[0])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	hi, lo = bits.Mul64(x[3], y[0])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t3, c = lo, hi
+	t4, t5 = bits.Add64(t4, c, 0)
+	// t = (t + u*m) / 2^64
+	u = t0 * md.mInv
+	hi, lo = bits.Mul64(u, m[0])
+	_, carry = bits.Add64(lo, t0, 0)
+	c = hi + carry
+	hi, lo = bits.Mul64(u, m[1])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(u, m[2])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(u, m[3])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	t3, carry = bits.Add64(t4, c, 0)
+	t4 = t5 + carry
+
+	// t = t + x*y[1]
+	hi, lo = bits.Mul64(x[0], y[1])
+	lo, carry = bits.Add64(lo, t0, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(x[1], y[1])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(x[2], y[1])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	hi, lo = bits.Mul64(x[3], y[1])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t3, c = lo, hi
+	t4, t5 = bits.Add64(t4, c, 0)
+	// t = (t + u*m) / 2^64
+	u = t0 * md.mInv
+	hi, lo = bits.Mul64(u, m[0])
+	_, carry = bits.Add64(lo, t0, 0)
+	c = hi + carry
+	hi, lo = bits.Mul64(u, m[1])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(u, m[2])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(u, m[3])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	t3, carry = bits.Add64(t4, c, 0)
+	t4 = t5 + carry
+
+	// t = t + x*y[2]
+	hi, lo = bits.Mul64(x[0], y[2])
+	lo, carry = bits.Add64(lo, t0, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(x[1], y[2])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(x[2], y[2])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	hi, lo = bits.Mul64(x[3], y[2])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t3, c = lo, hi
+	t4, t5 = bits.Add64(t4, c, 0)
+	// t = (t + u*m) / 2^64
+	u = t0 * md.mInv
+	hi, lo = bits.Mul64(u, m[0])
+	_, carry = bits.Add64(lo, t0, 0)
+	c = hi + carry
+	hi, lo = bits.Mul64(u, m[1])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(u, m[2])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(u, m[3])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	t3, carry = bits.Add64(t4, c, 0)
+	t4 = t5 + carry
+
+	// t = t + x*y[3]
+	hi, lo = bits.Mul64(x[0], y[3])
+	lo, carry = bits.Add64(lo, t0, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(x[1], y[3])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(x[2], y[3])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	hi, lo = bits.Mul64(x[3], y[3])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t3, c = lo, hi
+	t4, t5 = bits.Add64(t4, c, 0)
+	// t = (t + u*m) / 2^64
+	u = t0 * md.mInv
+	hi, lo = bits.Mul64(u, m[0])
+	_, carry = bits.Add64(lo, t0, 0)
+	c = hi + carry
+	hi, lo = bits.Mul64(u, m[1])
+	lo, carry = bits.Add64(lo, t1, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t0, c = lo, hi
+	hi, lo = bits.Mul64(u, m[2])
+	lo, carry = bits.Add64(lo, t2, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t1, c = lo, hi
+	hi, lo = bits.Mul64(u, m[3])
+	lo, carry = bits.Add64(lo, t3, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	t2, c = lo, hi
+	t3, carry = bits.Add64(t4, c, 0)
+	t4 = t5 + carry
+
+	md.reduce(z, &[4]uint64{t0, t1, t2, t3}, t4)
+}
+
+// sqr computes z = x^2*R^-1 mod m.
+func (md *modulus) sqr(z, x *elem) { md.mul(z, x, x) }
+
+// add computes z = x+y mod m.
+func (md *modulus) add(z, x, y *elem) {
+	var t [4]uint64
+	var c uint64
+	t[0], c = bits.Add64(x[0], y[0], 0)
+	t[1], c = bits.Add64(x[1], y[1], c)
+	t[2], c = bits.Add64(x[2], y[2], c)
+	t[3], c = bits.Add64(x[3], y[3], c)
+	md.reduce(z, &t, c)
+}
+
+// sub computes z = x-y mod m.
+func (md *modulus) sub(z, x, y *elem) {
+	var t [4]uint64
+	var b, c uint64
+	t[0], b = bits.Sub64(x[0], y[0], 0)
+	t[1], b = bits.Sub64(x[1], y[1], b)
+	t[2], b = bits.Sub64(x[2], y[2], b)
+	t[3], b = bits.Sub64(x[3], y[3], b)
+	// Add m back if subtraction borrowed
+	mask := -b
+	z[0], c = bits.Add64(t[0], md.m[0]&mask, 0)
+	z[1], c = bits.Add64(t[1], md.m[1]&mask, c)
+	z[2], c = bits.Add64(t[2], md.m[2]&mask, c)
+	z[3], _ = bits.Add64(t[3], md.m[3]&mask, c)
+}
+
+// neg computes z = -x mod m.
+func (md *modulus) neg(z, x *elem) {
+	var zero elem
+	md.sub(z, &zero, x)
+}
+
+// inv computes z = x^-1 mod m with Fermat's little theorem. Exponent is
+// public, hence execution time doesn't depend on x. Returns 0 if x is 0.
+func (md *modulus) inv(z, x *elem) {
+	var t elem
+	t = md.one
+	for i := 255; i >= 0; i-- {
+		md.sqr(&t, &t)
+		if (md.mMinus2[i/64]>>uint(i%64))&1 == 1 {
+			md.mul(&t, &t, x)
+		}
+	}
+	*z = t
+}
+
+// fromBytes sets z to 32-byte big-endian value b, reduced modulo m, and
+// converts it to Montgomery form. It returns 1 if b was smaller than m,
+// otherwise 0.
+func (md *modulus) fromBytes(z *elem, b []byte) int {
+	var x elem
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		x[i] = load64(b[32-8*(i+1):])
+	}
+	_, borrow = bits.Sub64(x[0], md.m[0], 0)
+	_, borrow = bits.Sub64(x[1], md.m[1], borrow)
+	_, borrow = bits.Sub64(x[2], md.m[2], borrow)
+	_, borrow = bits.Sub64(x[3], md.m[3], borrow)
+	// For x < 2^256 and m*R^2 mod m < m, the product is smaller than m*R,
+	// so the result is fully reduced.
+	md.mul(z, &x, &md.rr)
+	return int(borrow)
+}
+
+// toBytes writes x, converted from Montgomery form, to 32-byte big-endian
+// buffer b.
+func (md *modulus) toBytes(b []byte, x *elem) {
+	var t elem
+	one := elem{1}
+	md.mul(&t, x, &one)
+	for i := 0; i < 4; i++ {
+		store64(b[32-8*(i+1):], t[i])
+	}
+}
+
+// isZero returns 1 if x is 0, otherwise 0. Constant time.
+func (x *elem) isZero() int {
+	v := x[0] | x[1] | x[2] | x[3]
+	return int(1 ^ ((v | -v) >> 63))
+}
+
+// equal returns 1 if x == y, otherwise 0. Constant time.
+func (x *elem) equal(y *elem) int {
+	var d elem
+	for i := range d {
+		d[i] = x[i] ^ y[i]
+	}
+	return d.isZero()
+}
+
+// cmov sets z to x if flag is 1, leaves z unchanged if flag is 0.
+// Constant time.
+func (z *elem) cmov(x *elem, flag int) {
+	mask := -uint64(flag)
+	for i := range z {
+		z[i] = (z[i] &^ mask) | (x[i] & mask)
+	}
+}
@@ -0,0 +1,114 @@
+package sm2
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Curve from examples in GB/T 32918, annex A. Unlike sm2p256v1, a != -3.
+var exampleCurve = newCurve("GB/T 32918 example",
+	"8542D69E4C044F18E8B92435BF6FF7DE457283915C45517D722EDB8B08F1DFC3",
+	"787968B4FA32C3FD2417842E73BBFEFF2F3C848B6831D7E0EC65228B3937E498",
+	"63E4C6D3B23B0C849CF84241484BFE48F61D59A5B16BA06E6E12D1DA27C5249A",
+	"421DEBD61B62EAB6746434EBC3CC315E32220B3BADD50BDC4C4E6C147FEDD43D",
+	"0680512BCBB42C07D47349D2153B70C4E5D7FDFCBFA36EA1A85841B9E46E09A2",
+	"8542D69E4C044F18E8B92435BF6FF7DD297720630485628D5AE74EE7C32E79B7")
+
+func modulusToBig(md *modulus) *big.Int {
+	var b [32]byte
+	for i := 0; i < 4; i++ {
+		store64(b[32-8*(i+1):], md.m[i])
+	}
+	return new(big.Int).SetBytes(b[:])
+}
+
+func elemToBig(md *modulus, x *elem) *big.Int {
+	var b [32]byte
+	md.toBytes(b[:], x)
+	return new(big.Int).SetBytes(b[:])
+}
+
+// randBytes returns 32 random bytes, with some special values.
+func randBytes(r *rand.Rand, m *big.Int) []byte {
+	var b [32]byte
+	switch r.Intn(8) {
+	case 0:
+		// zero
+	case 1:
+		v := new(big.Int).Sub(m, big.NewInt(1)).Bytes()
+		copy(b[32-len(v):], v)
+	case 2:
+		for i := range b {
+			b[i] = 0xFF
+		}
+	default:
+		r.Read(b[:])
+	}
+	return b[:]
+}
+
+func TestField(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, md := range []*modulus{sm2p256v1.p, sm2p256v1.n, exampleCurve.p, exampleCurve.n} {
+		m := modulusToBig(md)
+		for i := 0; i < 1000; i++ {
+			var x, y, z elem
+			xb, yb := randBytes(r, m), randBytes(r, m)
+			bx := new(big.Int).SetBytes(xb)
+			by := new(big.Int).SetBytes(yb)
+
+			if got, exp := md.fromBytes(&x, xb), bx.Cmp(m) < 0; (got == 1) != exp {
+				t.Fatalf("fromBytes: wrong range check for %X", xb)
+			}
+			md.fromBytes(&y, yb)
+			bx.Mod(bx, m)
+			by.Mod(by, m)
+			if elemToBig(md, &x).Cmp(bx) != 0 {
+				t.Fatalf("fromBytes/toBytes: got %X, want %X", elemToBig(md, &x), bx)
+			}
+
+			check := func(op string, exp *big.Int) {
+				if got := elemToBig(md, &z); got.Cmp(exp.Mod(exp, m)) != 0 {
+					t.Fatalf("%s(%X, %X): got %X, want %X", op, bx, by, got, exp)
+				}
+			}
+			md.mul(&z, &x, &y)
+			check("mul", new(big.Int).Mul(bx, by))
+			md.add(&z, &x, &y)
+			check("add", new(big.Int).Add(bx, by))
+			md.sub(&z, &x, &y)
+			check("sub", new(big.Int).Sub(bx, by))
+			md.neg(&z, &x)
+			check("neg", new(big.Int).Neg(bx))
+			md.sqr(&z, &x)
+			check("sqr", new(big.Int).Mul(bx, bx))
+			if i%10 == 0 {
+				md.inv(&z, &x)
+				exp := new(big.Int).ModInverse(bx, m)
+				if exp == nil {
+					exp = new(big.Int)
+				}
+				check("inv", exp)
+			}
+
+			if x.isZero() != btoi(bx.Sign() == 0) || x.equal(&y) != btoi(bx.Cmp(by) == 0) {
+				t.Fatal("wrong comparison")
+			}
+		}
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BenchmarkFieldMul(b *testing.B) {
+	x := sm2p256v1.g.x
+	for i := 0; i < b.N; i++ {
+		sm2p256v1.p.mul(&x, &x, &sm2p256v1.g.y)
+	}
+}
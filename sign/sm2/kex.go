@@ -0,0 +1,191 @@
+package sm2
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sm3"
+)
+
+var (
+	errKeyExchangeState = errors.New("sm2: key exchange called in wrong order")
+	errKeyExchange      = errors.New("sm2: key exchange failed")
+)
+
+// KeyExchange keeps state of one party of the SM2 key exchange protocol,
+// described in GB/T 32918.3, 6.1. The initiator (user A) and the responder
+// (user B) exchange ephemeral public keys R_A and R_B, compute shared key
+// and optionally exchange confirmation values S_B and S_A:
+//
+//	A: R_A = GenerateEphemeral()          ---- R_A --->
+//	B: R_B = GenerateEphemeral()
+//	   K = ComputeKey(R_A), S_B = Confirmation()
+//	                                      <--- R_B, S_B ---
+//	A: K = ComputeKey(R_B), VerifyConfirmation(S_B)
+//	   S_A = Confirmation()               ---- S_A --->
+//	B: VerifyConfirmation(S_A)
+type KeyExchange struct {
+	priv *PrivateKey
+	peer *PublicKey
+	// Z of the initiator and the responder
+	za, zb    []byte
+	initiator bool
+	// Ephemeral private key r and public key R
+	r      elem
+	rx, ry elem
+	hasR   bool
+	// Own confirmation value and the one expected from the peer
+	own, expected []byte
+}
+
+// NewKeyExchange creates a party of the key exchange with own key pair
+// 'priv' and identity 'uid', and peer's public key 'peer' and identity
+// 'peerUID'. The initiator flag selects the role of the party.
+// Identities may be nil, in which case DefaultUID is used.
+func NewKeyExchange(priv *PrivateKey, uid []byte, peer *PublicKey, peerUID []byte, initiator bool) (*KeyExchange, error) {
+	if uid == nil {
+		uid = DefaultUID
+	}
+	if peerUID == nil {
+		peerUID = DefaultUID
+	}
+	if priv.c != peer.c {
+		return nil, errInvalidPublicKey
+	}
+	zOwn, err := priv.ZA(uid)
+	if err != nil {
+		return nil, err
+	}
+	zPeer, err := peer.ZA(peerUID)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KeyExchange{priv: priv, peer: peer, initiator: initiator}
+	if initiator {
+		k.za, k.zb = zOwn, zPeer
+	} else {
+		k.za, k.zb = zPeer, zOwn
+	}
+	return k, nil
+}
+
+// GenerateEphemeral generates ephemeral key pair (r, R = [r]G) using
+// entropy from rand. It returns R encoded as uncompressed point, which
+// must be sent to the peer.
+func (k *KeyExchange) GenerateEphemeral(rand io.Reader) ([]byte, error) {
+	var rb [KeySize]byte
+	var p point
+
+	c := k.priv.c
+	if err := randScalar(c, rb[:], rand); err != nil {
+		return nil, err
+	}
+	c.n.fromBytes(&k.r, rb[:])
+	c.scalarBaseMult(&p, rb[:])
+	c.toAffine(&k.rx, &k.ry, &p)
+	k.hasR = true
+	return c.encodePoint(&k.rx, &k.ry), nil
+}
+
+// xBar computes x̄ = 2^w + (x mod 2^w), w = 127, as a scalar modulo n.
+func (k *KeyExchange) xBar(z *elem, x *elem) {
+	var b [KeySize]byte
+	c := k.priv.c
+	c.p.toBytes(b[:], x)
+	for i := 0; i < KeySize/2; i++ {
+		b[i] = 0
+	}
+	b[KeySize/2] |= 0x80
+	c.n.fromBytes(z, b[:])
+}
+
+// ComputeKey computes shared key of 'klen' bytes from peer's ephemeral
+// public key 'peerR'. GenerateEphemeral must be called before. After
+// successful call, confirmation values are available.
+func (k *KeyExchange) ComputeKey(peerR []byte, klen int) ([]byte, error) {
+	var t, xb elem
+	var rx, ry, vx, vy elem
+	var p, q point
+	var tb [KeySize]byte
+
+	if !k.hasR {
+		return nil, errKeyExchangeState
+	}
+	c := k.priv.c
+	fn := c.n
+	if !c.decodePoint(&rx, &ry, peerR) {
+		return nil, errKeyExchange
+	}
+
+	// t = (d + x̄ * r) mod n
+	k.xBar(&xb, &k.rx)
+	fn.mul(&t, &xb, &k.r)
+	fn.add(&t, &t, &k.priv.d)
+
+	// V = [t](P_peer + [x̄_peer]R_peer), cofactor is 1
+	k.xBar(&xb, &rx)
+	fn.toBytes(tb[:], &xb)
+	q = point{x: rx, y: ry, z: c.p.one}
+	c.scalarMult(&q, &q, tb[:])
+	p = point{x: k.peer.x, y: k.peer.y, z: c.p.one}
+	c.add(&p, &p, &q)
+	fn.toBytes(tb[:], &t)
+	c.scalarMult(&p, &p, tb[:])
+	if !c.toAffine(&vx, &vy, &p) {
+		return nil, errKeyExchange
+	}
+
+	// K = KDF(x_V || y_V || Z_A || Z_B, klen)
+	z := make([]byte, 2*KeySize, 2*KeySize+2*sm3.Size)
+	c.p.toBytes(z[:KeySize], &vx)
+	c.p.toBytes(z[KeySize:], &vy)
+	z = append(z, k.za...)
+	z = append(z, k.zb...)
+	key := sm3.KDF(z, klen)
+
+	// Confirmation values:
+	// S_B = SM3(0x02 || y_V || SM3(x_V || Z_A || Z_B || x1 || y1 || x2 || y2))
+	// S_A = SM3(0x03 || y_V || SM3(x_V || Z_A || Z_B || x1 || y1 || x2 || y2))
+	var ra, rb []byte
+	if k.initiator {
+		ra, rb = c.encodePoint(&k.rx, &k.ry), peerR
+	} else {
+		ra, rb = peerR, c.encodePoint(&k.rx, &k.ry)
+	}
+	h := sm3.New()
+	h.Write(z[:KeySize])
+	h.Write(k.za)
+	h.Write(k.zb)
+	h.Write(ra[1:])
+	h.Write(rb[1:])
+	inner := h.Sum(nil)
+
+	confirm := func(tag byte) []byte {
+		h.Reset()
+		h.Write([]byte{tag})
+		h.Write(z[KeySize : 2*KeySize])
+		h.Write(inner)
+		return h.Sum(nil)
+	}
+	sb, sa := confirm(0x02), confirm(0x03)
+	if k.initiator {
+		k.own, k.expected = sa, sb
+	} else {
+		k.own, k.expected = sb, sa
+	}
+	return key, nil
+}
+
+// Confirmation returns own confirmation value, which can be sent to the
+// peer: S_B for the responder and S_A for the initiator. It returns nil if
+// ComputeKey wasn't called.
+func (k *KeyExchange) Confirmation() []byte {
+	return append([]byte(nil), k.own...)
+}
+
+// VerifyConfirmation checks confirmation value 's' received from the peer.
+func (k *KeyExchange) VerifyConfirmation(s []byte) bool {
+	return k.expected != nil && subtle.ConstantTimeCompare(s, k.expected) == 1
+}
@@ -0,0 +1,276 @@
+package sm2
+
+import (
+	"errors"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sm3"
+)
+
+const (
+	// Size of the private key and the field element in bytes
+	KeySize = 32
+	// Size of encoded public key (uncompressed point) in bytes
+	PublicKeySize = 65
+	// Size of the signature r || s in bytes
+	SignatureSize = 64
+)
+
+// DefaultUID is the user identity used when none is specified, as
+// recommended by GM/T 0009-2012.
+var DefaultUID = []byte("1234567812345678")
+
+var (
+	errInvalidPrivateKey = errors.New("sm2: invalid private key")
+	errInvalidPublicKey  = errors.New("sm2: invalid public key")
+	errInvalidUID        = errors.New("sm2: user identity too long")
+)
+
+// PublicKey represents SM2 public key.
+type PublicKey struct {
+	c *curve
+	// Affine coordinates of the point
+	x, y elem
+}
+
+// PrivateKey represents SM2 private key.
+type PrivateKey struct {
+	PublicKey
+	// Private scalar d, in Montgomery form modulo n
+	d elem
+	// Big-endian encoding of d
+	dBytes [KeySize]byte
+}
+
+// NewPublicKey decodes public key encoded as uncompressed point
+// 04 || x || y. The point is checked to be on the curve.
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	return newPublicKey(sm2p256v1, b)
+}
+
+func newPublicKey(c *curve, b []byte) (*PublicKey, error) {
+	pub := &PublicKey{c: c}
+	if !c.decodePoint(&pub.x, &pub.y, b) {
+		return nil, errInvalidPublicKey
+	}
+	return pub, nil
+}
+
+// Bytes returns public key encoded as uncompressed point 04 || x || y.
+func (pub *PublicKey) Bytes() []byte {
+	return pub.c.encodePoint(&pub.x, &pub.y)
+}
+
+// Equal returns true if pub and x represent the same public key.
+func (pub *PublicKey) Equal(x *PublicKey) bool {
+	return pub.c == x.c && pub.x.equal(&x.x)&pub.y.equal(&x.y) == 1
+}
+
+// NewPrivateKey creates private key from 32-byte big-endian scalar d.
+// The scalar must be in range [1, n-2].
+func NewPrivateKey(d []byte) (*PrivateKey, error) {
+	return newPrivateKey(sm2p256v1, d)
+}
+
+func newPrivateKey(c *curve, d []byte) (*PrivateKey, error) {
+	var p point
+	var dPlus1 elem
+
+	if len(d) != KeySize {
+		return nil, errInvalidPrivateKey
+	}
+	priv := &PrivateKey{PublicKey: PublicKey{c: c}}
+	ok := c.n.fromBytes(&priv.d, d)
+	// d+1 must be invertible mod n
+	c.n.add(&dPlus1, &priv.d, &c.n.one)
+	if ok != 1 || priv.d.isZero() == 1 || dPlus1.isZero() == 1 {
+		return nil, errInvalidPrivateKey
+	}
+	copy(priv.dBytes[:], d)
+	c.scalarBaseMult(&p, d)
+	c.toAffine(&priv.x, &priv.y, &p)
+	return priv, nil
+}
+
+// GenerateKey generates a new key pair using entropy from rand.
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	return generateKey(sm2p256v1, rand)
+}
+
+func generateKey(c *curve, rand io.Reader) (*PrivateKey, error) {
+	var d [KeySize]byte
+	for {
+		if err := randScalar(c, d[:], rand); err != nil {
+			return nil, err
+		}
+		priv, err := newPrivateKey(c, d[:])
+		if err == nil {
+			return priv, nil
+		}
+	}
+}
+
+// Bytes returns the private scalar d as 32-byte big-endian value.
+func (priv *PrivateKey) Bytes() []byte {
+	return append([]byte(nil), priv.dBytes[:]...)
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() *PublicKey {
+	pub := priv.PublicKey
+	return &pub
+}
+
+// randScalar reads random scalar in range [1, n-1] from rand to k.
+func randScalar(c *curve, k []byte, rand io.Reader) error {
+	var e elem
+	// Mask for the top byte, so that number of bits is the same as in n
+	mask := byte(0xFF >> uint((8-c.n.bitLen%8)%8))
+	for {
+		if _, err := io.ReadFull(rand, k[:KeySize]); err != nil {
+			return err
+		}
+		k[0] &= mask
+		if c.n.fromBytes(&e, k) == 1 && e.isZero() == 0 {
+			return nil
+		}
+	}
+}
+
+// ZA computes the hash of the user identity 'uid' and public key, which
+// is prepended to the message before signing, as described in
+// GB/T 32918.2, 5.5. Length of the uid must be at most 8191 bytes.
+func (pub *PublicKey) ZA(uid []byte) ([]byte, error) {
+	var buf [2 * KeySize]byte
+	if len(uid) > 0x1FFF {
+		return nil, errInvalidUID
+	}
+	h := sm3.New()
+	entl := len(uid) * 8
+	h.Write([]byte{byte(entl >> 8), byte(entl)})
+	h.Write(uid)
+	h.Write(pub.c.encParams)
+	pub.c.p.toBytes(buf[:KeySize], &pub.x)
+	pub.c.p.toBytes(buf[KeySize:], &pub.y)
+	h.Write(buf[:])
+	return h.Sum(nil), nil
+}
+
+// hashMessage computes e = SM3(Z_A || msg), reduced modulo n.
+func (pub *PublicKey) hashMessage(e *elem, uid, msg []byte) error {
+	za, err := pub.ZA(uid)
+	if err != nil {
+		return err
+	}
+	h := sm3.New()
+	h.Write(za)
+	h.Write(msg)
+	pub.c.n.fromBytes(e, h.Sum(nil))
+	return nil
+}
+
+// Sign signs message 'msg' with the private key and user identity
+// 'uid' as described in GB/T 32918.2, 6.1. The uid may be nil in which
+// case DefaultUID is used. Random nonce is read from rand. It returns
+// signature encoded as r || s, where both r and s are 32-byte big-endian
+// values.
+func Sign(rand io.Reader, priv *PrivateKey, uid, msg []byte) ([]byte, error) {
+	var e, r, s, x1, y1, k, t elem
+	var p point
+	var kb [KeySize]byte
+
+	if uid == nil {
+		uid = DefaultUID
+	}
+	c := priv.c
+	fn := c.n
+	if err := priv.hashMessage(&e, uid, msg); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := randScalar(c, kb[:], rand); err != nil {
+			return nil, err
+		}
+		fn.fromBytes(&k, kb[:])
+
+		// (x1, y1) = [k]G
+		c.scalarBaseMult(&p, kb[:])
+		c.toAffine(&x1, &y1, &p)
+
+		// r = (e + x1) mod n
+		var x1b [KeySize]byte
+		c.p.toBytes(x1b[:], &x1)
+		fn.fromBytes(&x1, x1b[:])
+		fn.add(&r, &e, &x1)
+		// Retry if r == 0 or r + k == n
+		fn.add(&t, &r, &k)
+		if r.isZero() == 1 || t.isZero() == 1 {
+			continue
+		}
+
+		// s = (1 + d)^-1 * (k - r*d) mod n
+		fn.add(&t, &priv.d, &fn.one)
+		fn.inv(&t, &t)
+		fn.mul(&s, &r, &priv.d)
+		fn.sub(&s, &k, &s)
+		fn.mul(&s, &s, &t)
+		if s.isZero() == 1 {
+			continue
+		}
+		break
+	}
+
+	sig := make([]byte, SignatureSize)
+	fn.toBytes(sig[:KeySize], &r)
+	fn.toBytes(sig[KeySize:], &s)
+	return sig, nil
+}
+
+// Verify verifies signature 'sig' of message 'msg' with the public key
+// and user identity 'uid' as described in GB/T 32918.2, 7.1. The uid may
+// be nil in which case DefaultUID is used.
+func Verify(pub *PublicKey, uid, msg, sig []byte) bool {
+	var e, r, s, t, x1, y1 elem
+	var p, q point
+	var tb [KeySize]byte
+
+	if uid == nil {
+		uid = DefaultUID
+	}
+	c := pub.c
+	fn := c.n
+	if len(sig) != SignatureSize {
+		return false
+	}
+	// r, s in [1, n-1]
+	if fn.fromBytes(&r, sig[:KeySize]) != 1 || fn.fromBytes(&s, sig[KeySize:]) != 1 ||
+		r.isZero() == 1 || s.isZero() == 1 {
+		return false
+	}
+	if err := pub.hashMessage(&e, uid, msg); err != nil {
+		return false
+	}
+
+	// t = (r + s) mod n, t != 0
+	fn.add(&t, &r, &s)
+	if t.isZero() == 1 {
+		return false
+	}
+
+	// (x1, y1) = [s]G + [t]P
+	c.scalarBaseMult(&p, sig[KeySize:])
+	fn.toBytes(tb[:], &t)
+	q = point{x: pub.x, y: pub.y, z: c.p.one}
+	c.scalarMult(&q, &q, tb[:])
+	c.add(&p, &p, &q)
+	if !c.toAffine(&x1, &y1, &p) {
+		return false
+	}
+
+	// R = (e + x1) mod n
+	c.p.toBytes(tb[:], &x1)
+	fn.fromBytes(&x1, tb[:])
+	fn.add(&t, &e, &x1)
+	return t.equal(&r) == 1
+}
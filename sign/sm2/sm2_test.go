@@ -0,0 +1,336 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// fixedReader returns concatenation of given hex encoded values, used to
+// provide nonces from test vectors.
+func fixedReader(vals ...string) *bytes.Reader {
+	var b []byte
+	for _, v := range vals {
+		b = append(b, test.FromHex(v)...)
+	}
+	return bytes.NewReader(b)
+}
+
+// GB/T 32918.2, annex A.2
+func TestSignExample(t *testing.T) {
+	priv, err := newPrivateKey(exampleCurve, test.FromHex("128B2FA8BD433C6C068C8D803DFF79792A519A55171B1B650C23661D15897263"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expPub := "04" +
+		"0AE4C7798AA0F119471BEE11825BE46202BB79E2A5844495E97C04FF4DF2548A" +
+		"7C0240F88F1CD4E16352A73C17B7F16F07353E53A176D684A9FE0C6BB798E857"
+	if pub := priv.Public().Bytes(); !bytes.Equal(pub, test.FromHex(expPub)) {
+		t.Errorf("public key: got %X, want %s", pub, expPub)
+	}
+
+	uid := []byte("ALICE123@YAHOO.COM")
+	msg := []byte("message digest")
+	za, _ := priv.ZA(uid)
+	if exp := test.FromHex("F4A38489E32B45B6F876E3AC2168CA392362DC8F23459C1D1146FC3DBFB7BC9A"); !bytes.Equal(za, exp) {
+		t.Errorf("Z_A: got %X, want %X", za, exp)
+	}
+
+	sig, err := Sign(fixedReader("6CB28D99385C175C94F94E934817663FC176D925DD72B727260DBAAE1FB2F96F"), priv, uid, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := test.FromHex("40F1EC59F793D9F49E09DCEF49130D4194F79FB1EED2CAA55BACDB49C4E755D1" +
+		"6FC6DAC32C5D5CF10C77DFB20F7C2EB667A457872FB09EC56327A67EC7DEEBE7")
+	if !bytes.Equal(sig, exp) {
+		t.Errorf("signature: got %X, want %X", sig, exp)
+	}
+	if !Verify(priv.Public(), uid, msg, sig) {
+		t.Error("signature not verified")
+	}
+}
+
+// GB/T 32918.4, annex A.2
+func TestEncryptExample(t *testing.T) {
+	priv, err := newPrivateKey(exampleCurve, test.FromHex("1649AB77A00637BD5E2EFE283FBF353534AA7F7CB89463F208DDBC2920BB0DA0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("encryption standard")
+	exp := test.FromHex("04" +
+		"245C26FB68B1DDDDB12C4B6BF9F2B6D5FE60A383B0D18D1C4144ABF17F6252E7" +
+		"76CB9264C2A7E88E52B19903FDC47378F605E36811F5C07423A24B84400F01B8" +
+		"9C3D7360C30156FAB7C80A0276712DA9D8094A634B766D3A285E07480653426D" +
+		"650053A89B41C418B0C3AAD00D886C00286467")
+
+	ct, err := Encrypt(fixedReader("4C62EEFD6ECFC2B95B92FD6C3D9575148AFA17425546D49018E5388D49DD7B4F"), priv.Public(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct, exp) {
+		t.Errorf("ciphertext: got %X, want %X", ct, exp)
+	}
+	pt, err := Decrypt(priv, ct)
+	if err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("decryption: got %q, %v", pt, err)
+	}
+}
+
+// GB/T 32918.3, annex A.2
+func TestKeyExchangeExample(t *testing.T) {
+	privA, _ := newPrivateKey(exampleCurve, test.FromHex("6FCBA2EF9AE0AB902BC3BDE3FF915D44BA4CC78F88E2F8E7F8996D3B8CCEEDEE"))
+	privB, _ := newPrivateKey(exampleCurve, test.FromHex("5E35D7D3F3C54DBAC72E61819E730B019A84208CA3A35E4C2E353DFCCB2A3B53"))
+	uidA := []byte("ALICE123@YAHOO.COM")
+	uidB := []byte("BILL456@YAHOO.COM")
+
+	a, err := NewKeyExchange(privA, uidA, privB.Public(), uidB, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewKeyExchange(privB, uidB, privA.Public(), uidA, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ra, err := a.GenerateEphemeral(fixedReader("83A2C9C8B96E5AF70BD480B472409A9A327257F1EBB73F5B073354B248668563"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, err := b.GenerateEphemeral(fixedReader("33FE21940342161C55619C4A0C060293D543C80AF19748CE176D83477DE71C80"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expK := test.FromHex("55B0AC62A6B927BA23703832C853DED4")
+	expSB := test.FromHex("284C8F198F141B502E81250F1581C7E9EEB4CA6990F9E02DF388B45471F5BC5C")
+	expSA := test.FromHex("23444DAF8ED7534366CB901C84B3BDBB63504F4065C1116C91A4C00697E6CF7A")
+
+	kb, err := b.ComputeKey(ra, 16)
+	if err != nil || !bytes.Equal(kb, expK) {
+		t.Fatalf("K_B: got %X, %v", kb, err)
+	}
+	if sb := b.Confirmation(); !bytes.Equal(sb, expSB) {
+		t.Errorf("S_B: got %X, want %X", sb, expSB)
+	}
+
+	ka, err := a.ComputeKey(rb, 16)
+	if err != nil || !bytes.Equal(ka, expK) {
+		t.Fatalf("K_A: got %X, %v", ka, err)
+	}
+	if !a.VerifyConfirmation(b.Confirmation()) {
+		t.Error("S_B not verified")
+	}
+	if sa := a.Confirmation(); !bytes.Equal(sa, expSA) {
+		t.Errorf("S_A: got %X, want %X", sa, expSA)
+	}
+	if !b.VerifyConfirmation(a.Confirmation()) {
+		t.Error("S_A not verified")
+	}
+	if a.VerifyConfirmation(a.Confirmation()) {
+		t.Error("own confirmation accepted")
+	}
+}
+
+// Signatures and ciphertext generated by OpenSSL 3.0 with sm2p256v1
+func TestOpenSSLInterop(t *testing.T) {
+	priv, err := NewPrivateKey(test.FromHex("A4D0C77189C36C6277606E6A59E2B8D57DD64ECD77070EB0E44BB14BC06992D9"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKey(test.FromHex("04" +
+		"FF9943B31B053401F06E42FCFDBE07C9DC5C7AB38CBB4FD977CB9F0D827C4210" +
+		"CFE296C8658377616CFFB4784FEB7688690FA28C1218EAD901DC4D53D2E058DE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(priv.Public()) {
+		t.Fatal("public key differs")
+	}
+
+	msg := []byte("message digest")
+	// Default identity
+	sig := test.FromHex("45849E13C879FD1B1FE55116E1707B58EAE9DAEE8B649B0485BE008623D6CF52" +
+		"0737994AC21EB73A4F85981007567DA794E67F6786CD025818C0B6B58F6B6D14")
+	if !Verify(pub, nil, msg, sig) {
+		t.Error("signature with default identity not verified")
+	}
+	// Empty identity
+	sig = test.FromHex("F540E88CE2280A25C213A78D4647D707A075F8AE75B592B3CB207682B3C737FF" +
+		"EBDF57D194F0FB30378C49B99FC298E267C253BC0E9967102728625C7FA4FD07")
+	if !Verify(pub, []byte{}, msg, sig) {
+		t.Error("signature with empty identity not verified")
+	}
+	if Verify(pub, nil, msg, sig) {
+		t.Error("signature verified with wrong identity")
+	}
+
+	ct := test.FromHex("04" +
+		"9A2D32053A844D966B48A3634EDD908F90C07BB95181CFC1F77FF70904B4D66C" +
+		"6D8CC8EB1DD6D1E1B8B25F52F4EB4E0CA21F3CD3B55F511A6410EA55CB5D5D4A" +
+		"327771A4289F3AFC8F5B707B58E5375831F339FE3A1D53B36D38BE816F152BB8" +
+		"839795849EC991DFB93405C2BEA67B70E22ACA")
+	pt, err := Decrypt(priv, ct)
+	if err != nil || string(pt) != "encryption standard" {
+		t.Errorf("decryption: got %q, %v", pt, err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv2, err := NewPrivateKey(priv.Bytes())
+	if err != nil || !priv2.Public().Equal(priv.Public()) {
+		t.Fatal("private key serialization failed")
+	}
+
+	msg := []byte("hello")
+	sig, err := Sign(rand.Reader, priv, nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, _ := NewPublicKey(priv.Public().Bytes())
+	if !Verify(pub, nil, msg, sig) {
+		t.Fatal("signature not verified")
+	}
+	if Verify(pub, []byte("other"), msg, sig) {
+		t.Error("verified with wrong identity")
+	}
+	if Verify(pub, nil, []byte("hellO"), sig) {
+		t.Error("verified wrong message")
+	}
+	for i := range sig {
+		s := append([]byte(nil), sig...)
+		s[i] ^= 0x10
+		if Verify(pub, nil, msg, s) {
+			t.Fatalf("verified modified signature, byte %d", i)
+		}
+	}
+	if Verify(pub, nil, msg, sig[:63]) {
+		t.Error("verified truncated signature")
+	}
+	// r = 0 and s = 0
+	if Verify(pub, nil, msg, make([]byte, 64)) {
+		t.Error("verified zero signature")
+	}
+	if _, err := Sign(rand.Reader, priv, make([]byte, 8192), msg); err == nil {
+		t.Error("expected error for long identity")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 32, 100} {
+		msg := make([]byte, n)
+		rand.Read(msg)
+		ct, err := Encrypt(rand.Reader, priv.Public(), msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != PublicKeySize+32+n {
+			t.Errorf("wrong ciphertext length %d", len(ct))
+		}
+		pt, err := Decrypt(priv, ct)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Fatalf("len=%d: decryption failed: %v", n, err)
+		}
+		for _, i := range []int{0, 10, 70, 100, len(ct) - 1} {
+			if i >= len(ct) {
+				continue
+			}
+			m := append([]byte(nil), ct...)
+			m[i] ^= 1
+			if _, err := Decrypt(priv, m); err == nil {
+				t.Errorf("len=%d: modified ciphertext at %d decrypted", n, i)
+			}
+		}
+	}
+	if _, err := Decrypt(priv, make([]byte, PublicKeySize+31)); err == nil {
+		t.Error("short ciphertext decrypted")
+	}
+}
+
+func TestKeyExchange(t *testing.T) {
+	privA, _ := GenerateKey(rand.Reader)
+	privB, _ := GenerateKey(rand.Reader)
+	a, _ := NewKeyExchange(privA, nil, privB.Public(), []byte("B"), true)
+	b, _ := NewKeyExchange(privB, []byte("B"), privA.Public(), nil, false)
+
+	if _, err := a.ComputeKey(privB.Public().Bytes(), 16); err == nil {
+		t.Error("expected error before GenerateEphemeral")
+	}
+	ra, _ := a.GenerateEphemeral(rand.Reader)
+	rb, _ := b.GenerateEphemeral(rand.Reader)
+	if _, err := b.ComputeKey(ra[:64], 16); err == nil {
+		t.Error("invalid ephemeral key accepted")
+	}
+	kb, err := b.ComputeKey(ra, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ka, err := a.ComputeKey(rb, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ka, kb) {
+		t.Error("keys differ")
+	}
+	if !a.VerifyConfirmation(b.Confirmation()) || !b.VerifyConfirmation(a.Confirmation()) {
+		t.Error("confirmation failed")
+	}
+
+	// Mismatched identity gives different key
+	c, _ := NewKeyExchange(privA, []byte("A"), privB.Public(), []byte("B"), true)
+	c.GenerateEphemeral(rand.Reader)
+	if kc, _ := c.ComputeKey(rb, 48); bytes.Equal(kc, kb) {
+		t.Error("keys equal for different identities")
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	n := modulusToBig(sm2p256v1.n)
+	for _, d := range [][]byte{
+		make([]byte, 32),
+		make([]byte, 31),
+		n.Bytes(),
+		// d = n-1, d+1 is not invertible
+		new(big.Int).Sub(n, big.NewInt(1)).Bytes(),
+	} {
+		if _, err := NewPrivateKey(d); err == nil {
+			t.Errorf("invalid private key %X accepted", d)
+		}
+	}
+	if _, err := NewPublicKey(make([]byte, 65)); err == nil {
+		t.Error("invalid public key accepted")
+	}
+	// Key of the example curve can't be used with sm2p256v1 key
+	privA, _ := GenerateKey(rand.Reader)
+	privB, _ := generateKey(exampleCurve, rand.Reader)
+	if _, err := NewKeyExchange(privA, nil, privB.Public(), nil, true); err == nil {
+		t.Error("keys from different curves accepted")
+	}
+}
+
+func BenchmarkSign(b *testing.B) {
+	priv, _ := GenerateKey(rand.Reader)
+	msg := []byte("message")
+	for i := 0; i < b.N; i++ {
+		Sign(rand.Reader, priv, nil, msg)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	priv, _ := GenerateKey(rand.Reader)
+	msg := []byte("message")
+	sig, _ := Sign(rand.Reader, priv, nil, msg)
+	for i := 0; i < b.N; i++ {
+		Verify(priv.Public(), nil, msg, sig)
+	}
+}
@@ -0,0 +1,76 @@
+// Package state provides persistent storage of private keys of stateful
+// hash-based signature schemes, LMS/HSS and XMSS/XMSS^MT.
+//
+// Private key of a stateful scheme contains index of the next one-time
+// key. Using the same index twice allows forgeries, so the index must be
+// durably stored before a signature made with it is released. Signing
+// functions of the schemes call Manager.Store with the updated private key
+// and return error without producing the signature if storing fails. After
+// a crash the key is restored from the last stored state, which may skip
+// some indices, but never reuses them.
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Manager stores private key of a stateful signature scheme.
+type Manager interface {
+	// Store durably replaces stored private key with key. It must not
+	// return nil before the data reached the stable storage, and the
+	// previously stored key must remain valid if it fails. key must not
+	// be retained after the call.
+	Store(key []byte) error
+}
+
+// File is a Manager which stores the key in a file. The file is replaced
+// atomically: the key is written to a temporary file in the same
+// directory, which is synced and renamed over the original one.
+type File struct {
+	// Path of the file
+	Path string
+}
+
+// Store implements Manager.
+func (f *File) Store(key []byte) error {
+	dir, name := filepath.Split(f.Path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(key); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), f.Path); err != nil {
+		return err
+	}
+
+	// Make the rename durable
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Load reads the stored key.
+func (f *File) Load() ([]byte, error) {
+	return ioutil.ReadFile(f.Path)
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &File{Path: filepath.Join(dir, "key")}
+	if _, err = f.Load(); err == nil {
+		t.Error("missing file loaded")
+	}
+	for _, key := range [][]byte{[]byte("first key"), []byte("second")} {
+		if err = f.Store(key); err != nil {
+			t.Fatal(err)
+		}
+		out, err := f.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, key) {
+			t.Errorf("got %q, want %q", out, key)
+		}
+	}
+	// No temporary files are left
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("%d files in the directory", len(files))
+	}
+
+	f.Path = filepath.Join(dir, "missing", "key")
+	if err = f.Store([]byte("key")); err == nil {
+		t.Error("key stored to nonexistent directory")
+	}
+}
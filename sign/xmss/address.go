@@ -0,0 +1,51 @@
+package xmss
+
+import "encoding/binary"
+
+// Types of addresses, RFC 8391, Section 2.5
+const (
+	addrOTS uint32 = iota
+	addrLTree
+	addrHashTree
+)
+
+// address is the 32-byte hash function address ADRS, RFC 8391,
+// Section 2.5. Words are stored in big-endian order: layer (4 bytes),
+// tree (8 bytes), type (4 bytes) and four type-specific words.
+type address [32]byte
+
+func (a *address) setLayer(l uint32) {
+	binary.BigEndian.PutUint32(a[0:], l)
+}
+
+func (a *address) setTree(t uint64) {
+	binary.BigEndian.PutUint64(a[4:], t)
+}
+
+// setType sets type of the address and zeroes the remaining words, as
+// the reference implementation uses separate addresses for each type.
+func (a *address) setType(t uint32) {
+	binary.BigEndian.PutUint32(a[12:], t)
+	for i := 16; i < len(a); i++ {
+		a[i] = 0
+	}
+}
+
+// setOTS sets OTS address or L-tree address.
+func (a *address) setOTS(i uint32) {
+	binary.BigEndian.PutUint32(a[16:], i)
+}
+
+// setChain sets chain address or tree height.
+func (a *address) setChain(i uint32) {
+	binary.BigEndian.PutUint32(a[20:], i)
+}
+
+// setHash sets hash address or tree index.
+func (a *address) setHash(i uint32) {
+	binary.BigEndian.PutUint32(a[24:], i)
+}
+
+func (a *address) setKeyAndMask(i uint32) {
+	binary.BigEndian.PutUint32(a[28:], i)
+}
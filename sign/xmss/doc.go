@@ -0,0 +1,26 @@
+// Package xmss implements the eXtended Merkle Signature Scheme (XMSS) and
+// its multi-tree variant XMSS^MT specified in RFC 8391, with SHAKE256
+// parameter sets from NIST SP 800-208: XMSS-SHAKE256_*_256,
+// XMSS-SHAKE256_*_192, XMSSMT-SHAKE256_*_256 and XMSSMT-SHAKE256_*_192.
+// SHAKE256 comes from hash/sha3.
+//
+// XMSS is a stateful scheme: each signature uses one-time key with an
+// index, which must never be used again. Private key keeps the index of
+// the next one-time key and stores itself with state.Manager before a
+// signature is released, so the index isn't reused even if the program
+// crashes.
+//
+// WOTS+ private keys are derived with PRF_keygen, as required by SP 800-208.
+// Private key is encoded as OID || idx || SK_SEED || SK_PRF || root ||
+// SEED, the format of the reference implementation. Signing keeps one
+// tree of each layer in memory, which takes 2^(h/d+1)*n bytes per layer.
+// When a tree at a lower layer is exhausted, the next one is generated
+// during signing. Chains of WOTS+ keys and nodes of L-trees and Merkle
+// trees are computed four at a time with sha3.ShakeX4.
+//
+// References:
+//   - [RFC8391] XMSS: eXtended Merkle Signature Scheme,
+//     https://www.rfc-editor.org/rfc/rfc8391
+//   - [SP800-208] Recommendation for Stateful Hash-Based Signature Schemes,
+//     https://doi.org/10.6028/NIST.SP.800-208
+package xmss
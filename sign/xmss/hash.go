@@ -0,0 +1,133 @@
+package xmss
+
+import "github.com/henrydcase/nobs/hash/sha3"
+
+// Domain separation values of hash functions, RFC 8391, Section 5.1 and
+// SP 800-208, Section 5. Each function computes n bytes of
+// SHAKE256(toByte(x, pad) || KEY || M).
+const (
+	padF         = 0
+	padH         = 1
+	padHashMsg   = 2
+	padPRF       = 3
+	padPRFKeygen = 4
+)
+
+// hasher computes hash functions of XMSS with SHAKE256.
+type hasher struct {
+	p       *params
+	pubSeed []byte
+	skSeed  []byte
+	xof     sha3.ShakeHash
+	x4      *sha3.ShakeX4
+	// Inputs of the four instances of hashX4
+	buf [4][maxPad + 4*maxN]byte
+}
+
+func newHasher(p *params, pubSeed, skSeed []byte) *hasher {
+	return &hasher{
+		p:       p,
+		pubSeed: pubSeed,
+		skSeed:  skSeed,
+		xof:     sha3.NewShake256(),
+		x4:      sha3.NewShake256X4(),
+	}
+}
+
+// hash computes n bytes of SHAKE256(toByte(x, pad) || in...) and stores
+// them in out. out may overlap in.
+func (h *hasher) hash(out []byte, x byte, in ...[]byte) {
+	var pad [maxPad]byte
+	pad[h.p.pad()-1] = x
+	h.xof.Reset()
+	_, _ = h.xof.Write(pad[:h.p.pad()])
+	for _, m := range in {
+		_, _ = h.xof.Write(m)
+	}
+	_, _ = h.xof.Read(out[:h.p.n])
+}
+
+// hashX4 computes four hashes like hash in parallel. Input of lane j is
+// a concatenation of in[k][j], all lanes must have the same length of at
+// most 4n bytes. Outputs may overlap inputs.
+func (h *hasher) hashX4(out *[4][]byte, x byte, in ...*[4][]byte) {
+	pad := h.p.pad()
+	var l int
+	for j := range h.buf {
+		for i := 0; i < pad; i++ {
+			h.buf[j][i] = 0
+		}
+		h.buf[j][pad-1] = x
+		l = pad
+		for _, m := range in {
+			l += copy(h.buf[j][l:], m[j])
+		}
+	}
+	n := h.p.n
+	h.x4.Reset()
+	_, _ = h.x4.Write(h.buf[0][:l], h.buf[1][:l], h.buf[2][:l], h.buf[3][:l])
+	_, _ = h.x4.Read(out[0][:n], out[1][:n], out[2][:n], out[3][:n])
+}
+
+// prf computes PRF(SEED, ADRS).
+func (h *hasher) prf(out []byte, adrs *address) {
+	h.hash(out, padPRF, h.pubSeed, adrs[:])
+}
+
+// prfX4 computes four PRFs in parallel.
+func (h *hasher) prfX4(out *[4][]byte, adrs *[4]address) {
+	seed := [4][]byte{h.pubSeed, h.pubSeed, h.pubSeed, h.pubSeed}
+	a := [4][]byte{adrs[0][:], adrs[1][:], adrs[2][:], adrs[3][:]}
+	h.hashX4(out, padPRF, &seed, &a)
+}
+
+// randHash computes RAND_HASH(left, right, SEED, ADRS), RFC 8391,
+// Algorithm 7. Key and bitmasks are derived with keyAndMask 0, 1 and 2.
+// out may overlap left or right.
+func (h *hasher) randHash(out, left, right []byte, adrs *address) {
+	var key [maxN]byte
+	var bm [2 * maxN]byte
+	n := h.p.n
+
+	adrs.setKeyAndMask(0)
+	h.prf(key[:], adrs)
+	adrs.setKeyAndMask(1)
+	h.prf(bm[:], adrs)
+	adrs.setKeyAndMask(2)
+	h.prf(bm[n:], adrs)
+	for i := 0; i < n; i++ {
+		bm[i] ^= left[i]
+		bm[n+i] ^= right[i]
+	}
+	h.hash(out, padH, key[:n], bm[:2*n])
+}
+
+// randHashX4 computes four RAND_HASHes in parallel.
+func (h *hasher) randHashX4(out, left, right *[4][]byte, adrs *[4]address) {
+	var key, bm [4][2 * maxN]byte
+	var k, b0, b1, m [4][]byte
+	n := h.p.n
+
+	for j := range adrs {
+		k[j], b0[j], b1[j], m[j] = key[j][:n], bm[j][:n], bm[j][n:2*n], bm[j][:2*n]
+	}
+	for j := range adrs {
+		adrs[j].setKeyAndMask(0)
+	}
+	h.prfX4(&k, adrs)
+	for j := range adrs {
+		adrs[j].setKeyAndMask(1)
+	}
+	h.prfX4(&b0, adrs)
+	for j := range adrs {
+		adrs[j].setKeyAndMask(2)
+	}
+	h.prfX4(&b1, adrs)
+	for j := range adrs {
+		for i := 0; i < n; i++ {
+			b0[j][i] ^= left[j][i]
+			b1[j][i] ^= right[j][i]
+		}
+	}
+	h.hashX4(out, padH, &k, &m)
+}
@@ -0,0 +1,134 @@
+package xmss
+
+// Identifiers of XMSS and XMSS^MT parameter sets. Names are
+// XMSS-SHAKE256_h_8n and XMSSMT-SHAKE256_h/d_8n.
+const (
+	XMSS_SHAKE256_10_256 uint8 = iota
+	XMSS_SHAKE256_16_256
+	XMSS_SHAKE256_20_256
+	XMSS_SHAKE256_10_192
+	XMSS_SHAKE256_16_192
+	XMSS_SHAKE256_20_192
+	XMSSMT_SHAKE256_20_2_256
+	XMSSMT_SHAKE256_20_4_256
+	XMSSMT_SHAKE256_40_2_256
+	XMSSMT_SHAKE256_40_4_256
+	XMSSMT_SHAKE256_40_8_256
+	XMSSMT_SHAKE256_60_3_256
+	XMSSMT_SHAKE256_60_6_256
+	XMSSMT_SHAKE256_60_12_256
+	XMSSMT_SHAKE256_20_2_192
+	XMSSMT_SHAKE256_20_4_192
+	XMSSMT_SHAKE256_40_2_192
+	XMSSMT_SHAKE256_40_4_192
+	XMSSMT_SHAKE256_40_8_192
+	XMSSMT_SHAKE256_60_3_192
+	XMSSMT_SHAKE256_60_6_192
+	XMSSMT_SHAKE256_60_12_192
+)
+
+const (
+	// Maximal size of hash values
+	maxN = 32
+	// Maximal number of WOTS+ chains
+	maxLen = 2*maxN + wotsLen2
+	// Maximal size of the padding of hash function inputs
+	maxPad = 32
+	// Winternitz parameter
+	wotsW = 16
+	// Number of base-w digits of the WOTS+ checksum
+	wotsLen2 = 3
+)
+
+// params describes an XMSS or XMSS^MT parameter set, SP 800-208,
+// Section 5.
+type params struct {
+	id   uint8
+	name string
+	// Identifier used in the encoded keys, RFC 8391, Section 5.3 and 5.4
+	// and SP 800-208, Section 5
+	oid uint32
+	// Size of hash values in bytes
+	n int
+	// Total height of the tree
+	h int
+	// Number of layers, 1 for XMSS
+	d int
+}
+
+var xmssParams = [...]params{
+	XMSS_SHAKE256_10_256:      {XMSS_SHAKE256_10_256, "XMSS-SHAKE256_10_256", 0x10, 32, 10, 1},
+	XMSS_SHAKE256_16_256:      {XMSS_SHAKE256_16_256, "XMSS-SHAKE256_16_256", 0x11, 32, 16, 1},
+	XMSS_SHAKE256_20_256:      {XMSS_SHAKE256_20_256, "XMSS-SHAKE256_20_256", 0x12, 32, 20, 1},
+	XMSS_SHAKE256_10_192:      {XMSS_SHAKE256_10_192, "XMSS-SHAKE256_10_192", 0x13, 24, 10, 1},
+	XMSS_SHAKE256_16_192:      {XMSS_SHAKE256_16_192, "XMSS-SHAKE256_16_192", 0x14, 24, 16, 1},
+	XMSS_SHAKE256_20_192:      {XMSS_SHAKE256_20_192, "XMSS-SHAKE256_20_192", 0x15, 24, 20, 1},
+	XMSSMT_SHAKE256_20_2_256:  {XMSSMT_SHAKE256_20_2_256, "XMSSMT-SHAKE256_20/2_256", 0x29, 32, 20, 2},
+	XMSSMT_SHAKE256_20_4_256:  {XMSSMT_SHAKE256_20_4_256, "XMSSMT-SHAKE256_20/4_256", 0x2A, 32, 20, 4},
+	XMSSMT_SHAKE256_40_2_256:  {XMSSMT_SHAKE256_40_2_256, "XMSSMT-SHAKE256_40/2_256", 0x2B, 32, 40, 2},
+	XMSSMT_SHAKE256_40_4_256:  {XMSSMT_SHAKE256_40_4_256, "XMSSMT-SHAKE256_40/4_256", 0x2C, 32, 40, 4},
+	XMSSMT_SHAKE256_40_8_256:  {XMSSMT_SHAKE256_40_8_256, "XMSSMT-SHAKE256_40/8_256", 0x2D, 32, 40, 8},
+	XMSSMT_SHAKE256_60_3_256:  {XMSSMT_SHAKE256_60_3_256, "XMSSMT-SHAKE256_60/3_256", 0x2E, 32, 60, 3},
+	XMSSMT_SHAKE256_60_6_256:  {XMSSMT_SHAKE256_60_6_256, "XMSSMT-SHAKE256_60/6_256", 0x2F, 32, 60, 6},
+	XMSSMT_SHAKE256_60_12_256: {XMSSMT_SHAKE256_60_12_256, "XMSSMT-SHAKE256_60/12_256", 0x30, 32, 60, 12},
+	XMSSMT_SHAKE256_20_2_192:  {XMSSMT_SHAKE256_20_2_192, "XMSSMT-SHAKE256_20/2_192", 0x31, 24, 20, 2},
+	XMSSMT_SHAKE256_20_4_192:  {XMSSMT_SHAKE256_20_4_192, "XMSSMT-SHAKE256_20/4_192", 0x32, 24, 20, 4},
+	XMSSMT_SHAKE256_40_2_192:  {XMSSMT_SHAKE256_40_2_192, "XMSSMT-SHAKE256_40/2_192", 0x33, 24, 40, 2},
+	XMSSMT_SHAKE256_40_4_192:  {XMSSMT_SHAKE256_40_4_192, "XMSSMT-SHAKE256_40/4_192", 0x34, 24, 40, 4},
+	XMSSMT_SHAKE256_40_8_192:  {XMSSMT_SHAKE256_40_8_192, "XMSSMT-SHAKE256_40/8_192", 0x35, 24, 40, 8},
+	XMSSMT_SHAKE256_60_3_192:  {XMSSMT_SHAKE256_60_3_192, "XMSSMT-SHAKE256_60/3_192", 0x36, 24, 60, 3},
+	XMSSMT_SHAKE256_60_6_192:  {XMSSMT_SHAKE256_60_6_192, "XMSSMT-SHAKE256_60/6_192", 0x37, 24, 60, 6},
+	XMSSMT_SHAKE256_60_12_192: {XMSSMT_SHAKE256_60_12_192, "XMSSMT-SHAKE256_60/12_192", 0x38, 24, 60, 12},
+}
+
+func getParams(id uint8) *params {
+	if int(id) >= len(xmssParams) {
+		panic("xmss: parameter set ID unregistered")
+	}
+	return &xmssParams[id]
+}
+
+// Height of the trees of a single layer
+func (p *params) hp() int {
+	return p.h / p.d
+}
+
+// Number of WOTS+ chains
+func (p *params) wotsLen() int {
+	return 2*p.n + wotsLen2
+}
+
+// Size of the padding of hash function inputs. SP 800-208 uses 4 bytes
+// for parameter sets with n = 24.
+func (p *params) pad() int {
+	if p.n == 24 {
+		return 4
+	}
+	return 32
+}
+
+// Size of the encoded index of the signature
+func (p *params) indexSize() int {
+	if p.d == 1 {
+		return 4
+	}
+	return (p.h + 7) / 8
+}
+
+// Size of a single layer of the signature: WOTS+ signature and
+// authentication path
+func (p *params) layerSize() int {
+	return (p.wotsLen() + p.hp()) * p.n
+}
+
+func (p *params) publicKeySize() int {
+	return 4 + 2*p.n
+}
+
+func (p *params) privateKeySize() int {
+	return 4 + p.indexSize() + 4*p.n
+}
+
+func (p *params) signatureSize() int {
+	return p.indexSize() + p.n + p.d*p.layerSize()
+}
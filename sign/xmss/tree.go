@@ -0,0 +1,122 @@
+package xmss
+
+// lTree compresses WOTS+ public key pk into a single node and stores it
+// in out, RFC 8391, Algorithm 8. pk is overwritten. Nodes of each level
+// are computed four at a time.
+func (h *hasher) lTree(out, pk []byte, adrs *address) {
+	var x4 [4]address
+	var o, l, r [4][]byte
+	n := h.p.n
+
+	for height, k := 0, h.p.wotsLen(); k > 1; height, k = height+1, (k+1)/2 {
+		adrs.setChain(uint32(height))
+		i := 0
+		for ; i+4 <= k/2; i += 4 {
+			for j := range x4 {
+				x4[j] = *adrs
+				x4[j].setHash(uint32(i + j))
+				o[j] = pk[(i+j)*n : (i+j+1)*n]
+				l[j] = pk[2*(i+j)*n : (2*(i+j)+1)*n]
+				r[j] = pk[(2*(i+j)+1)*n : (2*(i+j)+2)*n]
+			}
+			h.randHashX4(&o, &l, &r, &x4)
+		}
+		for ; i < k/2; i++ {
+			adrs.setHash(uint32(i))
+			h.randHash(pk[i*n:], pk[2*i*n:], pk[(2*i+1)*n:], adrs)
+		}
+		if k%2 == 1 {
+			copy(pk[(k/2)*n:], pk[(k-1)*n:k*n])
+		}
+	}
+	copy(out, pk[:n])
+}
+
+// leaf computes the leaf i of the tree at address adrs, which has layer
+// and tree set.
+func (h *hasher) leaf(out []byte, i uint32, adrs *address) {
+	var pk [maxLen * maxN]byte
+	a := *adrs
+	a.setType(addrOTS)
+	a.setOTS(i)
+	h.wotsPK(pk[:], &a)
+	a.setType(addrLTree)
+	a.setOTS(i)
+	h.lTree(out, pk[:], &a)
+}
+
+// tree computes all nodes of the tree at address adrs, which has layer and
+// tree set. Node at height z and index i is stored at position
+// 2^(h'-z) + i of nodes, so the root is the node 1.
+func (h *hasher) tree(nodes []byte, adrs *address) {
+	var x4 [4]address
+	var o, l, r [4][]byte
+	n, hp := h.p.n, uint(h.p.hp())
+
+	for i := 0; i < 1<<hp; i++ {
+		h.leaf(nodes[((1<<hp)+i)*n:], uint32(i), adrs)
+	}
+	a := *adrs
+	a.setType(addrHashTree)
+	for z := uint(1); z <= hp; z++ {
+		first := 1 << (hp - z)
+		a.setChain(uint32(z - 1))
+		i := 0
+		for ; i+4 <= first; i += 4 {
+			for j := range x4 {
+				p := first + i + j
+				x4[j] = a
+				x4[j].setHash(uint32(i + j))
+				o[j] = nodes[p*n : (p+1)*n]
+				l[j] = nodes[2*p*n : (2*p+1)*n]
+				r[j] = nodes[(2*p+1)*n : (2*p+2)*n]
+			}
+			h.randHashX4(&o, &l, &r, &x4)
+		}
+		for ; i < first; i++ {
+			p := first + i
+			a.setHash(uint32(i))
+			h.randHash(nodes[p*n:], nodes[2*p*n:], nodes[(2*p+1)*n:], &a)
+		}
+	}
+}
+
+// authPath copies authentication path of the leaf i from nodes of the tree
+// to out.
+func (h *hasher) authPath(out, nodes []byte, i uint32) {
+	n, hp := h.p.n, uint(h.p.hp())
+	p := int(1<<hp + i)
+	for k := 0; k < int(hp); k++ {
+		copy(out[k*n:], nodes[(p^1)*n:(p^1+1)*n])
+		p >>= 1
+	}
+}
+
+// rootFromSig computes root of the tree at address adrs from signature sig
+// of the leaf i on n-byte message m, RFC 8391, Algorithm 13.
+func (h *hasher) rootFromSig(out []byte, i uint32, sig, m []byte, adrs *address) {
+	var pk [maxLen * maxN]byte
+	var node [maxN]byte
+	n, l := h.p.n, h.p.wotsLen()
+
+	a := *adrs
+	a.setType(addrOTS)
+	a.setOTS(i)
+	h.wotsPKFromSig(pk[:], sig, m, &a)
+	a.setType(addrLTree)
+	a.setOTS(i)
+	h.lTree(node[:], pk[:], &a)
+
+	auth := sig[l*n:]
+	a.setType(addrHashTree)
+	for k := 0; k < h.p.hp(); k++ {
+		a.setChain(uint32(k))
+		a.setHash(i >> uint(k+1))
+		if (i>>uint(k))&1 == 0 {
+			h.randHash(node[:], node[:], auth[k*n:], &a)
+		} else {
+			h.randHash(node[:], auth[k*n:], node[:], &a)
+		}
+	}
+	copy(out, node[:n])
+}
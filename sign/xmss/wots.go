@@ -0,0 +1,132 @@
+package xmss
+
+import "encoding/binary"
+
+// digits computes base-16 digits of message m followed by its checksum,
+// RFC 8391, Algorithm 5, steps 1 to 6.
+func (p *params) digits(a []byte, m []byte) {
+	var sum uint16
+	var cs [2]byte
+	for i := 0; i < p.n; i++ {
+		a[2*i] = m[i] >> 4
+		a[2*i+1] = m[i] & 0xf
+		sum += 2*(wotsW-1) - uint16(a[2*i]) - uint16(a[2*i+1])
+	}
+	binary.BigEndian.PutUint16(cs[:], sum<<4)
+	a[2*p.n] = cs[0] >> 4
+	a[2*p.n+1] = cs[0] & 0xf
+	a[2*p.n+2] = cs[1] >> 4
+}
+
+// chain applies steps iterations of the chaining function to x in place,
+// starting at start, RFC 8391, Algorithm 2. adrs must have the chain set.
+func (h *hasher) chain(x []byte, start, steps int, adrs *address) {
+	var key, bm [maxN]byte
+	n := h.p.n
+	for i := start; i < start+steps; i++ {
+		adrs.setHash(uint32(i))
+		adrs.setKeyAndMask(0)
+		h.prf(key[:], adrs)
+		adrs.setKeyAndMask(1)
+		h.prf(bm[:], adrs)
+		for k := 0; k < n; k++ {
+			bm[k] ^= x[k]
+		}
+		h.hash(x, padF, key[:n], bm[:n])
+	}
+}
+
+// wotsSKX4 computes private keys of the chains of four OTS addresses
+// with hash address and keyAndMask set to zero, SP 800-208, Section 5.
+func (h *hasher) wotsSKX4(out *[4][]byte, adrs *[4]address) {
+	sk := [4][]byte{h.skSeed, h.skSeed, h.skSeed, h.skSeed}
+	seed := [4][]byte{h.pubSeed, h.pubSeed, h.pubSeed, h.pubSeed}
+	a := [4][]byte{adrs[0][:], adrs[1][:], adrs[2][:], adrs[3][:]}
+	h.hashX4(out, padPRFKeygen, &sk, &seed, &a)
+}
+
+// wotsPK computes WOTS+ public key of the OTS address adrs and stores it
+// in out, RFC 8391, Algorithm 4. Chains are computed four at a time, the
+// last group is padded with duplicates of the last chain.
+func (h *hasher) wotsPK(out []byte, adrs *address) {
+	var x4 [4]address
+	var x, key, bm, m [4][]byte
+	var keys, masks, bms [4][maxN]byte
+	n, l := h.p.n, h.p.wotsLen()
+
+	for i := 0; i < l; i += 4 {
+		for j := range x4 {
+			c := i + j
+			if c >= l {
+				c = l - 1
+			}
+			x4[j] = *adrs
+			x4[j].setChain(uint32(c))
+			x[j] = out[c*n : (c+1)*n]
+			key[j], bm[j], m[j] = keys[j][:n], masks[j][:n], bms[j][:n]
+		}
+		h.wotsSKX4(&x, &x4)
+		for s := 0; s < wotsW-1; s++ {
+			for j := range x4 {
+				x4[j].setHash(uint32(s))
+				x4[j].setKeyAndMask(0)
+			}
+			h.prfX4(&key, &x4)
+			for j := range x4 {
+				x4[j].setKeyAndMask(1)
+			}
+			h.prfX4(&bm, &x4)
+			for j := range x4 {
+				for k := 0; k < n; k++ {
+					m[j][k] = bm[j][k] ^ x[j][k]
+				}
+			}
+			h.hashX4(&x, padF, &key, &m)
+		}
+	}
+}
+
+// wotsSign computes WOTS+ signature of n-byte message m and stores it in
+// out, RFC 8391, Algorithm 5.
+func (h *hasher) wotsSign(out []byte, m []byte, adrs *address) {
+	var a [maxLen]byte
+	var x4 [4]address
+	var x [4][]byte
+	n, l := h.p.n, h.p.wotsLen()
+
+	h.p.digits(a[:], m)
+	for i := 0; i < l; i += 4 {
+		var sk [4][maxN]byte
+		for j := range x4 {
+			c := i + j
+			if c >= l {
+				c = l - 1
+			}
+			x4[j] = *adrs
+			x4[j].setChain(uint32(c))
+			x[j] = sk[j][:n]
+		}
+		h.wotsSKX4(&x, &x4)
+		for j := 0; j < 4 && i+j < l; j++ {
+			copy(out[(i+j)*n:], x[j])
+		}
+	}
+	for i := 0; i < l; i++ {
+		adrs.setChain(uint32(i))
+		h.chain(out[i*n:(i+1)*n], 0, int(a[i]), adrs)
+	}
+}
+
+// wotsPKFromSig computes WOTS+ public key from signature sig of message m
+// and stores it in out, RFC 8391, Algorithm 6.
+func (h *hasher) wotsPKFromSig(out []byte, sig, m []byte, adrs *address) {
+	var a [maxLen]byte
+	n, l := h.p.n, h.p.wotsLen()
+
+	h.p.digits(a[:], m)
+	copy(out, sig[:l*n])
+	for i := 0; i < l; i++ {
+		adrs.setChain(uint32(i))
+		h.chain(out[i*n:(i+1)*n], int(a[i]), wotsW-1-int(a[i]), adrs)
+	}
+}
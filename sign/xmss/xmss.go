@@ -0,0 +1,390 @@
+package xmss
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/henrydcase/nobs/sign/state"
+)
+
+var (
+	errInvalidPublicKey  = errors.New("xmss: invalid public key")
+	errInvalidPrivateKey = errors.New("xmss: invalid private key")
+	errKeyNotGenerated   = errors.New("xmss: private key not generated")
+	errKeyExhausted      = errors.New("xmss: private key exhausted")
+)
+
+// PublicKey represents XMSS or XMSS^MT public key.
+type PublicKey struct {
+	params *params
+	// Root of the top layer tree
+	root [maxN]byte
+	// SEED, used in all hashes
+	seed [maxN]byte
+}
+
+// NewPublicKey initializes public key for a given parameter set.
+func NewPublicKey(id uint8) *PublicKey {
+	return &PublicKey{params: getParams(id)}
+}
+
+// Import imports public key stored in the byte string. Returns error in
+// case size of the input or OID is wrong. RFC 8391, Section 4.1.7.
+func (pub *PublicKey) Import(input []byte) error {
+	n := pub.params.n
+	if len(input) != pub.Size() || binary.BigEndian.Uint32(input) != pub.params.oid {
+		return errInvalidPublicKey
+	}
+	copy(pub.root[:], input[4:])
+	copy(pub.seed[:], input[4+n:])
+	return nil
+}
+
+// Export writes public key to out, which must be at least Size() bytes long.
+// Encoding is OID || root || SEED.
+func (pub *PublicKey) Export(out []byte) {
+	n := pub.params.n
+	binary.BigEndian.PutUint32(out, pub.params.oid)
+	copy(out[4:], pub.root[:n])
+	copy(out[4+n:], pub.seed[:n])
+}
+
+// Size returns size of the public key in bytes.
+func (pub *PublicKey) Size() int {
+	return pub.params.publicKeySize()
+}
+
+// SignatureSize returns size of the signature in bytes.
+func (pub *PublicKey) SignatureSize() int {
+	return pub.params.signatureSize()
+}
+
+// PrivateKey represents XMSS or XMSS^MT private key. It contains
+// corresponding public key and is safe for concurrent use.
+type PrivateKey struct {
+	PublicKey
+	mu sync.Mutex
+	// SK_SEED, used to derive WOTS+ private keys
+	skSeed [maxN]byte
+	// SK_PRF, used to derive randomizer of the message
+	prf    [maxN]byte
+	states state.Manager
+
+	// Index of the next signature
+	idx uint64
+	// Indices below reserved may have been used, according to the state
+	// stored with the manager
+	reserved uint64
+
+	// hasher is nil until the key is generated or imported
+	hasher *hasher
+	// Nodes of the current trees of the layers and their indices. Trees
+	// are nil until needed.
+	nodes [][]byte
+	trees []uint64
+	// WOTS+ signatures of the roots of the current trees, made with the
+	// trees at the layer above. Element 0 is unused.
+	sigs [][]byte
+}
+
+// NewPrivateKey initializes private key for a given parameter set, which
+// stores its state with the manager m.
+func NewPrivateKey(id uint8, m state.Manager) *PrivateKey {
+	p := getParams(id)
+	return &PrivateKey{
+		PublicKey: PublicKey{params: p},
+		states:    m,
+		nodes:     make([][]byte, p.d),
+		trees:     make([]uint64, p.d),
+		sigs:      make([][]byte, p.d),
+	}
+}
+
+// Import imports private key stored in the byte string. Returns error in
+// case size of the input, OID or the index is wrong. Function doesn't check
+// if root corresponds to the rest of the key, as it requires computing
+// a whole tree.
+func (prv *PrivateKey) Import(input []byte) error {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	p := prv.params
+	if len(input) != prv.Size() || binary.BigEndian.Uint32(input) != p.oid {
+		return errInvalidPrivateKey
+	}
+	input = input[4:]
+	var idx uint64
+	for _, b := range input[:p.indexSize()] {
+		idx = idx<<8 | uint64(b)
+	}
+	if idx > prv.total() {
+		return errInvalidPrivateKey
+	}
+	prv.idx, prv.reserved = idx, idx
+	input = input[p.indexSize():]
+	copy(prv.skSeed[:], input)
+	copy(prv.prf[:], input[p.n:])
+	copy(prv.root[:], input[2*p.n:])
+	copy(prv.seed[:], input[3*p.n:])
+	prv.init()
+	return nil
+}
+
+// Export writes private key to out, which must be at least Size() bytes
+// long. Encoding is OID || idx || SK_SEED || SK_PRF || root || SEED, where
+// idx is the first index not reserved.
+func (prv *PrivateKey) Export(out []byte) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	prv.export(out)
+}
+
+func (prv *PrivateKey) export(out []byte) {
+	p := prv.params
+	binary.BigEndian.PutUint32(out, p.oid)
+	out = out[4:]
+	putIndex(out[:p.indexSize()], prv.reserved)
+	out = out[p.indexSize():]
+	copy(out, prv.skSeed[:p.n])
+	copy(out[p.n:], prv.prf[:p.n])
+	copy(out[2*p.n:], prv.root[:p.n])
+	copy(out[3*p.n:], prv.seed[:p.n])
+}
+
+// Size returns size of the private key in bytes.
+func (prv *PrivateKey) Size() int {
+	return prv.params.privateKeySize()
+}
+
+// Generate generates a random key pair and stores it with the state
+// manager. It reads 3n bytes from rng, which are SK_SEED, SK_PRF and SEED.
+// Returns error in case rng or the manager fails. RFC 8391, Algorithm 10
+// and 15.
+func (prv *PrivateKey) Generate(rng io.Reader) error {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	var seeds [3 * maxN]byte
+	p := prv.params
+	if _, err := io.ReadFull(rng, seeds[:3*p.n]); err != nil {
+		return err
+	}
+	copy(prv.skSeed[:], seeds[:p.n])
+	copy(prv.prf[:], seeds[p.n:2*p.n])
+	copy(prv.seed[:], seeds[2*p.n:3*p.n])
+	prv.idx, prv.reserved = 0, 0
+	prv.init()
+
+	// Top layer tree is kept for signing
+	top := p.d - 1
+	prv.nodes[top] = prv.tree(top, 0)
+	copy(prv.root[:], prv.nodes[top][p.n:2*p.n])
+
+	out := make([]byte, prv.Size())
+	prv.export(out)
+	if err := prv.states.Store(out); err != nil {
+		prv.hasher = nil
+		return err
+	}
+	return nil
+}
+
+// GeneratePublicKey copies public part of the key pair to pub.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	*pub = prv.PublicKey
+}
+
+// Remaining returns number of signatures which can still be made.
+func (prv *PrivateKey) Remaining() uint64 {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	return prv.total() - prv.idx
+}
+
+// Reserve stores the state in which next count indices are used. The
+// following count signatures are made without calling the manager. If the
+// program crashes, the reserved indices which weren't used are lost.
+func (prv *PrivateKey) Reserve(count uint64) error {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+	return prv.reserve(count)
+}
+
+func (prv *PrivateKey) reserve(count uint64) error {
+	res := prv.total()
+	if count < res-prv.idx {
+		res = prv.idx + count
+	}
+	if res <= prv.reserved {
+		return nil
+	}
+
+	old := prv.reserved
+	prv.reserved = res
+	out := make([]byte, prv.Size())
+	prv.export(out)
+	if err := prv.states.Store(out); err != nil {
+		prv.reserved = old
+		return err
+	}
+	return nil
+}
+
+// Total number of signatures
+func (prv *PrivateKey) total() uint64 {
+	return uint64(1) << uint(prv.params.h)
+}
+
+// init drops the trees and prepares the hasher. Trees are computed by
+// update.
+func (prv *PrivateKey) init() {
+	n := prv.params.n
+	prv.hasher = newHasher(prv.params, prv.seed[:n], prv.skSeed[:n])
+	for i := range prv.nodes {
+		prv.nodes[i] = nil
+		prv.sigs[i] = nil
+	}
+}
+
+// tree computes nodes of the tree t at the layer l.
+func (prv *PrivateKey) tree(l int, t uint64) []byte {
+	var adrs address
+	p := prv.params
+	nodes := make([]byte, p.n<<uint(p.hp()+1))
+	adrs.setLayer(uint32(l))
+	adrs.setTree(t)
+	prv.hasher.tree(nodes, &adrs)
+	prv.trees[l] = t
+	return nodes
+}
+
+// update replaces trees, which aren't the ones for the current index,
+// starting from the top layer, and signs their roots with the layer
+// above. Roots of the same tree are always signed with the same leaf, so
+// the signature is the same after Import.
+func (prv *PrivateKey) update() {
+	p := prv.params
+	hp := uint(p.hp())
+	for l := p.d - 1; l >= 0; l-- {
+		t := prv.idx >> (hp * uint(l+1))
+		if prv.nodes[l] != nil && prv.trees[l] == t {
+			continue
+		}
+		prv.nodes[l] = prv.tree(l, t)
+		if l == p.d-1 {
+			continue
+		}
+
+		var adrs address
+		adrs.setLayer(uint32(l + 1))
+		adrs.setTree(t >> hp)
+		adrs.setType(addrOTS)
+		adrs.setOTS(uint32(t) & (1<<hp - 1))
+		prv.sigs[l+1] = make([]byte, p.wotsLen()*p.n)
+		prv.hasher.wotsSign(prv.sigs[l+1], prv.nodes[l][p.n:2*p.n], &adrs)
+	}
+}
+
+// putIndex writes idx to out in big-endian order.
+func putIndex(out []byte, idx uint64) {
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = byte(idx)
+		idx >>= 8
+	}
+}
+
+// hashMessage computes randomized hash of msg, RFC 8391, Algorithm 12
+// and 16.
+func (h *hasher) hashMessage(out, r, root []byte, idx uint64, msg []byte) {
+	var buf [maxN]byte
+	putIndex(buf[:h.p.n], idx)
+	h.hash(out, padHashMsg, r, root, buf[:h.p.n], msg)
+}
+
+// Sign signs message msg with the private key. Before the signature is
+// made, updated private key is stored with the state manager, unless the
+// index was reserved by Reserve. Returns error if the key is exhausted or
+// the manager fails. RFC 8391, Algorithm 12 and 16.
+func Sign(prv *PrivateKey, msg []byte) ([]byte, error) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	if prv.hasher == nil {
+		return nil, errKeyNotGenerated
+	}
+	if prv.idx >= prv.total() {
+		return nil, errKeyExhausted
+	}
+	if prv.idx >= prv.reserved {
+		if err := prv.reserve(1); err != nil {
+			return nil, err
+		}
+	}
+	prv.update()
+
+	var buf [32]byte
+	var m [maxN]byte
+	var adrs address
+	p, h := prv.params, prv.hasher
+	hp := uint(p.hp())
+	sig := make([]byte, p.signatureSize())
+
+	putIndex(sig[:p.indexSize()], prv.idx)
+	r := sig[p.indexSize() : p.indexSize()+p.n]
+	putIndex(buf[:], prv.idx)
+	h.hash(r, padPRF, prv.prf[:p.n], buf[:])
+	h.hashMessage(m[:], r, prv.root[:p.n], prv.idx, msg)
+
+	out := sig[p.indexSize()+p.n:]
+	leaf := uint32(prv.idx) & (1<<hp - 1)
+	adrs.setTree(prv.idx >> hp)
+	adrs.setType(addrOTS)
+	adrs.setOTS(leaf)
+	h.wotsSign(out, m[:p.n], &adrs)
+	for l := 0; l < p.d; l++ {
+		leaf = uint32(prv.idx>>(hp*uint(l))) & (1<<hp - 1)
+		if l > 0 {
+			copy(out, prv.sigs[l])
+		}
+		h.authPath(out[p.wotsLen()*p.n:], prv.nodes[l], leaf)
+		out = out[p.layerSize():]
+	}
+	prv.idx++
+	return sig, nil
+}
+
+// Verify verifies signature sig of message msg with the public key.
+// RFC 8391, Algorithm 14 and 17.
+func Verify(pub *PublicKey, msg, sig []byte) bool {
+	var node [maxN]byte
+	var adrs address
+	p := pub.params
+	hp := uint(p.hp())
+	if len(sig) != p.signatureSize() {
+		return false
+	}
+
+	var idx uint64
+	for _, b := range sig[:p.indexSize()] {
+		idx = idx<<8 | uint64(b)
+	}
+	if idx >= uint64(1)<<uint(p.h) {
+		return false
+	}
+	h := newHasher(p, pub.seed[:p.n], nil)
+	h.hashMessage(node[:], sig[p.indexSize():p.indexSize()+p.n], pub.root[:p.n], idx, msg)
+	sig = sig[p.indexSize()+p.n:]
+	for l := 0; l < p.d; l++ {
+		adrs.setLayer(uint32(l))
+		adrs.setTree(idx >> hp)
+		h.rootFromSig(node[:], uint32(idx)&(1<<hp-1), sig, node[:p.n], &adrs)
+		idx >>= hp
+		sig = sig[p.layerSize():]
+	}
+	return subtle.ConstantTimeCompare(node[:p.n], pub.root[:p.n]) == 1
+}
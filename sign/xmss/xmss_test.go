@@ -0,0 +1,333 @@
+package xmss
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/internal/test"
+)
+
+// Key generated from SK_SEED = 00 01 ..., SK_PRF and SEED consecutive
+// bytes, with the index of the next signature set to idx.
+func testKey(t testing.TB, m *test.MemState, id uint8, idx uint64) *PrivateKey {
+	sk := NewPrivateKey(id, m)
+	seed := test.Seed(3 * sk.params.n)
+	if err := sk.Generate(bytes.NewReader(seed)); err != nil {
+		t.Fatal(err)
+	}
+	if idx != 0 {
+		key := make([]byte, sk.Size())
+		sk.Export(key)
+		putIndex(key[4:4+sk.params.indexSize()], idx)
+		if err := sk.Import(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sk
+}
+
+// Public keys and SHA3-256 of signatures of the message "message" made
+// with keys from testKey. RFC 8391 test vectors use SHA-2 only, so the
+// values were computed with an independent implementation of RFC 8391
+// with SP 800-208 SHAKE256 instances.
+var signTests = []struct {
+	id  uint8
+	idx uint64
+	pk  string
+	sig string
+}{
+	{
+		XMSSMT_SHAKE256_20_4_256, 0,
+		"0000002ac1d281bf4510b02f0b61980b99c85f3268d42267040016d6b9194c2f3b04" +
+			"0f1a404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f",
+		"2327e57c1ba37363e8cceaa0c85f9c2628aa3bbf6a8a6b6a43cca4740dfe1e2e",
+	},
+	{
+		XMSSMT_SHAKE256_20_4_256, 123456,
+		"0000002ac1d281bf4510b02f0b61980b99c85f3268d42267040016d6b9194c2f3b04" +
+			"0f1a404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f",
+		"4ddc6d87e521bd25fdfb8dc5725aba3adb12816381746dd6d0713a8d1e84f8a1",
+	},
+	{
+		XMSSMT_SHAKE256_20_4_192, 987654,
+		"00000032296d594ddd9688b47a9c461c70e3d9f29e901b8cedca" +
+			"aa4c303132333435363738393a3b3c3d3e3f4041424344454647",
+		"17dd04e7537665824d81376efdecacf77c9cd547e1df5876ca38abb870db6ed1",
+	},
+	{
+		XMSS_SHAKE256_10_256, 5,
+		"00000010ba62bdc39af136a63e66f19d3cfcda232cf5cf485aec1e22c35d739bdc51" +
+			"1425404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f",
+		"737b4adabc7489b6fbea1289ec49d068bfd9551427d9196fe6e5599139891c5b",
+	},
+	{
+		XMSS_SHAKE256_10_192, 1023,
+		"00000013bbf748c8607840958c52df9cdaa1f8705dd8e4c87d3e" +
+			"54a8303132333435363738393a3b3c3d3e3f4041424344454647",
+		"b09fe18e09ffde45c8d53af5adb62c2659a44256166a387c184640b7cab43609",
+	},
+}
+
+func TestSign(t *testing.T) {
+	msg := []byte("message")
+	for _, tt := range signTests {
+		name := getParams(tt.id).name
+		if testing.Short() && getParams(tt.id).d == 1 {
+			continue
+		}
+		sk := testKey(t, new(test.MemState), tt.id, tt.idx)
+		pk := NewPublicKey(tt.id)
+		sk.GeneratePublicKey(pk)
+		pkb := make([]byte, pk.Size())
+		pk.Export(pkb)
+		if !bytes.Equal(pkb, test.FromHex(tt.pk)) {
+			t.Errorf("%s: wrong public key %x", name, pkb)
+		}
+
+		sig, err := Sign(sk, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != pk.SignatureSize() {
+			t.Errorf("%s: wrong signature size %d", name, len(sig))
+		}
+		if !bytes.Equal(test.SHA3Sum(sig), test.FromHex(tt.sig)) {
+			t.Errorf("%s: wrong signature %x", name, test.SHA3Sum(sig))
+		}
+		pk2 := NewPublicKey(tt.id)
+		if err = pk2.Import(pkb); err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(pk2, msg, sig) {
+			t.Errorf("%s: signature rejected", name)
+		}
+	}
+}
+
+// Vectors generated with test/vectors of the XMSS reference implementation
+// (https://github.com/XMSS/xmss-reference), which hashes the public key
+// without OID and the signature with 10 bytes of SHAKE128. Keys come from
+// testKey and the message {37} is signed with index 2^(h-1).
+var refTests = []struct {
+	id  uint8
+	pk  string
+	sig string
+}{
+	{XMSS_SHAKE256_10_256, "cef3d38791d56efee1b3", "9939a0f87502df5d1e31"},
+	{XMSS_SHAKE256_10_192, "7fa280e502275858b27b", "7782c54424c9ca082926"},
+	{XMSSMT_SHAKE256_20_4_256, "2d6ae135fda1077788ca", "09a73575932668ca5e8d"},
+	{XMSSMT_SHAKE256_20_4_192, "21d799da214da955d915", "45f8be8e21f1af08c828"},
+}
+
+func refHash(b []byte) []byte {
+	h := make([]byte, 10)
+	sha3.ShakeSum128(h, b)
+	return h
+}
+
+func TestReference(t *testing.T) {
+	for _, tt := range refTests {
+		p := getParams(tt.id)
+		if testing.Short() && p.d == 1 {
+			continue
+		}
+		sk := testKey(t, new(test.MemState), tt.id, 1<<uint(p.h-1))
+		pk := NewPublicKey(tt.id)
+		sk.GeneratePublicKey(pk)
+		pkb := make([]byte, pk.Size())
+		pk.Export(pkb)
+		if !bytes.Equal(refHash(pkb[4:]), test.FromHex(tt.pk)) {
+			t.Errorf("%s: wrong public key", p.name)
+		}
+		sig, err := Sign(sk, []byte{37})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(refHash(sig), test.FromHex(tt.sig)) {
+			t.Errorf("%s: wrong signature", p.name)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	msg := []byte("message")
+	sk := NewPrivateKey(XMSSMT_SHAKE256_20_4_192, new(test.MemState))
+	pk := NewPublicKey(XMSSMT_SHAKE256_20_4_192)
+	if err := sk.Generate(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sk.GeneratePublicKey(pk)
+
+	// Signatures made across the boundary of the lowest trees
+	sigs := make([][]byte, 40)
+	for i := range sigs {
+		var err error
+		if sigs[i], err = Sign(sk, msg); err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(pk, msg, sigs[i]) {
+			t.Fatalf("signature %d rejected", i)
+		}
+	}
+	if sk.Remaining() != 1<<20-40 {
+		t.Errorf("wrong number of remaining signatures %d", sk.Remaining())
+	}
+
+	sig := sigs[33]
+	if Verify(pk, []byte("massage"), sig) {
+		t.Error("signature of other message accepted")
+	}
+	// Index, randomizer, WOTS+ signature and authentication path of each
+	// layer
+	for _, i := range []int{2, 10, 100, 1700, 1900, 3000, 4000, 5000, len(sig) - 1} {
+		sig[i] ^= 1
+		if Verify(pk, msg, sig) {
+			t.Errorf("modified signature accepted (byte %d)", i)
+		}
+		sig[i] ^= 1
+	}
+	if Verify(pk, msg, sig[:len(sig)-1]) || Verify(pk, msg, append(sig, 0)) {
+		t.Error("signature of wrong size accepted")
+	}
+}
+
+func TestState(t *testing.T) {
+	const id = XMSSMT_SHAKE256_20_4_192
+	msg := []byte("message")
+	m := new(test.MemState)
+	sk := testKey(t, m, id, 0)
+	index := func(sig []byte) int { return int(sig[0])<<16 | int(sig[1])<<8 | int(sig[2]) }
+	restore := func() *PrivateKey {
+		sk := NewPrivateKey(id, m)
+		if err := sk.Import(m.Key); err != nil {
+			t.Fatal(err)
+		}
+		return sk
+	}
+
+	// Key is stored before each signature
+	for i := 0; i < 3; i++ {
+		stores := m.Stores
+		sig, err := Sign(sk, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index(sig) != i || m.Stores != stores+1 {
+			t.Errorf("signature %d: wrong index %d or no store", i, index(sig))
+		}
+	}
+
+	// Key restored after crash doesn't reuse indices
+	sk = restore()
+	sig, _ := Sign(sk, msg)
+	if index(sig) != 3 {
+		t.Errorf("restored key used index %d", index(sig))
+	}
+
+	// Failing manager prevents signing
+	m.Fail = true
+	if _, err := Sign(sk, msg); err == nil {
+		t.Error("signature made without storing the state")
+	}
+	m.Fail = false
+	sig, _ = Sign(sk, msg)
+	if index(sig) != 4 {
+		t.Errorf("wrong index %d after failed store", index(sig))
+	}
+
+	// Reserved indices are used without storing, the unused ones are lost
+	// after crash
+	stores := m.Stores
+	if err := sk.Reserve(10); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = Sign(sk, msg)
+	}
+	if m.Stores != stores+1 {
+		t.Errorf("%d stores for reserved indices", m.Stores-stores)
+	}
+	sk = restore()
+	if sig, _ = Sign(sk, msg); index(sig) != 15 {
+		t.Errorf("wrong index %d after reservation", index(sig))
+	}
+
+	// Exhausted key
+	sk = testKey(t, m, id, 1<<20-1)
+	if _, err := Sign(sk, msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sign(sk, msg); err != errKeyExhausted {
+		t.Errorf("exhausted key: got error %v", err)
+	}
+	if _, err := Sign(NewPrivateKey(id, m), msg); err == nil {
+		t.Error("key which wasn't generated signed a message")
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	const id = XMSSMT_SHAKE256_20_4_192
+	sk := testKey(t, new(test.MemState), id, 0)
+	pk := NewPublicKey(id)
+	sk.GeneratePublicKey(pk)
+	pkb := make([]byte, pk.Size())
+	pk.Export(pkb)
+	skb := make([]byte, sk.Size())
+	sk.Export(skb)
+
+	for i, b := range [][]byte{
+		nil,
+		pkb[:len(pkb)-1],
+		append(pkb, 0),
+		append([]byte{0, 0, 0, 0x2A}, pkb[4:]...),
+	} {
+		if err := NewPublicKey(id).Import(b); err == nil {
+			t.Errorf("%d: invalid public key accepted", i)
+		}
+	}
+	for i, b := range [][]byte{
+		skb[:len(skb)-1],
+		append([]byte{0, 0, 0, 0x2A}, skb[4:]...),
+		// Index beyond the last one
+		append(append([]byte{}, skb[:4]...), append([]byte{0x10, 0, 1}, skb[7:]...)...),
+	} {
+		if err := NewPrivateKey(id, new(test.MemState)).Import(b); err == nil {
+			t.Errorf("%d: invalid private key accepted", i)
+		}
+	}
+	mustPanic(t, func() { NewPublicKey(XMSSMT_SHAKE256_60_12_192 + 1) })
+}
+
+func mustPanic(t *testing.T, f func()) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	f()
+}
+
+func BenchmarkSign(b *testing.B) {
+	sk := testKey(b, new(test.MemState), XMSSMT_SHAKE256_20_4_256, 0)
+	msg := []byte("message")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Reset the index, computing the trees isn't benchmarked
+		sk.idx, sk.reserved = 0, 1
+		_, _ = Sign(sk, msg)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	sk := testKey(b, new(test.MemState), XMSSMT_SHAKE256_20_4_256, 0)
+	pk := NewPublicKey(XMSSMT_SHAKE256_20_4_256)
+	sk.GeneratePublicKey(pk)
+	msg := []byte("message")
+	sig, _ := Sign(sk, msg)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Verify(pk, msg, sig)
+	}
+}
@@ -10,8 +10,18 @@ type x86 struct {
 	// Signals support for AES
 	HasAES bool
 
+	// Signals support for SSSE3
+	HasSSSE3 bool
+
 	// Signals support for RDSEED
 	HasRDSEED bool
+
+	// Signals support for RDRAND
+	HasRDRAND bool
+
+	// Signals support for AVX2 instructions and that OS saves
+	// YMM registers on context switch
+	HasAVX2 bool
 }
 
 var X86 x86
@@ -11,6 +11,10 @@ package utils
 // go:nosplit
 func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
 
+// Returns content of the XCR0 register
+// go:nosplit
+func xgetbv() (eax, edx uint32)
+
 // Returns true in case bit 'n' in 'bits' is set, otherwise false
 func bitn(bits uint32, n uint8) bool {
 	return (bits>>n)&1 == 1
@@ -23,11 +27,21 @@ func init() {
 		return
 	}
 
-	_, ecx, _, _ := cpuid(1, 0)
+	_, _, ecx, _ := cpuid(1, 0)
 	X86.HasAES = bitn(ecx, 25)
+	X86.HasSSSE3 = bitn(ecx, 9)
+	X86.HasRDRAND = bitn(ecx, 30)
+	// OS uses XSAVE and AVX is supported by the CPU
+	hasAVX := bitn(ecx, 27) && bitn(ecx, 28)
+	if hasAVX {
+		// Check if OS saves XMM and YMM registers
+		xcr0, _ := xgetbv()
+		hasAVX = xcr0&0x6 == 0x6
+	}
 
-	_, ecx, _, _ = cpuid(7, 0)
-	X86.HasBMI2 = bitn(ecx, 8)
-	X86.HasADX = bitn(ecx, 19)
-	X86.HasRDSEED = bitn(ecx, 18)
+	_, ebx, _, _ := cpuid(7, 0)
+	X86.HasBMI2 = bitn(ebx, 8)
+	X86.HasADX = bitn(ebx, 19)
+	X86.HasRDSEED = bitn(ebx, 18)
+	X86.HasAVX2 = hasAVX && bitn(ebx, 5)
 }